@@ -27,8 +27,10 @@ import (
 
 	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/controller"
 	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/health"
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/netconfig"
 	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/pollers"
 	bigIPPrometheus "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/prometheus"
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/tracing"
 	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vxlan"
 	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/writer"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -120,23 +122,38 @@ var (
 	customResourceMode *bool
 	controllerMode     *string
 	defaultRouteDomain *int
+	defaultPolicy      *string
 
 	pythonBaseDir    *string
 	logLevel         *string
 	ccclLogLevel     *string
 	logFile          *string
+	logFormat        *string
+	subsystemLogLvl  *string
 	verifyInterval   *int
 	nodePollInterval *int
 	syncInterval     *int
 	printVersion     *bool
 	httpAddress      *string
+	debugTokenFile   *string
+	tracingEndpoint  *string
+	tracingInsecure  *bool
+	tracingRatio     *float64
+	webhookAddress   *string
+	webhookCertFile  *string
+	webhookKeyFile   *string
 	dgPath           string
 	disableTeems     *bool
 	enableIPV6       *bool
 
 	namespaces             *[]string
+	multiClusterSecrets    *[]string
 	useNodeInternal        *bool
 	poolMemberType         *string
+	podReadinessGate       *bool
+	drainPeriod            *int
+	drainAdminState        *string
+	nodePortEndpointNodes  *bool
 	inCluster              *bool
 	kubeConfig             *string
 	namespaceLabel         *string
@@ -159,6 +176,7 @@ var (
 	bigIPPartitions           *[]string
 	credsDir                  *string
 	as3Validation             *bool
+	as3TenantValidation       *bool
 	sslInsecure               *bool
 	ipam                      *bool
 	enableTLS                 *string
@@ -170,9 +188,12 @@ var (
 	trustedCertsCfgmap     *string
 	agent                  *string
 	ccclGtmAgent           *bool
+	netConfigDriver        *string
+	staticRoutingMode      *bool
 	logAS3Response         *bool
 	shareNodes             *bool
 	overriderAS3CfgmapName *string
+	fastTemplateConfigMap  *string
 	filterTenants          *bool
 
 	vxlanMode        string
@@ -233,6 +254,11 @@ func _init() {
 		"Optional, logging level for cccl")
 	logFile = globalFlags.String("log-file", "",
 		"Optional, filepath to store the CIS logs")
+	logFormat = globalFlags.String("log-format", "text",
+		"Optional, format of the CIS logs, 'text' or 'json'")
+	subsystemLogLvl = globalFlags.String("subsystem-log-level", "",
+		"Optional, comma separated logging levels of subsystems overriding the log-level, "+
+			"for example 'AS3=DEBUG,VxLAN=ERROR'")
 	verifyInterval = globalFlags.Int("verify-interval", 30,
 		"Optional, interval (in seconds) at which to verify the BIG-IP configuration.")
 	nodePollInterval = globalFlags.Int("node-poll-interval", 30,
@@ -243,6 +269,23 @@ func _init() {
 		"Optional, print version and exit.")
 	httpAddress = globalFlags.String("http-listen-address", "0.0.0.0:8080",
 		"Optional, address to serve http based informations (/metrics and /health).")
+	debugTokenFile = globalFlags.String("debug-token-file", "",
+		"Optional, file with the bearer token to access the /debug endpoints on the http-listen-address. "+
+			"The debug endpoints are disabled if not set. Supported only in controller mode")
+	tracingEndpoint = globalFlags.String("tracing-endpoint", "",
+		"Optional, host:port of the OpenTelemetry OTLP HTTP receiver to export the traces. "+
+			"Tracing is disabled if not set. Supported only in controller mode")
+	tracingInsecure = globalFlags.Bool("tracing-insecure", false,
+		"Optional, disable TLS to the OpenTelemetry OTLP HTTP receiver")
+	tracingRatio = globalFlags.Float64("tracing-sample-ratio", 1,
+		"Optional, fraction of the traces to sample, between 0 and 1")
+	webhookAddress = globalFlags.String("webhook-listen-address", "",
+		"Optional, address to serve the ValidatingAdmissionWebhook of the custom resources over HTTPS. "+
+			"The webhook is disabled if not set. Supported only in custom resource mode")
+	webhookCertFile = globalFlags.String("webhook-cert-file", "",
+		"Optional, file with the TLS certificate of the admission webhook")
+	webhookKeyFile = globalFlags.String("webhook-key-file", "",
+		"Optional, file with the TLS private key of the admission webhook")
 	disableTeems = globalFlags.Bool("disable-teems", false,
 		"Optional, flag to disable sending telemetry data to TEEM")
 	// Custom Resource
@@ -254,6 +297,9 @@ func _init() {
 		"Optional, to put the controller to process desired resources.")
 	defaultRouteDomain = globalFlags.Int("default-route-domain", 0,
 		"Optional, CIS uses this value as default Route Domain in BIG-IP ")
+	defaultPolicy = globalFlags.String("default-policy", "",
+		"Optional, Policy CR as <namespace>/<policy-name> applied to all VirtualServers, TransportServers "+
+			"and Services of type LoadBalancer. Namespace default and resource level Policies override it.")
 
 	globalFlags.Usage = func() {
 		fmt.Fprintf(os.Stderr, "  Global:\n%s\n", globalFlags.FlagUsagesWrapped(width))
@@ -273,6 +319,9 @@ func _init() {
 			"url files. To be used instead of username, password, and/or url arguments.")
 	as3Validation = bigIPFlags.Bool("as3-validation", true,
 		"Optional, when set to false, disables as3 template validation on the controller.")
+	as3TenantValidation = bigIPFlags.Bool("as3-tenant-validation", false,
+		"Optional, when set to true, validates the AS3 declaration of each tenant against the AS3 schema "+
+			"and excludes the invalid resources in controller mode.")
 	sslInsecure = bigIPFlags.Bool("insecure", false,
 		"Optional, when set to true, enable insecure SSL communication to BIGIP.")
 	ipam = bigIPFlags.Bool("ipam", false,
@@ -295,9 +344,15 @@ func _init() {
 		"Optional, when set to cccl, orchestration agent will be CCCL instead of AS3")
 	ccclGtmAgent = bigIPFlags.Bool("cccl-gtm-agent", true,
 		"Optional, Option to configure GTM objects using CCCL or AS3 Agent. Default Agent is CCCL.")
+	netConfigDriver = bigIPFlags.String("net-config-driver", netconfig.Python,
+		"Optional, driver of the tunnel FDB records, ARP entries and static routes. "+
+			"'python' uses the python config driver, 'go' configures them with iControl REST from the controller")
 	overrideAS3UsageStr := "Optional, provide Namespace and Name of that ConfigMap as <namespace>/<configmap-name>." +
 		"The JSON key/values from this ConfigMap will override key/values from internally generated AS3 declaration."
 	overriderAS3CfgmapName = bigIPFlags.String("override-as3-declaration", "", overrideAS3UsageStr)
+	fastTemplateConfigMap = bigIPFlags.String("fast-template-configmap", "",
+		"Optional, deploy each virtual server as an application of the FAST template in this ConfigMap "+
+			"instead of an AS3 declaration. Usage: --fast-template-configmap=<namespace>/<configmap-name>")
 	filterTenants = kubeFlags.Bool("filter-tenants", false,
 		"Optional, specify whether or not to use tenant filtering API for AS3 declaration")
 	bigIPFlags.Usage = func() {
@@ -316,6 +371,25 @@ func _init() {
 			"'cluster' will use service endpoints. "+
 			"The BIG-IP must be able access the cluster network"+
 			"'nodeportlocal' only supported with antrea cni")
+	podReadinessGate = kubeFlags.Bool("pod-readiness-gate", false,
+		"Optional, set the pod readiness gate condition once the pod is a BIG-IP pool member. "+
+			"Supported only with 'cluster' pool member type")
+	drainPeriod = kubeFlags.Int("drain-period", 0,
+		"Optional, time in seconds to keep a removed or terminating pool member on BIG-IP "+
+			"with the drain admin state so that existing connections complete. 0 disables draining")
+	drainAdminState = kubeFlags.String("drain-admin-state", "disable",
+		"Optional, admin state of a draining pool member. "+
+			"'disable' allows persistent and active connections, 'offline' allows only active connections")
+	staticRoutingMode = kubeFlags.Bool("static-routing-mode", false,
+		"Optional, add a static route to the pod CIDR of each node through the node address. "+
+			"Supported only with 'go' net-config-driver")
+	nodePortEndpointNodes = kubeFlags.Bool("nodeport-endpoint-nodes", false,
+		"Optional, add only the nodes hosting the ready endpoints of the service as pool members, "+
+			"weighted by the number of endpoints. Supported only with 'nodeport' pool member type")
+	multiClusterSecrets = kubeFlags.StringArray("multi-cluster-kubeconfig-secret", []string{},
+		"Optional, namespace/name of a Secret with the kubeconfig of an additional cluster, "+
+			"whose services serve the pools of the VirtualServers. The Secret name is the cluster name. "+
+			"Supported only in custom resource mode")
 	inCluster = kubeFlags.Bool("running-in-cluster", true,
 		"Optional, if this controller is running in a kubernetes cluster,"+
 			"use the pod secrets for creating a Kubernetes client.")
@@ -438,12 +512,24 @@ func _init() {
 	}
 }
 
-func initLogger(logLevel, logFile string) error {
+func initLogger(logLevel, logFile, logFormat, subsystemLogLevel string) error {
 	var logger log.Logger
-	if len(logFile) > 0 {
-		logger = log.NewFileLogger(logFile)
-	} else {
-		logger = log.NewConsoleLogger()
+	switch strings.ToLower(logFormat) {
+	case "json":
+		if len(logFile) > 0 {
+			logger = log.NewJSONFileLogger(logFile)
+		} else {
+			logger = log.NewJSONLogger()
+		}
+	case "text":
+		if len(logFile) > 0 {
+			logger = log.NewFileLogger(logFile)
+		} else {
+			logger = log.NewConsoleLogger()
+		}
+	default:
+		return fmt.Errorf("Unknown log format requested: %s\n"+
+			"    Valid log formats are: text, json", logFormat)
 	}
 	log.RegisterLogger(
 		log.LL_MIN_LEVEL, log.LL_MAX_LEVEL, logger)
@@ -454,6 +540,13 @@ func initLogger(logLevel, logFile string) error {
 		return fmt.Errorf("Unknown log level requested: %s\n"+
 			"    Valid log levels are: DEBUG, INFO, WARNING, ERROR, CRITICAL", logLevel)
 	}
+	levels, err := log.NewSubsystemLogLevels(subsystemLogLevel)
+	if err != nil {
+		return err
+	}
+	for subsystem, ll := range levels {
+		log.SetSubsystemLogLevel(subsystem, ll)
+	}
 	return nil
 }
 
@@ -473,7 +566,7 @@ func hasCommonPartition(partitions []string) bool {
 
 func verifyArgs() error {
 	*logLevel = strings.ToUpper(*logLevel)
-	logErr := initLogger(*logLevel, *logFile)
+	logErr := initLogger(*logLevel, *logFile, *logFormat, *subsystemLogLvl)
 	if nil != logErr {
 		return logErr
 	}
@@ -500,6 +593,16 @@ func verifyArgs() error {
 		return fmt.Errorf("Can not specify both namespace and namespace-label")
 	}
 
+	if *webhookAddress != "" && (*webhookCertFile == "" || *webhookKeyFile == "") {
+		return fmt.Errorf("webhook-cert-file and webhook-key-file are required for the webhook-listen-address")
+	}
+
+	for _, secret := range *multiClusterSecrets {
+		if len(strings.Split(secret, "/")) != 2 {
+			return fmt.Errorf("invalid multi-cluster-kubeconfig-secret %v, expected namespace/name", secret)
+		}
+	}
+
 	if len(*namespaces) == 0 && len(*namespaceLabel) == 0 {
 		watchAllNamespaces = true
 	} else {
@@ -514,6 +617,41 @@ func verifyArgs() error {
 		return fmt.Errorf("'%v' is not a valid Pool Member Type", *poolMemberType)
 	}
 
+	if *podReadinessGate && *poolMemberType != "cluster" {
+		return fmt.Errorf("pod-readiness-gate is supported only with 'cluster' pool member type")
+	}
+
+	if *netConfigDriver != netconfig.Python && *netConfigDriver != netconfig.Go {
+		return fmt.Errorf("'%v' is not a valid net-config-driver, use 'python' or 'go'", *netConfigDriver)
+	}
+	if *staticRoutingMode && *netConfigDriver != netconfig.Go {
+		return fmt.Errorf("static-routing-mode is supported only with 'go' net-config-driver")
+	}
+
+	if *nodePortEndpointNodes && *poolMemberType != "nodeport" {
+		return fmt.Errorf("nodeport-endpoint-nodes is supported only with 'nodeport' pool member type")
+	}
+
+	if len(*fastTemplateConfigMap) > 0 && len(strings.Split(*fastTemplateConfigMap, "/")) != 2 {
+		return fmt.Errorf("invalid value provided for --fast-template-configmap. " +
+			"Usage: --fast-template-configmap=<namespace>/<configmap-name>")
+	}
+	if len(*fastTemplateConfigMap) > 0 && (len(*gtmBigIPURL) > 0 || len(*gtmCredsDir) > 0) {
+		return fmt.Errorf("fast-template-configmap is not supported with GTM BIG-IP, " +
+			"the GTM config is deployed only with the AS3 declaration")
+	}
+
+	if *tracingRatio < 0 || *tracingRatio > 1 {
+		return fmt.Errorf("tracing-sample-ratio must be between 0 and 1")
+	}
+
+	if *drainPeriod < 0 {
+		return fmt.Errorf("drain-period must not be negative")
+	}
+	if *drainAdminState != "disable" && *drainAdminState != "offline" {
+		return fmt.Errorf("'%v' is not a valid drain-admin-state, use 'disable' or 'offline'", *drainAdminState)
+	}
+
 	if len(*openshiftSDNName) > 0 && len(*flannelName) > 0 {
 		return fmt.Errorf("Cannot have both openshift-sdn-name and flannel-name specified.")
 	}
@@ -555,6 +693,13 @@ func verifyArgs() error {
 		}
 	}
 
+	if *defaultPolicy != "" {
+		if len(strings.Split(*defaultPolicy, "/")) != 2 {
+			return fmt.Errorf("Invalid value provided for --default-policy. " +
+				"Usage: --default-policy=<namespace>/<policy-name>")
+		}
+	}
+
 	switch *controllerMode {
 	case "",
 		string(controller.CustomResourceMode),
@@ -719,6 +864,16 @@ func setupNodePolling(
 		}
 	}
 
+	if *staticRoutingMode {
+		routeMgr := netconfig.NewStaticRouteMgr(appMgr.UseNodeInternal(), getConfigWriter())
+		// Register routeMgr to watch for node updates to process static routes
+		err = np.RegisterListener(routeMgr.ProcessNodeUpdate)
+		if nil != err {
+			return fmt.Errorf("error registering node update listener for static routes: %v",
+				err)
+		}
+	}
+
 	return nil
 }
 
@@ -794,11 +949,8 @@ func setupWatchers(appMgr *appmanager.Manager, resyncPeriod time.Duration) {
 	}
 }
 
-func initController(
-	config *rest.Config,
-) *controller.Controller {
-
-	postMgrParams := controller.PostParams{
+func getPostParams() controller.PostParams {
+	return controller.PostParams{
 		BIGIPUsername: *bigIPUsername,
 		BIGIPPassword: *bigIPPassword,
 		BIGIPURL:      *bigIPURL,
@@ -807,6 +959,13 @@ func initController(
 		AS3PostDelay:  *as3PostDelay,
 		LogResponse:   *logAS3Response,
 	}
+}
+
+func initController(
+	config *rest.Config,
+) *controller.Controller {
+
+	postMgrParams := getPostParams()
 
 	GtmParams := controller.GTMParams{
 		GTMBigIpUsername: *gtmBigIPUsername,
@@ -815,17 +974,20 @@ func initController(
 	}
 
 	agentParams := controller.AgentParams{
-		PostParams:     postMgrParams,
-		GTMParams:      GtmParams,
-		Partition:      (*bigIPPartitions)[0],
-		LogLevel:       *logLevel,
-		VerifyInterval: *verifyInterval,
-		VXLANName:      vxlanName,
-		PythonBaseDir:  *pythonBaseDir,
-		UserAgent:      getUserAgentInfo(),
-		HttpAddress:    *httpAddress,
-		EnableIPV6:     *enableIPV6,
-		CCCLGTMAgent:   *ccclGtmAgent,
+		PostParams:      postMgrParams,
+		GTMParams:       GtmParams,
+		Partition:       (*bigIPPartitions)[0],
+		LogLevel:        *logLevel,
+		VerifyInterval:  *verifyInterval,
+		VXLANName:       vxlanName,
+		PythonBaseDir:   *pythonBaseDir,
+		UserAgent:       getUserAgentInfo(),
+		HttpAddress:     *httpAddress,
+		EnableIPV6:      *enableIPV6,
+		CCCLGTMAgent:    *ccclGtmAgent,
+		AS3Validation:   *as3TenantValidation,
+		SchemaLocal:     *schemaLocal,
+		NetConfigDriver: *netConfigDriver,
 	}
 
 	// When CIS is configured in OCP cluster mode disable ARP in globalSection
@@ -833,27 +995,57 @@ func initController(
 		agentParams.DisableARP = true
 	}
 
-	agent := controller.NewAgent(agentParams)
+	netAgent := controller.NewNetAgent(agentParams)
+
+	// In FAST mode the FAST backend posts the resource configs, so CIS does not run the AS3 agent
+	var agent *controller.Agent
+	var backend controller.Backend
+	if *fastTemplateConfigMap != "" {
+		backend = controller.NewFASTAgent(
+			postMgrParams,
+			*fastTemplateConfigMap,
+			(*bigIPPartitions)[0],
+			kubeClient,
+			netAgent.EventChan,
+		)
+	} else {
+		agent = controller.NewAgent(agentParams, netAgent)
+	}
 
 	ctlr := controller.NewController(
 		controller.Params{
-			Config:             config,
-			Namespaces:         *namespaces,
-			NamespaceLabel:     *namespaceLabel,
-			Partition:          (*bigIPPartitions)[0],
-			Agent:              agent,
-			PoolMemberType:     *poolMemberType,
-			VXLANName:          vxlanName,
-			VXLANMode:          vxlanMode,
-			UseNodeInternal:    *useNodeInternal,
-			NodePollInterval:   *nodePollInterval,
-			NodeLabelSelector:  *nodeLabelSelector,
-			IPAM:               *ipam,
-			ShareNodes:         *shareNodes,
-			DefaultRouteDomain: *defaultRouteDomain,
-			Mode:               controller.ControllerMode(*controllerMode),
-			RouteSpecConfigmap: *routeSpecConfigmap,
-			RouteLabel:         *routeLabel,
+			Config:                config,
+			Namespaces:            *namespaces,
+			NamespaceLabel:        *namespaceLabel,
+			Partition:             (*bigIPPartitions)[0],
+			Agent:                 agent,
+			Backend:               backend,
+			NetAgent:              netAgent,
+			BigIPURL:              *bigIPURL,
+			CCCLGTMAgent:          *ccclGtmAgent,
+			PoolMemberType:        *poolMemberType,
+			VXLANName:             vxlanName,
+			VXLANMode:             vxlanMode,
+			UseNodeInternal:       *useNodeInternal,
+			NodePollInterval:      *nodePollInterval,
+			NodeLabelSelector:     *nodeLabelSelector,
+			IPAM:                  *ipam,
+			ShareNodes:            *shareNodes,
+			DefaultRouteDomain:    *defaultRouteDomain,
+			Mode:                  controller.ControllerMode(*controllerMode),
+			RouteSpecConfigmap:    *routeSpecConfigmap,
+			RouteLabel:            *routeLabel,
+			DefaultPolicy:         *defaultPolicy,
+			PodReadinessGate:      *podReadinessGate,
+			DrainPeriod:           time.Duration(*drainPeriod) * time.Second,
+			DrainAdminState:       *drainAdminState,
+			NodePortEndpointNodes: *nodePortEndpointNodes,
+			StaticRoutingMode:     *staticRoutingMode,
+			DebugTokenFile:        *debugTokenFile,
+			WebhookAddress:        *webhookAddress,
+			WebhookCertFile:       *webhookCertFile,
+			WebhookKeyFile:        *webhookKeyFile,
+			MultiClusterSecrets:   *multiClusterSecrets,
 		},
 	)
 
@@ -983,11 +1175,27 @@ func main() {
 	}
 
 	if *customResourceMode || *controllerMode != "" {
+		if *tracingEndpoint != "" {
+			shutdown, err := tracing.Init(tracing.Params{
+				Endpoint:    *tracingEndpoint,
+				Insecure:    *tracingInsecure,
+				SampleRatio: *tracingRatio,
+				Version:     version,
+			})
+			if err != nil {
+				log.Errorf("Failed to setup tracing: %v", err)
+			} else {
+				log.Infof("Exporting traces to %v", *tracingEndpoint)
+				defer func() {
+					_ = shutdown(context.Background())
+				}()
+			}
+		}
 		getGTMCredentials()
 		ctlr := initController(config)
 		ctlr.TeemData = td
 		if !(*disableTeems) {
-			key, err := ctlr.Agent.GetBigipRegKey()
+			key, err := controller.NewPostManager(getPostParams()).GetBigipRegKey()
 			if err != nil {
 				log.Errorf("%v", err)
 			}
@@ -1031,11 +1239,35 @@ func main() {
 		BigIPPartitions: *bigIPPartitions,
 	}
 
-	subPidCh, err := startPythonDriver(getConfigWriter(), gs, bs, *pythonBaseDir)
-	if nil != err {
-		log.Fatalf("Could not initialize subprocess configuration: %v", err)
+	var netDriver *netconfig.Driver
+	if *netConfigDriver == netconfig.Go {
+		netDriver, err = netconfig.NewDriver(netconfig.Params{
+			BigIPURL:       *bigIPURL,
+			BigIPUsername:  *bigIPUsername,
+			BigIPPassword:  *bigIPPassword,
+			TrustedCerts:   getBIGIPTrustedCerts(),
+			SSLInsecure:    *sslInsecure,
+			Partition:      vxlanPartition,
+			VerifyInterval: time.Duration(*verifyInterval) * time.Second,
+			DisableARP:     disableARP,
+		}, getConfigWriter())
+		if nil != err {
+			log.Fatalf("[INIT] Failed creating network config driver: %v", err)
+		}
+		// The network config sections are written to the network config driver
+		configWriter = netDriver
+		gs.VXLANPartition = ""
+	}
+
+	// The python driver is required only for the CCCL agent with the network config driver
+	var subPid int
+	if netDriver == nil || *agent == cisAgent.CCCLAgent {
+		subPidCh, err := startPythonDriver(getConfigWriter(), gs, bs, *pythonBaseDir)
+		if nil != err {
+			log.Fatalf("Could not initialize subprocess configuration: %v", err)
+		}
+		subPid = <-subPidCh
 	}
-	subPid := <-subPidCh
 	defer func(pid int) {
 		if 0 != pid {
 			var proc *os.Process
@@ -1118,6 +1350,9 @@ func main() {
 	hc := &health.HealthChecker{
 		SubPID: subPid,
 	}
+	if netDriver != nil {
+		hc.NetDriver = netDriver
+	}
 	http.Handle("/health", hc.HealthCheckHandler())
 	bigIPPrometheus.RegisterMetrics()
 	go func() {
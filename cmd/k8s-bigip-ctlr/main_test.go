@@ -202,6 +202,7 @@ var _ = Describe("Main Tests", func() {
 				"bigipUsername": bigIPUsername,
 				"bigipPassword": bigIPPassword,
 				"logLevel":      logLevel,
+				"logFormat":     logFormat,
 			}
 
 			for argName, arg := range allArgs {
@@ -49,6 +49,7 @@ type VirtualServerSpec struct {
 	BotDefense             string           `json:"botDefense,omitempty"`
 	Profiles               ProfileSpec      `json:"profiles,omitempty"`
 	AllowSourceRange       []string         `json:"allowSourceRange,omitempty"`
+	Partition              string           `json:"partition,omitempty"`
 }
 
 // ServiceAddress Service IP address definition (BIG-IP virtual-address).
@@ -74,18 +75,68 @@ type Pool struct {
 	ServiceNamespace  string    `json:"serviceNamespace,omitempty"`
 	ReselectTries     int32     `json:"reselectTries,omitempty"`
 	ServiceDownAction string    `json:"serviceDownAction,omitempty"`
+	MinimumMonitors   int       `json:"minimumMonitors,omitempty"`
+	// Weight is the ratio of the members of the service in this cluster
+	Weight               int                            `json:"weight,omitempty"`
+	MultiClusterServices []MultiClusterServiceReference `json:"multiClusterServices,omitempty"`
+	// Topology sets the ratio and the priority group of the members by a node or pod label
+	Topology *PoolTopology `json:"topology,omitempty"`
+	// MinActiveMembers is the number of the members of a priority group to be up before
+	// the lower priority group receives the traffic
+	MinActiveMembers int32 `json:"minActiveMembers,omitempty"`
+}
+
+// PoolTopology maps the values of a node or pod label like topology.kubernetes.io/zone
+// to the ratio and the priority group of the pool members
+type PoolTopology struct {
+	Label  string          `json:"label"`
+	Groups []TopologyGroup `json:"groups"`
+}
+
+// TopologyGroup is the ratio and the priority group of the members with the label value
+type TopologyGroup struct {
+	Value         string `json:"value"`
+	Ratio         int    `json:"ratio,omitempty"`
+	PriorityGroup int    `json:"priorityGroup,omitempty"`
+}
+
+// MultiClusterServiceReference is a service in another cluster whose endpoints are
+// merged into the pool
+type MultiClusterServiceReference struct {
+	ClusterName string `json:"clusterName"`
+	SvcName     string `json:"service"`
+	Namespace   string `json:"namespace,omitempty"`
+	ServicePort int32  `json:"servicePort,omitempty"`
+	Weight      int    `json:"weight,omitempty"`
 }
 
 // Monitor defines a monitor object in BIG-IP.
 type Monitor struct {
-	Type       string `json:"type"`
-	Send       string `json:"send"`
-	Recv       string `json:"recv"`
-	Interval   int    `json:"interval"`
-	Timeout    int    `json:"timeout"`
-	TargetPort int32  `json:"targetPort"`
-	Name       string `json:"name,omitempty"`
-	Reference  string `json:"reference,omitempty"`
+	Type        string `json:"type"`
+	Send        string `json:"send"`
+	Recv        string `json:"recv"`
+	Interval    int    `json:"interval"`
+	Timeout     int    `json:"timeout"`
+	TargetPort  int32  `json:"targetPort"`
+	Name        string `json:"name,omitempty"`
+	Reference   string `json:"reference,omitempty"`
+	UpInterval  int    `json:"upInterval,omitempty"`
+	TimeUntilUp int    `json:"timeUntilUp,omitempty"`
+	Reverse     bool   `json:"reverse,omitempty"`
+	// ClientCertificate is the name of the secret with client certificate for https monitor
+	ClientCertificate string `json:"clientCertificate,omitempty"`
+	// ServerName is sent as SNI by https monitor
+	ServerName string `json:"serverName,omitempty"`
+	QueryName  string `json:"queryName,omitempty"`
+	QueryType  string `json:"queryType,omitempty"`
+	// Script is the pathname of external monitor program on BIG-IP
+	Script    string `json:"script,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	// Failures, FailureInterval, ResponseTime and RetryTime are the options of inband monitor
+	Failures        int `json:"failures,omitempty"`
+	FailureInterval int `json:"failureInterval,omitempty"`
+	ResponseTime    int `json:"responseTime,omitempty"`
+	RetryTime       int `json:"retryTime,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -150,6 +201,7 @@ type IngressLink struct {
 // IngressLinkStatus is the status of the ingressLink resource.
 type IngressLinkStatus struct {
 	VSAddress string `json:"vsAddress,omitempty"`
+	StatusOk  string `json:"status,omitempty"`
 }
 
 // IngressLinkSpec is Spec for IngressLink
@@ -159,6 +211,24 @@ type IngressLinkSpec struct {
 	Selector             *metav1.LabelSelector `json:"selector"`
 	IRules               []string              `json:"iRules,omitempty"`
 	IPAMLabel            string                `json:"ipamLabel"`
+	Partition            string                `json:"partition,omitempty"`
+	// IngressController is the preset of the monitor: nginx, istio, contour or traefik
+	IngressController string              `json:"ingressController,omitempty"`
+	Monitor           *IngressLinkMonitor `json:"monitor,omitempty"`
+	// Ports are the service ports exposed on BIG-IP, all except the monitor port by default
+	Ports []int32 `json:"ports,omitempty"`
+	// ProxyProtocol is the version of the Proxy Protocol header sent to the ingress controller: v1 or v2
+	ProxyProtocol string `json:"proxyProtocol,omitempty"`
+}
+
+// IngressLinkMonitor is the readiness monitor of the ingress controller, overrides the preset
+type IngressLinkMonitor struct {
+	Type     string `json:"type,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Port     int32  `json:"port,omitempty"`
+	Recv     string `json:"recv,omitempty"`
+	Interval int    `json:"interval,omitempty"`
+	Timeout  int    `json:"timeout,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -193,25 +263,32 @@ type TransportServerStatus struct {
 
 // TransportServerSpec is the spec of the VirtualServer resource.
 type TransportServerSpec struct {
-	VirtualServerAddress string           `json:"virtualServerAddress"`
-	VirtualServerPort    int32            `json:"virtualServerPort"`
-	VirtualServerName    string           `json:"virtualServerName"`
-	Host                 string           `json:"host,omitempty"`
-	HostGroup            string           `json:"hostGroup,omitempty"`
-	Mode                 string           `json:"mode"`
-	SNAT                 string           `json:"snat"`
-	Pool                 Pool             `json:"pool"`
-	AllowVLANs           []string         `json:"allowVlans,omitempty"`
-	Type                 string           `json:"type,omitempty"`
-	ServiceIPAddress     []ServiceAddress `json:"serviceAddress"`
-	IPAMLabel            string           `json:"ipamLabel"`
-	IRules               []string         `json:"iRules,omitempty"`
-	PolicyName           string           `json:"policyName,omitempty"`
-	PersistenceProfile   string           `json:"persistenceProfile,omitempty"`
-	ProfileL4            string           `json:"profileL4,omitempty"`
-	DOS                  string           `json:"dos,omitempty"`
-	BotDefense           string           `json:"botDefense,omitempty"`
-	Profiles             ProfileSpec      `json:"profiles,omitempty"`
+	VirtualServerAddress string `json:"virtualServerAddress"`
+	VirtualServerPort    int32  `json:"virtualServerPort"`
+	// VirtualServerPorts are the ports like "5060" and the port ranges like "10000-10100"
+	// of the TransportServer, which override the virtualServerPort; "0" is any port
+	VirtualServerPorts []string         `json:"virtualServerPorts,omitempty"`
+	VirtualServerName  string           `json:"virtualServerName"`
+	Host               string           `json:"host,omitempty"`
+	HostGroup          string           `json:"hostGroup,omitempty"`
+	Mode               string           `json:"mode"`
+	SNAT               string           `json:"snat"`
+	Pool               Pool             `json:"pool"`
+	AllowVLANs         []string         `json:"allowVlans,omitempty"`
+	Type               string           `json:"type,omitempty"`
+	ServiceIPAddress   []ServiceAddress `json:"serviceAddress"`
+	IPAMLabel          string           `json:"ipamLabel"`
+	IRules             []string         `json:"iRules,omitempty"`
+	PolicyName         string           `json:"policyName,omitempty"`
+	PersistenceProfile string           `json:"persistenceProfile,omitempty"`
+	ProfileL4          string           `json:"profileL4,omitempty"`
+	DOS                string           `json:"dos,omitempty"`
+	BotDefense         string           `json:"botDefense,omitempty"`
+	Profiles           ProfileSpec      `json:"profiles,omitempty"`
+	Partition          string           `json:"partition,omitempty"`
+	// TLSPassthrough routes the TLS connections by the server name matching the host, the
+	// TransportServers with the same address and port share the virtual
+	TLSPassthrough bool `json:"tlsPassthrough,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -299,12 +376,63 @@ type ProfileSpec struct {
 	LogProfiles        []string   `json:"logProfiles,omitempty"`
 	ProfileL4          string     `json:"profileL4,omitempty"`
 	ProfileMultiplex   string     `json:"profileMultiplex,omitempty"`
+	// HTTPOptions are rendered by CIS as AS3 profiles in the tenant
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
 }
 type ProfileTCP struct {
 	Client string `json:"client,omitempty"`
 	Server string `json:"server,omitempty"`
 }
 
+// HTTPOptions defines the HTTP behaviours of a virtual server.
+type HTTPOptions struct {
+	Compression     *HTTPCompression   `json:"compression,omitempty"`
+	Caching         *HTTPCaching       `json:"caching,omitempty"`
+	XForwardedFor   bool               `json:"xForwardedFor,omitempty"`
+	HSTS            *HSTS              `json:"hsts,omitempty"`
+	RequestHeaders  HeaderManipulation `json:"requestHeaders,omitempty"`
+	ResponseHeaders HeaderManipulation `json:"responseHeaders,omitempty"`
+	// ServerHeader replaces the Server header of responses, "none" removes it
+	ServerHeader string `json:"serverHeader,omitempty"`
+}
+
+// HTTPCompression defines the HTTP compression profile options.
+type HTTPCompression struct {
+	ContentTypeIncludes []string `json:"contentTypeIncludes,omitempty"`
+	ContentTypeExcludes []string `json:"contentTypeExcludes,omitempty"`
+	MinimumSize         int      `json:"minimumSize,omitempty"`
+	GzipLevel           int      `json:"gzipLevel,omitempty"`
+}
+
+// HTTPCaching defines the web acceleration profile options.
+type HTTPCaching struct {
+	MaximumAge        int      `json:"maximumAge,omitempty"`
+	MaximumEntries    int      `json:"maximumEntries,omitempty"`
+	MaximumObjectSize int      `json:"maximumObjectSize,omitempty"`
+	MinimumObjectSize int      `json:"minimumObjectSize,omitempty"`
+	URIIncludes       []string `json:"uriIncludes,omitempty"`
+	URIExcludes       []string `json:"uriExcludes,omitempty"`
+}
+
+// HSTS defines the HTTP Strict Transport Security headers inserted in responses.
+type HSTS struct {
+	Period            int  `json:"period,omitempty"`
+	IncludeSubdomains bool `json:"includeSubdomains,omitempty"`
+	Preload           bool `json:"preload,omitempty"`
+}
+
+// HeaderManipulation defines the HTTP headers to be inserted and removed.
+type HeaderManipulation struct {
+	Insert []HTTPHeader `json:"insert,omitempty"`
+	Remove []string     `json:"remove,omitempty"`
+}
+
+// HTTPHeader is a HTTP header name and value.
+type HTTPHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -325,3 +453,53 @@ type PolicyList struct {
 
 	Items []Policy `json:"items"`
 }
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:validation:Optional
+// +kubebuilder:subresource:status
+
+// RouteGroup defines the extended spec of a group of OpenShift Routes.
+type RouteGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RouteGroupSpec   `json:"spec"`
+	Status RouteGroupStatus `json:"status,omitempty"`
+}
+
+// RouteGroupSpec is the spec of the RouteGroup resource, the Routes in the
+// namespace or in the namespaces with the namespaceLabel form the group.
+type RouteGroupSpec struct {
+	Namespace              string   `json:"namespace,omitempty"`
+	NamespaceLabel         string   `json:"namespaceLabel,omitempty"`
+	BigIpPartition         string   `json:"bigIpPartition,omitempty"`
+	VServerName            string   `json:"vserverName,omitempty"`
+	VServerAddr            string   `json:"vserverAddr,omitempty"`
+	AdditionalVServerAddrs []string `json:"additionalVserverAddrs,omitempty"`
+	IPAMLabel              string   `json:"ipamLabel,omitempty"`
+	HTTPPort               int32    `json:"vserverHTTPPort,omitempty"`
+	HTTPSPort              int32    `json:"vserverHTTPSPort,omitempty"`
+	SNAT                   string   `json:"snat,omitempty"`
+	AllowOverride          bool     `json:"allowOverride,omitempty"`
+	Policy                 string   `json:"policyCR,omitempty"`
+}
+
+// RouteGroupStatus is the status of the RouteGroup resource.
+type RouteGroupStatus struct {
+	Partition  string   `json:"partition,omitempty"`
+	VSAddress  string   `json:"vsAddress,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+	StatusOk   string   `json:"status,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RouteGroupList is list of RouteGroup resources
+type RouteGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []RouteGroup `json:"items"`
+}
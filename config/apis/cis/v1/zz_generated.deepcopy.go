@@ -131,6 +131,149 @@ func (in *ExternalDNSSpec) DeepCopy() *ExternalDNSSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HSTS) DeepCopyInto(out *HSTS) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HSTS.
+func (in *HSTS) DeepCopy() *HSTS {
+	if in == nil {
+		return nil
+	}
+	out := new(HSTS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPCaching) DeepCopyInto(out *HTTPCaching) {
+	*out = *in
+	if in.URIIncludes != nil {
+		in, out := &in.URIIncludes, &out.URIIncludes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.URIExcludes != nil {
+		in, out := &in.URIExcludes, &out.URIExcludes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPCaching.
+func (in *HTTPCaching) DeepCopy() *HTTPCaching {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPCaching)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPCompression) DeepCopyInto(out *HTTPCompression) {
+	*out = *in
+	if in.ContentTypeIncludes != nil {
+		in, out := &in.ContentTypeIncludes, &out.ContentTypeIncludes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ContentTypeExcludes != nil {
+		in, out := &in.ContentTypeExcludes, &out.ContentTypeExcludes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPCompression.
+func (in *HTTPCompression) DeepCopy() *HTTPCompression {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPCompression)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPHeader) DeepCopyInto(out *HTTPHeader) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPHeader.
+func (in *HTTPHeader) DeepCopy() *HTTPHeader {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPHeader)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPOptions) DeepCopyInto(out *HTTPOptions) {
+	*out = *in
+	if in.Compression != nil {
+		in, out := &in.Compression, &out.Compression
+		*out = new(HTTPCompression)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Caching != nil {
+		in, out := &in.Caching, &out.Caching
+		*out = new(HTTPCaching)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HSTS != nil {
+		in, out := &in.HSTS, &out.HSTS
+		*out = new(HSTS)
+		**out = **in
+	}
+	in.RequestHeaders.DeepCopyInto(&out.RequestHeaders)
+	in.ResponseHeaders.DeepCopyInto(&out.ResponseHeaders)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPOptions.
+func (in *HTTPOptions) DeepCopy() *HTTPOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeaderManipulation) DeepCopyInto(out *HeaderManipulation) {
+	*out = *in
+	if in.Insert != nil {
+		in, out := &in.Insert, &out.Insert
+		*out = make([]HTTPHeader, len(*in))
+		copy(*out, *in)
+	}
+	if in.Remove != nil {
+		in, out := &in.Remove, &out.Remove
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeaderManipulation.
+func (in *HeaderManipulation) DeepCopy() *HeaderManipulation {
+	if in == nil {
+		return nil
+	}
+	out := new(HeaderManipulation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressLink) DeepCopyInto(out *IngressLink) {
 	*out = *in
@@ -192,6 +335,22 @@ func (in *IngressLinkList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressLinkMonitor) DeepCopyInto(out *IngressLinkMonitor) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressLinkMonitor.
+func (in *IngressLinkMonitor) DeepCopy() *IngressLinkMonitor {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressLinkMonitor)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressLinkSpec) DeepCopyInto(out *IngressLinkSpec) {
 	*out = *in
@@ -205,6 +364,16 @@ func (in *IngressLinkSpec) DeepCopyInto(out *IngressLinkSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Monitor != nil {
+		in, out := &in.Monitor, &out.Monitor
+		*out = new(IngressLinkMonitor)
+		**out = **in
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -308,6 +477,22 @@ func (in *Monitor) DeepCopy() *Monitor {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MultiClusterServiceReference) DeepCopyInto(out *MultiClusterServiceReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiClusterServiceReference.
+func (in *MultiClusterServiceReference) DeepCopy() *MultiClusterServiceReference {
+	if in == nil {
+		return nil
+	}
+	out := new(MultiClusterServiceReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Policy) DeepCopyInto(out *Policy) {
 	*out = *in
@@ -398,6 +583,16 @@ func (in *Pool) DeepCopyInto(out *Pool) {
 		*out = make([]Monitor, len(*in))
 		copy(*out, *in)
 	}
+	if in.MultiClusterServices != nil {
+		in, out := &in.MultiClusterServices, &out.MultiClusterServices
+		*out = make([]MultiClusterServiceReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Topology != nil {
+		in, out := &in.Topology, &out.Topology
+		*out = new(PoolTopology)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -411,6 +606,27 @@ func (in *Pool) DeepCopy() *Pool {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolTopology) DeepCopyInto(out *PoolTopology) {
+	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]TopologyGroup, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolTopology.
+func (in *PoolTopology) DeepCopy() *PoolTopology {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolTopology)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProfileSpec) DeepCopyInto(out *ProfileSpec) {
 	*out = *in
@@ -420,6 +636,11 @@ func (in *ProfileSpec) DeepCopyInto(out *ProfileSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.HTTPOptions != nil {
+		in, out := &in.HTTPOptions, &out.HTTPOptions
+		*out = new(HTTPOptions)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -449,6 +670,109 @@ func (in *ProfileTCP) DeepCopy() *ProfileTCP {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteGroup) DeepCopyInto(out *RouteGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteGroup.
+func (in *RouteGroup) DeepCopy() *RouteGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RouteGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteGroupList) DeepCopyInto(out *RouteGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RouteGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteGroupList.
+func (in *RouteGroupList) DeepCopy() *RouteGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RouteGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteGroupSpec) DeepCopyInto(out *RouteGroupSpec) {
+	*out = *in
+	if in.AdditionalVServerAddrs != nil {
+		in, out := &in.AdditionalVServerAddrs, &out.AdditionalVServerAddrs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteGroupSpec.
+func (in *RouteGroupSpec) DeepCopy() *RouteGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteGroupStatus) DeepCopyInto(out *RouteGroupStatus) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteGroupStatus.
+func (in *RouteGroupStatus) DeepCopy() *RouteGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceAddress) DeepCopyInto(out *ServiceAddress) {
 	*out = *in
@@ -573,6 +897,22 @@ func (in *TLSProfileSpec) DeepCopy() *TLSProfileSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologyGroup) DeepCopyInto(out *TopologyGroup) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologyGroup.
+func (in *TopologyGroup) DeepCopy() *TopologyGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologyGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TransportServer) DeepCopyInto(out *TransportServer) {
 	*out = *in
@@ -637,6 +977,11 @@ func (in *TransportServerList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TransportServerSpec) DeepCopyInto(out *TransportServerSpec) {
 	*out = *in
+	if in.VirtualServerPorts != nil {
+		in, out := &in.VirtualServerPorts, &out.VirtualServerPorts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	in.Pool.DeepCopyInto(&out.Pool)
 	if in.AllowVLANs != nil {
 		in, out := &in.AllowVLANs, &out.AllowVLANs
@@ -29,6 +29,7 @@ type CisV1Interface interface {
 	ExternalDNSesGetter
 	IngressLinksGetter
 	PoliciesGetter
+	RouteGroupsGetter
 	TLSProfilesGetter
 	TransportServersGetter
 	VirtualServersGetter
@@ -51,6 +52,10 @@ func (c *CisV1Client) Policies(namespace string) PolicyInterface {
 	return newPolicies(c, namespace)
 }
 
+func (c *CisV1Client) RouteGroups(namespace string) RouteGroupInterface {
+	return newRouteGroups(c, namespace)
+}
+
 func (c *CisV1Client) TLSProfiles(namespace string) TLSProfileInterface {
 	return newTLSProfiles(c, namespace)
 }
@@ -40,6 +40,10 @@ func (c *FakeCisV1) Policies(namespace string) v1.PolicyInterface {
 	return &FakePolicies{c, namespace}
 }
 
+func (c *FakeCisV1) RouteGroups(namespace string) v1.RouteGroupInterface {
+	return &FakeRouteGroups{c, namespace}
+}
+
 func (c *FakeCisV1) TLSProfiles(namespace string) v1.TLSProfileInterface {
 	return &FakeTLSProfiles{c, namespace}
 }
@@ -0,0 +1,142 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	cisv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeRouteGroups implements RouteGroupInterface
+type FakeRouteGroups struct {
+	Fake *FakeCisV1
+	ns   string
+}
+
+var routegroupsResource = schema.GroupVersionResource{Group: "cis.f5.com", Version: "v1", Resource: "routegroups"}
+
+var routegroupsKind = schema.GroupVersionKind{Group: "cis.f5.com", Version: "v1", Kind: "RouteGroup"}
+
+// Get takes name of the routeGroup, and returns the corresponding routeGroup object, and an error if there is any.
+func (c *FakeRouteGroups) Get(ctx context.Context, name string, options v1.GetOptions) (result *cisv1.RouteGroup, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(routegroupsResource, c.ns, name), &cisv1.RouteGroup{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*cisv1.RouteGroup), err
+}
+
+// List takes label and field selectors, and returns the list of RouteGroups that match those selectors.
+func (c *FakeRouteGroups) List(ctx context.Context, opts v1.ListOptions) (result *cisv1.RouteGroupList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(routegroupsResource, routegroupsKind, c.ns, opts), &cisv1.RouteGroupList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &cisv1.RouteGroupList{ListMeta: obj.(*cisv1.RouteGroupList).ListMeta}
+	for _, item := range obj.(*cisv1.RouteGroupList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested routeGroups.
+func (c *FakeRouteGroups) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(routegroupsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a routeGroup and creates it.  Returns the server's representation of the routeGroup, and an error, if there is any.
+func (c *FakeRouteGroups) Create(ctx context.Context, routeGroup *cisv1.RouteGroup, opts v1.CreateOptions) (result *cisv1.RouteGroup, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(routegroupsResource, c.ns, routeGroup), &cisv1.RouteGroup{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*cisv1.RouteGroup), err
+}
+
+// Update takes the representation of a routeGroup and updates it. Returns the server's representation of the routeGroup, and an error, if there is any.
+func (c *FakeRouteGroups) Update(ctx context.Context, routeGroup *cisv1.RouteGroup, opts v1.UpdateOptions) (result *cisv1.RouteGroup, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(routegroupsResource, c.ns, routeGroup), &cisv1.RouteGroup{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*cisv1.RouteGroup), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeRouteGroups) UpdateStatus(ctx context.Context, routeGroup *cisv1.RouteGroup, opts v1.UpdateOptions) (*cisv1.RouteGroup, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(routegroupsResource, "status", c.ns, routeGroup), &cisv1.RouteGroup{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*cisv1.RouteGroup), err
+}
+
+// Delete takes name of the routeGroup and deletes it. Returns an error if one occurs.
+func (c *FakeRouteGroups) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(routegroupsResource, c.ns, name), &cisv1.RouteGroup{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeRouteGroups) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(routegroupsResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &cisv1.RouteGroupList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched routeGroup.
+func (c *FakeRouteGroups) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *cisv1.RouteGroup, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(routegroupsResource, c.ns, name, pt, data, subresources...), &cisv1.RouteGroup{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*cisv1.RouteGroup), err
+}
@@ -24,6 +24,8 @@ type IngressLinkExpansion interface{}
 
 type PolicyExpansion interface{}
 
+type RouteGroupExpansion interface{}
+
 type TLSProfileExpansion interface{}
 
 type TransportServerExpansion interface{}
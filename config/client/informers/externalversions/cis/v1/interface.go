@@ -30,6 +30,8 @@ type Interface interface {
 	IngressLinks() IngressLinkInformer
 	// Policies returns a PolicyInformer.
 	Policies() PolicyInformer
+	// RouteGroups returns a RouteGroupInformer.
+	RouteGroups() RouteGroupInformer
 	// TLSProfiles returns a TLSProfileInformer.
 	TLSProfiles() TLSProfileInformer
 	// TransportServers returns a TransportServerInformer.
@@ -64,6 +66,11 @@ func (v *version) Policies() PolicyInformer {
 	return &policyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// RouteGroups returns a RouteGroupInformer.
+func (v *version) RouteGroups() RouteGroupInformer {
+	return &routeGroupInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // TLSProfiles returns a TLSProfileInformer.
 func (v *version) TLSProfiles() TLSProfileInformer {
 	return &tLSProfileInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
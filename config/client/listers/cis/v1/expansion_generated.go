@@ -42,6 +42,14 @@ type PolicyListerExpansion interface{}
 // PolicyNamespaceLister.
 type PolicyNamespaceListerExpansion interface{}
 
+// RouteGroupListerExpansion allows custom methods to be added to
+// RouteGroupLister.
+type RouteGroupListerExpansion interface{}
+
+// RouteGroupNamespaceListerExpansion allows custom methods to be added to
+// RouteGroupNamespaceLister.
+type RouteGroupNamespaceListerExpansion interface{}
+
 // TLSProfileListerExpansion allows custom methods to be added to
 // TLSProfileLister.
 type TLSProfileListerExpansion interface{}
@@ -0,0 +1,99 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// RouteGroupLister helps list RouteGroups.
+// All objects returned here must be treated as read-only.
+type RouteGroupLister interface {
+	// List lists all RouteGroups in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.RouteGroup, err error)
+	// RouteGroups returns an object that can list and get RouteGroups.
+	RouteGroups(namespace string) RouteGroupNamespaceLister
+	RouteGroupListerExpansion
+}
+
+// routeGroupLister implements the RouteGroupLister interface.
+type routeGroupLister struct {
+	indexer cache.Indexer
+}
+
+// NewRouteGroupLister returns a new RouteGroupLister.
+func NewRouteGroupLister(indexer cache.Indexer) RouteGroupLister {
+	return &routeGroupLister{indexer: indexer}
+}
+
+// List lists all RouteGroups in the indexer.
+func (s *routeGroupLister) List(selector labels.Selector) (ret []*v1.RouteGroup, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.RouteGroup))
+	})
+	return ret, err
+}
+
+// RouteGroups returns an object that can list and get RouteGroups.
+func (s *routeGroupLister) RouteGroups(namespace string) RouteGroupNamespaceLister {
+	return routeGroupNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// RouteGroupNamespaceLister helps list and get RouteGroups.
+// All objects returned here must be treated as read-only.
+type RouteGroupNamespaceLister interface {
+	// List lists all RouteGroups in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.RouteGroup, err error)
+	// Get retrieves the RouteGroup from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.RouteGroup, error)
+	RouteGroupNamespaceListerExpansion
+}
+
+// routeGroupNamespaceLister implements the RouteGroupNamespaceLister
+// interface.
+type routeGroupNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all RouteGroups in the indexer for a given namespace.
+func (s routeGroupNamespaceLister) List(selector labels.Selector) (ret []*v1.RouteGroup, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.RouteGroup))
+	})
+	return ret, err
+}
+
+// Get retrieves the RouteGroup from the indexer for a given namespace and name.
+func (s routeGroupNamespaceLister) Get(name string) (*v1.RouteGroup, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("routegroup"), name)
+	}
+	return obj.(*v1.RouteGroup), nil
+}
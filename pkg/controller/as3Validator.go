@@ -0,0 +1,168 @@
+/*-
+ * Copyright (c) 2019-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"crypto/md5"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// validated results are reset when the cache grows beyond this size
+const as3ValidatedCacheSize = 1024
+
+// as3Validator validates AS3 declarations against the AS3 schema
+type as3Validator struct {
+	schema     *gojsonschema.Schema
+	schemaFile string
+	mutex      sync.Mutex
+	// validated holds the schema errors of the validated declarations, keyed by declaration md5
+	validated map[string][]string
+}
+
+// newAS3Validator loads the AS3 schema of the AS3 version reported by BIG-IP from the schema
+// directory, or the bundled AS3 schema when the schema of that version is not available.
+// It returns nil when no schema could be loaded.
+func (agent *Agent) newAS3Validator(schemaLocal string) *as3Validator {
+	schemaFiles := []string{as3SchemaFileName}
+	if agent.AS3VersionInfo.as3Release != "" {
+		reported := "as3-schema-" + agent.AS3VersionInfo.as3Release + "-cis.json"
+		if reported != as3SchemaFileName {
+			schemaFiles = append([]string{reported}, schemaFiles...)
+		}
+	}
+	for _, schemaFile := range schemaFiles {
+		validator, err := newAS3ValidatorFromFile(schemaLocal + schemaFile)
+		if err != nil {
+			log.Debugf("[AS3] Unable to load AS3 schema %v: %v", schemaLocal+schemaFile, err)
+			continue
+		}
+		log.Infof("[AS3] Validating declarations with AS3 schema %v", schemaFile)
+		return validator
+	}
+	log.Warningf("[AS3] Unable to load AS3 schema from %v, declarations are not validated", schemaLocal)
+	return nil
+}
+
+// newAS3ValidatorFromFile compiles the AS3 definition of the schema at the schema URL
+func newAS3ValidatorFromFile(schemaURL string) (*as3Validator, error) {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(
+		fmt.Sprintf(`{"$ref": "%s#/definitions/AS3"}`, schemaURL)))
+	if err != nil {
+		return nil, err
+	}
+	return &as3Validator{
+		schema:     schema,
+		schemaFile: schemaURL,
+		validated:  make(map[string][]string),
+	}, nil
+}
+
+// validate returns the schema errors of the declaration
+func (v *as3Validator) validate(decl as3Declaration) []string {
+	key := fmt.Sprintf("%x", md5.Sum([]byte(decl)))
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if errs, ok := v.validated[key]; ok {
+		return errs
+	}
+
+	var errs []string
+	result, err := v.schema.Validate(gojsonschema.NewStringLoader(string(decl)))
+	if err != nil {
+		errs = []string{err.Error()}
+	} else if !result.Valid() {
+		seen := make(map[string]struct{})
+		for _, desc := range result.Errors() {
+			// the errors of the combined schemas only repeat the errors of their members
+			switch desc.Type() {
+			case "number_all_of", "number_any_of", "number_one_of", "condition_then", "condition_else":
+				continue
+			}
+			if _, ok := seen[desc.String()]; !ok {
+				seen[desc.String()] = struct{}{}
+				errs = append(errs, desc.String())
+			}
+		}
+		if len(errs) == 0 {
+			errs = append(errs, result.Errors()[0].String())
+		}
+	}
+
+	if len(v.validated) >= as3ValidatedCacheSize {
+		v.validated = make(map[string][]string)
+	}
+	v.validated[key] = errs
+	return errs
+}
+
+// validateTenantDecl validates the tenant declaration and, when it is invalid, validates the declaration
+// of each resource of the tenant. The invalid resources are excluded so that the other resources of
+// the tenant are deployed, and are reported in the status of their kubernetes resources. The tenant
+// declaration is returned as is when no resource is found invalid.
+func (agent *Agent) validateTenantDecl(
+	tenantDecl as3Tenant,
+	rsMap ResourceMap,
+	config ResourceConfigRequest,
+	tenantName string,
+) as3Tenant {
+	if len(agent.as3Validator.validate(agent.createAS3Declaration(map[string]as3Tenant{tenantName: tenantDecl}))) == 0 {
+		return tenantDecl
+	}
+
+	var names []string
+	for name := range rsMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	validRsMap := make(ResourceMap)
+	invalid := make(map[string]*failedResource)
+	var excluded []string
+	for _, name := range names {
+		rsCfg := rsMap[name]
+		rsDecl := createTenantDecl(ResourceMap{name: rsCfg}, config, tenantName)
+		errs := agent.as3Validator.validate(agent.createAS3Declaration(map[string]as3Tenant{tenantName: rsDecl}))
+		if len(errs) > 0 {
+			log.WithFields(log.Fields{
+				"tenant":    tenantName,
+				"resource":  name,
+				"namespace": rsCfg.MetaData.namespace,
+			}).Errorf("[AS3] Excluding resource from the declaration, invalid AS3: %v", strings.Join(errs, "; "))
+			excluded = append(excluded, name)
+			invalid[name] = &failedResource{
+				baseResources: copyBaseResources(rsCfg),
+				message:       "invalid AS3: " + strings.Join(errs, "; "),
+			}
+			continue
+		}
+		validRsMap[name] = rsCfg
+	}
+	if len(excluded) == 0 {
+		log.Warningf("[AS3] Declaration of tenant %v is not valid with the AS3 schema, posting it to BIG-IP", tenantName)
+		return tenantDecl
+	}
+	if agent.invalidResources == nil {
+		agent.invalidResources = make(map[string]map[string]*failedResource)
+	}
+	agent.invalidResources[tenantName] = invalid
+	return createTenantDecl(validRsMap, config, tenantName)
+}
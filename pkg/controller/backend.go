@@ -17,6 +17,7 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -29,7 +30,7 @@ import (
 
 	rsc "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/resource"
 	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
-	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/writer"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -56,27 +57,25 @@ var baseAS3Config = `{
 
 var DEFAULT_PARTITION string
 
-func NewAgent(params AgentParams) *Agent {
-	DEFAULT_PARTITION = params.Partition
+func NewAgent(params AgentParams, netAgent *NetAgent) *Agent {
 	postMgr := NewPostManager(params.PostParams)
-	configWriter, err := writer.NewConfigWriter()
-	if nil != err {
-		log.Fatalf("Failed creating ConfigWriter tool: %v", err)
-	}
 	agent := &Agent{
 		PostManager:           postMgr,
 		Partition:             params.Partition,
-		ConfigWriter:          configWriter,
-		EventChan:             make(chan interface{}),
+		ConfigWriter:          netAgent.ConfigWriter,
+		EventChan:             netAgent.EventChan,
 		postChan:              make(chan ResourceConfigRequest, 1),
 		retryChan:             make(chan struct{}, 1),
+		isolationChan:         make(chan struct{}, 1),
 		respChan:              make(chan resourceStatusMeta, 1),
+		stopCh:                make(chan struct{}),
 		cachedTenantDeclMap:   make(map[string]as3Tenant),
 		incomingTenantDeclMap: make(map[string]as3Tenant),
 		retryTenantDeclMap:    make(map[string]*tenantParams),
 		tenantPriorityMap:     make(map[string]int),
+		failedResources:       make(map[string]map[string]*failedResource),
+		invalidResources:      make(map[string]map[string]*failedResource),
 		userAgent:             params.UserAgent,
-		HttpAddress:           params.HttpAddress,
 		ccclGTMAgent:          params.CCCLGTMAgent,
 	}
 	// agentWorker runs as a separate go routine
@@ -87,77 +86,35 @@ func NewAgent(params AgentParams) *Agent {
 	// blocks on retryChan ; retries failed declarations and polls for accepted tenant statuses
 	go agent.retryWorker()
 
-	// If running in VXLAN mode, extract the partition name from the tunnel
-	// to be used in configuring a net instance of CCCL for that partition
-	var vxlanPartition string
-	if len(params.VXLANName) > 0 {
-		cleanPath := strings.TrimLeft(params.VXLANName, "/")
-		slashPos := strings.Index(cleanPath, "/")
-		if slashPos == -1 {
-			// No partition
-			vxlanPartition = "Common"
-		} else {
-			// Partition and name
-			vxlanPartition = cleanPath[:slashPos]
-		}
-	}
-
-	gs := globalSection{
-		LogLevel:       params.LogLevel,
-		VerifyInterval: params.VerifyInterval,
-		VXLANPartition: vxlanPartition,
-		DisableLTM:     true,
-		GTM:            params.CCCLGTMAgent,
-		DisableARP:     params.DisableARP,
-	}
+	// failedResourceWorker runs as a separate go routine
+	// retries the resources rejected by BIG-IP which are excluded from their tenants
+	go agent.failedResourceWorker()
 
-	bs := bigIPSection{
-		BigIPUsername:   params.PostParams.BIGIPUsername,
-		BigIPPassword:   params.PostParams.BIGIPPassword,
-		BigIPURL:        params.PostParams.BIGIPURL,
-		BigIPPartitions: []string{params.Partition},
-	}
+	// failedResourceIsolationWorker runs as a separate go routine
+	// identifies the resources of the tenants rejected by BIG-IP and posts the tenants without them
+	go agent.failedResourceIsolationWorker()
 
-	var gtm gtmBigIPSection
-	if len(params.GTMParams.GTMBigIpUrl) == 0 || len(params.GTMParams.GTMBigIpUsername) == 0 || len(params.GTMParams.GTMBigIpPassword) == 0 {
-		// gs.GTM = false
-		gtm = gtmBigIPSection{
-			GtmBigIPUsername: params.PostParams.BIGIPUsername,
-			GtmBigIPPassword: params.PostParams.BIGIPPassword,
-			GtmBigIPURL:      params.PostParams.BIGIPURL,
-		}
-		log.Warning("Creating GTM with default bigip credentials as GTM BIGIP Url or GTM BIGIP Username or GTM BIGIP Password is missing on CIS args.")
-	} else {
-		gtm = gtmBigIPSection{
-			GtmBigIPUsername: params.GTMParams.GTMBigIpUsername,
-			GtmBigIPPassword: params.GTMParams.GTMBigIpPassword,
-			GtmBigIPURL:      params.GTMParams.GTMBigIpUrl,
-		}
-	}
-	//For IPV6 net config is not required. f5-sdk doesnt support ipv6
-	if !(params.EnableIPV6) {
-		agent.startPythonDriver(
-			gs,
-			bs,
-			gtm,
-			params.PythonBaseDir,
-		)
-	}
 	// Set the AS3 version for the agent
-	err = agent.IsBigIPAppServicesAvailable()
+	err := agent.IsBigIPAppServicesAvailable()
 	if err != nil {
 		log.Errorf("%v", err)
 		agent.Stop()
+		netAgent.Stop()
 		os.Exit(1)
 	}
+	if params.AS3Validation {
+		agent.as3Validator = agent.newAS3Validator(params.SchemaLocal)
+	}
 	return agent
 }
 
+// Stop stops the workers of the agent, the config writer is stopped with the NetAgent
 func (agent *Agent) Stop() {
-	agent.ConfigWriter.Stop()
-	if !(agent.EnableIPV6) {
-		agent.stopPythonDriver()
-	}
+	agent.stopOnce.Do(func() {
+		if agent.stopCh != nil {
+			close(agent.stopCh)
+		}
+	})
 }
 
 // Method to verify if App Services are installed or CIS as3 version is
@@ -214,6 +171,29 @@ func (agent *Agent) PostConfig(rsConfig ResourceConfigRequest) {
 	}
 }
 
+// StatusChan returns the channel of the status of the posted resource configs
+func (agent *Agent) StatusChan() chan resourceStatusMeta {
+	return agent.respChan
+}
+
+// BuildDeclaration returns the AS3 declaration of all the tenants of the resource config,
+// the agent is not updated
+func (agent *Agent) BuildDeclaration(config ResourceConfigRequest) ([]byte, error) {
+	// the failed resources are read under the declaration lock
+	agent.declUpdate.Lock()
+	adc := agent.buildAS3LTMConfigADC(config)
+	agent.declUpdate.Unlock()
+	if !agent.ccclGTMAgent {
+		adc = agent.createAS3GTMConfigADC(config, adc)
+	}
+
+	tenantDeclMap := make(map[string]as3Tenant)
+	for tenant, cfg := range adc {
+		tenantDeclMap[tenant] = cfg.(as3Tenant)
+	}
+	return []byte(agent.createAS3Declaration(tenantDeclMap)), nil
+}
+
 // agentWorker blocks on postChan
 // whenever it gets unblocked, it creates an as3 declaration for modified tenants and posts the request
 func (agent *Agent) agentWorker() {
@@ -232,7 +212,10 @@ func (agent *Agent) agentWorker() {
 			agent.PostGTMConfig(rsConfig)
 		}
 
+		_, declSpan := startSpan(rsConfig.traceCtx, "createTenantAS3Declaration", attribute.Int("request.id", rsConfig.reqId))
 		decl := agent.createTenantAS3Declaration(rsConfig)
+		declSpan.SetAttributes(attribute.Int("tenants.updated", len(agent.incomingTenantDeclMap)))
+		declSpan.End()
 
 		if len(agent.incomingTenantDeclMap) == 0 {
 			agent.declUpdate.Unlock()
@@ -272,15 +255,25 @@ func (agent *Agent) agentWorker() {
 
 // Post the tenants declaration
 func (agent *Agent) postTenantsDeclaration(decl as3Declaration, rsConfig ResourceConfigRequest, tenants []string) {
+	ctx, span := startSpan(rsConfig.traceCtx, "postTenantsDeclaration",
+		attribute.Int("request.id", rsConfig.reqId), attribute.StringSlice("tenants", tenants))
+	defer span.End()
 	cfg := agentConfig{
 		data:      string(decl),
 		as3APIURL: agent.getAS3APIURL(tenants),
 		id:        rsConfig.reqId,
+		tenants:   tenants,
+		traceCtx:  ctx,
 	}
 
+	// the isolation of the failed resources of the tenants is superseded by the new declaration
+	for _, tenant := range tenants {
+		delete(agent.isolatingTenants, tenant)
+	}
+	log.WithFields(log.Fields{"id": cfg.id, "tenants": tenants}).Debug("[AS3] Posting tenants declaration")
 	agent.publishConfig(cfg)
 
-	go agent.updatePoolMembers(rsConfig)
+	go sendPoolMembers(agent.EventChan, rsConfig)
 
 	agent.updateTenantResponse(true)
 
@@ -297,21 +290,31 @@ func (agent *Agent) postTenantsDeclaration(decl as3Declaration, rsConfig Resourc
 		If there are any tenants with 201 response code,
 		poll for its status continuously and block incoming requests
 	*/
+	_, pollSpan := startSpan(ctx, "pollTenantStatus", attribute.Int("request.id", cfg.id))
 	agent.pollTenantStatus()
+	pollSpan.End()
+
+	// the isolation worker posts the tenants rejected by BIG-IP without their failed resources
+	agent.queueFailedResourceIsolation(rsConfig)
 
 	// notify resourceStatusUpdate response handler on successful tenant update
-	agent.notifyRscStatusHandler(cfg.id, true)
+	agent.notifyRscStatusHandler(cfg.traceCtx, cfg.id, true)
 }
 
-func (agent *Agent) notifyRscStatusHandler(id int, overwriteCfg bool) {
+func (agent *Agent) notifyRscStatusHandler(traceCtx context.Context, id int, overwriteCfg bool) {
 
 	rscUpdateMeta := resourceStatusMeta{
-		id,
-		make(map[string]struct{}),
+		id:              id,
+		failedTenants:   make(map[string]struct{}),
+		failedResources: agent.failedResourceStatus(),
+		traceCtx:        traceCtx,
 	}
 	for tenant := range agent.retryTenantDeclMap {
 		rscUpdateMeta.failedTenants[tenant] = struct{}{}
 	}
+	for tenant := range agent.isolatingTenants {
+		rscUpdateMeta.failedTenants[tenant] = struct{}{}
+	}
 	// If triggerred from retry block, process the previous successful request completely
 	if !overwriteCfg {
 		agent.respChan <- rscUpdateMeta
@@ -344,7 +347,11 @@ func (agent *Agent) updateRetryMap(tenant string, resp tenantResponse, tenDecl i
 	}
 }
 
-func (agent *Agent) updatePoolMembers(rsConfig ResourceConfigRequest) {
+// sendPoolMembers sends the pool members of the resource config to the vxlan manager
+func sendPoolMembers(eventChan chan interface{}, rsConfig ResourceConfigRequest) {
+	if eventChan == nil {
+		return
+	}
 	allPoolMembers := rsConfig.ltmConfig.GetAllPoolMembers()
 
 	// Convert allPoolMembers to rsc.Members so that vxlan Manger accepts
@@ -353,15 +360,18 @@ func (agent *Agent) updatePoolMembers(rsConfig ResourceConfigRequest) {
 	for _, poolMem := range allPoolMembers {
 		allPoolMems = append(
 			allPoolMems,
-			rsc.Member(poolMem),
+			rsc.Member{
+				Address: poolMem.Address,
+				Port:    poolMem.Port,
+				SvcPort: poolMem.SvcPort,
+				Session: poolMem.Session,
+			},
 		)
 	}
-	if agent.EventChan != nil {
-		select {
-		case agent.EventChan <- allPoolMems:
-			log.Debugf("Controller wrote endpoints to VxlanMgr")
-		case <-time.After(3 * time.Second):
-		}
+	select {
+	case eventChan <- allPoolMems:
+		log.Debugf("Controller wrote endpoints to VxlanMgr")
+	case <-time.After(3 * time.Second):
 	}
 }
 
@@ -428,7 +438,7 @@ func (agent *Agent) retryWorker() {
 			//If there are any failed tenants, retry posting them
 			agent.retryFailedTenant()
 
-			agent.notifyRscStatusHandler(0, false)
+			agent.notifyRscStatusHandler(context.Background(), 0, false)
 
 			agent.declUpdate.Unlock()
 		}
@@ -687,53 +697,81 @@ func (agent *Agent) createAS3LTMConfigADC(config ResourceConfigRequest) as3ADC {
 		if partitionConfig.Priority > 0 {
 			agent.tenantPriorityMap[tenantName] = partitionConfig.Priority
 		}
+		delete(agent.invalidResources, tenantName)
 		if len(partitionConfig.ResourceMap) == 0 {
-			if agent.Partition == tenantName {
-				// Flush Partition contents
-				sharedApp := as3Application{}
-				sharedApp["class"] = "Application"
-				sharedApp["template"] = "shared"
-
-				tenantDecl := as3Tenant{
-					"class":              "Tenant",
-					as3SharedApplication: sharedApp,
-				}
-				adc[tenantName] = tenantDecl
-			} else {
-				// Remove Partition
-				adc[tenantName] = as3Tenant{
-					"class": "Tenant",
-				}
-			}
+			adc[tenantName] = agent.createFlushedTenantDecl(tenantName)
 			continue
 		}
-		// Create Shared as3Application object
-		sharedApp := as3Application{}
-		sharedApp["class"] = "Application"
-		sharedApp["template"] = "shared"
+		// the resources rejected by BIG-IP are excluded till they are modified
+		rsMap := agent.excludeFailedResources(tenantName, partitionConfig.ResourceMap, config)
+		tenantDecl := createTenantDecl(rsMap, config, tenantName)
+		if agent.as3Validator != nil {
+			tenantDecl = agent.validateTenantDecl(tenantDecl, rsMap, config, tenantName)
+		}
+		adc[tenantName] = tenantDecl
+	}
+	return adc
+}
 
-		// Process rscfg to create AS3 Resources
-		processResourcesForAS3(partitionConfig.ResourceMap, sharedApp, config.shareNodes, tenantName)
+// buildAS3LTMConfigADC creates the tenants of the resource config without updating the
+// agent. The unmodified failed resources are excluded, the tenants are not validated.
+func (agent *Agent) buildAS3LTMConfigADC(config ResourceConfigRequest) as3ADC {
+	adc := as3ADC{}
+	for tenantName, partitionConfig := range config.ltmConfig {
+		if len(partitionConfig.ResourceMap) == 0 {
+			adc[tenantName] = agent.createFlushedTenantDecl(tenantName)
+			continue
+		}
+		rsMap, _ := agent.filterFailedResources(tenantName, partitionConfig.ResourceMap, config)
+		adc[tenantName] = createTenantDecl(rsMap, config, tenantName)
+	}
+	return adc
+}
 
-		// Process CustomProfiles
-		processCustomProfilesForAS3(partitionConfig.ResourceMap, sharedApp)
+// createFlushedTenantDecl creates the tenant without resources, the default partition is
+// flushed and the other partitions are removed
+func (agent *Agent) createFlushedTenantDecl(tenantName string) as3Tenant {
+	if agent.Partition != tenantName {
+		return as3Tenant{
+			"class": "Tenant",
+		}
+	}
+	sharedApp := as3Application{}
+	sharedApp["class"] = "Application"
+	sharedApp["template"] = "shared"
 
-		// Process Profiles
-		processProfilesForAS3(partitionConfig.ResourceMap, sharedApp)
+	return as3Tenant{
+		"class":              "Tenant",
+		as3SharedApplication: sharedApp,
+	}
+}
 
-		processIRulesForAS3(partitionConfig.ResourceMap, sharedApp)
+// createTenantDecl creates the AS3 tenant of the resources of the partition
+func createTenantDecl(rsMap ResourceMap, config ResourceConfigRequest, tenantName string) as3Tenant {
+	// Create Shared as3Application object
+	sharedApp := as3Application{}
+	sharedApp["class"] = "Application"
+	sharedApp["template"] = "shared"
 
-		processDataGroupForAS3(partitionConfig.ResourceMap, sharedApp)
+	// Process rscfg to create AS3 Resources
+	processResourcesForAS3(rsMap, sharedApp, config.shareNodes, tenantName)
 
-		// Create AS3 Tenant
-		tenantDecl := as3Tenant{
-			"class":              "Tenant",
-			"defaultRouteDomain": config.defaultRouteDomain,
-			as3SharedApplication: sharedApp,
-		}
-		adc[tenantName] = tenantDecl
+	// Process CustomProfiles
+	processCustomProfilesForAS3(rsMap, sharedApp)
+
+	// Process Profiles
+	processProfilesForAS3(rsMap, sharedApp)
+
+	processIRulesForAS3(rsMap, sharedApp)
+
+	processDataGroupForAS3(rsMap, sharedApp)
+
+	// Create AS3 Tenant
+	return as3Tenant{
+		"class":              "Tenant",
+		"defaultRouteDomain": config.defaultRouteDomain,
+		as3SharedApplication: sharedApp,
 	}
-	return adc
 }
 
 func processIRulesForAS3(rsMap ResourceMap, sharedApp as3Application) {
@@ -825,11 +863,53 @@ func createPoliciesDecl(cfg *ResourceConfig, sharedApp as3Application) {
 
 			ep.Rules = append(ep.Rules, rulesData)
 		}
+		addDefaultWAFActions(ep, cfg.Virtual.WAF)
 		//Setting Endpoint_Policy Name
 		sharedApp[pl.Name] = ep
 	}
 }
 
+// addDefaultWAFActions adds the WAF action of the virtual to the rules without WAF
+// action and a default rule, as BIG-IP requires a WAF action in every rule of a policy
+// when any rule has one. WAF is disabled when the virtual has no WAF policy.
+func addDefaultWAFActions(ep *as3EndpointPolicy, virtualWAF string) {
+	hasWAFRule := func(rule *as3Rule) bool {
+		for _, action := range rule.Actions {
+			if action.Type == "waf" {
+				return true
+			}
+		}
+		return false
+	}
+	wafRuleFound := false
+	for _, rule := range ep.Rules {
+		if hasWAFRule(rule) {
+			wafRuleFound = true
+			break
+		}
+	}
+	if !wafRuleFound {
+		return
+	}
+
+	defaultAction := &as3Action{Type: "waf"}
+	if virtualWAF != "" {
+		defaultAction.Policy = &as3ResourcePointer{BigIP: virtualWAF}
+	} else {
+		enabled := false
+		defaultAction.Enabled = &enabled
+	}
+	for _, rule := range ep.Rules {
+		if !hasWAFRule(rule) {
+			rule.Actions = append(rule.Actions, defaultAction)
+		}
+	}
+	ep.Rules = append(ep.Rules, &as3Rule{
+		Name:    "default_waf",
+		Actions: []*as3Action{defaultAction},
+	})
+}
+
 // Create AS3 Pools for CRD
 func createPoolDecl(cfg *ResourceConfig, sharedApp as3Application, shareNodes bool, tenant string) {
 	for _, v := range cfg.Pools {
@@ -838,14 +918,24 @@ func createPoolDecl(cfg *ResourceConfig, sharedApp as3Application, shareNodes bo
 		pool.Class = "Pool"
 		pool.ReselectTries = v.ReselectTries
 		pool.ServiceDownAction = v.ServiceDownAction
+		pool.MinimumMonitors = v.MinimumMonitors
+		pool.MinimumMembersActive = v.MinActiveMembers
 		for _, val := range v.Members {
 			var member as3PoolMember
 			member.AddressDiscovery = "static"
 			member.ServicePort = val.Port
 			member.ServerAddresses = append(member.ServerAddresses, val.Address)
+			member.Ratio = val.Ratio
+			member.PriorityGroup = val.PriorityGroup
 			if shareNodes {
 				member.ShareNodes = shareNodes
 			}
+			switch val.Session {
+			case MemberSessionDisabled:
+				member.AdminState = "disable"
+			case MemberSessionOffline:
+				member.AdminState = "offline"
+			}
 			pool.Members = append(pool.Members, member)
 		}
 		for _, val := range v.MonitorNames {
@@ -890,7 +980,15 @@ func processIrulesForCRD(cfg *ResourceConfig, svc *as3Service) {
 		if strings.HasSuffix(iRuleNoPort, HttpRedirectIRuleName) ||
 			strings.HasSuffix(iRuleNoPort, HttpRedirectNoHostIRuleName) ||
 			strings.HasSuffix(iRuleName, TLSIRuleName) ||
-			strings.HasSuffix(iRuleName, ABPathIRuleName) {
+			strings.HasSuffix(iRuleName, ABPathIRuleName) ||
+			strings.HasSuffix(iRuleName, HTTPHeaderIRuleName) ||
+			strings.HasSuffix(iRuleName, ProxyProtocolIRuleName) ||
+			strings.HasSuffix(iRuleName, PortRangeIRuleName) {
+			// HTTP events can not be attached to passthrough virtual
+			if strings.HasSuffix(iRuleName, HTTPHeaderIRuleName) &&
+				cfg.Virtual.TLSTermination == TLSPassthrough {
+				continue
+			}
 
 			IRules = append(IRules, iRuleName)
 		} else {
@@ -942,8 +1040,9 @@ func createServiceDecl(cfg *ResourceConfig, sharedApp as3Application, tenant str
 	if cfg.Virtual.TLSTermination != TLSPassthrough {
 		svc.Layer4 = cfg.Virtual.IpProtocol
 		svc.Source = "0.0.0.0/0"
+		translateServerPort := true
 		svc.TranslateServerAddress = true
-		svc.TranslateServerPort = true
+		svc.TranslateServerPort = &translateServerPort
 		svc.Class = "Service_HTTP"
 	} else {
 		if len(cfg.Virtual.PersistenceProfile) == 0 {
@@ -1027,6 +1126,11 @@ func createServiceDecl(cfg *ResourceConfig, sharedApp as3Application, tenant str
 		}
 	}
 
+	// Attaching HTTP profiles rendered from Policy CRD
+	if cfg.Virtual.HTTPOptions != nil && cfg.Virtual.TLSTermination != TLSPassthrough {
+		createHTTPOptionsDecl(cfg, sharedApp, svc, tenant)
+	}
+
 	//Attaching WAF policy
 	if cfg.Virtual.WAF != "" {
 		svc.WAF = &as3ResourcePointer{
@@ -1055,6 +1159,57 @@ func createServiceDecl(cfg *ResourceConfig, sharedApp as3Application, tenant str
 	sharedApp[cfg.Virtual.Name] = svc
 }
 
+// Create AS3 HTTP, HTTP compression and web acceleration profiles for the HTTP options of Virtual
+func createHTTPOptionsDecl(cfg *ResourceConfig, sharedApp as3Application, svc *as3Service, tenant string) {
+	httpOptions := cfg.Virtual.HTTPOptions
+	profilePath := func(name string) string {
+		return fmt.Sprintf("/%s/%s/%s", tenant, as3SharedApplication, name)
+	}
+
+	hsts := httpOptions.HSTS != nil && cfg.MetaData.Protocol == "https"
+	if httpOptions.XForwardedFor || hsts {
+		httpProfile := &as3HTTPProfile{
+			Class:         "HTTP_Profile",
+			XForwardedFor: httpOptions.XForwardedFor,
+		}
+		if hsts {
+			httpProfile.HSTSInsert = true
+			httpProfile.HSTSPeriod = httpOptions.HSTS.Period
+			httpProfile.HSTSIncludeSubdomains = httpOptions.HSTS.IncludeSubdomains
+			httpProfile.HSTSPreload = httpOptions.HSTS.Preload
+		}
+		name := cfg.Virtual.Name + "_http_profile"
+		sharedApp[name] = httpProfile
+		svc.ProfileHTTP = &as3ResourcePointer{Use: profilePath(name)}
+	}
+
+	if httpOptions.Compression != nil {
+		name := cfg.Virtual.Name + "_http_compress"
+		sharedApp[name] = &as3HTTPCompress{
+			Class:               "HTTP_Compress",
+			ContentTypeIncludes: httpOptions.Compression.ContentTypeIncludes,
+			ContentTypeExcludes: httpOptions.Compression.ContentTypeExcludes,
+			MinimumSize:         httpOptions.Compression.MinimumSize,
+			GzipLevel:           httpOptions.Compression.GzipLevel,
+		}
+		svc.ProfileHTTPCompression = &as3ResourcePointer{Use: profilePath(name)}
+	}
+
+	if httpOptions.Caching != nil {
+		name := cfg.Virtual.Name + "_web_acceleration"
+		sharedApp[name] = &as3HTTPAcceleration{
+			Class:             "HTTP_Acceleration_Profile",
+			MaximumAge:        httpOptions.Caching.MaximumAge,
+			MaximumEntries:    httpOptions.Caching.MaximumEntries,
+			MaximumObjectSize: httpOptions.Caching.MaximumObjectSize,
+			MinimumObjectSize: httpOptions.Caching.MinimumObjectSize,
+			URIIncludeList:    httpOptions.Caching.URIIncludes,
+			URIExcludeList:    httpOptions.Caching.URIExcludes,
+		}
+		svc.ProfileHTTPAcceleration = &as3ResourcePointer{Use: profilePath(name)}
+	}
+}
+
 // Create AS3 Service Address for Virtual Server Address
 func createServiceAddressDecl(cfg *ResourceConfig, virtualAddress string, sharedApp as3Application) string {
 	var name string
@@ -1171,6 +1326,10 @@ func createRuleAction(rl *Rule, rulesData *as3Rule) {
 		if v.Location != "" {
 			action.Location = v.Location
 		}
+		if v.WAF != "" {
+			action.Type = "waf"
+			action.Policy = &as3ResourcePointer{BigIP: v.WAF}
+		}
 		// Handle vsHostname rewrite.
 		if v.Replace && v.HTTPHost {
 			action.Replace = &as3ActionReplaceMap{
@@ -1442,6 +1601,18 @@ func createTLSClient(
 func createMonitorDecl(cfg *ResourceConfig, sharedApp as3Application) {
 
 	for _, v := range cfg.Monitors {
+		// inband monitor observes the traffic to the pool members and has none of the probe options
+		if v.Type == "inband" {
+			sharedApp[v.Name] = &as3InbandMonitor{
+				Class:           "Monitor",
+				MonitorType:     v.Type,
+				Failures:        v.Failures,
+				FailureInterval: v.FailureInterval,
+				ResponseTime:    v.ResponseTime,
+				RetryTime:       v.RetryTime,
+			}
+			continue
+		}
 		monitor := &as3Monitor{}
 		monitor.Class = "Monitor"
 		monitor.Interval = v.Interval
@@ -1451,6 +1622,11 @@ func createMonitorDecl(cfg *ResourceConfig, sharedApp as3Application) {
 		monitor.TargetPort = v.TargetPort
 		targetAddressStr := ""
 		monitor.TargetAddress = &targetAddressStr
+		monitor.UpInterval = v.UpInterval
+		if v.TimeUntilUp != 0 {
+			timeUntilUp := v.TimeUntilUp
+			monitor.TimeUnitilUp = &timeUntilUp
+		}
 		//Monitor type
 		switch v.Type {
 		case "http":
@@ -1461,27 +1637,64 @@ func createMonitorDecl(cfg *ResourceConfig, sharedApp as3Application) {
 			if v.Recv != "" {
 				monitor.Receive = v.Recv
 			}
-			monitor.TimeUnitilUp = &val
+			if monitor.TimeUnitilUp == nil {
+				monitor.TimeUnitilUp = &val
+			}
 			monitor.Send = v.Send
+			monitor.Reverse = v.Reverse
 		case "https":
-			//Todo: For https monitor type
 			adaptiveFalse := false
 			monitor.Adaptive = &adaptiveFalse
 			if v.Recv != "" {
 				monitor.Receive = v.Recv
 			}
 			monitor.Send = v.Send
+			monitor.Reverse = v.Reverse
+			createMonitorTLSDecl(v, monitor, sharedApp)
 		case "tcp", "udp":
 			adaptiveFalse := false
 			monitor.Adaptive = &adaptiveFalse
 			monitor.Receive = v.Recv
 			monitor.Send = v.Send
+			monitor.Reverse = v.Reverse
+		case "dns":
+			monitor.QueryName = v.QueryName
+			monitor.QueryType = v.QueryType
+			monitor.Receive = v.Recv
+		case "external":
+			monitor.Pathname = v.Script
+			monitor.Arguments = v.Arguments
 		}
 		sharedApp[v.Name] = monitor
 	}
 
 }
 
+// Create AS3 Certificate and TLS_Client for the client certificate and SNI of https monitor
+func createMonitorTLSDecl(v Monitor, monitor *as3Monitor, sharedApp as3Application) {
+	var certName string
+	if v.ClientCert != "" && v.ClientKey != "" {
+		certName = v.Name + "_client_cert"
+		sharedApp[certName] = &as3Certificate{
+			Class:       "Certificate",
+			Certificate: v.ClientCert,
+			PrivateKey:  v.ClientKey,
+		}
+	}
+	if v.ServerName == "" {
+		monitor.ClientCertificate = certName
+		return
+	}
+	// SNI is supported only with TLS_Client
+	tlsClientName := v.Name + "_tls_client"
+	sharedApp[tlsClientName] = &as3TLSClient{
+		Class:             "TLS_Client",
+		ServerName:        v.ServerName,
+		ClientCertificate: certName,
+	}
+	monitor.ClientTLS = &as3ResourcePointer{Use: tlsClientName}
+}
+
 // Create AS3 transport Service for CRD
 func createTransportServiceDecl(cfg *ResourceConfig, sharedApp as3Application) {
 	svc := &as3Service{}
@@ -1502,6 +1715,10 @@ func createTransportServiceDecl(cfg *ResourceConfig, sharedApp as3Application) {
 		} else {
 			svc.Layer4 = "tcp"
 		}
+	} else if cfg.Virtual.Mode == "forwarding" {
+		svc.Class = "Service_Forwarding"
+		svc.ForwardingType = "ip"
+		svc.Layer4 = cfg.Virtual.IpProtocol
 	}
 
 	svc.ProfileL4 = "basic"
@@ -1511,7 +1728,10 @@ func createTransportServiceDecl(cfg *ResourceConfig, sharedApp as3Application) {
 		}
 	}
 
-	svc.addPersistenceMethod(cfg.Virtual.PersistenceProfile)
+	// forwarding virtuals do not persist the connections
+	if cfg.Virtual.Mode != "forwarding" {
+		svc.addPersistenceMethod(cfg.Virtual.PersistenceProfile)
+	}
 
 	if len(cfg.Virtual.ProfileDOS) > 0 {
 		svc.ProfileDOS = &as3ResourcePointer{
@@ -1519,7 +1739,8 @@ func createTransportServiceDecl(cfg *ResourceConfig, sharedApp as3Application) {
 		}
 	}
 
-	if len(cfg.Virtual.ProfileBotDefense) > 0 {
+	// forwarding virtuals can not have a bot defense profile
+	if len(cfg.Virtual.ProfileBotDefense) > 0 && cfg.Virtual.Mode != "forwarding" {
 		svc.ProfileBotDefense = &as3ResourcePointer{
 			BigIP: cfg.Virtual.ProfileBotDefense,
 		}
@@ -1565,14 +1786,19 @@ func createTransportServiceDecl(cfg *ResourceConfig, sharedApp as3Application) {
 		svc.TranslateServerAddress = cfg.Virtual.TranslateServerAddress
 	}
 	if cfg.Virtual.TranslateServerPort == true {
-		svc.TranslateServerPort = cfg.Virtual.TranslateServerPort
+		svc.TranslateServerPort = &cfg.Virtual.TranslateServerPort
 	}
 	if cfg.Virtual.Source != "" {
 		svc.Source = cfg.Virtual.Source
 	}
 	virtualAddress, port := extractVirtualAddressAndPort(cfg.Virtual.Destination)
-	// verify that ip address and port exists.
-	if virtualAddress != "" && port != 0 {
+	// verify that ip address exists, port 0 is the wildcard port.
+	if virtualAddress != "" {
+		if port == 0 {
+			// wildcard port virtual keeps the destination port of the connection
+			translateServerPort := false
+			svc.TranslateServerPort = &translateServerPort
+		}
 		if len(cfg.ServiceAddress) == 0 {
 			va := append(svc.VirtualAddresses, virtualAddress)
 			svc.VirtualAddresses = va
@@ -2,7 +2,10 @@ package controller
 
 import (
 	"encoding/json"
+	"path/filepath"
+	"strings"
 
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
 	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/test"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -292,6 +295,30 @@ var _ = Describe("Backend Tests", func() {
 			Expect(agent.incomingTenantDeclMap["default"]).To(Equal(deletedTenantDecl), "Failed to Create AS3 Declaration for deleted tenant")
 			Expect(adc["default"]).To(Equal(map[string]interface{}(deletedTenantDecl)), "Failed to Create AS3 Declaration for deleted tenant")
 		})
+		It("Builds the declaration of all the tenants", func() {
+			agent.cachedTenantDeclMap = map[string]as3Tenant{"default": {"class": "Tenant"}}
+			config := ResourceConfigRequest{ltmConfig: make(LTMConfig)}
+			config.ltmConfig["default"] = &PartitionConfig{make(ResourceMap), 1}
+			decl, err := agent.BuildDeclaration(config)
+			Expect(err).To(BeNil())
+			var as3Config map[string]interface{}
+			Expect(json.Unmarshal(decl, &as3Config)).To(BeNil())
+			adc := as3Config["declaration"].(map[string]interface{})
+			Expect(adc["default"]).To(Equal(map[string]interface{}{"class": "Tenant"}),
+				"Declaration should include the tenants which are not modified")
+			Expect(agent.tenantPriorityMap).To(BeEmpty(), "Tenant priorities should not be modified")
+
+			// the failed resources are excluded without updating the agent
+			rsCfg := &ResourceConfig{}
+			rsCfg.MetaData.Active = true
+			rsCfg.Virtual.Name = "crd_vs_2"
+			rsCfg.Virtual.Destination = "/default/10.1.1.2:80"
+			config.ltmConfig["default"].ResourceMap["crd_vs_2"] = rsCfg
+			agent.failedResources = map[string]map[string]*failedResource{"default": {"crd_vs_1": {}}}
+			_, err = agent.BuildDeclaration(config)
+			Expect(err).To(BeNil())
+			Expect(agent.failedResources["default"]).To(HaveKey("crd_vs_1"), "Failed resources should not be modified")
+		})
 		It("Handles Persistence Methods", func() {
 			svc := &as3Service{}
 			// Default persistence methods
@@ -420,6 +447,144 @@ var _ = Describe("Backend Tests", func() {
 			Expect(ok).To(BeTrue())
 			Expect(val).NotTo(BeNil())
 		})
+
+		It("Monitor declaration", func() {
+			rsCfg := &ResourceConfig{}
+			rsCfg.Monitors = Monitors{
+				{
+					Name: "https_monitor",
+					Type: "https",
+					MonitorOptions: MonitorOptions{
+						ServerName: "db.example.com",
+						ClientCert: "cert",
+						ClientKey:  "key",
+						Reverse:    true,
+					},
+				},
+				{
+					Name:           "dns_monitor",
+					Type:           "dns",
+					Recv:           "10.1.1.1",
+					MonitorOptions: MonitorOptions{QueryName: "example.com", QueryType: "a"},
+				},
+				{
+					Name:           "ext_monitor",
+					Type:           "external",
+					MonitorOptions: MonitorOptions{Script: "/Common/db_check", Arguments: "--db test"},
+				},
+				{
+					Name:           "tcp_monitor",
+					Type:           "tcp",
+					MonitorOptions: MonitorOptions{TimeUntilUp: 10, UpInterval: 20},
+				},
+				{
+					Name:           "inband_monitor",
+					Type:           "inband",
+					Interval:       5,
+					MonitorOptions: MonitorOptions{Failures: 5, FailureInterval: 60},
+				},
+			}
+			app := as3Application{}
+			createMonitorDecl(rsCfg, app)
+
+			monitor := app["https_monitor"].(*as3Monitor)
+			Expect(monitor.Reverse).To(BeTrue())
+			Expect(monitor.ClientTLS).To(Equal(&as3ResourcePointer{Use: "https_monitor_tls_client"}))
+			tlsClient := app["https_monitor_tls_client"].(*as3TLSClient)
+			Expect(tlsClient.ServerName).To(Equal("db.example.com"))
+			Expect(tlsClient.ClientCertificate).To(Equal("https_monitor_client_cert"))
+			Expect(app["https_monitor_client_cert"]).NotTo(BeNil(), "Client certificate not created")
+
+			monitor = app["dns_monitor"].(*as3Monitor)
+			Expect(monitor.QueryName).To(Equal("example.com"))
+			Expect(monitor.Receive).To(Equal("10.1.1.1"))
+
+			monitor = app["ext_monitor"].(*as3Monitor)
+			Expect(monitor.Pathname).To(Equal("/Common/db_check"))
+			Expect(monitor.Arguments).To(Equal("--db test"))
+
+			monitor = app["tcp_monitor"].(*as3Monitor)
+			Expect(*monitor.TimeUnitilUp).To(Equal(10))
+			Expect(monitor.UpInterval).To(Equal(20))
+
+			inband := app["inband_monitor"].(*as3InbandMonitor)
+			Expect(inband.MonitorType).To(Equal("inband"))
+			Expect(inband.Failures).To(Equal(5))
+			Expect(inband.FailureInterval).To(Equal(60))
+		})
+
+		It("HTTP options declaration", func() {
+			rsCfg := &ResourceConfig{}
+			rsCfg.MetaData.Protocol = "http"
+			rsCfg.Virtual.Name = "crd_vs_172.13.14.15"
+			rsCfg.Virtual.HTTPOptions = &cisapiv1.HTTPOptions{
+				XForwardedFor: true,
+				HSTS:          &cisapiv1.HSTS{Period: 300},
+				Compression:   &cisapiv1.HTTPCompression{GzipLevel: 5},
+				Caching:       &cisapiv1.HTTPCaching{MaximumAge: 60, URIIncludes: []string{"/static/*"}},
+			}
+			svc := &as3Service{}
+			app := as3Application{}
+			createHTTPOptionsDecl(rsCfg, app, svc, "test")
+
+			httpProfile, ok := app["crd_vs_172.13.14.15_http_profile"].(*as3HTTPProfile)
+			Expect(ok).To(BeTrue(), "HTTP profile not created")
+			Expect(httpProfile.XForwardedFor).To(BeTrue())
+			Expect(httpProfile.HSTSInsert).To(BeFalse(), "HSTS should not be inserted for http")
+			Expect(svc.ProfileHTTP).To(Equal(&as3ResourcePointer{
+				Use: "/test/Shared/crd_vs_172.13.14.15_http_profile"}))
+
+			compress, ok := app["crd_vs_172.13.14.15_http_compress"].(*as3HTTPCompress)
+			Expect(ok).To(BeTrue(), "HTTP compression profile not created")
+			Expect(compress.GzipLevel).To(Equal(5))
+			Expect(svc.ProfileHTTPCompression).NotTo(BeNil())
+
+			acceleration, ok := app["crd_vs_172.13.14.15_web_acceleration"].(*as3HTTPAcceleration)
+			Expect(ok).To(BeTrue(), "Web acceleration profile not created")
+			Expect(acceleration.URIIncludeList).To(Equal([]string{"/static/*"}))
+			Expect(svc.ProfileHTTPAcceleration).NotTo(BeNil())
+
+			rsCfg.MetaData.Protocol = "https"
+			createHTTPOptionsDecl(rsCfg, app, svc, "test")
+			httpProfile = app["crd_vs_172.13.14.15_http_profile"].(*as3HTTPProfile)
+			Expect(httpProfile.HSTSInsert).To(BeTrue(), "HSTS should be inserted for https")
+			Expect(httpProfile.HSTSPeriod).To(Equal(300))
+
+			// AS3 inserts X-Forwarded-For by default, it is disabled explicitly
+			rsCfg.Virtual.HTTPOptions = &cisapiv1.HTTPOptions{HSTS: &cisapiv1.HSTS{Period: 300}}
+			createHTTPOptionsDecl(rsCfg, app, svc, "test")
+			data, _ := json.Marshal(app["crd_vs_172.13.14.15_http_profile"])
+			Expect(string(data)).To(ContainSubstring(`"xForwardedFor":false`))
+		})
+	})
+
+	Describe("WAF in policy rules", func() {
+		It("Adds the default WAF actions", func() {
+			rsCfg := &ResourceConfig{}
+			rsCfg.Virtual.Destination = "/test/172.13.14.15:80"
+			rsCfg.Policies = Policies{{
+				Name:     "foo_policy",
+				Strategy: "first-match",
+				Rules: Rules{
+					{Name: "waf_rule", Actions: []*action{{Name: "0", Forward: true, Pool: "foo"}, {Name: "1", WAF: "/Common/WAF_Policy"}}},
+					{Name: "rule", Actions: []*action{{Name: "0", Forward: true, Pool: "bar"}}},
+				},
+			}}
+			app := as3Application{}
+			createPoliciesDecl(rsCfg, app)
+			ep := app["foo_policy"].(*as3EndpointPolicy)
+			Expect(ep.Rules).To(HaveLen(3))
+			Expect(ep.Rules[0].Actions[1]).To(Equal(&as3Action{Type: "waf", Policy: &as3ResourcePointer{BigIP: "/Common/WAF_Policy"}}))
+			disabled := false
+			Expect(ep.Rules[1].Actions[1]).To(Equal(&as3Action{Type: "waf", Enabled: &disabled}))
+			Expect(ep.Rules[2].Name).To(Equal("default_waf"))
+
+			// rules without WAF action use the WAF policy of the virtual
+			rsCfg.Virtual.WAF = "/Common/Default_WAF"
+			createPoliciesDecl(rsCfg, app)
+			ep = app["foo_policy"].(*as3EndpointPolicy)
+			Expect(ep.Rules[1].Actions[1].Policy).To(Equal(&as3ResourcePointer{BigIP: "/Common/Default_WAF"}))
+		})
 	})
 
 	Describe("JSON comparision of AS3 declaration", func() {
@@ -440,4 +605,91 @@ var _ = Describe("Backend Tests", func() {
 		})
 	})
 
+	Describe("AS3 schema validation", func() {
+		var agent *Agent
+		var config ResourceConfigRequest
+
+		newVirtual := func(name, destination string) *ResourceConfig {
+			rsCfg := &ResourceConfig{}
+			rsCfg.MetaData.Active = true
+			rsCfg.MetaData.ResourceType = VirtualServer
+			rsCfg.MetaData.namespace = "default"
+			rsCfg.Virtual.Name = name
+			rsCfg.Virtual.Destination = destination
+			rsCfg.Virtual.Enabled = true
+			rsCfg.Virtual.IpProtocol = "tcp"
+			rsCfg.Virtual.SNAT = "auto"
+			rsCfg.Virtual.PoolName = name + "_pool"
+			rsCfg.Pools = Pools{
+				Pool{
+					Name:    name + "_pool",
+					Members: []PoolMember{{Address: "10.244.1.2", Port: 8080}},
+				},
+			}
+			return rsCfg
+		}
+
+		BeforeEach(func() {
+			agent = newMockAgent(nil)
+			agent.userAgent = "as3"
+			agent.tenantPriorityMap = make(map[string]int)
+			agent.AS3VersionInfo = as3VersionInfo{
+				as3Version:       defaultAS3Version,
+				as3SchemaVersion: defaultAS3Version,
+				as3Release:       defaultAS3Version + "-" + defaultAS3Build,
+			}
+			schemaDir, err := filepath.Abs("../../schemas")
+			Expect(err).To(BeNil())
+			agent.as3Validator = agent.newAS3Validator("file://" + schemaDir + "/")
+			Expect(agent.as3Validator).ToNot(BeNil(), "Failed to load the AS3 schema")
+
+			config = ResourceConfigRequest{
+				ltmConfig:          make(LTMConfig),
+				defaultRouteDomain: 0,
+			}
+			config.ltmConfig["test"] = &PartitionConfig{make(ResourceMap), 0}
+			config.ltmConfig["test"].ResourceMap["crd_vs_10.1.1.1"] = newVirtual("crd_vs_10.1.1.1", "/test/10.1.1.1:80")
+		})
+
+		It("Deploys the valid resources", func() {
+			adc := agent.createAS3LTMConfigADC(config)
+			sharedApp := adc["test"].(as3Tenant)[as3SharedApplication].(as3Application)
+			Expect(sharedApp).To(HaveKey("crd_vs_10.1.1.1"))
+			Expect(agent.as3Validator.validate(agent.createAS3Declaration(
+				map[string]as3Tenant{"test": adc["test"].(as3Tenant)}))).To(BeEmpty())
+		})
+
+		It("Excludes the invalid resources from the tenant", func() {
+			config.ltmConfig["test"].ResourceMap["crd_vs_10.1.1.2"] = newVirtual("crd_vs_10.1.1.2", "/test/10.1.1.2:70000")
+			adc := agent.createAS3LTMConfigADC(config)
+			sharedApp := adc["test"].(as3Tenant)[as3SharedApplication].(as3Application)
+			Expect(sharedApp).To(HaveKey("crd_vs_10.1.1.1"))
+			Expect(sharedApp).To(HaveKey("crd_vs_10.1.1.1_pool"))
+			Expect(sharedApp).ToNot(HaveKey("crd_vs_10.1.1.2"))
+			Expect(sharedApp).ToNot(HaveKey("crd_vs_10.1.1.2_pool"))
+		})
+
+		It("Reports the invalid resources in the resource status", func() {
+			rsCfg := newVirtual("crd_vs_10.1.1.2", "/test/10.1.1.2:70000")
+			rsCfg.MetaData.baseResources = map[string]string{"default/vs2": VirtualServer}
+			config.ltmConfig["test"].ResourceMap["crd_vs_10.1.1.2"] = rsCfg
+			agent.createAS3LTMConfigADC(config)
+			status := agent.failedResourceStatus()
+			Expect(status).To(HaveLen(1))
+			Expect(status["VirtualServer/default/vs2"]).To(ContainSubstring("virtualPort"))
+
+			// the corrected resource is not reported anymore
+			config.ltmConfig["test"].ResourceMap["crd_vs_10.1.1.2"] = newVirtual("crd_vs_10.1.1.2", "/test/10.1.1.2:80")
+			agent.createAS3LTMConfigADC(config)
+			Expect(agent.failedResourceStatus()).To(BeEmpty())
+		})
+
+		It("Reports the schema errors of the declaration", func() {
+			rsDecl := createTenantDecl(ResourceMap{"crd_vs_10.1.1.2": newVirtual("crd_vs_10.1.1.2", "/test/10.1.1.2:70000")},
+				config, "test")
+			errs := agent.as3Validator.validate(agent.createAS3Declaration(map[string]as3Tenant{"test": rsDecl}))
+			Expect(errs).ToNot(BeEmpty())
+			Expect(strings.Join(errs, " ")).To(ContainSubstring("virtualPort"))
+		})
+	})
 })
@@ -0,0 +1,513 @@
+/*-
+ * Copyright (c) 2019-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+	cisscheme "github.com/F5Networks/k8s-bigip-ctlr/v2/config/client/clientset/versioned/scheme"
+	bigIPPrometheus "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/prometheus"
+	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+	routeapi "github.com/openshift/api/route/v1"
+	routescheme "github.com/openshift/client-go/route/clientset/versioned/scheme"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// ConflictedStatus is the status of a resource discarded in favour of an older resource
+const ConflictedStatus = "Conflicted"
+
+type (
+	// resourceClaim is a virtual address and port claimed by a resource. The virtuals of
+	// the same host or hostGroup share the address and port as long as their paths differ.
+	resourceClaim struct {
+		address  string
+		port     int32       // zero claims all the ports of the address
+		wildcard bool        // claims the wildcard port virtual, which leaves the other ports
+		ranges   []portRange // port ranges sharing the port range virtual of the address
+		protocol string      // any claims all the protocols
+		group    string      // empty claims the port irrespective of the host and path
+		path     string
+	}
+
+	// claimant is a resource along with the virtual addresses it claims
+	claimant struct {
+		kind    string
+		key     string
+		created metav1.Time
+		rsc     runtime.Object
+		claims  []resourceClaim
+	}
+
+	// resourceConflict is a resource discarded in favour of an older resource
+	resourceConflict struct {
+		kind    string
+		key     string
+		rsc     runtime.Object
+		message string
+	}
+)
+
+func init() {
+	// Events refer to the resources through the client-go scheme
+	_ = cisscheme.AddToScheme(scheme.Scheme)
+	_ = routescheme.AddToScheme(scheme.Scheme)
+}
+
+func (claim resourceClaim) String() string {
+	if claim.port == 0 && !claim.wildcard {
+		return claim.address
+	}
+	if len(claim.ranges) > 0 {
+		var ranges []string
+		for _, rng := range claim.ranges {
+			ranges = append(ranges, rng.String())
+		}
+		return fmt.Sprintf("%v:%v", claim.address, strings.Join(ranges, ","))
+	}
+	if claim.path == "" {
+		return fmt.Sprintf("%v:%v", claim.address, claim.port)
+	}
+	return fmt.Sprintf("%v:%v %v", claim.address, claim.port, claim.path)
+}
+
+// overlaps returns true if both the claims can not be configured on BIG-IP together
+func (claim resourceClaim) overlaps(other resourceClaim) bool {
+	if claim.address != other.address ||
+		claim.protocol != other.protocol && claim.protocol != "any" && other.protocol != "any" {
+		return false
+	}
+	if claim.port != other.port && claim.port != 0 && other.port != 0 {
+		return false
+	}
+	// virtuals of the other ports take precedence over the wildcard port virtual
+	if claim.wildcard && other.port != 0 || other.wildcard && claim.port != 0 {
+		return false
+	}
+	// port ranges share the port range virtual as long as they are disjoint
+	if len(claim.ranges) > 0 && len(other.ranges) > 0 {
+		for _, rng := range claim.ranges {
+			for _, otherRng := range other.ranges {
+				if rng.overlaps(otherRng) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if claim.group == "" || other.group == "" || claim.group != other.group {
+		return true
+	}
+	// virtuals of the same host or hostGroup are merged unless they expose the same path
+	return claim.path != "" && claim.path == other.path
+}
+
+// virtualServerClaims returns a claim for every port and path of the VirtualServer
+func (ctlr *Controller) virtualServerClaims(vs *cisapiv1.VirtualServer) []resourceClaim {
+	address := vs.Spec.VirtualServerAddress
+	if address == "" {
+		address = vs.Status.VSAddress
+	}
+	if address == "" {
+		return nil
+	}
+	// VirtualServers are grouped by hostGroup across namespaces and by host within a namespace
+	group := "host:" + vs.Namespace + "/" + vs.Spec.Host
+	if vs.Spec.HostGroup != "" {
+		group = "hostGroup:" + vs.Spec.HostGroup
+	}
+	paths := []string{""}
+	if len(vs.Spec.Pools) > 0 {
+		paths = paths[:0]
+		for _, pool := range vs.Spec.Pools {
+			path := pool.Path
+			if path == "" {
+				path = "/"
+			}
+			paths = append(paths, vs.Spec.Host+path)
+		}
+	}
+	var claims []resourceClaim
+	for _, portStruct := range ctlr.virtualPorts(vs) {
+		for _, path := range paths {
+			claims = append(claims, resourceClaim{
+				address:  address,
+				port:     portStruct.port,
+				protocol: "tcp",
+				group:    group,
+				path:     path,
+			})
+		}
+	}
+	return claims
+}
+
+// transportServerClaims returns a claim for every port of the TransportServer
+func transportServerClaims(ts *cisapiv1.TransportServer) []resourceClaim {
+	address := ts.Spec.VirtualServerAddress
+	if address == "" {
+		address = ts.Status.VSAddress
+	}
+	if address == "" {
+		return nil
+	}
+	protocol := getTransportServerProtocol(ts)
+	ports, ranges, _ := parseTransportServerPorts(ts)
+	var claims []resourceClaim
+	for _, port := range ports {
+		claim := resourceClaim{address: address, port: port, wildcard: port == 0, protocol: protocol}
+		// TLS passthrough TransportServers share the port as long as their hosts differ
+		if ts.Spec.TLSPassthrough {
+			claim.group = "tlsPassthrough"
+			claim.path = strings.ToLower(ts.Spec.Host)
+		}
+		claims = append(claims, claim)
+	}
+	if len(ranges) > 0 {
+		claims = append(claims, resourceClaim{address: address, wildcard: true, ranges: ranges, protocol: protocol})
+	}
+	return claims
+}
+
+// ingressLinkClaims returns the claim of the IngressLink, which exposes all the ports of
+// the ingress controller service on its address
+func ingressLinkClaims(il *cisapiv1.IngressLink) []resourceClaim {
+	address := il.Spec.VirtualServerAddress
+	if address == "" {
+		address = il.Status.VSAddress
+	}
+	if address == "" {
+		return nil
+	}
+	return []resourceClaim{{address: address, protocol: "tcp"}}
+}
+
+// lbServiceClaims returns a claim for every port of the Service of type LoadBalancer
+func lbServiceClaims(svc *v1.Service) []resourceClaim {
+	if svc.Spec.Type != v1.ServiceTypeLoadBalancer || len(svc.Status.LoadBalancer.Ingress) == 0 {
+		return nil
+	}
+	if _, ok := svc.Annotations[LBServiceIPAMLabelAnnotation]; !ok {
+		return nil
+	}
+	address := svc.Status.LoadBalancer.Ingress[0].IP
+	if address == "" {
+		return nil
+	}
+	var claims []resourceClaim
+	for _, port := range svc.Spec.Ports {
+		claims = append(claims, resourceClaim{
+			address:  address,
+			port:     port.Port,
+			protocol: strings.ToLower(string(port.Protocol)),
+		})
+	}
+	return claims
+}
+
+// isAddressClaimant returns true for the resources claiming virtual addresses
+func isAddressClaimant(rKey *rqKey) bool {
+	switch rKey.kind {
+	case VirtualServer, TransportServer, IngressLink:
+		return true
+	case Service:
+		svc, ok := rKey.rsc.(*v1.Service)
+		return ok && svc.Spec.Type == v1.ServiceTypeLoadBalancer
+	}
+	return false
+}
+
+// getClaimants returns the resources claiming virtual addresses in the order of precedence,
+// i.e. the oldest resource first
+func (ctlr *Controller) getClaimants() []*claimant {
+	var claimants []*claimant
+	add := func(kind string, meta metav1.ObjectMeta, rsc runtime.Object, claims []resourceClaim) {
+		if len(claims) == 0 {
+			return
+		}
+		claimants = append(claimants, &claimant{
+			kind:    kind,
+			key:     meta.Namespace + "/" + meta.Name,
+			created: meta.CreationTimestamp,
+			rsc:     rsc,
+			claims:  claims,
+		})
+	}
+	for _, crInf := range ctlr.crInformers {
+		for _, obj := range crInf.vsInformer.GetIndexer().List() {
+			vs := obj.(*cisapiv1.VirtualServer)
+			add(VirtualServer, vs.ObjectMeta, vs, ctlr.virtualServerClaims(vs))
+		}
+		for _, obj := range crInf.tsInformer.GetIndexer().List() {
+			ts := obj.(*cisapiv1.TransportServer)
+			add(TransportServer, ts.ObjectMeta, ts, transportServerClaims(ts))
+		}
+		for _, obj := range crInf.ilInformer.GetIndexer().List() {
+			il := obj.(*cisapiv1.IngressLink)
+			add(IngressLink, il.ObjectMeta, il, ingressLinkClaims(il))
+		}
+	}
+	for _, comInf := range ctlr.comInformers {
+		for _, obj := range comInf.svcInformer.GetIndexer().List() {
+			svc := obj.(*v1.Service)
+			add(Service, svc.ObjectMeta, svc, lbServiceClaims(svc))
+		}
+	}
+	sort.SliceStable(claimants, func(i, j int) bool {
+		ci, cj := claimants[i], claimants[j]
+		if !ci.created.Equal(&cj.created) {
+			return ci.created.Before(&cj.created)
+		}
+		if ci.kind != cj.kind {
+			return ci.kind < cj.kind
+		}
+		return ci.key < cj.key
+	})
+	return claimants
+}
+
+// detectConflicts returns the claimants discarded in favour of an older claimant, keyed
+// by kind and namespace/name. The claimants are expected in the order of precedence.
+func detectConflicts(claimants []*claimant) map[string]resourceConflict {
+	type acceptedClaim struct {
+		resourceClaim
+		owner *claimant
+	}
+	conflicts := make(map[string]resourceConflict)
+	accepted := make(map[string][]acceptedClaim)
+	for _, c := range claimants {
+		var message string
+	claims:
+		for _, claim := range c.claims {
+			for _, ac := range accepted[claim.address] {
+				if ac.overlaps(claim) {
+					message = fmt.Sprintf("%v %v conflicts with the older %v %v on %v",
+						c.kind, c.key, ac.owner.kind, ac.owner.key, claim)
+					break claims
+				}
+			}
+		}
+		if message != "" {
+			conflicts[c.kind+"/"+c.key] = resourceConflict{
+				kind:    c.kind,
+				key:     c.key,
+				rsc:     c.rsc,
+				message: message,
+			}
+			continue
+		}
+		for _, claim := range c.claims {
+			accepted[claim.address] = append(accepted[claim.address], acceptedClaim{claim, c})
+		}
+	}
+	return conflicts
+}
+
+// updateConflicts resolves the virtual addresses claimed by the resources, reports and
+// removes the newly conflicted resources and requeues the resources no longer conflicted
+func (ctlr *Controller) updateConflicts() {
+	conflicts := detectConflicts(ctlr.getClaimants())
+	for key, conflict := range ctlr.conflicts {
+		// Route conflicts are tracked along with the host paths of the routes
+		if conflict.kind == Route {
+			conflicts[key] = conflict
+			continue
+		}
+		if _, ok := conflicts[key]; !ok {
+			log.Infof("%v %v is no longer conflicted", conflict.kind, conflict.key)
+			ctlr.enqueueResolvedConflict(conflict)
+		}
+	}
+	for key, conflict := range conflicts {
+		if _, ok := ctlr.conflicts[key]; ok {
+			continue
+		}
+		log.Errorf("Discarding %v", conflict.message)
+		ctlr.reportConflict(conflict)
+		ctlr.removeConflictedResource(conflict.key)
+	}
+	ctlr.conflicts = conflicts
+	ctlr.updateConflictMetrics()
+}
+
+// isConflicted returns true if the resource is discarded in favour of an older resource
+func (ctlr *Controller) isConflicted(kind, key string) bool {
+	_, ok := ctlr.conflicts[kind+"/"+key]
+	return ok
+}
+
+// reportConflict sets the Conflicted status and records a warning event on the resource
+func (ctlr *Controller) reportConflict(conflict resourceConflict) {
+	switch rsc := conflict.rsc.(type) {
+	case *cisapiv1.VirtualServer:
+		vs := rsc.DeepCopy()
+		ctlr.updateVirtualServerStatus(vs, vs.Status.VSAddress, ConflictedStatus)
+	case *cisapiv1.TransportServer:
+		ts := rsc.DeepCopy()
+		ctlr.updateTransportServerStatus(ts, ts.Status.VSAddress, ConflictedStatus)
+	case *cisapiv1.IngressLink:
+		il := rsc.DeepCopy()
+		il.Status.StatusOk = ConflictedStatus
+		_, err := ctlr.kubeCRClient.CisV1().IngressLinks(il.Namespace).UpdateStatus(context.TODO(), il, metav1.UpdateOptions{})
+		if err != nil {
+			log.Debugf("Error while updating ingresslink status:%v", err)
+		}
+	}
+	ctlr.recordEvent(conflict.rsc, v1.EventTypeWarning, ConflictedStatus, conflict.message)
+}
+
+// removeConflictedResource removes the virtuals configured for the resource. The virtuals
+// shared with other VirtualServers are rebuilt by requeueing them.
+func (ctlr *Controller) removeConflictedResource(rscKey string) {
+	for partition, partitionConfig := range ctlr.resources.ltmConfig {
+		var delRes []string
+		for rsName, rsCfg := range partitionConfig.ResourceMap {
+			if _, ok := rsCfg.MetaData.baseResources[rscKey]; !ok {
+				continue
+			}
+			if len(rsCfg.MetaData.baseResources) == 1 {
+				delRes = append(delRes, rsName)
+				continue
+			}
+			for key, kind := range rsCfg.MetaData.baseResources {
+				if key == rscKey || kind != VirtualServer {
+					continue
+				}
+				if vs := ctlr.fetchVirtualServer(key); vs != nil {
+					ctlr.enqueueVirtualServer(vs)
+				}
+			}
+		}
+		for _, rsName := range delRes {
+			ctlr.deleteSvcDepResource(rsName, partitionConfig.ResourceMap[rsName])
+			ctlr.deleteVirtualServer(partition, rsName)
+		}
+	}
+}
+
+// enqueueResolvedConflict requeues the latest copy of a resource no longer conflicted
+func (ctlr *Controller) enqueueResolvedConflict(conflict resourceConflict) {
+	namespace := strings.Split(conflict.key, "/")[0]
+	var obj interface{}
+	var exists bool
+	switch conflict.kind {
+	case Service:
+		if comInf, ok := ctlr.getNamespacedCommonInformer(namespace); ok {
+			obj, exists, _ = comInf.svcInformer.GetIndexer().GetByKey(conflict.key)
+		}
+		if exists {
+			ctlr.enqueueService(obj)
+		}
+		return
+	}
+	crInf, ok := ctlr.getNamespacedCRInformer(namespace)
+	if !ok {
+		return
+	}
+	switch conflict.kind {
+	case VirtualServer:
+		if obj, exists, _ = crInf.vsInformer.GetIndexer().GetByKey(conflict.key); exists {
+			ctlr.enqueueVirtualServer(obj)
+		}
+	case TransportServer:
+		if obj, exists, _ = crInf.tsInformer.GetIndexer().GetByKey(conflict.key); exists {
+			ctlr.enqueueTransportServer(obj)
+		}
+	case IngressLink:
+		if obj, exists, _ = crInf.ilInformer.GetIndexer().GetByKey(conflict.key); exists {
+			ctlr.enqueueIngressLink(obj)
+		}
+	}
+}
+
+// fetchVirtualServer returns the VirtualServer of the namespace/name key from the informer cache
+func (ctlr *Controller) fetchVirtualServer(key string) *cisapiv1.VirtualServer {
+	crInf, ok := ctlr.getNamespacedCRInformer(strings.Split(key, "/")[0])
+	if !ok {
+		return nil
+	}
+	obj, exists, err := crInf.vsInformer.GetIndexer().GetByKey(key)
+	if err != nil || !exists {
+		return nil
+	}
+	return obj.(*cisapiv1.VirtualServer)
+}
+
+// setRouteConflict records the route discarded in favour of an older route exposing the
+// same host and path
+func (ctlr *Controller) setRouteConflict(route *routeapi.Route, message string) {
+	key := route.Namespace + "/" + route.Name
+	if ctlr.isConflicted(Route, key) {
+		return
+	}
+	if ctlr.conflicts == nil {
+		ctlr.conflicts = make(map[string]resourceConflict)
+	}
+	ctlr.conflicts[Route+"/"+key] = resourceConflict{
+		kind:    Route,
+		key:     key,
+		rsc:     route,
+		message: message,
+	}
+	ctlr.recordEvent(route, v1.EventTypeWarning, ConflictedStatus, message)
+	ctlr.updateConflictMetrics()
+}
+
+// clearConflict forgets the conflict of a resource which is accepted or deleted
+func (ctlr *Controller) clearConflict(kind, key string) {
+	if !ctlr.isConflicted(kind, key) {
+		return
+	}
+	delete(ctlr.conflicts, kind+"/"+key)
+	ctlr.updateConflictMetrics()
+}
+
+// updateConflictMetrics exports the count of conflicted resources by kind
+func (ctlr *Controller) updateConflictMetrics() {
+	counts := map[string]int{
+		VirtualServer:   0,
+		TransportServer: 0,
+		IngressLink:     0,
+		Service:         0,
+		Route:           0,
+	}
+	for _, conflict := range ctlr.conflicts {
+		counts[conflict.kind]++
+	}
+	for kind, count := range counts {
+		bigIPPrometheus.ResourceConflicts.WithLabelValues(kind).Set(float64(count))
+	}
+}
+
+// recordEvent records an event on the resource in its namespace
+func (ctlr *Controller) recordEvent(rsc runtime.Object, eventType, reason, message string) {
+	if ctlr.eventNotifier == nil || ctlr.kubeClient == nil {
+		return
+	}
+	meta, ok := rsc.(metav1.Object)
+	if !ok {
+		return
+	}
+	evNotifier := ctlr.eventNotifier.CreateNotifierForNamespace(meta.GetNamespace(), ctlr.kubeClient.CoreV1())
+	evNotifier.RecordEvent(rsc, eventType, reason, message)
+}
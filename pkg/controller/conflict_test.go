@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"time"
+
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+	crdfake "github.com/F5Networks/k8s-bigip-ctlr/v2/config/client/clientset/versioned/fake"
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	routeapi "github.com/openshift/api/route/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/workqueue"
+)
+
+var _ = Describe("Resource Conflict Tests", func() {
+	var mockCtlr *mockController
+	namespace := "default"
+	now := time.Now()
+
+	newVS := func(name string, age int, spec cisapiv1.VirtualServerSpec) *cisapiv1.VirtualServer {
+		vs := test.NewVirtualServer(name, namespace, spec)
+		vs.CreationTimestamp = metav1.NewTime(now.Add(-time.Duration(age) * time.Minute))
+		return vs
+	}
+	newTS := func(name string, age int, spec cisapiv1.TransportServerSpec) *cisapiv1.TransportServer {
+		ts := test.NewTransportServer(name, namespace, spec)
+		ts.CreationTimestamp = metav1.NewTime(now.Add(-time.Duration(age) * time.Minute))
+		return ts
+	}
+	pools := func(paths ...string) []cisapiv1.Pool {
+		var pools []cisapiv1.Pool
+		for _, path := range paths {
+			pools = append(pools, cisapiv1.Pool{Path: path, Service: "svc", ServicePort: 80})
+		}
+		return pools
+	}
+
+	BeforeEach(func() {
+		mockCtlr = newMockController()
+		mockCtlr.mode = CustomResourceMode
+		mockCtlr.kubeCRClient = crdfake.NewSimpleClientset()
+		mockCtlr.kubeClient = k8sfake.NewSimpleClientset()
+		mockCtlr.resources = NewResourceStore()
+		mockCtlr.resourceQueue = workqueue.NewNamedRateLimitingQueue(
+			workqueue.DefaultControllerRateLimiter(), "custom-resource-controller")
+		mockCtlr.crInformers = make(map[string]*CRInformer)
+		mockCtlr.comInformers = make(map[string]*CommonInformer)
+		mockCtlr.namespaces = map[string]bool{namespace: true}
+		_ = mockCtlr.addNamespacedInformers(namespace, false)
+	})
+
+	It("Detects the overlapping claims", func() {
+		vs1 := newVS("vs1", 3, cisapiv1.VirtualServerSpec{
+			Host: "foo.com", VirtualServerAddress: "10.1.1.1", Pools: pools("/foo"),
+		})
+		vs2 := newVS("vs2", 2, cisapiv1.VirtualServerSpec{
+			Host: "foo.com", VirtualServerAddress: "10.1.1.1", Pools: pools("/bar"),
+		})
+		vs3 := newVS("vs3", 1, cisapiv1.VirtualServerSpec{
+			Host: "bar.com", VirtualServerAddress: "10.1.1.1", Pools: pools("/foo"),
+		})
+		mockCtlr.addVirtualServer(vs1)
+		mockCtlr.addVirtualServer(vs2)
+		mockCtlr.addVirtualServer(vs3)
+		conflicts := detectConflicts(mockCtlr.getClaimants())
+		Expect(conflicts).To(HaveLen(1), "Only the other host on the same address should conflict")
+		Expect(conflicts).To(HaveKey(VirtualServer + "/default/vs3"))
+		Expect(conflicts[VirtualServer+"/default/vs3"].message).To(ContainSubstring("older VirtualServer default/vs1"))
+
+		// same path of the same host
+		vs2.Spec.Pools = pools("/foo")
+		conflicts = detectConflicts(mockCtlr.getClaimants())
+		Expect(conflicts).To(HaveKey(VirtualServer + "/default/vs2"))
+
+		// hostGroup shares the address across the hosts
+		vs2.Spec.Pools = pools("/bar")
+		vs1.Spec.HostGroup = "hg"
+		vs3.Spec.HostGroup = "hg"
+		conflicts = detectConflicts(mockCtlr.getClaimants())
+		Expect(conflicts).To(HaveKey(VirtualServer + "/default/vs2"))
+		Expect(conflicts).NotTo(HaveKey(VirtualServer + "/default/vs3"))
+	})
+
+	It("Detects the conflicts of the TransportServers and IngressLinks", func() {
+		mockCtlr.addTransportServer(newTS("ts1", 3, cisapiv1.TransportServerSpec{
+			VirtualServerAddress: "10.1.1.1", VirtualServerPort: 80,
+		}))
+		mockCtlr.addTransportServer(newTS("ts2", 2, cisapiv1.TransportServerSpec{
+			VirtualServerAddress: "10.1.1.1", VirtualServerPort: 80, Type: "udp",
+		}))
+		Expect(detectConflicts(mockCtlr.getClaimants())).To(BeEmpty(), "Other protocol should not conflict")
+
+		mockCtlr.addVirtualServer(newVS("vs1", 1, cisapiv1.VirtualServerSpec{
+			Host: "foo.com", VirtualServerAddress: "10.1.1.1", Pools: pools("/foo"),
+		}))
+		il := test.NewIngressLink("il1", namespace, "1", cisapiv1.IngressLinkSpec{VirtualServerAddress: "10.1.1.1"})
+		il.CreationTimestamp = metav1.NewTime(now)
+		mockCtlr.addIngressLink(il)
+		conflicts := detectConflicts(mockCtlr.getClaimants())
+		Expect(conflicts).To(HaveLen(2))
+		Expect(conflicts).To(HaveKey(VirtualServer + "/default/vs1"))
+		Expect(conflicts).To(HaveKey(IngressLink + "/default/il1"))
+	})
+
+	It("Shares the port among the TLS passthrough TransportServers", func() {
+		spec := cisapiv1.TransportServerSpec{
+			VirtualServerAddress: "10.1.1.1", VirtualServerPort: 443, Host: "foo.com", TLSPassthrough: true,
+		}
+		mockCtlr.addTransportServer(newTS("ts1", 3, spec))
+		spec.Host = "bar.com"
+		mockCtlr.addTransportServer(newTS("ts2", 2, spec))
+		Expect(detectConflicts(mockCtlr.getClaimants())).To(BeEmpty(), "Other host should not conflict")
+
+		spec.Host = "FOO.com"
+		mockCtlr.addTransportServer(newTS("ts3", 1, spec))
+		conflicts := detectConflicts(mockCtlr.getClaimants())
+		Expect(conflicts).To(HaveLen(1))
+		Expect(conflicts).To(HaveKey(TransportServer + "/default/ts3"))
+	})
+
+	It("Leaves the other ports to the wildcard port TransportServers", func() {
+		mockCtlr.addTransportServer(newTS("ts1", 3, cisapiv1.TransportServerSpec{
+			VirtualServerAddress: "10.1.1.1", VirtualServerPorts: []string{"5060", "10000-10100"},
+		}))
+		mockCtlr.addTransportServer(newTS("ts2", 2, cisapiv1.TransportServerSpec{
+			VirtualServerAddress: "10.1.1.1", VirtualServerPort: 80,
+		}))
+		Expect(detectConflicts(mockCtlr.getClaimants())).To(BeEmpty(), "Other port should not conflict")
+
+		mockCtlr.addTransportServer(newTS("ts3", 1, cisapiv1.TransportServerSpec{
+			VirtualServerAddress: "10.1.1.1", VirtualServerPort: 0, Type: "any", Mode: "forwarding",
+		}))
+		conflicts := detectConflicts(mockCtlr.getClaimants())
+		Expect(conflicts).To(HaveLen(1))
+		Expect(conflicts).To(HaveKey(TransportServer + "/default/ts3"))
+		Expect(conflicts[TransportServer+"/default/ts3"].message).To(ContainSubstring("10.1.1.1:0"))
+	})
+
+	It("Shares the port range virtual between the disjoint port ranges", func() {
+		mockCtlr.addTransportServer(newTS("ts1", 3, cisapiv1.TransportServerSpec{
+			VirtualServerAddress: "10.1.1.1", VirtualServerPorts: []string{"10000-10100"},
+		}))
+		mockCtlr.addTransportServer(newTS("ts2", 2, cisapiv1.TransportServerSpec{
+			VirtualServerAddress: "10.1.1.1", VirtualServerPorts: []string{"20000-20100"},
+		}))
+		Expect(detectConflicts(mockCtlr.getClaimants())).To(BeEmpty(), "Disjoint port ranges should not conflict")
+
+		mockCtlr.addTransportServer(newTS("ts3", 1, cisapiv1.TransportServerSpec{
+			VirtualServerAddress: "10.1.1.1", VirtualServerPorts: []string{"10050-10060"},
+		}))
+		conflicts := detectConflicts(mockCtlr.getClaimants())
+		Expect(conflicts).To(HaveLen(1))
+		Expect(conflicts).To(HaveKey(TransportServer + "/default/ts3"))
+		Expect(conflicts[TransportServer+"/default/ts3"].message).To(ContainSubstring("10.1.1.1:10050-10060"))
+	})
+
+	It("Breaks the ties by kind and name", func() {
+		spec := cisapiv1.TransportServerSpec{VirtualServerAddress: "10.1.1.1", VirtualServerPort: 80}
+		mockCtlr.addTransportServer(newTS("ts2", 1, spec))
+		mockCtlr.addTransportServer(newTS("ts1", 1, spec))
+		conflicts := detectConflicts(mockCtlr.getClaimants())
+		Expect(conflicts).To(HaveLen(1))
+		Expect(conflicts).To(HaveKey(TransportServer + "/default/ts2"))
+	})
+
+	It("Removes and requeues the conflicted resources", func() {
+		ts1 := newTS("ts1", 2, cisapiv1.TransportServerSpec{VirtualServerAddress: "10.1.1.1", VirtualServerPort: 80})
+		ts2 := newTS("ts2", 1, cisapiv1.TransportServerSpec{VirtualServerAddress: "10.1.1.1", VirtualServerPort: 80})
+		mockCtlr.addTransportServer(ts2)
+		rsCfg := &ResourceConfig{}
+		rsCfg.Virtual.Name = "crd_10_1_1_1_80"
+		rsCfg.MetaData.baseResources = map[string]string{"default/ts2": TransportServer}
+		mockCtlr.resources.getPartitionResourceMap("test")[rsCfg.Virtual.Name] = rsCfg
+
+		mockCtlr.updateConflicts()
+		Expect(mockCtlr.conflicts).To(BeEmpty())
+		Expect(mockCtlr.resources.getPartitionResourceMap("test")).To(HaveKey(rsCfg.Virtual.Name))
+
+		mockCtlr.addTransportServer(ts1)
+		mockCtlr.updateConflicts()
+		Expect(mockCtlr.isConflicted(TransportServer, "default/ts2")).To(BeTrue())
+		Expect(mockCtlr.resources.getPartitionResourceMap("test")).NotTo(HaveKey(rsCfg.Virtual.Name),
+			"Virtual of the conflicted TransportServer should be removed")
+
+		mockCtlr.deleteTransportServer(ts1)
+		queued := mockCtlr.resourceQueue.Len()
+		mockCtlr.updateConflicts()
+		Expect(mockCtlr.isConflicted(TransportServer, "default/ts2")).To(BeFalse())
+		Expect(mockCtlr.resourceQueue.Len()).To(Equal(queued+1), "Resolved TransportServer should be requeued")
+	})
+
+	It("Tracks the route conflicts", func() {
+		route := test.NewRoute("route1", "1", namespace, routeapi.RouteSpec{Host: "foo.com", Path: "/foo"}, nil)
+		mockCtlr.setRouteConflict(route, "conflict")
+		Expect(mockCtlr.isConflicted(Route, "default/route1")).To(BeTrue())
+		mockCtlr.updateConflicts()
+		Expect(mockCtlr.isConflicted(Route, "default/route1")).To(BeTrue(), "Route conflicts should be retained")
+		mockCtlr.clearConflict(Route, "default/route1")
+		Expect(mockCtlr.conflicts).To(BeEmpty())
+	})
+})
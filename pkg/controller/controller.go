@@ -19,6 +19,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -38,6 +39,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 )
 
@@ -72,8 +74,11 @@ const (
 	ConfigMap = "ConfigMap"
 	// Route is OpenShift Route
 	Route = "Route"
+	// RouteGroup is a F5 Custom Resource Kind for the extended spec of a group of Routes
+	RouteGroup = "RouteGroup"
 
 	NodePort = "nodeport"
+	Cluster  = "cluster"
 
 	PolicyControlForward = "forwarding"
 	// Namespace for IPAM CRD
@@ -93,8 +98,22 @@ const (
 	LBServiceIPAMLabelAnnotation  = "cis.f5.com/ipamLabel"
 	HealthMonitorAnnotation       = "cis.f5.com/health"
 	LBServicePolicyNameAnnotation = "cis.f5.com/policyName"
+	DefaultPolicyAnnotation       = "cis.f5.com/defaultPolicy"
+	PartitionAnnotation           = "cis.f5.com/partition"
+	// PodReadinessGateConditionType is set by CIS once the pod is a BIG-IP pool member
+	PodReadinessGateConditionType = "cis.f5.com/pool-member-ready"
 	LegacyHealthMonitorAnnotation = "virtual-server.f5.com/health"
 
+	// Pool member sessions, draining members are rendered with the matching AS3 adminState
+	MemberSessionEnabled  = "user-enabled"
+	MemberSessionDisabled = "user-disabled"
+	MemberSessionOffline  = "user-offline"
+	// endpoints terminating but still serving, drained with the drain admin state
+	MemberSessionTerminating = "terminating"
+
+	// endpointSliceServiceIndex indexes the EndpointSlices by namespace/name of their service
+	endpointSliceServiceIndex = "service"
+
 	//Antrea NodePortLocal support
 	NPLPodAnnotation = "nodeportlocal.antrea.io"
 	NPLSvcAnnotation = "nodeportlocal.antrea.io/enabled"
@@ -107,31 +126,49 @@ const (
 	as3Version        = 3.41
 	defaultAS3Version = "3.41.0"
 	defaultAS3Build   = "1"
+	// as3SchemaFileName is the AS3 schema bundled in the schema-db-base-dir
+	as3SchemaFileName = "as3-schema-3.41.0-1-cis.json"
 )
 
 // NewController creates a new Controller Instance.
 func NewController(params Params) *Controller {
 
 	ctlr := &Controller{
-		namespaces:         make(map[string]bool),
-		resources:          NewResourceStore(),
-		Agent:              params.Agent,
-		PoolMemberType:     params.PoolMemberType,
-		UseNodeInternal:    params.UseNodeInternal,
-		Partition:          params.Partition,
-		initState:          true,
-		dgPath:             strings.Join([]string{DEFAULT_PARTITION, "Shared"}, "/"),
-		shareNodes:         params.ShareNodes,
-		eventNotifier:      apm.NewEventNotifier(nil),
-		defaultRouteDomain: params.DefaultRouteDomain,
-		mode:               params.Mode,
-		namespaceLabel:     params.NamespaceLabel,
+		namespaces:            make(map[string]bool),
+		resources:             NewResourceStore(),
+		Agent:                 params.Agent,
+		backend:               params.Backend,
+		netAgent:              params.NetAgent,
+		bigIPURL:              params.BigIPURL,
+		ccclGTMAgent:          params.CCCLGTMAgent,
+		PoolMemberType:        params.PoolMemberType,
+		UseNodeInternal:       params.UseNodeInternal,
+		Partition:             params.Partition,
+		initState:             true,
+		dgPath:                strings.Join([]string{DEFAULT_PARTITION, "Shared"}, "/"),
+		shareNodes:            params.ShareNodes,
+		eventNotifier:         apm.NewEventNotifier(nil),
+		defaultRouteDomain:    params.DefaultRouteDomain,
+		mode:                  params.Mode,
+		namespaceLabel:        params.NamespaceLabel,
+		defaultPolicy:         params.DefaultPolicy,
+		podReadinessGate:      params.PodReadinessGate,
+		drainPeriod:           params.DrainPeriod,
+		drainAdminState:       params.DrainAdminState,
+		nodePortEndpointNodes: params.NodePortEndpointNodes,
+		staticRoutingMode:     params.StaticRoutingMode,
+		conflicts:             make(map[string]resourceConflict),
 	}
 
 	log.Debug("Controller Created")
 
-	ctlr.resourceQueue = workqueue.NewNamedRateLimitingQueue(
-		workqueue.DefaultControllerRateLimiter(), "nextgen-resource-controller")
+	if params.DebugTokenFile != "" {
+		// Served on the http-listen-address along with /metrics and /health
+		http.Handle("/debug/", ctlr.debugHandler(params.DebugTokenFile))
+	}
+
+	ctlr.resourceQueue = newTracedQueue(workqueue.NewNamedRateLimitingQueue(
+		workqueue.DefaultControllerRateLimiter(), "nextgen-resource-controller"))
 	ctlr.comInformers = make(map[string]*CommonInformer)
 	ctlr.nrInformers = make(map[string]*NRInformer)
 	ctlr.crInformers = make(map[string]*CRInformer)
@@ -158,6 +195,10 @@ func NewController(params Params) *Controller {
 		log.Errorf("Failed to Setup Clients: %v", err)
 	}
 
+	if len(params.MultiClusterSecrets) > 0 && ctlr.mode == CustomResourceMode {
+		ctlr.setupMultiClusters(params.MultiClusterSecrets)
+	}
+
 	if ctlr.namespaceLabel == "" {
 		if len(params.Namespaces) == 0 {
 			ctlr.namespaces[""] = true
@@ -180,10 +221,19 @@ func NewController(params Params) *Controller {
 		}
 	}
 
+	if ctlr.namespaceLabel == "" && ctlr.mode == CustomResourceMode && ctlr.kubeClient != nil {
+		ctlr.nsPartitionInformer = ctlr.newPartitionNamespaceInformer()
+	}
+
 	if err3 := ctlr.setupInformers(); err3 != nil {
 		log.Error("Failed to Setup Informers")
 	}
 
+	if ctlr.mode == OpenShiftMode && ctlr.routeSpecCMKey != "" {
+		// RouteGroups are watched in the namespace of the extended route spec configmap
+		ctlr.rgInformer = ctlr.newRouteGroupInformer(strings.Split(ctlr.routeSpecCMKey, "/")[0])
+	}
+
 	err := ctlr.SetupNodePolling(
 		params.NodePollInterval,
 		params.NodeLabelSelector,
@@ -209,10 +259,14 @@ func NewController(params Params) *Controller {
 		_ = ctlr.createIPAMResource()
 	}
 
-	go ctlr.responseHandler(ctlr.Agent.respChan)
+	go ctlr.responseHandler(ctlr.getBackend().StatusChan())
 
 	go ctlr.Start()
 
+	if params.WebhookAddress != "" {
+		go ctlr.startAdmissionWebhook(params.WebhookAddress, params.WebhookCertFile, params.WebhookKeyFile)
+	}
+
 	go ctlr.setOtherSDNType()
 
 	return ctlr
@@ -407,11 +461,28 @@ func (ctlr *Controller) Start() {
 		for _, inf := range ctlr.nrInformers {
 			inf.start()
 		}
+		if ctlr.rgInformer != nil {
+			ctlr.rgInformer.start()
+		}
 	default:
 		// start customer resource informers in custom resource mode only
 		for _, inf := range ctlr.crInformers {
 			inf.start()
 		}
+		if ctlr.nsPartitionInformer != nil {
+			ctlr.nsPartitionInformer.start()
+			cache.WaitForNamedCacheSync(
+				"F5 CIS Namespace Partition Controller",
+				ctlr.nsPartitionInformer.stopCh,
+				ctlr.nsPartitionInformer.nsInformer.HasSynced,
+			)
+		}
+		for _, secretInf := range ctlr.multiClusterSecrets {
+			secretInf.start()
+		}
+		for _, mcInf := range ctlr.multiClusters {
+			ctlr.startMultiCluster(mcInf)
+		}
 	}
 
 	if ctlr.ipamCli != nil {
@@ -428,6 +499,19 @@ func (ctlr *Controller) Start() {
 	ctlr.Stop()
 }
 
+// getBackend returns the target of the resource config, the AS3 Agent unless another
+// backend is set
+func (ctlr *Controller) getBackend() Backend {
+	if ctlr.backend != nil {
+		return ctlr.backend
+	}
+	// a nil Agent is returned as a nil Backend rather than a Backend holding a nil *Agent
+	if ctlr.Agent == nil {
+		return nil
+	}
+	return ctlr.Agent
+}
+
 // Stop the Controller
 func (ctlr *Controller) Stop() {
 	switch ctlr.mode {
@@ -436,11 +520,23 @@ func (ctlr *Controller) Stop() {
 		for _, inf := range ctlr.nrInformers {
 			inf.stop()
 		}
+		if ctlr.rgInformer != nil {
+			ctlr.rgInformer.stop()
+		}
 	default:
 		// stop custom resource informers
 		for _, inf := range ctlr.crInformers {
 			inf.stop()
 		}
+		if ctlr.nsPartitionInformer != nil {
+			ctlr.nsPartitionInformer.stop()
+		}
+		for _, secretInf := range ctlr.multiClusterSecrets {
+			secretInf.stop()
+		}
+		for _, mcInf := range ctlr.multiClusters {
+			mcInf.stop()
+		}
 	}
 
 	// stop common informers & namespace informers in all modes
@@ -452,7 +548,15 @@ func (ctlr *Controller) Stop() {
 	}
 
 	ctlr.nodePoller.Stop()
-	ctlr.Agent.Stop()
+	if ctlr.backend != nil {
+		ctlr.backend.Stop()
+	}
+	if ctlr.Agent != nil {
+		ctlr.Agent.Stop()
+	}
+	if ctlr.netAgent != nil {
+		ctlr.netAgent.Stop()
+	}
 	if ctlr.ipamCli != nil {
 		ctlr.ipamCli.Stop()
 	}
@@ -95,12 +95,11 @@ func (mockPM *mockPostManager) setResponses(responces []responceCtx, method stri
 
 func newMockAgent(writer writer.Writer) *Agent {
 	return &Agent{
-		PostManager:     nil,
-		Partition:       "test",
-		ConfigWriter:    writer,
-		EventChan:       make(chan interface{}),
-		postChan:        make(chan ResourceConfigRequest, 1),
-		PythonDriverPID: 0,
+		PostManager:  nil,
+		Partition:    "test",
+		ConfigWriter: writer,
+		EventChan:    make(chan interface{}),
+		postChan:     make(chan ResourceConfigRequest, 1),
 		//cachedTenantDeclMap:   make(map[string]interface{}),
 		//incomingTenantDeclMap: make(map[string]interface{}),
 		userAgent: "",
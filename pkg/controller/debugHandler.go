@@ -0,0 +1,341 @@
+/*-
+ * Copyright (c) 2019-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+)
+
+// pemPrefix starts the PEM encoded certificates and keys in the declarations
+const pemPrefix = "-----BEGIN "
+
+type (
+	// logLevelSpec is the request and response body of the loglevel endpoint,
+	// an empty subsystem level resets the subsystem to the global log level
+	logLevelSpec struct {
+		LogLevel   *log.LogLevel     `json:"logLevel,omitempty"`
+		Subsystems map[string]string `json:"subsystems,omitempty"`
+	}
+
+	as3ResponseSpec struct {
+		LogAS3Response *bool `json:"logAS3Response"`
+	}
+
+	// debugResourceConfig is the JSON view of a ResourceConfig
+	debugResourceConfig struct {
+		Virtual            Virtual                          `json:"virtual"`
+		Pools              Pools                            `json:"pools,omitempty"`
+		Policies           Policies                         `json:"policies,omitempty"`
+		Monitors           []Monitor                        `json:"monitors,omitempty"`
+		ServiceAddress     []ServiceAddress                 `json:"serviceAddress,omitempty"`
+		IRules             map[string]*IRule                `json:"iRules,omitempty"`
+		InternalDataGroups map[string]DataGroupNamespaceMap `json:"internalDataGroups,omitempty"`
+	}
+
+	debugPartitionConfig struct {
+		Priority    int                             `json:"priority"`
+		ResourceMap map[string]*debugResourceConfig `json:"resources"`
+	}
+
+	debugRetryTenant struct {
+		ResponseCode int         `json:"responseCode"`
+		TaskId       string      `json:"taskId,omitempty"`
+		Declaration  interface{} `json:"declaration"`
+	}
+)
+
+// debugHandler serves the runtime debug endpoints, every request must carry the
+// token in the tokenFile as a bearer token.
+func (ctlr *Controller) debugHandler(tokenFile string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/loglevel", ctlr.logLevelHandler)
+	mux.HandleFunc("/debug/as3response", ctlr.as3ResponseHandler)
+	mux.HandleFunc("/debug/config", ctlr.resourceConfigHandler)
+	mux.HandleFunc("/debug/retry", ctlr.retryTenantsHandler)
+	mux.HandleFunc("/debug/declaration", ctlr.declarationHandler)
+	mux.HandleFunc("/debug/policies", ctlr.effectivePoliciesHandler)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := ioutil.ReadFile(tokenFile)
+		if err != nil || len(strings.TrimSpace(string(token))) == 0 {
+			log.Errorf("Unable to read the debug token file %v: %v", tokenFile, err)
+			http.Error(w, "debug endpoints are not available", http.StatusServiceUnavailable)
+			return
+		}
+		auth := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte(strings.TrimSpace(string(token)))) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// logLevelHandler returns the log levels and updates them on PUT
+func (ctlr *Controller) logLevelHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var spec logLevelSpec
+		if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		levels := make(map[string]*log.LogLevel, len(spec.Subsystems))
+		for subsystem, level := range spec.Subsystems {
+			if level == "" {
+				levels[subsystem] = nil
+				continue
+			}
+			ll := log.NewLogLevel(level)
+			if ll == nil {
+				http.Error(w, "unknown log level "+level+" for subsystem "+subsystem, http.StatusBadRequest)
+				return
+			}
+			levels[subsystem] = ll
+		}
+		if spec.LogLevel != nil {
+			log.SetLogLevel(*spec.LogLevel)
+			log.Infof("Log level set to %v", spec.LogLevel)
+		}
+		for subsystem, ll := range levels {
+			if ll == nil {
+				log.ResetSubsystemLogLevel(subsystem)
+				log.Infof("Log level of subsystem %v reset", subsystem)
+			} else {
+				log.SetSubsystemLogLevel(subsystem, *ll)
+				log.Infof("Log level of subsystem %v set to %v", subsystem, ll)
+			}
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	logLevel := log.GetLogLevel()
+	spec := logLevelSpec{LogLevel: &logLevel, Subsystems: make(map[string]string)}
+	for subsystem, ll := range log.GetSubsystemLogLevels() {
+		spec.Subsystems[subsystem] = ll.String()
+	}
+	writeDebugResponse(w, spec)
+}
+
+// as3ResponseHandler returns whether the AS3 responses are logged and toggles it on PUT
+func (ctlr *Controller) as3ResponseHandler(w http.ResponseWriter, r *http.Request) {
+	if ctlr.Agent == nil || ctlr.Agent.PostManager == nil {
+		http.Error(w, "agent is not available", http.StatusServiceUnavailable)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var spec as3ResponseSpec
+		if err := json.NewDecoder(r.Body).Decode(&spec); err != nil || spec.LogAS3Response == nil {
+			http.Error(w, "logAS3Response is required", http.StatusBadRequest)
+			return
+		}
+		ctlr.Agent.setLogAS3Response(*spec.LogAS3Response)
+		log.Infof("Logging of AS3 response set to %v", *spec.LogAS3Response)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	logResponse := ctlr.Agent.logAS3Response()
+	writeDebugResponse(w, as3ResponseSpec{LogAS3Response: &logResponse})
+}
+
+// resourceConfigHandler returns the LTM and GTM config of the CIS resource store
+func (ctlr *Controller) resourceConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// marshal while holding the lock as the resource configs are shared with the worker
+	ctlr.resourcesMutex.RLock()
+	ltm := make(map[string]*debugPartitionConfig, len(ctlr.resources.ltmConfig))
+	for partition, partitionConfig := range ctlr.resources.ltmConfig {
+		// skip the partitions without virtuals, which are removed on the next post
+		if len(partitionConfig.ResourceMap) == 0 {
+			continue
+		}
+		dpc := &debugPartitionConfig{
+			Priority:    partitionConfig.Priority,
+			ResourceMap: make(map[string]*debugResourceConfig, len(partitionConfig.ResourceMap)),
+		}
+		for rsName, rsCfg := range partitionConfig.ResourceMap {
+			dpc.ResourceMap[rsName] = newDebugResourceConfig(rsCfg)
+		}
+		ltm[partition] = dpc
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"ltm": ltm,
+		"gtm": ctlr.resources.gtmConfig,
+	})
+	ctlr.resourcesMutex.RUnlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// retryTenantsHandler returns the tenants waiting to be re-posted to BIG-IP
+func (ctlr *Controller) retryTenantsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ctlr.Agent == nil {
+		http.Error(w, "agent is not available", http.StatusServiceUnavailable)
+		return
+	}
+	ctlr.Agent.declUpdate.Lock()
+	tenants := make(map[string]debugRetryTenant, len(ctlr.Agent.retryTenantDeclMap))
+	for tenant, params := range ctlr.Agent.retryTenantDeclMap {
+		tenants[tenant] = debugRetryTenant{
+			ResponseCode: params.agentResponseCode,
+			TaskId:       params.taskId,
+			Declaration:  params.as3Decl,
+		}
+	}
+	// marshal while holding the lock as the declarations are shared with the agent
+	data, err := json.Marshal(tenants)
+	ctlr.Agent.declUpdate.Unlock()
+	if err == nil {
+		data, err = redactPEM(data)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// declarationHandler returns the declaration of the last processed request built by the backend
+func (ctlr *Controller) declarationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	backend := ctlr.getBackend()
+	if backend == nil {
+		http.Error(w, "backend is not available", http.StatusServiceUnavailable)
+		return
+	}
+	ctlr.lastConfigMutex.Lock()
+	config := ctlr.lastConfig
+	ctlr.lastConfigMutex.Unlock()
+	data, err := backend.BuildDeclaration(config)
+	if err == nil {
+		data, err = redactPEM(data)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// effectivePoliciesHandler returns the effective Policies of the resource Policies keyed by namespace/name
+func (ctlr *Controller) effectivePoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// marshal while holding the lock as the effective Policies are updated by the worker
+	ctlr.effectivePolicyMutex.Lock()
+	data, err := json.Marshal(ctlr.resources.effectivePolicyMap)
+	ctlr.effectivePolicyMutex.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func newDebugResourceConfig(rsCfg *ResourceConfig) *debugResourceConfig {
+	drc := &debugResourceConfig{
+		Virtual:        rsCfg.Virtual,
+		Pools:          rsCfg.Pools,
+		Policies:       rsCfg.Policies,
+		Monitors:       rsCfg.Monitors,
+		ServiceAddress: rsCfg.ServiceAddress,
+	}
+	if len(rsCfg.IRulesMap) > 0 {
+		drc.IRules = make(map[string]*IRule, len(rsCfg.IRulesMap))
+		for ref, iRule := range rsCfg.IRulesMap {
+			drc.IRules[JoinBigipPath(ref.Partition, ref.Name)] = iRule
+		}
+	}
+	if len(rsCfg.IntDgMap) > 0 {
+		drc.InternalDataGroups = make(map[string]DataGroupNamespaceMap, len(rsCfg.IntDgMap))
+		for ref, dgMap := range rsCfg.IntDgMap {
+			drc.InternalDataGroups[JoinBigipPath(ref.Partition, ref.Name)] = dgMap
+		}
+	}
+	return drc
+}
+
+func writeDebugResponse(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// redactPEM replaces the PEM encoded certificates and private keys in the JSON data, so that
+// the debug endpoints do not expose the keys of the TLS profiles
+func redactPEM(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte(pemPrefix)) {
+		return data, nil
+	}
+	var obj interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	return json.Marshal(redactPEMValue(obj))
+}
+
+func redactPEMValue(obj interface{}) interface{} {
+	switch val := obj.(type) {
+	case map[string]interface{}:
+		for key, v := range val {
+			val[key] = redactPEMValue(v)
+		}
+	case []interface{}:
+		for i, v := range val {
+			val[i] = redactPEMValue(v)
+		}
+	case string:
+		if strings.Contains(val, pemPrefix) {
+			return "REDACTED"
+		}
+	}
+	return obj
+}
@@ -0,0 +1,652 @@
+/*-
+ * Copyright (c) 2019-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+	"go.opentelemetry.io/otel/attribute"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	fastApplicationsURI = "/mgmt/shared/fast/applications"
+	fastTasksURI        = "/mgmt/shared/fast/tasks/"
+
+	// keys of the FAST template ConfigMap
+	fastTemplateKey             = "template"
+	fastParametersKey           = "parameters"
+	fastTenantParameterKey      = "tenantParameter"
+	fastApplicationParameterKey = "applicationParameter"
+
+	// fastMaxOperations is the number of FAST applications deployed at a time
+	fastMaxOperations = 8
+)
+
+type (
+	// fastAgent is the backend which deploys each virtual of the resource config as a
+	// FAST application of the template of the FAST template ConfigMap
+	fastAgent struct {
+		*PostManager
+		templateConfigMap string
+		// partition is the default partition of CIS
+		partition  string
+		kubeClient kubernetes.Interface
+		// cmInformer watches the FAST template ConfigMap
+		cmInformer cache.SharedIndexInformer
+		eventChan  chan interface{}
+		postChan   chan ResourceConfigRequest
+		// templateChan is notified when the FAST template ConfigMap changes
+		templateChan chan struct{}
+		respChan     chan resourceStatusMeta
+		stopCh       chan struct{}
+		stopOnce     sync.Once
+		gtmWarnOnce  sync.Once
+		pollInterval time.Duration
+		mutex        sync.Mutex
+		// deployedApps are the applications deployed on BIG-IP, keyed by tenant/application
+		deployedApps map[string]fastApplication
+		// appsLoaded is set once the applications of the template are read from BIG-IP
+		appsLoaded bool
+		// template is the parsed template of the ConfigMap version templateVersion
+		template        *fastTemplate
+		templateVersion string
+	}
+
+	// fastTemplate is the FAST template and the parameter mapping of the ConfigMap
+	fastTemplate struct {
+		name                 string
+		tenantParameter      string
+		applicationParameter string
+		parameters           *template.Template
+	}
+
+	// fastApplication is the request body of a FAST application
+	fastApplication struct {
+		Template   string                 `json:"name"`
+		Parameters map[string]interface{} `json:"parameters"`
+	}
+
+	// fastAppData is the data of the parameter mapping of a virtual
+	fastAppData struct {
+		Partition string
+		Name      string
+		Address   string
+		Port      int
+		Protocol  string
+		Pools     []fastPoolData
+		Virtual   Virtual
+	}
+
+	fastPoolData struct {
+		Name                string
+		LoadBalancingMethod string
+		Monitors            []string
+		Members             []fastMemberData
+	}
+
+	// fastOperation creates, updates or deletes the FAST application of the key
+	fastOperation struct {
+		key    string
+		app    fastApplication
+		update bool
+		delete bool
+		err    error
+	}
+
+	fastMemberData struct {
+		Address string `json:"address"`
+		Port    int32  `json:"port"`
+	}
+)
+
+// NewFASTAgent returns the FAST backend, templateConfigMap is the namespace/name of
+// the FAST template ConfigMap and partition is the default partition of CIS
+func NewFASTAgent(
+	params PostParams,
+	templateConfigMap string,
+	partition string,
+	kubeClient kubernetes.Interface,
+	eventChan chan interface{},
+) *fastAgent {
+	fa := &fastAgent{
+		PostManager:       NewPostManager(params),
+		templateConfigMap: templateConfigMap,
+		partition:         partition,
+		kubeClient:        kubeClient,
+		eventChan:         eventChan,
+		postChan:          make(chan ResourceConfigRequest, 1),
+		templateChan:      make(chan struct{}, 1),
+		respChan:          make(chan resourceStatusMeta, 1),
+		stopCh:            make(chan struct{}),
+		pollInterval:      timeoutSmall,
+		deployedApps:      make(map[string]fastApplication),
+	}
+	if cmKey := strings.Split(templateConfigMap, "/"); len(cmKey) == 2 {
+		fa.cmInformer = newFASTTemplateInformer(kubeClient, cmKey[0], cmKey[1])
+		fa.cmInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { fa.notifyTemplateChange() },
+			UpdateFunc: func(oldObj, newObj interface{}) { fa.notifyTemplateChange() },
+			DeleteFunc: func(obj interface{}) { fa.notifyTemplateChange() },
+		})
+		go fa.cmInformer.Run(fa.stopCh)
+	}
+	go fa.fastWorker()
+	log.Infof("[FAST] Deploying FAST applications of the template in ConfigMap %v", templateConfigMap)
+	return fa
+}
+
+// newFASTTemplateInformer returns the informer of the FAST template ConfigMap
+func newFASTTemplateInformer(kubeClient kubernetes.Interface, namespace, name string) cache.SharedIndexInformer {
+	nameSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = nameSelector
+				return kubeClient.CoreV1().ConfigMaps(namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.FieldSelector = nameSelector
+				return kubeClient.CoreV1().ConfigMaps(namespace).Watch(context.TODO(), options)
+			},
+		},
+		&v1.ConfigMap{},
+		0,
+		cache.Indexers{},
+	)
+}
+
+func (fa *fastAgent) notifyTemplateChange() {
+	select {
+	case fa.templateChan <- struct{}{}:
+	default:
+	}
+}
+
+// templateChanged returns whether the ConfigMap differs from the version of the parsed template
+func (fa *fastAgent) templateChanged() bool {
+	obj, found, _ := fa.cmInformer.GetStore().GetByKey(fa.templateConfigMap)
+	fa.mutex.Lock()
+	defer fa.mutex.Unlock()
+	if !found {
+		return fa.template != nil
+	}
+	return fa.template == nil || fa.templateVersion != obj.(*v1.ConfigMap).ResourceVersion
+}
+
+// PostConfig posts the latest resource config to the FAST worker
+func (fa *fastAgent) PostConfig(rsConfig ResourceConfigRequest) {
+	select {
+	case fa.postChan <- rsConfig:
+	case <-fa.postChan:
+		fa.postChan <- rsConfig
+	}
+}
+
+// StatusChan returns the channel of the status of the posted resource configs
+func (fa *fastAgent) StatusChan() chan resourceStatusMeta {
+	return fa.respChan
+}
+
+// BuildDeclaration returns the FAST applications of the resource config, keyed by tenant/application
+func (fa *fastAgent) BuildDeclaration(config ResourceConfigRequest) ([]byte, error) {
+	tmpl, err := fa.loadTemplate()
+	if err != nil {
+		return nil, err
+	}
+	apps, errs := fa.createApplications(config, tmpl)
+	if len(errs) > 0 {
+		var msgs []string
+		for key, err := range errs {
+			msgs = append(msgs, fmt.Sprintf("%v: %v", key, err))
+		}
+		return nil, fmt.Errorf("%v", strings.Join(msgs, "; "))
+	}
+	return json.Marshal(apps)
+}
+
+// Stop stops the FAST worker
+func (fa *fastAgent) Stop() {
+	fa.stopOnce.Do(func() {
+		close(fa.stopCh)
+	})
+}
+
+// fastWorker deploys the posted resource config, the failed applications are
+// deployed again after a delay
+func (fa *fastAgent) fastWorker() {
+	var rsConfig ResourceConfigRequest
+	var retry <-chan time.Time
+	posted := false
+	for {
+		id := 0
+		select {
+		case <-fa.stopCh:
+			return
+		case rsConfig = <-fa.postChan:
+			id = rsConfig.reqId
+			posted = true
+		case <-retry:
+			log.Debug("[FAST] Deploying the failed applications")
+		case <-fa.templateChan:
+			// the applications are deployed only after the first resource config
+			if !posted || !fa.templateChanged() {
+				continue
+			}
+			log.Debug("[FAST] Deploying the applications of the updated template")
+		}
+		retry = nil
+		failedTenants := fa.deploy(rsConfig)
+		if len(failedTenants) > 0 {
+			retry = time.After(timeoutMedium)
+		}
+		fa.notifyRscStatusHandler(rsConfig.traceCtx, id, failedTenants)
+	}
+}
+
+func (fa *fastAgent) notifyRscStatusHandler(traceCtx context.Context, id int, failedTenants map[string]struct{}) {
+	rscUpdateMeta := resourceStatusMeta{
+		id:            id,
+		failedTenants: failedTenants,
+		traceCtx:      traceCtx,
+	}
+	select {
+	case fa.respChan <- rscUpdateMeta:
+	case <-fa.respChan:
+		fa.respChan <- rscUpdateMeta
+	}
+}
+
+// deploy creates, updates and deletes the FAST applications of the tenants of the
+// resource config and returns the tenants with failed applications
+func (fa *fastAgent) deploy(config ResourceConfigRequest) map[string]struct{} {
+	_, span := startSpan(config.traceCtx, "fastDeploy", attribute.Int("request.id", config.reqId))
+	defer span.End()
+
+	if len(config.gtmConfig) > 0 {
+		fa.gtmWarnOnce.Do(func() {
+			log.Warning("[FAST] GTM config of the ExternalDNS resources is not deployed with the FAST template")
+		})
+	}
+	failedTenants := make(map[string]struct{})
+	tmpl, err := fa.loadTemplate()
+	if err != nil {
+		log.Errorf("[FAST] %v", err)
+		for tenant := range config.ltmConfig {
+			failedTenants[tenant] = struct{}{}
+		}
+		return failedTenants
+	}
+	if !fa.appsLoaded {
+		partitions := map[string]struct{}{fa.partition: {}}
+		for tenant := range config.ltmConfig {
+			partitions[tenant] = struct{}{}
+		}
+		if err = fa.loadDeployedApplications(tmpl, partitions); err != nil {
+			log.Errorf("[FAST] Failed to get the FAST applications: %v", err)
+		} else {
+			fa.appsLoaded = true
+		}
+	}
+
+	apps, errs := fa.createApplications(config, tmpl)
+	for key, err := range errs {
+		log.Errorf("[FAST] %v: %v", key, err)
+		failedTenants[strings.Split(key, "/")[0]] = struct{}{}
+	}
+
+	// the applications which are not in the resource config are deleted, including the
+	// applications of the tenants which are not in the resource config
+	var ops []*fastOperation
+	fa.mutex.Lock()
+	for key := range fa.deployedApps {
+		if _, ok := apps[key]; ok {
+			continue
+		}
+		if _, ok := errs[key]; ok {
+			continue
+		}
+		ops = append(ops, &fastOperation{key: key, delete: true})
+	}
+	for key, app := range apps {
+		deployed, found := fa.deployedApps[key]
+		if found && reflect.DeepEqual(deployed, app) {
+			continue
+		}
+		// an application of another template is created again
+		ops = append(ops, &fastOperation{key: key, app: app, update: found && deployed.Template == app.Template})
+	}
+	fa.mutex.Unlock()
+
+	fa.runOperations(ops)
+	for _, op := range ops {
+		if op.err != nil {
+			log.Errorf("[FAST] %v", op.err)
+			failedTenants[strings.Split(op.key, "/")[0]] = struct{}{}
+		}
+	}
+
+	go sendPoolMembers(fa.eventChan, config)
+	return failedTenants
+}
+
+// runOperations runs up to fastMaxOperations operations concurrently, the deployed
+// applications are updated with the succeeded operations
+func (fa *fastAgent) runOperations(ops []*fastOperation) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, fastMaxOperations)
+	for _, op := range ops {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(op *fastOperation) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if op.delete {
+				op.err = fa.deleteApplication(op.key)
+			} else {
+				op.err = fa.postApplication(op.key, op.app, op.update)
+			}
+		}(op)
+	}
+	wg.Wait()
+
+	fa.mutex.Lock()
+	defer fa.mutex.Unlock()
+	for _, op := range ops {
+		switch {
+		case op.err != nil:
+		case op.delete:
+			delete(fa.deployedApps, op.key)
+		default:
+			fa.deployedApps[op.key] = op.app
+		}
+	}
+}
+
+// loadTemplate returns the FAST template and the parameter mapping of the ConfigMap,
+// the template is parsed again only when the ConfigMap changes
+func (fa *fastAgent) loadTemplate() (*fastTemplate, error) {
+	if fa.cmInformer == nil {
+		return nil, fmt.Errorf("invalid FAST template ConfigMap %v, use namespace/name", fa.templateConfigMap)
+	}
+	if !cache.WaitForCacheSync(fa.stopCh, fa.cmInformer.HasSynced) {
+		return nil, fmt.Errorf("FAST template ConfigMap %v is not synced", fa.templateConfigMap)
+	}
+	obj, found, err := fa.cmInformer.GetStore().GetByKey(fa.templateConfigMap)
+	if err != nil || !found {
+		return nil, fmt.Errorf("unable to get FAST template ConfigMap %v: %v", fa.templateConfigMap, err)
+	}
+	cm := obj.(*v1.ConfigMap)
+	fa.mutex.Lock()
+	defer fa.mutex.Unlock()
+	if fa.template != nil && fa.templateVersion == cm.ResourceVersion {
+		return fa.template, nil
+	}
+	tmpl := &fastTemplate{
+		name:                 cm.Data[fastTemplateKey],
+		tenantParameter:      cm.Data[fastTenantParameterKey],
+		applicationParameter: cm.Data[fastApplicationParameterKey],
+	}
+	if tmpl.name == "" || cm.Data[fastParametersKey] == "" {
+		return nil, fmt.Errorf("FAST template ConfigMap %v requires %v and %v",
+			fa.templateConfigMap, fastTemplateKey, fastParametersKey)
+	}
+	if tmpl.tenantParameter == "" {
+		tmpl.tenantParameter = "tenant_name"
+	}
+	if tmpl.applicationParameter == "" {
+		tmpl.applicationParameter = "app_name"
+	}
+	tmpl.parameters, err = template.New(fastParametersKey).Option("missingkey=error").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(cm.Data[fastParametersKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters of FAST template ConfigMap %v: %v", fa.templateConfigMap, err)
+	}
+	fa.template = tmpl
+	fa.templateVersion = cm.ResourceVersion
+	return tmpl, nil
+}
+
+// createApplications returns the FAST application and the error of each virtual keyed by tenant/application
+func (fa *fastAgent) createApplications(
+	config ResourceConfigRequest,
+	tmpl *fastTemplate,
+) (map[string]fastApplication, map[string]error) {
+	apps := make(map[string]fastApplication)
+	errs := make(map[string]error)
+	for tenant, partitionConfig := range config.ltmConfig {
+		for name, rsCfg := range partitionConfig.ResourceMap {
+			key := tenant + "/" + name
+			params, err := renderFASTParameters(tmpl, newFASTAppData(tenant, name, rsCfg))
+			if err != nil {
+				errs[key] = err
+				continue
+			}
+			apps[key] = fastApplication{Template: tmpl.name, Parameters: params}
+		}
+	}
+	return apps, errs
+}
+
+func newFASTAppData(tenant, name string, rsCfg *ResourceConfig) fastAppData {
+	address, port := extractVirtualAddressAndPort(rsCfg.Virtual.Destination)
+	data := fastAppData{
+		Partition: tenant,
+		Name:      name,
+		Address:   address,
+		Port:      port,
+		Protocol:  rsCfg.Virtual.IpProtocol,
+		Virtual:   rsCfg.Virtual,
+	}
+	for _, pool := range rsCfg.Pools {
+		pd := fastPoolData{
+			Name:                pool.Name,
+			LoadBalancingMethod: pool.Balance,
+			Members:             []fastMemberData{},
+		}
+		for _, monitor := range pool.MonitorNames {
+			pd.Monitors = append(pd.Monitors, monitor.Name)
+		}
+		for _, member := range pool.Members {
+			pd.Members = append(pd.Members, fastMemberData{Address: member.Address, Port: member.Port})
+		}
+		data.Pools = append(data.Pools, pd)
+	}
+	return data
+}
+
+// renderFASTParameters renders the parameter mapping into the parameters of the application,
+// the tenant and application parameters are set to the partition and the virtual name
+func renderFASTParameters(tmpl *fastTemplate, data fastAppData) (map[string]interface{}, error) {
+	var buf bytes.Buffer
+	if err := tmpl.parameters.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	params := make(map[string]interface{})
+	if err := json.Unmarshal(buf.Bytes(), &params); err != nil {
+		return nil, fmt.Errorf("parameters are not a JSON object: %v", err)
+	}
+	params[tmpl.tenantParameter] = data.Partition
+	params[tmpl.applicationParameter] = data.Name
+	return params, nil
+}
+
+// loadDeployedApplications reads the applications of the template in the partitions managed
+// by CIS from BIG-IP, so that the applications of the deleted virtuals are deleted after a
+// restart. The applications of the other partitions are not owned by CIS.
+func (fa *fastAgent) loadDeployedApplications(tmpl *fastTemplate, partitions map[string]struct{}) error {
+	resp, err := fa.fastRequest(http.MethodGet, fastApplicationsURI, nil)
+	if err != nil {
+		return err
+	}
+	var apps []struct {
+		Tenant   string `json:"tenant"`
+		Name     string `json:"name"`
+		Template string `json:"template"`
+	}
+	if err = json.Unmarshal(resp, &apps); err != nil {
+		return err
+	}
+	fa.mutex.Lock()
+	defer fa.mutex.Unlock()
+	for _, app := range apps {
+		if _, ok := partitions[app.Tenant]; !ok {
+			continue
+		}
+		key := app.Tenant + "/" + app.Name
+		if _, ok := fa.deployedApps[key]; !ok && app.Template == tmpl.name {
+			fa.deployedApps[key] = fastApplication{Template: app.Template}
+		}
+	}
+	return nil
+}
+
+// postApplication creates or updates the application and waits for the task
+func (fa *fastAgent) postApplication(key string, app fastApplication, update bool) error {
+	var resp []byte
+	var err error
+	if update {
+		log.Debugf("[FAST] Updating application %v", key)
+		resp, err = fa.fastRequest(http.MethodPatch, fastApplicationsURI+"/"+key,
+			map[string]interface{}{"parameters": app.Parameters})
+	} else {
+		log.Debugf("[FAST] Creating application %v", key)
+		resp, err = fa.fastRequest(http.MethodPost, fastApplicationsURI, app)
+	}
+	if err == nil {
+		err = fa.waitForTask(resp)
+	}
+	if err != nil {
+		return fmt.Errorf("%v: failed to deploy application: %v", key, err)
+	}
+	log.Infof("[FAST] Deployed application %v", key)
+	return nil
+}
+
+// deleteApplication deletes the application and waits for the task
+func (fa *fastAgent) deleteApplication(key string) error {
+	log.Debugf("[FAST] Deleting application %v", key)
+	resp, err := fa.fastRequest(http.MethodDelete, fastApplicationsURI+"/"+key, nil)
+	if err == nil {
+		err = fa.waitForTask(resp)
+	}
+	if err != nil {
+		return fmt.Errorf("%v: failed to delete application: %v", key, err)
+	}
+	log.Infof("[FAST] Deleted application %v", key)
+	return nil
+}
+
+// waitForTask polls the status of the task of the response until the task completes
+func (fa *fastAgent) waitForTask(resp []byte) error {
+	var accepted struct {
+		Id      string `json:"id"`
+		Message []struct {
+			Id string `json:"id"`
+		} `json:"message"`
+	}
+	_ = json.Unmarshal(resp, &accepted)
+	taskId := accepted.Id
+	if taskId == "" && len(accepted.Message) > 0 {
+		taskId = accepted.Message[0].Id
+	}
+	if taskId == "" {
+		return nil
+	}
+	for i := 0; i*int(fa.pollInterval) < int(timeoutLarge); i++ {
+		<-time.After(fa.pollInterval)
+		data, err := fa.fastRequest(http.MethodGet, fastTasksURI+taskId, nil)
+		if err != nil {
+			return err
+		}
+		var task struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		if err = json.Unmarshal(data, &task); err != nil {
+			return err
+		}
+		switch {
+		case task.Message == "in progress" || task.Message == "pending" || task.Code == 0:
+			continue
+		case task.Code == http.StatusOK:
+			return nil
+		default:
+			return fmt.Errorf("task %v failed with code %v: %v", taskId, task.Code, task.Message)
+		}
+	}
+	return fmt.Errorf("task %v did not complete in %v", taskId, timeoutLarge)
+}
+
+// fastRequest sends the request to the FAST API and returns the response body
+func (fa *fastAgent) fastRequest(method, uri string, body interface{}) ([]byte, error) {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(method, fa.BIGIPURL+uri, &reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(fa.BIGIPUsername, fa.BIGIPPassword)
+	req.Header.Set("Content-Type", "application/json")
+	httpResp, err := fa.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	data, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if fa.logAS3Response() {
+		log.Debugf("[FAST] Response of %v %v: %v %v", method, uri, httpResp.StatusCode, string(data))
+	}
+	if httpResp.StatusCode >= http.StatusMultipleChoices {
+		var respErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &respErr) == nil && respErr.Message != "" {
+			return nil, fmt.Errorf("%v %v", httpResp.StatusCode, respErr.Message)
+		}
+		return nil, fmt.Errorf("%v %v", httpResp.StatusCode, http.StatusText(httpResp.StatusCode))
+	}
+	return data, nil
+}
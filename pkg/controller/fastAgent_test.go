@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("FAST Agent Tests", func() {
+	var fa *fastAgent
+	var server *httptest.Server
+	var mutex sync.Mutex
+	var apps map[string]fastApplication
+	var requests []string
+	var config ResourceConfigRequest
+	var cm *v1.ConfigMap
+	var kubeClient *k8sfake.Clientset
+
+	newRsCfg := func(name, destination string, members ...PoolMember) *ResourceConfig {
+		rsCfg := &ResourceConfig{}
+		rsCfg.MetaData.ResourceType = VirtualServer
+		rsCfg.Virtual.Name = name
+		rsCfg.Virtual.Destination = destination
+		rsCfg.Virtual.IpProtocol = "tcp"
+		rsCfg.Pools = Pools{
+			{
+				Name:         name + "_pool",
+				Balance:      "round-robin",
+				Members:      members,
+				MonitorNames: []MonitorName{{Name: "/test/http_monitor"}},
+			},
+		}
+		return rsCfg
+	}
+
+	BeforeEach(func() {
+		apps = make(map[string]fastApplication)
+		requests = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if !strings.HasPrefix(r.URL.Path, fastTasksURI) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+			}
+			key := strings.TrimPrefix(r.URL.Path, fastApplicationsURI+"/")
+			switch {
+			case strings.HasPrefix(r.URL.Path, fastTasksURI):
+				_, _ = w.Write([]byte(`{"code":200,"message":"success"}`))
+			case r.Method == http.MethodGet:
+				var list []map[string]string
+				for k, app := range apps {
+					list = append(list, map[string]string{
+						"tenant": strings.Split(k, "/")[0], "name": strings.Split(k, "/")[1], "template": app.Template})
+				}
+				_ = json.NewEncoder(w).Encode(list)
+			case r.Method == http.MethodPost:
+				var app fastApplication
+				_ = json.NewDecoder(r.Body).Decode(&app)
+				apps[app.Parameters["tenant_name"].(string)+"/"+app.Parameters["app_name"].(string)] = app
+				w.WriteHeader(http.StatusAccepted)
+				_, _ = w.Write([]byte(`{"message":[{"id":"task1"}]}`))
+			case r.Method == http.MethodPatch:
+				var app fastApplication
+				_ = json.NewDecoder(r.Body).Decode(&app)
+				app.Template = apps[key].Template
+				apps[key] = app
+				w.WriteHeader(http.StatusAccepted)
+				_, _ = w.Write([]byte(`{"id":"task2"}`))
+			case r.Method == http.MethodDelete:
+				delete(apps, key)
+				w.WriteHeader(http.StatusAccepted)
+				_, _ = w.Write([]byte(`{"id":"task3"}`))
+			}
+		}))
+
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "fast-template", Namespace: "kube-system"},
+			Data: map[string]string{
+				fastTemplateKey: "examples/simple_http",
+				fastParametersKey: `{"virtual_address": {{json .Address}}, "virtual_port": {{.Port}},` +
+					` "server_addresses": [{{range $i, $m := (index .Pools 0).Members}}{{if $i}},{{end}}{{json $m.Address}}{{end}}],` +
+					` "server_port": 80}`,
+			},
+		}
+		kubeClient = k8sfake.NewSimpleClientset(cm)
+		fa = NewFASTAgent(PostParams{BIGIPURL: server.URL}, "kube-system/fast-template", "test", kubeClient, nil)
+		fa.httpClient = server.Client()
+		fa.pollInterval = time.Millisecond
+
+		config = ResourceConfigRequest{ltmConfig: make(LTMConfig), reqId: 1}
+		config.ltmConfig["test"] = &PartitionConfig{make(ResourceMap), 0}
+		config.ltmConfig["test"].ResourceMap["crd_vs_10_1_1_1_80"] = newRsCfg("crd_vs_10_1_1_1_80",
+			"/test/10.1.1.1:80", PoolMember{Address: "192.168.1.1", Port: 8080})
+	})
+
+	AfterEach(func() {
+		fa.Stop()
+		server.Close()
+	})
+
+	It("Renders the parameters of the applications", func() {
+		decl, err := fa.BuildDeclaration(config)
+		Expect(err).To(BeNil())
+		var declApps map[string]fastApplication
+		Expect(json.Unmarshal(decl, &declApps)).To(BeNil())
+		Expect(declApps).To(HaveKey("test/crd_vs_10_1_1_1_80"))
+		app := declApps["test/crd_vs_10_1_1_1_80"]
+		Expect(app.Template).To(Equal("examples/simple_http"))
+		Expect(app.Parameters["tenant_name"]).To(Equal("test"))
+		Expect(app.Parameters["app_name"]).To(Equal("crd_vs_10_1_1_1_80"))
+		Expect(app.Parameters["virtual_address"]).To(Equal("10.1.1.1"))
+		Expect(app.Parameters["virtual_port"]).To(BeEquivalentTo(80))
+		Expect(app.Parameters["server_addresses"]).To(Equal([]interface{}{"192.168.1.1"}))
+	})
+
+	It("Creates, updates and deletes the applications", func() {
+		Expect(fa.deploy(config)).To(BeEmpty())
+		Expect(apps).To(HaveKey("test/crd_vs_10_1_1_1_80"))
+		Expect(requests).To(Equal([]string{"GET " + fastApplicationsURI, "POST " + fastApplicationsURI}))
+
+		// an unchanged application is not posted again
+		requests = nil
+		Expect(fa.deploy(config)).To(BeEmpty())
+		Expect(requests).To(BeEmpty())
+
+		config.ltmConfig["test"].ResourceMap["crd_vs_10_1_1_1_80"] = newRsCfg("crd_vs_10_1_1_1_80",
+			"/test/10.1.1.1:80", PoolMember{Address: "192.168.1.2", Port: 8080})
+		Expect(fa.deploy(config)).To(BeEmpty())
+		Expect(requests).To(Equal([]string{"PATCH " + fastApplicationsURI + "/test/crd_vs_10_1_1_1_80"}))
+		Expect(apps["test/crd_vs_10_1_1_1_80"].Parameters["server_addresses"]).To(Equal([]interface{}{"192.168.1.2"}))
+
+		requests = nil
+		delete(config.ltmConfig["test"].ResourceMap, "crd_vs_10_1_1_1_80")
+		Expect(fa.deploy(config)).To(BeEmpty())
+		Expect(requests).To(Equal([]string{"DELETE " + fastApplicationsURI + "/test/crd_vs_10_1_1_1_80"}))
+		Expect(apps).To(BeEmpty())
+	})
+
+	It("Adopts only the applications of the managed partitions", func() {
+		apps["test/stale_app"] = fastApplication{Template: "examples/simple_http"}
+		apps["admin/admin_app"] = fastApplication{Template: "examples/simple_http"}
+		Expect(fa.deploy(config)).To(BeEmpty())
+		Expect(apps).ToNot(HaveKey("test/stale_app"))
+		Expect(apps).To(HaveKey("admin/admin_app"))
+		Expect(fa.deployedApps).ToNot(HaveKey("admin/admin_app"))
+	})
+
+	It("Deletes the applications of the tenants which are not in the resource config", func() {
+		config.ltmConfig["test2"] = &PartitionConfig{make(ResourceMap), 0}
+		config.ltmConfig["test2"].ResourceMap["crd_vs_10_1_1_2_80"] = newRsCfg("crd_vs_10_1_1_2_80",
+			"/test2/10.1.1.2:80", PoolMember{Address: "192.168.1.1", Port: 8080})
+		Expect(fa.deploy(config)).To(BeEmpty())
+		Expect(apps).To(HaveLen(2))
+
+		requests = nil
+		delete(config.ltmConfig, "test2")
+		Expect(fa.deploy(config)).To(BeEmpty())
+		Expect(requests).To(Equal([]string{"DELETE " + fastApplicationsURI + "/test2/crd_vs_10_1_1_2_80"}))
+		Expect(apps).To(HaveLen(1))
+		Expect(fa.deployedApps).To(HaveLen(1))
+	})
+
+	It("Deploys the applications of the updated template", func() {
+		fa.PostConfig(config)
+		Eventually(fa.StatusChan()).Should(Receive())
+		mutex.Lock()
+		Expect(apps["test/crd_vs_10_1_1_1_80"].Template).To(Equal("examples/simple_http"))
+		mutex.Unlock()
+
+		cm.Data[fastTemplateKey] = "examples/simple_http_v2"
+		cm.ResourceVersion = "2"
+		_, err := kubeClient.CoreV1().ConfigMaps("kube-system").Update(context.TODO(), cm, metav1.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Eventually(func() string {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return apps["test/crd_vs_10_1_1_1_80"].Template
+		}).Should(Equal("examples/simple_http_v2"))
+	})
+
+	It("Reports the tenants of the failed applications", func() {
+		// the parameters of a virtual without pools can not be rendered
+		rsCfg := newRsCfg("crd_vs_10_1_1_2_80", "/test/10.1.1.2:80")
+		rsCfg.Pools = nil
+		config.ltmConfig["test"].ResourceMap["crd_vs_10_1_1_2_80"] = rsCfg
+		failedTenants := fa.deploy(config)
+		Expect(failedTenants).To(HaveKey("test"))
+		Expect(apps).To(HaveKey("test/crd_vs_10_1_1_1_80"))
+		Expect(apps).ToNot(HaveKey("test/crd_vs_10_1_1_2_80"))
+	})
+
+	It("Posts the status of the resource config", func() {
+		fa.PostConfig(config)
+		var status resourceStatusMeta
+		Eventually(fa.StatusChan()).Should(Receive(&status))
+		Expect(status.id).To(Equal(1))
+		Expect(status.failedTenants).To(BeEmpty())
+	})
+})
@@ -23,6 +23,8 @@ import (
 	"time"
 
 	routeapi "github.com/openshift/api/route/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 
@@ -31,6 +33,7 @@ import (
 	cisinfv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/client/informers/externalversions/cis/v1"
 	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
 )
@@ -138,6 +141,10 @@ func (comInfr *CommonInformer) start() {
 		go comInfr.secretsInformer.Run(comInfr.stopCh)
 		cacheSyncs = append(cacheSyncs, comInfr.secretsInformer.HasSynced)
 	}
+	if comInfr.epSliceInformer != nil {
+		go comInfr.epSliceInformer.Run(comInfr.stopCh)
+		cacheSyncs = append(cacheSyncs, comInfr.epSliceInformer.HasSynced)
+	}
 	cache.WaitForNamedCacheSync(
 		"F5 CIS Ingress Controller",
 		comInfr.stopCh,
@@ -434,8 +441,8 @@ func (ctlr *Controller) newNamespacedCommonResourceInformer(
 		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
 		crOptions,
 	)
-	//enable pod informer for nodeport local mode
-	if ctlr.PoolMemberType == NodePortLocal {
+	//enable pod informer for nodeport local mode and pod readiness gates
+	if ctlr.PoolMemberType == NodePortLocal || ctlr.podReadinessGate {
 		comInf.podInformer = cache.NewSharedIndexInformer(
 			cache.NewFilteredListWatchFromClient(
 				restClientv1,
@@ -448,9 +455,39 @@ func (ctlr *Controller) newNamespacedCommonResourceInformer(
 			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
 		)
 	}
+	//enable endpointslice informer to drain the terminating endpoints
+	if ctlr.drainPeriod > 0 {
+		comInf.epSliceInformer = cache.NewSharedIndexInformer(
+			cache.NewFilteredListWatchFromClient(
+				ctlr.kubeClient.DiscoveryV1().RESTClient(),
+				"endpointslices",
+				namespace,
+				everything,
+			),
+			&discoveryv1.EndpointSlice{},
+			resyncPeriod,
+			cache.Indexers{
+				cache.NamespaceIndex:      cache.MetaNamespaceIndexFunc,
+				endpointSliceServiceIndex: endpointSliceServiceIndexFunc,
+			},
+		)
+	}
 	return comInf
 }
 
+// endpointSliceServiceIndexFunc indexes the EndpointSlices by namespace/name of their service
+func endpointSliceServiceIndexFunc(obj interface{}) ([]string, error) {
+	slice, ok := obj.(*discoveryv1.EndpointSlice)
+	if !ok {
+		return nil, nil
+	}
+	svcName, ok := slice.Labels[discoveryv1.LabelServiceName]
+	if !ok {
+		return nil, nil
+	}
+	return []string{slice.Namespace + "/" + svcName}, nil
+}
+
 func (ctlr *Controller) addCustomResourceEventHandlers(crInf *CRInformer) {
 	if crInf.vsInformer != nil {
 		crInf.vsInformer.AddEventHandler(
@@ -527,13 +564,14 @@ func (ctlr *Controller) addCommonResourceEventHandlers(comInf *CommonInformer) {
 		comInf.plcInformer.AddEventHandler(
 			&cache.ResourceEventHandlerFuncs{
 				AddFunc:    func(obj interface{}) { ctlr.enqueuePolicy(obj, Create) },
-				UpdateFunc: func(obj, cur interface{}) { ctlr.enqueuePolicy(cur, Update) },
+				UpdateFunc: func(obj, cur interface{}) { ctlr.enqueueUpdatedPolicy(obj, cur) },
 				DeleteFunc: func(obj interface{}) { ctlr.enqueueDeletedPolicy(obj) },
 			},
 		)
 	}
 
-	if comInf.podInformer != nil {
+	// pods are processed only for the NPL annotations, readiness gates read them from the informer
+	if comInf.podInformer != nil && ctlr.PoolMemberType == NodePortLocal {
 		comInf.podInformer.AddEventHandler(
 			&cache.ResourceEventHandlerFuncs{
 				AddFunc:    func(obj interface{}) { ctlr.enqueuePod(obj) },
@@ -543,6 +581,22 @@ func (ctlr *Controller) addCommonResourceEventHandlers(comInf *CommonInformer) {
 		)
 	}
 
+	if comInf.podInformer != nil && ctlr.podReadinessGate {
+		comInf.podInformer.AddEventHandler(
+			&cache.ResourceEventHandlerFuncs{
+				UpdateFunc: func(obj, cur interface{}) { ctlr.enqueueUpdatedGatedPod(obj, cur) },
+			},
+		)
+	}
+
+	if comInf.epSliceInformer != nil {
+		comInf.epSliceInformer.AddEventHandler(
+			&cache.ResourceEventHandlerFuncs{
+				UpdateFunc: func(old, cur interface{}) { ctlr.enqueueUpdatedEndpointSlice(old, cur) },
+			},
+		)
+	}
+
 	if comInf.secretsInformer != nil {
 		comInf.secretsInformer.AddEventHandler(
 			&cache.ResourceEventHandlerFuncs{
@@ -747,6 +801,7 @@ func (ctlr *Controller) enqueueUpdatedTransportServer(oldObj, newObj interface{}
 
 	if oldVS.Spec.VirtualServerAddress != newVS.Spec.VirtualServerAddress ||
 		oldVS.Spec.VirtualServerPort != newVS.Spec.VirtualServerPort ||
+		!reflect.DeepEqual(oldVS.Spec.VirtualServerPorts, newVS.Spec.VirtualServerPorts) ||
 		oldVS.Spec.VirtualServerName != newVS.Spec.VirtualServerName ||
 		oldVS.Spec.IPAMLabel != newVS.Spec.IPAMLabel ||
 		oldVS.Spec.HostGroup != newVS.Spec.HostGroup {
@@ -801,6 +856,16 @@ func (ctlr *Controller) enqueuePolicy(obj interface{}, event string) {
 	ctlr.resourceQueue.Add(key)
 }
 
+func (ctlr *Controller) enqueueUpdatedPolicy(oldObj, newObj interface{}) {
+	oldPol := oldObj.(*cisapiv1.Policy)
+	newPol := newObj.(*cisapiv1.Policy)
+	// Resources inheriting the Policy need to be processed when it is no longer a default Policy
+	if isNamespaceDefaultPolicy(oldPol) && !isNamespaceDefaultPolicy(newPol) {
+		ctlr.enqueuePolicy(oldObj, Update)
+	}
+	ctlr.enqueuePolicy(newObj, Update)
+}
+
 func (ctlr *Controller) enqueueDeletedPolicy(obj interface{}) {
 	pol := obj.(*cisapiv1.Policy)
 	log.Infof("Enqueueing Policy: %v", pol)
@@ -1124,6 +1189,65 @@ func (ctlr *Controller) enqueuePod(obj interface{}) {
 	ctlr.resourceQueue.Add(key)
 }
 
+// enqueueUpdatedEndpointSlice re-processes the Endpoints of the service when the terminating
+// endpoints of its EndpointSlice change, the Endpoints do not report the terminating endpoints.
+func (ctlr *Controller) enqueueUpdatedEndpointSlice(oldObj, newObj interface{}) {
+	oldSlice := oldObj.(*discoveryv1.EndpointSlice)
+	newSlice := newObj.(*discoveryv1.EndpointSlice)
+	if !hasTerminatingEndpoints(oldSlice) && !hasTerminatingEndpoints(newSlice) {
+		return
+	}
+	svcName, ok := newSlice.Labels[discoveryv1.LabelServiceName]
+	if !ok {
+		return
+	}
+	ctlr.enqueueServiceEndpoints(newSlice.Namespace, svcName)
+}
+
+// enqueueServiceEndpoints enqueues the Endpoints of the service from the informer cache
+func (ctlr *Controller) enqueueServiceEndpoints(namespace, svcName string) {
+	comInf, ok := ctlr.getNamespacedCommonInformer(namespace)
+	if !ok || comInf.epsInformer == nil {
+		return
+	}
+	item, found, _ := comInf.epsInformer.GetIndexer().GetByKey(namespace + "/" + svcName)
+	if !found {
+		log.Debugf("Endpoints for service %v/%v not found", namespace, svcName)
+		return
+	}
+	ctlr.enqueueEndpoints(item, Update)
+}
+
+// enqueueUpdatedGatedPod re-processes the services selecting the pod when the ContainersReady
+// condition of a pod with the CIS readiness gate changes, the Endpoints may not change with it.
+func (ctlr *Controller) enqueueUpdatedGatedPod(oldObj, newObj interface{}) {
+	oldPod := oldObj.(*corev1.Pod)
+	newPod := newObj.(*corev1.Pod)
+	if !hasPodReadinessGate(newPod) ||
+		isPodConditionTrue(oldPod, corev1.ContainersReady) == isPodConditionTrue(newPod, corev1.ContainersReady) {
+		return
+	}
+	comInf, ok := ctlr.getNamespacedCommonInformer(newPod.Namespace)
+	if !ok || comInf.svcInformer == nil {
+		return
+	}
+	svcs, err := comInf.svcInformer.GetIndexer().ByIndex(cache.NamespaceIndex, newPod.Namespace)
+	if err != nil {
+		log.Debugf("Unable to list services of namespace %v: %v", newPod.Namespace, err)
+		return
+	}
+	for _, obj := range svcs {
+		svc := obj.(*corev1.Service)
+		if len(svc.Spec.Selector) == 0 ||
+			!labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(newPod.Labels)) {
+			continue
+		}
+		log.Debugf("Enqueueing service %v/%v for the readiness gate of pod %v",
+			svc.Namespace, svc.Name, newPod.Name)
+		ctlr.enqueueServiceEndpoints(svc.Namespace, svc.Name)
+	}
+}
+
 func (ctlr *Controller) enqueueDeletedPod(obj interface{}) {
 	pod := obj.(*corev1.Pod)
 	//skip if pod belongs to coreService
@@ -1152,6 +1276,86 @@ func (nsInfr *NSInformer) stop() {
 	close(nsInfr.stopCh)
 }
 
+func (rgInfr *RGInformer) start() {
+	log.Infof("Starting RouteGroup Informer")
+	go rgInfr.rgInformer.Run(rgInfr.stopCh)
+	cache.WaitForNamedCacheSync(
+		"F5 CIS RouteGroup Controller",
+		rgInfr.stopCh,
+		rgInfr.rgInformer.HasSynced,
+	)
+}
+
+func (rgInfr *RGInformer) stop() {
+	close(rgInfr.stopCh)
+}
+
+// newRouteGroupInformer creates the RouteGroup informer for the namespace,
+// returns nil when the RouteGroup CRD is not installed
+func (ctlr *Controller) newRouteGroupInformer(namespace string) *RGInformer {
+	resources, err := ctlr.kubeCRClient.Discovery().ServerResourcesForGroupVersion(
+		cisapiv1.SchemeGroupVersion.String())
+	if err != nil {
+		log.Debugf("Unable to discover the %v resources: %v", cisapiv1.SchemeGroupVersion, err)
+		return nil
+	}
+	found := false
+	for _, rsc := range resources.APIResources {
+		if rsc.Name == "routegroups" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		log.Infof("RouteGroup CRD not found, using the extended route spec configmap only")
+		return nil
+	}
+
+	log.Debugf("Creating RouteGroup Informer for Namespace: %v", namespace)
+	rgInf := &RGInformer{
+		namespace: namespace,
+		stopCh:    make(chan struct{}),
+		rgInformer: cisinfv1.NewFilteredRouteGroupInformer(
+			ctlr.kubeCRClient,
+			namespace,
+			0*time.Second,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+			nil,
+		),
+	}
+	rgInf.rgInformer.AddEventHandler(
+		&cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { ctlr.enqueueRouteGroup(obj, Create) },
+			UpdateFunc: func(old, cur interface{}) { ctlr.enqueueUpdatedRouteGroup(old, cur) },
+			DeleteFunc: func(obj interface{}) { ctlr.enqueueRouteGroup(obj, Delete) },
+		},
+	)
+	return rgInf
+}
+
+func (ctlr *Controller) enqueueRouteGroup(obj interface{}, event string) {
+	rg := obj.(*cisapiv1.RouteGroup)
+	log.Debugf("Enqueueing RouteGroup: %v/%v", rg.Namespace, rg.Name)
+	key := &rqKey{
+		namespace: rg.ObjectMeta.Namespace,
+		kind:      RouteGroup,
+		rscName:   rg.ObjectMeta.Name,
+		rsc:       obj,
+		event:     event,
+	}
+	ctlr.resourceQueue.Add(key)
+}
+
+func (ctlr *Controller) enqueueUpdatedRouteGroup(oldObj, newObj interface{}) {
+	oldRG := oldObj.(*cisapiv1.RouteGroup)
+	newRG := newObj.(*cisapiv1.RouteGroup)
+	// Skip the status updates of CIS
+	if reflect.DeepEqual(oldRG.Spec, newRG.Spec) {
+		return
+	}
+	ctlr.enqueueRouteGroup(newObj, Update)
+}
+
 func (ctlr *Controller) createNamespaceLabeledInformer(label string) error {
 	selector, err := createLabelSelector(label)
 	if err != nil {
@@ -1187,6 +1391,7 @@ func (ctlr *Controller) createNamespaceLabeledInformer(label string) error {
 	ctlr.nsInformers[label].nsInformer.AddEventHandlerWithResyncPeriod(
 		&cache.ResourceEventHandlerFuncs{
 			AddFunc:    func(obj interface{}) { ctlr.enqueueNamespace(obj) },
+			UpdateFunc: func(oldObj, newObj interface{}) { ctlr.enqueueUpdatedNamespace(oldObj, newObj) },
 			DeleteFunc: func(obj interface{}) { ctlr.enqueueDeletedNamespace(obj) },
 		},
 		resyncPeriod,
@@ -1195,6 +1400,29 @@ func (ctlr *Controller) createNamespaceLabeledInformer(label string) error {
 	return nil
 }
 
+// newPartitionNamespaceInformer watches the namespaces for the updates of the partition
+// annotation, the namespaces are only added to the CIS scope by a namespace label informer
+func (ctlr *Controller) newPartitionNamespaceInformer() *NSInformer {
+	nsInf := &NSInformer{
+		stopCh: make(chan struct{}),
+		nsInformer: cache.NewSharedIndexInformer(
+			cache.NewListWatchFromClient(
+				ctlr.kubeClient.CoreV1().RESTClient(),
+				"namespaces",
+				"",
+				fields.Everything(),
+			),
+			&corev1.Namespace{},
+			0*time.Second,
+			cache.Indexers{},
+		),
+	}
+	nsInf.nsInformer.AddEventHandler(&cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) { ctlr.enqueueUpdatedNamespace(oldObj, newObj) },
+	})
+	return nsInf
+}
+
 func (ctlr *Controller) enqueueNamespace(obj interface{}) {
 	ns := obj.(*corev1.Namespace)
 	log.Infof("Enqueueing Namespace: %v", ns)
@@ -1208,6 +1436,26 @@ func (ctlr *Controller) enqueueNamespace(obj interface{}) {
 	ctlr.resourceQueue.Add(key)
 }
 
+func (ctlr *Controller) enqueueUpdatedNamespace(oldObj, newObj interface{}) {
+	oldNs := oldObj.(*corev1.Namespace)
+	curNs := newObj.(*corev1.Namespace)
+
+	// Only the partition annotation affects the resources of the namespace
+	if oldNs.Annotations[PartitionAnnotation] == curNs.Annotations[PartitionAnnotation] {
+		return
+	}
+
+	log.Infof("Enqueueing Updated Namespace: %v", curNs)
+	key := &rqKey{
+		namespace: curNs.ObjectMeta.Namespace,
+		kind:      Namespace,
+		rscName:   curNs.ObjectMeta.Name,
+		rsc:       newObj,
+		event:     Update,
+	}
+	ctlr.resourceQueue.Add(key)
+}
+
 func (ctlr *Controller) enqueueDeletedNamespace(obj interface{}) {
 	ns := obj.(*corev1.Namespace)
 	log.Infof("Enqueueing Namespace: %v on Delete", ns)
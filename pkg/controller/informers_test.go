@@ -427,6 +427,36 @@ var _ = Describe("Informers Tests", func() {
 			Expect(mockCtlr.processResources()).To(Equal(true))
 		})
 
+		It("Pod with readiness gate", func() {
+			mockCtlr.podReadinessGate = true
+			mockCtlr.comInformers[namespace] = mockCtlr.newNamespacedCommonResourceInformer(namespace)
+			svc := test.NewService("svc1", "1", namespace, v1.ServiceTypeClusterIP, nil)
+			svc.Spec.Selector = map[string]string{"app": "svc1"}
+			mockCtlr.addService(svc)
+			_, _ = mockCtlr.resourceQueue.Get()
+			eps := test.NewEndpoints("svc1", "1", "worker1", namespace, nil, []string{"10.1.1.1"},
+				[]v1.EndpointPort{{Name: "port1", Port: 80}})
+			_ = mockCtlr.comInformers[namespace].epsInformer.GetStore().Add(eps)
+
+			oldPod := test.NewPod("pod1", namespace, 80, map[string]string{"app": "svc1"})
+			oldPod.Spec.ReadinessGates = []v1.PodReadinessGate{{ConditionType: PodReadinessGateConditionType}}
+			pod := oldPod.DeepCopy()
+			pod.Status.Conditions = []v1.PodCondition{{Type: v1.ContainersReady, Status: v1.ConditionTrue}}
+
+			mockCtlr.enqueueUpdatedGatedPod(pod, pod)
+			Expect(mockCtlr.resourceQueue.Len()).To(Equal(0), "Pod without ContainersReady change should be skipped")
+			mockCtlr.enqueueUpdatedGatedPod(oldPod, pod)
+			Expect(mockCtlr.resourceQueue.Len()).To(Equal(1), "Endpoints of the pod service should be enqueued")
+			key, _ := mockCtlr.resourceQueue.Get()
+			Expect(key.(*rqKey).kind).To(Equal(Endpoints))
+			Expect(key.(*rqKey).rscName).To(Equal("svc1"))
+
+			pod.Labels = map[string]string{"app": "other"}
+			oldPod.Labels = pod.Labels
+			mockCtlr.enqueueUpdatedGatedPod(oldPod, pod)
+			Expect(mockCtlr.resourceQueue.Len()).To(Equal(0), "Pod not selected by any service should be skipped")
+		})
+
 		It("Secret", func() {
 			secret := test.NewSecret(
 				"SampleSecret",
@@ -0,0 +1,577 @@
+/*-
+ * Copyright (c) 2019-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// MultiClusterService is the service of an additional cluster serving the pool members
+	MultiClusterService = "MultiClusterService"
+	// MultiClusterSecret is the kubeconfig Secret of an additional cluster
+	MultiClusterSecret = "MultiClusterSecret"
+	// MultiClusterKubeConfigKey is the key of the kubeconfig in the Secret of an additional cluster
+	MultiClusterKubeConfigKey = "kubeconfig"
+	// multiClusterSyncTimeout is the time after which a cluster not yet synced is reported
+	multiClusterSyncTimeout = 30 * time.Second
+)
+
+// setupMultiClusters creates the informers of the additional clusters from the kubeconfig
+// Secrets, the name of the Secret is the cluster name. The Secrets are watched so that the
+// clients of a cluster are rebuilt when its kubeconfig changes.
+func (ctlr *Controller) setupMultiClusters(secrets []string) {
+	ctlr.multiClusters = make(map[string]*MultiClusterInformer)
+	ctlr.multiClusterSecrets = make(map[string]*MultiClusterSecretInformer)
+	for _, secretKey := range secrets {
+		splits := strings.Split(secretKey, "/")
+		if len(splits) != 2 {
+			log.Errorf("[MultiCluster] Invalid kubeconfig Secret %v, expected namespace/name", secretKey)
+			continue
+		}
+		ctlr.multiClusterSecrets[secretKey] = ctlr.newMultiClusterSecretInformer(splits[0], splits[1])
+		secret, err := ctlr.kubeClient.CoreV1().Secrets(splits[0]).Get(context.TODO(), splits[1], metav1.GetOptions{})
+		if err != nil {
+			log.Errorf("[MultiCluster] Unable to fetch the kubeconfig Secret %v: %v", secretKey, err)
+			continue
+		}
+		mcInf, err := ctlr.newMultiCluster(secret)
+		if err != nil {
+			log.Errorf("[MultiCluster] %v", err)
+			continue
+		}
+		ctlr.multiClusters[secret.Name] = mcInf
+		log.Infof("[MultiCluster] Watching the services of cluster %v", secret.Name)
+	}
+}
+
+// newMultiCluster creates the informers of the cluster from the kubeconfig in the Secret
+func (ctlr *Controller) newMultiCluster(secret *v1.Secret) (*MultiClusterInformer, error) {
+	kubeConfig := secret.Data[MultiClusterKubeConfigKey]
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid kubeconfig in Secret %v/%v: %v", secret.Namespace, secret.Name, err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create the kubeClient of cluster %v: %v", secret.Name, err)
+	}
+	mcInf := ctlr.newMultiClusterInformer(secret.Name, kubeClient)
+	mcInf.kubeConfig = kubeConfig
+	return mcInf, nil
+}
+
+// newMultiClusterSecretInformer watches the kubeconfig Secret of an additional cluster
+func (ctlr *Controller) newMultiClusterSecretInformer(namespace, name string) *MultiClusterSecretInformer {
+	secretInf := &MultiClusterSecretInformer{
+		stopCh: make(chan struct{}),
+		secretInformer: cache.NewSharedIndexInformer(
+			cache.NewListWatchFromClient(
+				ctlr.kubeClient.CoreV1().RESTClient(),
+				"secrets",
+				namespace,
+				fields.OneTermEqualSelector("metadata.name", name),
+			),
+			&v1.Secret{},
+			0*time.Second,
+			cache.Indexers{},
+		),
+	}
+	secretInf.secretInformer.AddEventHandler(
+		&cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) { ctlr.enqueueMultiClusterSecret(obj, Create) },
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				if !bytes.Equal(oldObj.(*v1.Secret).Data[MultiClusterKubeConfigKey],
+					newObj.(*v1.Secret).Data[MultiClusterKubeConfigKey]) {
+					ctlr.enqueueMultiClusterSecret(newObj, Update)
+				}
+			},
+			DeleteFunc: func(obj interface{}) { ctlr.enqueueMultiClusterSecret(obj, Delete) },
+		},
+	)
+	return secretInf
+}
+
+func (secretInf *MultiClusterSecretInformer) start() {
+	go secretInf.secretInformer.Run(secretInf.stopCh)
+}
+
+func (secretInf *MultiClusterSecretInformer) stop() {
+	close(secretInf.stopCh)
+}
+
+// enqueueMultiClusterSecret enqueues the kubeconfig Secret of a cluster
+func (ctlr *Controller) enqueueMultiClusterSecret(obj interface{}, event string) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	secret, ok := obj.(*v1.Secret)
+	if !ok {
+		return
+	}
+	log.Debugf("Enqueueing kubeconfig Secret %v/%v of cluster %v", secret.Namespace, secret.Name, secret.Name)
+	ctlr.resourceQueue.Add(&rqKey{
+		namespace: secret.Namespace,
+		kind:      MultiClusterSecret,
+		rscName:   secret.Name,
+		rsc:       secret,
+		event:     event,
+	})
+}
+
+// updateMultiCluster adds the cluster of the kubeconfig Secret, rebuilds the informers of the
+// cluster when its kubeconfig changes and removes the cluster with its Secret. It returns
+// whether a cluster is added or removed.
+func (ctlr *Controller) updateMultiCluster(secret *v1.Secret, rscDelete bool) bool {
+	mcInf, found := ctlr.multiClusters[secret.Name]
+	if rscDelete {
+		if !found {
+			return false
+		}
+		mcInf.stop()
+		delete(ctlr.multiClusters, secret.Name)
+		log.Infof("[MultiCluster] Removed cluster %v", secret.Name)
+		return true
+	}
+	if found && bytes.Equal(mcInf.kubeConfig, secret.Data[MultiClusterKubeConfigKey]) {
+		return false
+	}
+	newInf, err := ctlr.newMultiCluster(secret)
+	if err != nil {
+		log.Errorf("[MultiCluster] %v", err)
+		return false
+	}
+	if found {
+		// members of the cluster are refreshed once the new informers are synced
+		mcInf.stop()
+		log.Infof("[MultiCluster] Rebuilding the clients of cluster %v", secret.Name)
+	} else {
+		log.Infof("[MultiCluster] Watching the services of cluster %v", secret.Name)
+	}
+	ctlr.multiClusters[secret.Name] = newInf
+	ctlr.startMultiCluster(newInf)
+	return !found
+}
+
+// newMultiClusterInformer creates the informers of the services and endpoints of all the
+// namespaces of the cluster, and of the nodes in nodeport mode
+func (ctlr *Controller) newMultiClusterInformer(
+	clusterName string,
+	kubeClient kubernetes.Interface,
+) *MultiClusterInformer {
+	everything := func(options *metav1.ListOptions) {
+		options.LabelSelector = ""
+	}
+	resyncPeriod := 0 * time.Second
+	restClientv1 := kubeClient.CoreV1().RESTClient()
+	mcInf := &MultiClusterInformer{
+		clusterName: clusterName,
+		stopCh:      make(chan struct{}),
+		svcInformer: cache.NewSharedIndexInformer(
+			cache.NewFilteredListWatchFromClient(
+				restClientv1,
+				"services",
+				metav1.NamespaceAll,
+				everything,
+			),
+			&v1.Service{},
+			resyncPeriod,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		),
+		epsInformer: cache.NewSharedIndexInformer(
+			cache.NewFilteredListWatchFromClient(
+				restClientv1,
+				"endpoints",
+				metav1.NamespaceAll,
+				everything,
+			),
+			&v1.Endpoints{},
+			resyncPeriod,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		),
+	}
+	mcInf.svcInformer.AddEventHandler(
+		&cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) { ctlr.enqueueMultiClusterService(clusterName, obj) },
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				if !reflect.DeepEqual(oldObj.(*v1.Service).Spec, newObj.(*v1.Service).Spec) {
+					ctlr.enqueueMultiClusterService(clusterName, newObj)
+				}
+			},
+			DeleteFunc: func(obj interface{}) { ctlr.enqueueMultiClusterService(clusterName, obj) },
+		},
+	)
+	mcInf.epsInformer.AddEventHandler(
+		&cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) { ctlr.enqueueMultiClusterService(clusterName, obj) },
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				if !reflect.DeepEqual(oldObj.(*v1.Endpoints).Subsets, newObj.(*v1.Endpoints).Subsets) {
+					ctlr.enqueueMultiClusterService(clusterName, newObj)
+				}
+			},
+			DeleteFunc: func(obj interface{}) { ctlr.enqueueMultiClusterService(clusterName, obj) },
+		},
+	)
+	if ctlr.PoolMemberType == NodePort {
+		mcInf.nodeInformer = cache.NewSharedIndexInformer(
+			cache.NewFilteredListWatchFromClient(
+				restClientv1,
+				"nodes",
+				metav1.NamespaceAll,
+				everything,
+			),
+			&v1.Node{},
+			resyncPeriod,
+			cache.Indexers{},
+		)
+		mcInf.nodeInformer.AddEventHandler(
+			&cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) { ctlr.enqueueMultiClusterNodes(clusterName) },
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					oldNode, newNode := oldObj.(*v1.Node), newObj.(*v1.Node)
+					if !reflect.DeepEqual(oldNode.Status.Addresses, newNode.Status.Addresses) ||
+						!reflect.DeepEqual(oldNode.Labels, newNode.Labels) {
+						ctlr.enqueueMultiClusterNodes(clusterName)
+					}
+				},
+				DeleteFunc: func(obj interface{}) { ctlr.enqueueMultiClusterNodes(clusterName) },
+			},
+		)
+	}
+	return mcInf
+}
+
+func (mcInf *MultiClusterInformer) start() {
+	log.Infof("Starting Informers of cluster %v", mcInf.clusterName)
+	go mcInf.svcInformer.Run(mcInf.stopCh)
+	go mcInf.epsInformer.Run(mcInf.stopCh)
+	if mcInf.nodeInformer != nil {
+		go mcInf.nodeInformer.Run(mcInf.stopCh)
+	}
+}
+
+// startMultiCluster starts the informers of the cluster without waiting for them to sync, so
+// that an unreachable cluster does not hold up the controller. The services of the cluster
+// are enqueued once synced, until then the cluster serves no pool members.
+func (ctlr *Controller) startMultiCluster(mcInf *MultiClusterInformer) {
+	mcInf.start()
+	go func() {
+		start := time.Now()
+		reported := false
+		err := wait.PollImmediateUntil(time.Second, func() (bool, error) {
+			if mcInf.informersSynced() {
+				return true, nil
+			}
+			if !reported && time.Since(start) > multiClusterSyncTimeout {
+				reported = true
+				log.Errorf("[MultiCluster] Informers of cluster %v not synced in %v, its pool members are "+
+					"added once synced", mcInf.clusterName, multiClusterSyncTimeout)
+			}
+			return false, nil
+		}, mcInf.stopCh)
+		if err != nil {
+			return
+		}
+		atomic.StoreInt32(&mcInf.synced, 1)
+		log.Infof("[MultiCluster] Informers of cluster %v synced", mcInf.clusterName)
+		ctlr.enqueueMultiClusterNodes(mcInf.clusterName)
+	}()
+}
+
+func (mcInf *MultiClusterInformer) informersSynced() bool {
+	if mcInf.nodeInformer != nil && !mcInf.nodeInformer.HasSynced() {
+		return false
+	}
+	return mcInf.svcInformer.HasSynced() && mcInf.epsInformer.HasSynced()
+}
+
+// hasSynced returns whether the informers of the cluster are synced
+func (mcInf *MultiClusterInformer) hasSynced() bool {
+	return atomic.LoadInt32(&mcInf.synced) == 1
+}
+
+func (mcInf *MultiClusterInformer) stop() {
+	close(mcInf.stopCh)
+}
+
+// enqueueMultiClusterService enqueues the service of the cluster on a change in the
+// service or its endpoints
+func (ctlr *Controller) enqueueMultiClusterService(clusterName string, obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	namespace, name, _ := cache.SplitMetaNamespaceKey(key)
+	log.Debugf("Enqueueing Service %v of cluster %v", key, clusterName)
+	ctlr.resourceQueue.Add(&rqKey{
+		namespace: namespace,
+		kind:      MultiClusterService,
+		rscName:   name,
+		rsc: cisapiv1.MultiClusterServiceReference{
+			ClusterName: clusterName,
+			SvcName:     name,
+			Namespace:   namespace,
+		},
+		event: Update,
+	})
+}
+
+// enqueueMultiClusterNodes enqueues all the services of the cluster on a change in the nodes
+func (ctlr *Controller) enqueueMultiClusterNodes(clusterName string) {
+	log.Debugf("Enqueueing Nodes of cluster %v", clusterName)
+	ctlr.resourceQueue.Add(&rqKey{
+		kind:  MultiClusterService,
+		rsc:   cisapiv1.MultiClusterServiceReference{ClusterName: clusterName},
+		event: Update,
+	})
+}
+
+// getVirtualServersForMultiClusterService returns the VirtualServers with a pool served by
+// the service of the cluster, an empty service name matches all the services of the cluster
+func (ctlr *Controller) getVirtualServersForMultiClusterService(
+	ref cisapiv1.MultiClusterServiceReference,
+) []*cisapiv1.VirtualServer {
+	var virtuals []*cisapiv1.VirtualServer
+	for _, vs := range ctlr.getAllVSFromMonitoredNamespaces() {
+	pools:
+		for _, pool := range vs.Spec.Pools {
+			for _, mcSvc := range pool.MultiClusterServices {
+				if mcSvc.ClusterName != ref.ClusterName {
+					continue
+				}
+				namespace := mcSvc.Namespace
+				if namespace == "" {
+					namespace = vs.Namespace
+					if pool.ServiceNamespace != "" {
+						namespace = pool.ServiceNamespace
+					}
+				}
+				if ref.SvcName == "" || (mcSvc.SvcName == ref.SvcName && namespace == ref.Namespace) {
+					virtuals = append(virtuals, vs)
+					break pools
+				}
+			}
+		}
+	}
+	return virtuals
+}
+
+// updatePoolMembersForMultiClusterService updates the pool members of the virtuals with a pool
+// served by the service of the cluster, an empty service name matches all the services of the cluster
+func (ctlr *Controller) updatePoolMembersForMultiClusterService(ref cisapiv1.MultiClusterServiceReference) {
+	for partition, partitionConfig := range ctlr.resources.ltmConfig {
+		for rsName, rsCfg := range partitionConfig.ResourceMap {
+			if !servesMultiClusterService(rsCfg, ref) {
+				continue
+			}
+			freshRsCfg := &ResourceConfig{}
+			freshRsCfg.copyConfig(rsCfg)
+			ctlr.updatePoolMembersForMultiCluster(freshRsCfg)
+			_ = ctlr.resources.setResourceConfig(partition, rsName, freshRsCfg)
+		}
+	}
+}
+
+// servesMultiClusterService returns whether a pool of the virtual is served by the service of the cluster
+func servesMultiClusterService(rsCfg *ResourceConfig, ref cisapiv1.MultiClusterServiceReference) bool {
+	for _, pool := range rsCfg.Pools {
+		for _, mcSvc := range pool.MultiClusterServices {
+			if mcSvc.ClusterName != ref.ClusterName {
+				continue
+			}
+			if ref.SvcName == "" || (mcSvc.SvcName == ref.SvcName && mcSvc.Namespace == ref.Namespace) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setMultiClusterServices sets the services of the additional clusters serving the pool.
+// Weighted pools are load balanced in the ratio of the cluster weights.
+func (ctlr *Controller) setMultiClusterServices(pool *Pool, pl cisapiv1.Pool, svcNamespace string) {
+	weighted := pl.Weight > 0
+	for _, mcSvc := range pl.MultiClusterServices {
+		if mcSvc.Namespace == "" {
+			mcSvc.Namespace = svcNamespace
+		}
+		if mcSvc.ServicePort == 0 {
+			mcSvc.ServicePort = pl.ServicePort
+		}
+		weighted = weighted || mcSvc.Weight > 0
+		pool.MultiClusterServices = append(pool.MultiClusterServices, mcSvc)
+	}
+	if weighted && pool.Balance == "" {
+		pool.Balance = "ratio-member"
+	}
+}
+
+// updatePoolMembersForMultiCluster merges the members of the services of the additional
+// clusters into the pools, the ratio of the local members is weighted by the weight of the pool
+func (ctlr *Controller) updatePoolMembersForMultiCluster(rsCfg *ResourceConfig) {
+	for index, pool := range rsCfg.Pools {
+		if len(pool.MultiClusterServices) == 0 && pool.Weight == 0 {
+			continue
+		}
+		members := []PoolMember{}
+		for _, member := range pool.Members {
+			// members of the additional clusters are fetched again
+			if member.Cluster != "" {
+				continue
+			}
+			if pool.Weight > 0 {
+				// ratio of the endpoint node members is weighted by the weight of the cluster
+				if member.Ratio > 0 {
+					member.Ratio *= pool.Weight
+				} else {
+					member.Ratio = pool.Weight
+				}
+			}
+			members = append(members, member)
+		}
+		for _, mcSvc := range pool.MultiClusterServices {
+			members = append(members, ctlr.getMultiClusterPoolMembers(mcSvc, pool.NodeMemberLabel)...)
+		}
+		if len(members) > 0 {
+			rsCfg.MetaData.Active = true
+		}
+		rsCfg.Pools[index].Members = members
+	}
+}
+
+// getMultiClusterPoolMembers returns the endpoints of the service in the cluster mode and
+// the nodes of the cluster in the nodeport mode
+func (ctlr *Controller) getMultiClusterPoolMembers(
+	mcSvc cisapiv1.MultiClusterServiceReference,
+	nodeMemberLabel string,
+) []PoolMember {
+	mcInf, ok := ctlr.multiClusters[mcSvc.ClusterName]
+	if !ok {
+		log.Errorf("[MultiCluster] Cluster %v of service %v/%v is not configured",
+			mcSvc.ClusterName, mcSvc.Namespace, mcSvc.SvcName)
+		return nil
+	}
+	if !mcInf.hasSynced() {
+		log.Debugf("[MultiCluster] Cluster %v of service %v/%v is not synced yet",
+			mcSvc.ClusterName, mcSvc.Namespace, mcSvc.SvcName)
+		return nil
+	}
+	svcKey := mcSvc.Namespace + "/" + mcSvc.SvcName
+	obj, found, _ := mcInf.svcInformer.GetIndexer().GetByKey(svcKey)
+	if !found {
+		log.Debugf("[MultiCluster] Service %v not found in cluster %v", svcKey, mcSvc.ClusterName)
+		return nil
+	}
+	svc := obj.(*v1.Service)
+	var members []PoolMember
+	for _, svcPort := range svc.Spec.Ports {
+		if svcPort.Port != mcSvc.ServicePort {
+			continue
+		}
+		if ctlr.PoolMemberType == NodePort {
+			members = ctlr.getMultiClusterNodeMembers(mcInf, svcPort.NodePort, nodeMemberLabel)
+		} else {
+			members = getMultiClusterEndpointMembers(mcInf, svcKey, svcPort)
+		}
+	}
+	for i := range members {
+		members[i].Ratio = mcSvc.Weight
+		members[i].Cluster = mcSvc.ClusterName
+	}
+	return members
+}
+
+// getMultiClusterEndpointMembers returns the ready endpoints of the service port
+func getMultiClusterEndpointMembers(
+	mcInf *MultiClusterInformer,
+	svcKey string,
+	svcPort v1.ServicePort,
+) []PoolMember {
+	obj, found, _ := mcInf.epsInformer.GetIndexer().GetByKey(svcKey)
+	if !found {
+		return nil
+	}
+	var members []PoolMember
+	for _, subset := range obj.(*v1.Endpoints).Subsets {
+		for _, p := range subset.Ports {
+			if p.Name != svcPort.Name {
+				continue
+			}
+			for _, addr := range subset.Addresses {
+				members = append(members, PoolMember{
+					Address: addr.IP,
+					Port:    p.Port,
+					Session: MemberSessionEnabled,
+				})
+			}
+		}
+	}
+	return members
+}
+
+// getMultiClusterNodeMembers returns the nodes of the cluster with the node port
+func (ctlr *Controller) getMultiClusterNodeMembers(
+	mcInf *MultiClusterInformer,
+	nodePort int32,
+	nodeMemberLabel string,
+) []PoolMember {
+	if mcInf.nodeInformer == nil {
+		return nil
+	}
+	var labelKey, labelValue string
+	if nodeMemberLabel != "" {
+		label := strings.Split(nodeMemberLabel, "=")
+		if len(label) != 2 {
+			log.Warningf("Invalid NodeMemberLabel: %v", nodeMemberLabel)
+			return nil
+		}
+		labelKey, labelValue = label[0], label[1]
+	}
+	var nodes []v1.Node
+	for _, obj := range mcInf.nodeInformer.GetIndexer().List() {
+		nodes = append(nodes, *obj.(*v1.Node))
+	}
+	watchedNodes, _ := ctlr.getNodes(nodes)
+	var members []PoolMember
+	for _, node := range watchedNodes {
+		if labelKey != "" && node.Labels[labelKey] != labelValue {
+			continue
+		}
+		members = append(members, PoolMember{
+			Address: node.Addr,
+			Port:    nodePort,
+			Session: MemberSessionEnabled,
+		})
+	}
+	return members
+}
@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"sync/atomic"
+
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+	crdfake "github.com/F5Networks/k8s-bigip-ctlr/v2/config/client/clientset/versioned/fake"
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/workqueue"
+)
+
+var _ = Describe("Multi-Cluster Tests", func() {
+	var mockCtlr *mockController
+	namespace := "default"
+	svcPorts := []v1.ServicePort{{Name: "http", Port: 80, NodePort: 30080, TargetPort: intstr.FromInt(8080)}}
+
+	addRemoteService := func(mcInf *MultiClusterInformer, ips ...string) {
+		_ = mcInf.svcInformer.GetIndexer().Add(test.NewService("svc", "1", namespace, v1.ServiceTypeClusterIP, svcPorts))
+		_ = mcInf.epsInformer.GetIndexer().Add(test.NewEndpoints("svc", "1", "node", namespace, ips, nil,
+			[]v1.EndpointPort{{Name: "http", Port: 8080}}))
+	}
+
+	BeforeEach(func() {
+		mockCtlr = newMockController()
+		mockCtlr.mode = CustomResourceMode
+		mockCtlr.PoolMemberType = Cluster
+		mockCtlr.kubeCRClient = crdfake.NewSimpleClientset()
+		mockCtlr.kubeClient = k8sfake.NewSimpleClientset()
+		mockCtlr.resourceQueue = workqueue.NewNamedRateLimitingQueue(
+			workqueue.DefaultControllerRateLimiter(), "custom-resource-controller")
+		mockCtlr.crInformers = make(map[string]*CRInformer)
+		mockCtlr.comInformers = make(map[string]*CommonInformer)
+		mockCtlr.namespaces = map[string]bool{namespace: true}
+		mockCtlr.multiClusters = map[string]*MultiClusterInformer{
+			"cluster2": mockCtlr.newMultiClusterInformer("cluster2", k8sfake.NewSimpleClientset()),
+		}
+		atomic.StoreInt32(&mockCtlr.multiClusters["cluster2"].synced, 1)
+		_ = mockCtlr.addNamespacedInformers(namespace, false)
+	})
+
+	It("Sets the services of the pool", func() {
+		pool := Pool{}
+		mockCtlr.setMultiClusterServices(&pool, cisapiv1.Pool{
+			ServicePort:          80,
+			MultiClusterServices: []cisapiv1.MultiClusterServiceReference{{ClusterName: "cluster2", SvcName: "svc"}},
+		}, namespace)
+		Expect(pool.MultiClusterServices).To(HaveLen(1))
+		Expect(pool.MultiClusterServices[0].Namespace).To(Equal(namespace))
+		Expect(pool.MultiClusterServices[0].ServicePort).To(BeEquivalentTo(80))
+		Expect(pool.Balance).To(BeEmpty())
+
+		pool = Pool{}
+		mockCtlr.setMultiClusterServices(&pool, cisapiv1.Pool{
+			Weight:               70,
+			MultiClusterServices: []cisapiv1.MultiClusterServiceReference{{ClusterName: "cluster2", SvcName: "svc"}},
+		}, namespace)
+		Expect(pool.Balance).To(Equal("ratio-member"), "Weighted pool should use ratio-member")
+	})
+
+	It("Merges the endpoints of the additional cluster", func() {
+		addRemoteService(mockCtlr.multiClusters["cluster2"], "10.2.1.1", "10.2.1.2")
+		rsCfg := &ResourceConfig{}
+		rsCfg.Pools = Pools{{
+			Name:    "pool",
+			Weight:  70,
+			Members: []PoolMember{{Address: "10.1.1.1", Port: 8080}},
+			MultiClusterServices: []cisapiv1.MultiClusterServiceReference{
+				{ClusterName: "cluster2", SvcName: "svc", Namespace: namespace, ServicePort: 80, Weight: 30},
+			},
+		}}
+		mockCtlr.updatePoolMembersForMultiCluster(rsCfg)
+		Expect(rsCfg.MetaData.Active).To(BeTrue())
+		members := rsCfg.Pools[0].Members
+		Expect(members).To(HaveLen(3))
+		Expect(members[0]).To(Equal(PoolMember{Address: "10.1.1.1", Port: 8080, Ratio: 70}))
+		Expect(members[1]).To(Equal(PoolMember{
+			Address: "10.2.1.1", Port: 8080, Ratio: 30, Cluster: "cluster2", Session: MemberSessionEnabled,
+		}))
+
+		// remote members are refreshed on the next update
+		addRemoteService(mockCtlr.multiClusters["cluster2"], "10.2.1.3")
+		mockCtlr.updatePoolMembersForMultiCluster(rsCfg)
+		Expect(rsCfg.Pools[0].Members).To(HaveLen(2))
+		Expect(rsCfg.Pools[0].Members[1].Address).To(Equal("10.2.1.3"))
+	})
+
+	It("Uses the nodes of the additional cluster in nodeport mode", func() {
+		mockCtlr.PoolMemberType = NodePort
+		mcInf := mockCtlr.newMultiClusterInformer("cluster3", k8sfake.NewSimpleClientset())
+		atomic.StoreInt32(&mcInf.synced, 1)
+		mockCtlr.multiClusters["cluster3"] = mcInf
+		addRemoteService(mcInf)
+		node := test.NewNode("node1", "1", false,
+			[]v1.NodeAddress{{Type: v1.NodeExternalIP, Address: "10.3.1.1"}}, nil)
+		node.Labels = map[string]string{"zone": "a"}
+		_ = mcInf.nodeInformer.GetIndexer().Add(node)
+		_ = mcInf.nodeInformer.GetIndexer().Add(test.NewNode("node2", "1", false,
+			[]v1.NodeAddress{{Type: v1.NodeExternalIP, Address: "10.3.1.2"}}, nil))
+
+		ref := cisapiv1.MultiClusterServiceReference{ClusterName: "cluster3", SvcName: "svc", Namespace: namespace, ServicePort: 80}
+		Expect(mockCtlr.getMultiClusterPoolMembers(ref, "")).To(HaveLen(2))
+		members := mockCtlr.getMultiClusterPoolMembers(ref, "zone=a")
+		Expect(members).To(HaveLen(1))
+		Expect(members[0].Address).To(Equal("10.3.1.1"))
+		Expect(members[0].Port).To(BeEquivalentTo(30080))
+	})
+
+	It("Leaves the cluster out of the pool members until it is synced", func() {
+		addRemoteService(mockCtlr.multiClusters["cluster2"], "10.2.1.1")
+		ref := cisapiv1.MultiClusterServiceReference{ClusterName: "cluster2", SvcName: "svc", Namespace: namespace, ServicePort: 80}
+		Expect(mockCtlr.getMultiClusterPoolMembers(ref, "")).To(HaveLen(1))
+		atomic.StoreInt32(&mockCtlr.multiClusters["cluster2"].synced, 0)
+		Expect(mockCtlr.getMultiClusterPoolMembers(ref, "")).To(BeEmpty())
+	})
+
+	It("Updates the pool members of the service", func() {
+		mockCtlr.resources = NewResourceStore()
+		newRsCfg := func(svcName string) *ResourceConfig {
+			rsCfg := &ResourceConfig{}
+			rsCfg.Pools = Pools{{
+				Name: "pool",
+				MultiClusterServices: []cisapiv1.MultiClusterServiceReference{
+					{ClusterName: "cluster2", SvcName: svcName, Namespace: namespace, ServicePort: 80},
+				},
+			}}
+			return rsCfg
+		}
+		mockCtlr.resources.getPartitionResourceMap("test")["vs1"] = newRsCfg("svc")
+		mockCtlr.resources.getPartitionResourceMap("test")["vs2"] = newRsCfg("other")
+		addRemoteService(mockCtlr.multiClusters["cluster2"], "10.2.1.1")
+
+		mockCtlr.updatePoolMembersForMultiClusterService(cisapiv1.MultiClusterServiceReference{
+			ClusterName: "cluster2", SvcName: "svc", Namespace: namespace,
+		})
+		Expect(mockCtlr.getVirtualServer("test", "vs1").Pools[0].Members).To(HaveLen(1))
+		Expect(mockCtlr.getVirtualServer("test", "vs2").Pools[0].Members).To(BeEmpty(),
+			"Pool of another service should not be updated")
+	})
+
+	It("Rebuilds the cluster when its kubeconfig Secret changes", func() {
+		kubeConfig := func(server string) []byte {
+			return []byte("apiVersion: v1\nkind: Config\nclusters:\n- name: c\n  cluster:\n    server: " + server +
+				"\ncontexts:\n- name: c\n  context:\n    cluster: c\n    user: u\ncurrent-context: c\n" +
+				"users:\n- name: u\n  user:\n    token: t\n")
+		}
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster4", Namespace: "kube-system"},
+			Data:       map[string][]byte{MultiClusterKubeConfigKey: kubeConfig("https://127.0.0.1:1")},
+		}
+		Expect(mockCtlr.updateMultiCluster(secret, false)).To(BeTrue(), "New cluster should be added")
+		mcInf := mockCtlr.multiClusters["cluster4"]
+		Expect(mcInf).NotTo(BeNil())
+		Expect(mcInf.hasSynced()).To(BeFalse(), "Unreachable cluster should not be synced")
+		Expect(mockCtlr.updateMultiCluster(secret, false)).To(BeFalse())
+		Expect(mockCtlr.multiClusters["cluster4"]).To(BeIdenticalTo(mcInf), "Unchanged kubeconfig should be ignored")
+
+		secret.Data[MultiClusterKubeConfigKey] = kubeConfig("https://127.0.0.1:2")
+		Expect(mockCtlr.updateMultiCluster(secret, false)).To(BeFalse())
+		Expect(mockCtlr.multiClusters["cluster4"]).NotTo(BeIdenticalTo(mcInf), "Cluster should be rebuilt")
+		Expect(mcInf.stopCh).To(BeClosed(), "Informers of the old clients should be stopped")
+
+		secret.Data[MultiClusterKubeConfigKey] = []byte("invalid")
+		Expect(mockCtlr.updateMultiCluster(secret, false)).To(BeFalse())
+		Expect(mockCtlr.multiClusters).To(HaveKey("cluster4"), "Invalid kubeconfig should retain the cluster")
+
+		Expect(mockCtlr.updateMultiCluster(secret, true)).To(BeTrue(), "Cluster should be removed")
+		Expect(mockCtlr.multiClusters).NotTo(HaveKey("cluster4"))
+	})
+
+	It("Finds the VirtualServers of the service", func() {
+		vs := test.NewVirtualServer("vs1", namespace, cisapiv1.VirtualServerSpec{
+			Host: "foo.com",
+			Pools: []cisapiv1.Pool{{
+				Path: "/foo", Service: "svc", ServicePort: 80,
+				MultiClusterServices: []cisapiv1.MultiClusterServiceReference{{ClusterName: "cluster2", SvcName: "svc"}},
+			}},
+		})
+		mockCtlr.addVirtualServer(vs)
+		Expect(mockCtlr.getVirtualServersForMultiClusterService(cisapiv1.MultiClusterServiceReference{
+			ClusterName: "cluster2", SvcName: "svc", Namespace: namespace,
+		})).To(HaveLen(1))
+		Expect(mockCtlr.getVirtualServersForMultiClusterService(cisapiv1.MultiClusterServiceReference{
+			ClusterName: "cluster2", SvcName: "other", Namespace: namespace,
+		})).To(BeEmpty())
+		Expect(mockCtlr.getVirtualServersForMultiClusterService(cisapiv1.MultiClusterServiceReference{
+			ClusterName: "cluster2",
+		})).To(HaveLen(1), "Node change should match all the services of the cluster")
+
+		vs.Spec.VirtualServerAddress = "10.1.1.1"
+		vs.Spec.Pools[0].MultiClusterServices[0].ClusterName = "cluster4"
+		Expect(mockCtlr.validateVirtualServerSpec(vs)).NotTo(BeNil(), "Unknown cluster should be invalid")
+	})
+})
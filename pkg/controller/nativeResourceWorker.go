@@ -49,25 +49,33 @@ func (ctlr *Controller) processRoutes(routeGroup string, triggerDelete bool) err
 
 	if triggerDelete || len(routes) == 0 {
 		// Delete all possible virtuals for this route group
-		for _, portStruct := range getBasicVirtualPorts() {
-			rsName := frameRouteVSName(extdSpec.VServerName, extdSpec.VServerAddr, portStruct)
-			vs := ctlr.getVirtualServer(partition, rsName)
-			if vs != nil {
-				log.Debugf("Removing virtual %v belongs to RouteGroup: %v",
-					rsName, routeGroup)
-				ctlr.deleteVirtualServer(partition, rsName)
-				ctlr.ProcessRouteEDNS(vs.MetaData.hosts)
+		virtuals, _ := ctlr.getRouteGroupVirtuals(routeGroup, extdSpec, false)
+		for _, rgVirtual := range virtuals {
+			for _, portStruct := range getBasicVirtualPorts(extdSpec) {
+				rsName := frameRouteVSName(rgVirtual.name, rgVirtual.addr, portStruct)
+				vs := ctlr.getVirtualServer(partition, rsName)
+				if vs != nil {
+					log.Debugf("Removing virtual %v belongs to RouteGroup: %v",
+						rsName, routeGroup)
+					ctlr.deleteVirtualServer(partition, rsName)
+					ctlr.ProcessRouteEDNS(vs.MetaData.hosts)
+				}
 			}
 		}
 		return nil
 	}
 
-	portStructs := getVirtualPortsForRoutes(routes)
+	virtuals, err := ctlr.getRouteGroupVirtuals(routeGroup, extdSpec, true)
+	if err != nil {
+		return err
+	}
+	portStructs := getVirtualPortsForRoutes(routes, extdSpec)
 	vsMap := make(ResourceMap)
 	processingError := false
 
-	for _, portStruct := range portStructs {
-		rsName := frameRouteVSName(extdSpec.VServerName, extdSpec.VServerAddr, portStruct)
+	for _, vsPort := range getRouteGroupVirtualPorts(virtuals, portStructs) {
+		rgVirtual, portStruct := vsPort.virtual, vsPort.port
+		rsName := frameRouteVSName(rgVirtual.name, rgVirtual.addr, portStruct)
 
 		// Delete rsCfg if it is HTTP port and the Route does not handle HTTPTraffic
 		if portStruct.protocol == "http" && !doRoutesHandleHTTP(routes) {
@@ -82,7 +90,7 @@ func (ctlr *Controller) processRoutes(routeGroup string, triggerDelete bool) err
 		rsCfg.Virtual.Name = rsName
 		rsCfg.MetaData.Protocol = portStruct.protocol
 		rsCfg.Virtual.SetVirtualAddress(
-			extdSpec.VServerAddr,
+			rgVirtual.addr,
 			portStruct.port,
 		)
 		rsCfg.MetaData.baseResources = make(map[string]string)
@@ -99,6 +107,9 @@ func (ctlr *Controller) processRoutes(routeGroup string, triggerDelete bool) err
 			log.Errorf("%v", err)
 			break
 		}
+		if extdSpec.SNAT != "" {
+			rsCfg.Virtual.SNAT = extdSpec.SNAT
+		}
 
 		for _, rt := range routes {
 			rsCfg.MetaData.baseResources[rt.Namespace+"/"+rt.Name] = Route
@@ -113,7 +124,7 @@ func (ctlr *Controller) processRoutes(routeGroup string, triggerDelete bool) err
 
 			if isSecureRoute(rt) {
 				//TLS Logic
-				processed := ctlr.handleRouteTLS(rsCfg, rt, extdSpec.VServerAddr, servicePort)
+				processed := ctlr.handleRouteTLS(rsCfg, rt, rgVirtual.addr, getBasicVirtualPorts(extdSpec)[1].port, servicePort)
 				if !processed {
 					// Processing failed
 					// Stop processing further routes
@@ -373,7 +384,11 @@ func (ctlr *Controller) prepareResourceConfigFromRoute(
 		// skip the policy creation for passthrough termination
 		// skip the policy creation for A/B Deployment
 		if !isPassthroughRoute(route) && !IsRouteABDeployment(route) {
-			rules := ctlr.prepareRouteLTMRules(route, pool.Name, rsCfg.Virtual.AllowSourceRange)
+			allowSourceRange := rsCfg.Virtual.AllowSourceRange
+			if sourceRange, _ := getRouteAllowSourceRange(route); len(sourceRange) > 0 {
+				allowSourceRange = sourceRange
+			}
+			rules := ctlr.prepareRouteLTMRules(route, pool.Name, allowSourceRange)
 			if rules == nil {
 				return fmt.Errorf("failed to create LTM Rules")
 			}
@@ -407,17 +422,40 @@ func (ctlr *Controller) prepareRouteLTMRules(
 		return nil
 	}
 
-	if rewritePath, ok := route.Annotations[string(URLRewriteAnnotation)]; ok {
-		rewriteActions, err := getRewriteActions(
-			path,
-			rewritePath,
-			len(rl.Actions),
-		)
+	if urlRewrite, ok := route.Annotations[string(URLRewriteAnnotation)]; ok {
+		rewriteURL, err := parseRouteURLRewrite(urlRewrite)
 		if nil != err {
 			log.Errorf("Error configuring rule: %v", err)
 			return nil
 		}
-		rl.Actions = append(rl.Actions, rewriteActions...)
+		if rewriteURL.Host != "" {
+			rl.Actions = append(rl.Actions, &action{
+				Name:     fmt.Sprintf("%d", len(rl.Actions)),
+				HTTPHost: true,
+				Replace:  true,
+				Request:  true,
+				Value:    rewriteURL.Host,
+			})
+		}
+		if rewriteURL.Path != "" {
+			rewriteActions, err := getRewriteActions(
+				path,
+				rewriteURL.Path,
+				len(rl.Actions),
+			)
+			if nil != err {
+				log.Errorf("Error configuring rule: %v", err)
+				return nil
+			}
+			rl.Actions = append(rl.Actions, rewriteActions...)
+		}
+	}
+
+	if waf, ok := route.Annotations[resource.F5VsWAFPolicy]; ok {
+		rl.Actions = append(rl.Actions, &action{
+			Name: fmt.Sprintf("%d", len(rl.Actions)),
+			WAF:  waf,
+		})
 	}
 
 	if strings.HasPrefix(uri, "*.") == true {
@@ -426,6 +464,22 @@ func (ctlr *Controller) prepareRouteLTMRules(
 		rlMap[uri] = rl
 	}
 
+	var redirects Rules
+	if appRoot, ok := route.Annotations[resource.F5VsAppRootAnnotation]; ok {
+		appRootPath, err := parseRouteAppRoot(appRoot)
+		if nil != err {
+			log.Errorf("Error configuring redirect rule: %v", err)
+			return nil
+		}
+		redirectRuleName := formatVirtualServerRuleName(route.Spec.Host, route.Namespace, "redirectto", appRootPath)
+		redirect, err := createRedirectRule(route.Spec.Host+"/", appRootPath, redirectRuleName, allowSourceRange)
+		if nil != err {
+			log.Errorf("Error configuring redirect rule: %v", err)
+			return nil
+		}
+		redirects = append(redirects, redirect)
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(2)
 
@@ -451,6 +505,7 @@ func (ctlr *Controller) prepareRouteLTMRules(
 
 	rls = append(rls, w...)
 	sort.Sort(rls)
+	rls = append(redirects, rls...)
 
 	return &rls
 }
@@ -602,16 +657,17 @@ func (ctlr *Controller) GetServiceRouteWithoutHealthAnnotation(service *v1.Servi
 
 func (ctlr *Controller) updatePoolMembersForRoutes(svc *v1.Service, updatePoolHealthMon bool) {
 	namespace := svc.Namespace
-	for _, portStruct := range getBasicVirtualPorts() {
-		routeGroup, ok := ctlr.resources.invertedNamespaceLabelMap[namespace]
-		if !ok {
-			continue
-		}
-		extdSpec, partition := ctlr.resources.getExtendedRouteSpec(routeGroup)
-		if extdSpec == nil {
-			continue
-		}
-		rsName := frameRouteVSName(extdSpec.VServerName, extdSpec.VServerAddr, portStruct)
+	routeGroup, ok := ctlr.resources.invertedNamespaceLabelMap[namespace]
+	if !ok {
+		return
+	}
+	extdSpec, partition := ctlr.resources.getExtendedRouteSpec(routeGroup)
+	if extdSpec == nil {
+		return
+	}
+	virtuals, _ := ctlr.getRouteGroupVirtuals(routeGroup, extdSpec, false)
+	for _, vsPort := range getRouteGroupVirtualPorts(virtuals, getBasicVirtualPorts(extdSpec)) {
+		rsName := frameRouteVSName(vsPort.virtual.name, vsPort.virtual.addr, vsPort.port)
 		rsCfg := ctlr.getVirtualServer(partition, rsName)
 		if rsCfg == nil {
 			continue
@@ -635,11 +691,10 @@ func (ctlr *Controller) updatePoolMembersForRoutes(svc *v1.Service, updatePoolHe
 }
 
 func (ctlr *Controller) processGlobalExtendedRouteConfig() {
-	splits := strings.Split(ctlr.routeSpecCMKey, "/")
-	ns, cmName := splits[0], splits[1]
-	cm, err := ctlr.kubeClient.CoreV1().ConfigMaps(ns).Get(context.TODO(), cmName, metav1.GetOptions{})
+	cm, err := ctlr.getGlobalExtendedRouteConfigMap()
 	if err != nil {
 		log.Errorf("Unable to Get Extended Route Spec Config Map: %v, %v", ctlr.routeSpecCMKey, err)
+		return
 	}
 	err = ctlr.setNamespaceLabelMode(cm)
 	if err != nil {
@@ -660,9 +715,10 @@ func (ctlr *Controller) setNamespaceLabelMode(cm *v1.ConfigMap) error {
 	if err != nil {
 		return fmt.Errorf("invalid extended route spec in configmap: %v/%v error: %v", cm.Namespace, cm.Name, err)
 	}
+	es.ExtendedRouteGroupConfigs, _ = ctlr.mergeRouteGroups(es.ExtendedRouteGroupConfigs)
 	namespace, namespaceLabel := false, false
 	//Either defaultRouteGroup or ExtendedRouteGroupConfigs are allowed
-	if es.BaseRouteConfig.DefaultRouteGroupConfig != (DefaultRouteGroupConfig{}) && len(es.ExtendedRouteGroupConfigs) > 0 {
+	if !reflect.DeepEqual(es.BaseRouteConfig.DefaultRouteGroupConfig, DefaultRouteGroupConfig{}) && len(es.ExtendedRouteGroupConfigs) > 0 {
 		return fmt.Errorf("can not specify both defaultRouteGroup and ExtendedRouteGroupConfigs in extended configmap %v/%v", cm.Namespace, cm.Name)
 	}
 	for rg := range es.ExtendedRouteGroupConfigs {
@@ -734,6 +790,12 @@ func (ctlr *Controller) processConfigMap(cm *v1.ConfigMap, isDelete bool) (error
 
 	newExtdSpecMap := make(extendedSpecMap, len(ctlr.resources.extdSpecMap))
 	if ctlr.isGlobalExtendedRouteSpec(cm) {
+		if isDelete && ctlr.rgInformer != nil {
+			// route groups of the RouteGroups remain after deletion of the configmap
+			es, isDelete = extendedSpec{}, false
+		}
+		var rgResults []routeGroupResult
+		es.ExtendedRouteGroupConfigs, rgResults = ctlr.mergeRouteGroups(es.ExtendedRouteGroupConfigs)
 
 		// Get the base route config from the Global ConfigMap
 		ctlr.readBaseRouteConfigFromGlobalCM(es.BaseRouteConfig)
@@ -744,7 +806,7 @@ func (ctlr *Controller) processConfigMap(cm *v1.ConfigMap, isDelete bool) (error
 			partition = ctlr.Partition
 		}
 
-		if es.BaseRouteConfig.DefaultRouteGroupConfig != (DefaultRouteGroupConfig{}) {
+		if !reflect.DeepEqual(es.BaseRouteConfig.DefaultRouteGroupConfig, DefaultRouteGroupConfig{}) {
 			newExtdSpecMap[defaultRouteGroupName] = &extendedParsedSpec{
 				override:   false,
 				local:      nil,
@@ -813,6 +875,7 @@ func (ctlr *Controller) processConfigMap(cm *v1.ConfigMap, isDelete bool) (error
 
 				}
 			}
+			ctlr.updateRouteGroupStatus(rgResults)
 			return nil, true
 		}
 		deletedSpecs, modifiedSpecs, updatedSpecs, createdSpecs := getOperationalExtendedConfigMapSpecs(
@@ -820,6 +883,7 @@ func (ctlr *Controller) processConfigMap(cm *v1.ConfigMap, isDelete bool) (error
 		)
 		for _, routeGroupKey := range deletedSpecs {
 			_ = ctlr.processRoutes(routeGroupKey, true)
+			ctlr.releaseRouteGroupIP(routeGroupKey, nil)
 			if ctlr.resources.extdSpecMap[routeGroupKey].local == nil {
 				delete(ctlr.resources.extdSpecMap, routeGroupKey)
 				if ctlr.namespaceLabelMode {
@@ -843,6 +907,11 @@ func (ctlr *Controller) processConfigMap(cm *v1.ConfigMap, isDelete bool) (error
 
 		for _, routeGroupKey := range modifiedSpecs {
 			_ = ctlr.processRoutes(routeGroupKey, true)
+			if routeGroupKey == defaultRouteGroupName {
+				ctlr.releaseRouteGroupIP(routeGroupKey, newExtdSpecMap[routeGroupKey].defaultrg)
+			} else {
+				ctlr.releaseRouteGroupIP(routeGroupKey, newExtdSpecMap[routeGroupKey].global)
+			}
 			// deleting the bigip partition when partition is changes
 			if ctlr.resources.extdSpecMap[routeGroupKey].partition != newExtdSpecMap[routeGroupKey].partition {
 				if _, ok := ctlr.resources.ltmConfig[ctlr.resources.extdSpecMap[routeGroupKey].partition]; ok {
@@ -884,6 +953,7 @@ func (ctlr *Controller) processConfigMap(cm *v1.ConfigMap, isDelete bool) (error
 				log.Errorf("Failed to process RouteGroup: %v on addition of extended spec", routeGroupKey)
 			}
 		}
+		ctlr.updateRouteGroupStatus(rgResults)
 
 	} else if len(es.ExtendedRouteGroupConfigs) > 0 && !ctlr.resourceContext.namespaceLabelMode {
 		//local configmap processing.
@@ -984,7 +1054,7 @@ func (ctlr *Controller) readBaseRouteConfigFromGlobalCM(baseRouteConfig BaseRout
 	}
 	ctlr.resources.baseRouteConfig.DefaultTLS = DefaultSSLProfile{}
 	ctlr.resources.baseRouteConfig.DefaultRouteGroupConfig = DefaultRouteGroupConfig{}
-	if (baseRouteConfig != BaseRouteConfig{}) {
+	if !reflect.DeepEqual(baseRouteConfig, BaseRouteConfig{}) {
 		if baseRouteConfig.TLSCipher.TLSVersion != "" {
 			ctlr.resources.baseRouteConfig.TLSCipher.TLSVersion = baseRouteConfig.TLSCipher.TLSVersion
 		}
@@ -1001,10 +1071,15 @@ func (ctlr *Controller) readBaseRouteConfigFromGlobalCM(baseRouteConfig BaseRout
 		ctlr.resources.baseRouteConfig.DefaultTLS.ServerSSL = baseRouteConfig.DefaultTLS.ServerSSL
 		ctlr.resources.baseRouteConfig.DefaultTLS.Reference = baseRouteConfig.DefaultTLS.Reference
 	}
-	if baseRouteConfig.DefaultRouteGroupConfig != (DefaultRouteGroupConfig{}) {
+	if !reflect.DeepEqual(baseRouteConfig.DefaultRouteGroupConfig, DefaultRouteGroupConfig{}) {
 		ctlr.resources.baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.VServerName = baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.VServerName
 		ctlr.resources.baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.VServerAddr = baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.VServerAddr
 		ctlr.resources.baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.Policy = baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.Policy
+		ctlr.resources.baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.AdditionalVServerAddrs = baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.AdditionalVServerAddrs
+		ctlr.resources.baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.IPAMLabel = baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.IPAMLabel
+		ctlr.resources.baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.HTTPPort = baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.HTTPPort
+		ctlr.resources.baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.HTTPSPort = baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.HTTPSPort
+		ctlr.resources.baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.SNAT = baseRouteConfig.DefaultRouteGroupConfig.DefaultRouteGroupSpec.SNAT
 		ctlr.resources.baseRouteConfig.DefaultRouteGroupConfig.BigIpPartition = baseRouteConfig.DefaultRouteGroupConfig.BigIpPartition
 	}
 }
@@ -1072,7 +1147,7 @@ func getOperationalExtendedConfigMapSpecs(
 		if !reflect.DeepEqual(spec, newMap[routeGroupKey]) {
 			if routeGroupKey == defaultRouteGroupName {
 				//handle update to vserverName or partition in defaultRouteGroup
-				if spec.defaultrg.VServerName != newSpec.defaultrg.VServerName || spec.partition != newSpec.partition {
+				if isRouteGroupVirtualModified(spec.defaultrg, newSpec.defaultrg) || spec.partition != newSpec.partition {
 					// Update to VServerName or override should trigger delete and recreation of object
 					modifiedSpecs = append(modifiedSpecs, routeGroupKey)
 				} else {
@@ -1080,7 +1155,7 @@ func getOperationalExtendedConfigMapSpecs(
 					updateMap[routeGroupKey] = true
 				}
 			} else {
-				if spec.global.VServerName != newSpec.global.VServerName || spec.override != newSpec.override || spec.partition != newSpec.partition {
+				if isRouteGroupVirtualModified(spec.global, newSpec.global) || spec.override != newSpec.override || spec.partition != newSpec.partition {
 					// Update to VServerName or override should trigger delete and recreation of object
 					modifiedSpecs = append(modifiedSpecs, routeGroupKey)
 				} else {
@@ -1189,33 +1264,35 @@ func isPassthroughRoute(route *routeapi.Route) bool {
 	return false
 }
 
-func getBasicVirtualPorts() []portStruct {
+// getBasicVirtualPorts returns the HTTP and HTTPS ports of the route group
+func getBasicVirtualPorts(extdSpec *ExtendedRouteGroupSpec) []portStruct {
+	httpPort, httpsPort := DEFAULT_HTTP_PORT, DEFAULT_HTTPS_PORT
+	if extdSpec.HTTPPort != 0 {
+		httpPort = extdSpec.HTTPPort
+	}
+	if extdSpec.HTTPSPort != 0 {
+		httpsPort = extdSpec.HTTPSPort
+	}
 	return []portStruct{
 		{
 			protocol: "http",
-			port:     DEFAULT_HTTP_PORT,
+			port:     httpPort,
 		},
 		{
 			protocol: "https",
-			port:     DEFAULT_HTTPS_PORT,
+			port:     httpsPort,
 		},
 	}
 }
 
-func getVirtualPortsForRoutes(routes []*routeapi.Route) []portStruct {
-	ports := []portStruct{
-		{
-			protocol: "http",
-			port:     DEFAULT_HTTP_PORT,
-		},
-	}
-
+func getVirtualPortsForRoutes(routes []*routeapi.Route, extdSpec *ExtendedRouteGroupSpec) []portStruct {
+	ports := getBasicVirtualPorts(extdSpec)
 	for _, rt := range routes {
 		if isSecureRoute(rt) {
-			return getBasicVirtualPorts()
+			return ports
 		}
 	}
-	return ports
+	return ports[:1]
 }
 
 func frameRouteVSName(vServerName string,
@@ -1391,9 +1468,17 @@ func (ctlr *Controller) checkValidRoute(route *routeapi.Route) bool {
 			message := fmt.Sprintf("Discarding route %v as other route already exposes URI %v%v and is older ", route.Name, route.Spec.Host, route.Spec.Path)
 			log.Errorf(message)
 			go ctlr.updateRouteAdmitStatus(fmt.Sprintf("%v/%v", route.Namespace, route.Name), "HostAlreadyClaimed", message, v1.ConditionFalse)
+			ctlr.setRouteConflict(route, message)
 			return false
 		}
 	}
+	ctlr.clearConflict(Route, route.Namespace+"/"+route.Name)
+	if err := validateRouteAnnotations(route); err != nil {
+		message := fmt.Sprintf("Discarding route %v as it has invalid annotation: %v", route.Name, err)
+		log.Errorf(message)
+		go ctlr.updateRouteAdmitStatus(fmt.Sprintf("%v/%v", route.Namespace, route.Name), "ExtendedValidationFailed", message, v1.ConditionFalse)
+		return false
+	}
 	sslProfileOption := ctlr.getSSLProfileOption(route)
 	switch sslProfileOption {
 	case "":
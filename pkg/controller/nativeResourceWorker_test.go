@@ -582,6 +582,126 @@ extendedRouteSpec:
 
 		})
 
+		It("Route group with custom ports, additional addresses and SNAT", func() {
+			routeGroup := "default"
+			mockCtlr.resources = NewResourceStore()
+			mockCtlr.resources.extdSpecMap[routeGroup] = &extendedParsedSpec{
+				override: true,
+				global: &ExtendedRouteGroupSpec{
+					VServerAddr:            "10.8.3.11",
+					AdditionalVServerAddrs: []string{"2001:db8::11"},
+					HTTPPort:               8080,
+					HTTPSPort:              8443,
+					SNAT:                   "none",
+					AllowOverride:          "False",
+				},
+				namespaces: []string{routeGroup},
+				partition:  "test",
+			}
+			fooPorts := []v1.ServicePort{{Port: 80, NodePort: 30001}}
+			mockCtlr.addService(test.NewService("foo", "1", routeGroup, "NodePort", fooPorts))
+			route := test.NewRoute("route1", "1", routeGroup, routeapi.RouteSpec{
+				Host: "foo.com",
+				To:   routeapi.RouteTargetReference{Kind: "Service", Name: "foo"},
+			}, nil)
+			mockCtlr.addRoute(route)
+			mockCtlr.resources.invertedNamespaceLabelMap[routeGroup] = routeGroup
+
+			Expect(mockCtlr.processRoutes(routeGroup, false)).To(BeNil())
+			extdSpec := mockCtlr.resources.extdSpecMap[routeGroup].global
+			Expect(getBasicVirtualPorts(extdSpec)).To(Equal([]portStruct{
+				{protocol: "http", port: 8080}, {protocol: "https", port: 8443}}))
+			resourceMap := mockCtlr.resources.ltmConfig["test"].ResourceMap
+			Expect(resourceMap).To(HaveLen(2))
+			for _, addr := range []string{"10.8.3.11", "2001:db8::11"} {
+				rsCfg := resourceMap[frameRouteVSName("", addr, portStruct{protocol: "http", port: 8080})]
+				Expect(rsCfg).NotTo(BeNil(), addr)
+				Expect(rsCfg.Virtual.VirtualAddress.Port).To(Equal(int32(8080)))
+				Expect(rsCfg.Virtual.SNAT).To(Equal("none"))
+			}
+
+			// the virtuals of all addresses are deleted with the route group
+			Expect(mockCtlr.processRoutes(routeGroup, true)).To(BeNil())
+			Expect(mockCtlr.resources.ltmConfig["test"].ResourceMap).To(BeEmpty())
+
+			// a virtual name gets the address of an additional address
+			extdSpec.VServerName = "nextgenroutes"
+			virtuals, err := mockCtlr.getRouteGroupVirtuals(routeGroup, extdSpec, false)
+			Expect(err).To(BeNil())
+			Expect(virtuals).To(Equal([]routeGroupVirtual{
+				{name: "nextgenroutes", addr: "10.8.3.11"},
+				{name: "nextgenroutes_2001:db8::11", addr: "2001:db8::11"},
+			}))
+		})
+
+		It("Validates the Route annotations", func() {
+			route := test.NewRoute("route1", "1", "default", routeapi.RouteSpec{
+				Host: "foo.com",
+				Path: "/foo",
+				To:   routeapi.RouteTargetReference{Kind: "Service", Name: "foo"},
+			}, map[string]string{
+				resource.F5VsBalanceAnnotation:              "least-connections-member",
+				resource.F5VsURLRewriteAnnotation:           "bar.com/bar",
+				resource.F5VsWhitelistSourceRangeAnnotation: "10.1.0.0/16, 2001:db8::/32",
+				resource.F5VsWAFPolicy:                      "/Common/WAF_Policy",
+				resource.F5ServerSslSecureAnnotation:        "true",
+			})
+			Expect(validateRouteAnnotations(route)).To(BeNil())
+
+			invalid := map[string]string{
+				resource.F5VsBalanceAnnotation:              "fastest",
+				LegacyHealthMonitorAnnotation:               `{"path": "/"}`,
+				resource.F5VsURLRewriteAnnotation:           "foo.com/foo=bar.com/bar",
+				resource.F5VsAppRootAnnotation:              "/home",
+				resource.F5VsWhitelistSourceRangeAnnotation: "10.1.0.0/16,10.2.0.0",
+				resource.F5VsAllowSourceRangeAnnotation:     "10.2",
+				resource.F5VsWAFPolicy:                      "WAF_Policy",
+				resource.F5ServerSslSecureAnnotation:        "yes",
+			}
+			for annotation, value := range invalid {
+				route.Annotations = map[string]string{annotation: value}
+				Expect(validateRouteAnnotations(route)).NotTo(BeNil(), annotation)
+			}
+
+			// app root needs a route without path
+			route.Spec.Path = ""
+			route.Annotations = map[string]string{resource.F5VsAppRootAnnotation: "/home"}
+			Expect(validateRouteAnnotations(route)).To(BeNil())
+			route.Annotations[resource.F5VsAppRootAnnotation] = "foo.com/home"
+			Expect(validateRouteAnnotations(route)).NotTo(BeNil())
+		})
+
+		It("Prepares the LTM rules of the Route annotations", func() {
+			route := test.NewRoute("route1", "1", "default", routeapi.RouteSpec{
+				Host: "foo.com",
+				To:   routeapi.RouteTargetReference{Kind: "Service", Name: "foo"},
+			}, map[string]string{
+				resource.F5VsURLRewriteAnnotation:       "bar.com/bar",
+				resource.F5VsAppRootAnnotation:          "/home",
+				resource.F5VsAllowSourceRangeAnnotation: "10.1.0.0/16",
+				resource.F5VsWAFPolicy:                  "/Common/WAF_Policy",
+			})
+			sourceRange, err := getRouteAllowSourceRange(route)
+			Expect(err).To(BeNil())
+			rules := mockCtlr.prepareRouteLTMRules(route, "foo_80_default", sourceRange)
+			Expect(rules).NotTo(BeNil())
+			Expect(*rules).To(HaveLen(2))
+
+			redirect := (*rules)[0]
+			Expect(redirect.Actions[0].Redirect).To(BeTrue())
+			Expect(redirect.Actions[0].Location).To(Equal("/home"))
+			Expect(redirect.Conditions[len(redirect.Conditions)-1].Values).To(Equal([]string{"10.1.0.0/16"}))
+
+			forward := (*rules)[1]
+			Expect(forward.Actions).To(HaveLen(4))
+			Expect(forward.Actions[1].HTTPHost).To(BeTrue())
+			Expect(forward.Actions[1].Value).To(Equal("bar.com"))
+			Expect(forward.Actions[2].HTTPURI).To(BeTrue())
+			Expect(forward.Actions[2].Value).To(Equal("/bar"))
+			Expect(forward.Actions[3].WAF).To(Equal("/Common/WAF_Policy"))
+			Expect(forward.Conditions[len(forward.Conditions)-1].Values).To(Equal([]string{"10.1.0.0/16"}))
+		})
+
 		It("Check Route A/B Deploy", func() {
 			routeGroup := "default"
 			mockCtlr.resources = NewResourceStore()
@@ -749,6 +869,7 @@ extendedRouteSpec:
 				rsCfg,
 				route1,
 				extdSpec.VServerAddr,
+				DEFAULT_HTTPS_PORT,
 				intstr.IntOrString{IntVal: 443})).To(BeTrue())
 
 			//for edge route and global config map without client ssl profile - It should fail
@@ -757,6 +878,7 @@ extendedRouteSpec:
 				rsCfg,
 				route1,
 				extdSpec1.VServerAddr,
+				DEFAULT_HTTPS_PORT,
 				intstr.IntOrString{IntVal: 443})).To(BeFalse())
 
 			//for re-encrypt route, and big ip reference in global config map - It should pass
@@ -765,6 +887,7 @@ extendedRouteSpec:
 				rsCfg,
 				route2,
 				extdSpec.VServerAddr,
+				DEFAULT_HTTPS_PORT,
 				intstr.IntOrString{IntVal: 443})).To(BeTrue())
 
 			//for re encrypt route and global config map without server ssl profile - It should fail
@@ -773,6 +896,7 @@ extendedRouteSpec:
 				rsCfg,
 				route2,
 				extdSpec2.VServerAddr,
+				DEFAULT_HTTPS_PORT,
 				intstr.IntOrString{IntVal: 443})).To(BeFalse())
 		})
 
@@ -882,6 +1006,7 @@ extendedRouteSpec:
 				rsCfg,
 				route1,
 				extdSpec.VServerAddr,
+				DEFAULT_HTTPS_PORT,
 				intstr.IntOrString{IntVal: 443})).To(BeTrue())
 
 			//for edge route and global config map without client ssl profile - It should fail
@@ -890,6 +1015,7 @@ extendedRouteSpec:
 				rsCfg,
 				route1,
 				extdSpec1.VServerAddr,
+				DEFAULT_HTTPS_PORT,
 				intstr.IntOrString{IntVal: 443})).To(BeFalse())
 
 			//for re-encrypt route, and k8s secret as TLS certs in global config map - It should pass
@@ -898,6 +1024,7 @@ extendedRouteSpec:
 				rsCfg,
 				route2,
 				extdSpec.VServerAddr,
+				DEFAULT_HTTPS_PORT,
 				intstr.IntOrString{IntVal: 443})).To(BeTrue())
 
 			//for re encrypt route and global config map without server ssl profile - It should fail
@@ -906,6 +1033,7 @@ extendedRouteSpec:
 				rsCfg,
 				route2,
 				extdSpec2.VServerAddr,
+				DEFAULT_HTTPS_PORT,
 				intstr.IntOrString{IntVal: 443})).To(BeFalse())
 
 			// Verify that getRouteGroupForSecret fetches the z routeGroup on k8s secret update
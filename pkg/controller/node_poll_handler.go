@@ -8,6 +8,7 @@ import (
 
 	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
 
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/netconfig"
 	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/pollers"
 	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vxlan"
 
@@ -31,6 +32,10 @@ func (ctlr *Controller) SetupNodePolling(
 			err)
 	}
 
+	if ctlr.netAgent == nil && (0 != len(vxlanMode) || ctlr.staticRoutingMode) {
+		return fmt.Errorf("network config agent is not initialized")
+	}
+
 	if 0 != len(vxlanMode) {
 		// If partition is part of vxlanName, extract just the tunnel name
 		tunnelName := vxlanName
@@ -43,8 +48,8 @@ func (ctlr *Controller) SetupNodePolling(
 			vxlanMode,
 			tunnelName,
 			ctlr.UseNodeInternal,
-			ctlr.Agent.ConfigWriter,
-			ctlr.Agent.EventChan,
+			ctlr.netAgent.ConfigWriter,
+			ctlr.netAgent.EventChan,
 		)
 		if nil != err {
 			return fmt.Errorf("error creating vxlan manager: %v", err)
@@ -56,12 +61,22 @@ func (ctlr *Controller) SetupNodePolling(
 			return fmt.Errorf("error registering node update listener for vxlan mode: %v",
 				err)
 		}
-		if ctlr.Agent.EventChan != nil {
+		if ctlr.netAgent.EventChan != nil {
 			// It handles arp entries related to PoolMembers
 			vxMgr.ProcessAppmanagerEvents(ctlr.kubeClient)
 		}
 	}
 
+	if ctlr.staticRoutingMode {
+		routeMgr := netconfig.NewStaticRouteMgr(ctlr.UseNodeInternal, ctlr.netAgent.ConfigWriter)
+		// Register routeMgr to watch for node updates to process static routes
+		err = ctlr.nodePoller.RegisterListener(routeMgr.ProcessNodeUpdate)
+		if nil != err {
+			return fmt.Errorf("error registering node update listener for static routes: %v",
+				err)
+		}
+	}
+
 	return nil
 }
 
@@ -94,11 +109,11 @@ func (ctlr *Controller) ProcessNodeUpdate(
 						for _, virtual := range virtuals {
 							vs := virtual.(*cisapiv1.VirtualServer)
 							qKey := &rqKey{
-								vs.ObjectMeta.Namespace,
-								VirtualServer,
-								vs.ObjectMeta.Name,
-								vs,
-								Update,
+								namespace: vs.ObjectMeta.Namespace,
+								kind:      VirtualServer,
+								rscName:   vs.ObjectMeta.Name,
+								rsc:       vs,
+								event:     Update,
 							}
 							ctlr.resourceQueue.Add(qKey)
 						}
@@ -108,11 +123,11 @@ func (ctlr *Controller) ProcessNodeUpdate(
 						for _, virtual := range transportVirtuals {
 							vs := virtual.(*cisapiv1.TransportServer)
 							qKey := &rqKey{
-								vs.ObjectMeta.Namespace,
-								TransportServer,
-								vs.ObjectMeta.Name,
-								vs,
-								Update,
+								namespace: vs.ObjectMeta.Namespace,
+								kind:      TransportServer,
+								rscName:   vs.ObjectMeta.Name,
+								rsc:       vs,
+								event:     Update,
 							}
 							ctlr.resourceQueue.Add(qKey)
 						}
@@ -122,11 +137,11 @@ func (ctlr *Controller) ProcessNodeUpdate(
 						for _, ingressLink := range ingressLinks {
 							il := ingressLink.(*cisapiv1.IngressLink)
 							qKey := &rqKey{
-								il.ObjectMeta.Namespace,
-								IngressLink,
-								il.ObjectMeta.Name,
-								il,
-								Update,
+								namespace: il.ObjectMeta.Namespace,
+								kind:      IngressLink,
+								rscName:   il.ObjectMeta.Name,
+								rsc:       il,
+								event:     Update,
 							}
 							ctlr.resourceQueue.Add(qKey)
 						}
@@ -141,31 +156,31 @@ func (ctlr *Controller) ProcessNodeUpdate(
 						ingressLinks := ctlr.getAllIngressLinks(ns)
 						for _, virtual := range virtuals {
 							qKey := &rqKey{
-								ns,
-								VirtualServer,
-								virtual.ObjectMeta.Name,
-								virtual,
-								Update,
+								namespace: ns,
+								kind:      VirtualServer,
+								rscName:   virtual.ObjectMeta.Name,
+								rsc:       virtual,
+								event:     Update,
 							}
 							ctlr.resourceQueue.Add(qKey)
 						}
 						for _, virtual := range transportVirtuals {
 							qKey := &rqKey{
-								ns,
-								TransportServer,
-								virtual.ObjectMeta.Name,
-								virtual,
-								Update,
+								namespace: ns,
+								kind:      TransportServer,
+								rscName:   virtual.ObjectMeta.Name,
+								rsc:       virtual,
+								event:     Update,
 							}
 							ctlr.resourceQueue.Add(qKey)
 						}
 						for _, ingressLink := range ingressLinks {
 							qKey := &rqKey{
-								ns,
-								IngressLink,
-								ingressLink.ObjectMeta.Name,
-								ingressLink,
-								Update,
+								namespace: ns,
+								kind:      IngressLink,
+								rscName:   ingressLink.ObjectMeta.Name,
+								rsc:       ingressLink,
+								event:     Update,
 							}
 							ctlr.resourceQueue.Add(qKey)
 						}
@@ -20,6 +20,10 @@ var _ = Describe("Node Poller Handler", func() {
 	BeforeEach(func() {
 		mockCtlr = newMockController()
 		mockCtlr.Agent = newMockAgent(&test.MockWriter{FailStyle: test.Success})
+		mockCtlr.netAgent = &NetAgent{
+			ConfigWriter: mockCtlr.Agent.ConfigWriter,
+			EventChan:    mockCtlr.Agent.EventChan,
+		}
 	})
 
 	AfterEach(func() {
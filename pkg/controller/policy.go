@@ -0,0 +1,263 @@
+/*-
+* Copyright (c) 2016-2021, F5 Networks, Inc.
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*    http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package controller
+
+import (
+	"encoding/json"
+	"strings"
+
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+)
+
+// getEffectivePolicy returns the Policy that applies to a resource in namespace ns
+// which references the Policy plcName (may be empty).
+// Policies are merged in the order cluster default -> namespace default -> resource level,
+// where a non-empty field of a more specific Policy overrides the inherited one.
+func (ctlr *Controller) getEffectivePolicy(ns string, plcName string) (*cisapiv1.Policy, error) {
+	var chain []*cisapiv1.Policy
+
+	if plc := ctlr.getClusterDefaultPolicy(); plc != nil {
+		chain = append(chain, plc)
+	}
+	if plc := ctlr.getNamespaceDefaultPolicy(ns); plc != nil {
+		chain = appendPolicy(chain, plc)
+	}
+	if plcName != "" {
+		plc, err := ctlr.getPolicy(ns, plcName)
+		if err != nil {
+			return nil, err
+		}
+		chain = appendPolicy(chain, plc)
+	}
+
+	if len(chain) == 0 {
+		return nil, nil
+	}
+
+	// The most specific Policy gives the name to the effective Policy
+	effective := chain[len(chain)-1].DeepCopy()
+	effective.Spec = cisapiv1.PolicySpec{}
+	var chainKeys []string
+	for _, plc := range chain {
+		effective.Spec = mergePolicySpec(effective.Spec, plc.Spec)
+		chainKeys = append(chainKeys, plc.Namespace+"/"+plc.Name)
+	}
+
+	// The Policies inherited alone are recorded with the resource Policy that refers them
+	if plcName != "" {
+		ctlr.effectivePolicyMutex.Lock()
+		ctlr.resources.effectivePolicyMap[ns+"/"+plcName] = effectivePolicy{
+			Chain: chainKeys,
+			Spec:  effective.Spec,
+		}
+		ctlr.effectivePolicyMutex.Unlock()
+	}
+	if spec, err := json.Marshal(effective.Spec); err == nil {
+		log.Debugf("Effective Policy for namespace %v, policy %q from %v: %s",
+			ns, plcName, chainKeys, spec)
+	}
+	return effective, nil
+}
+
+// deleteEffectivePolicy removes the effective Policy of the Policy plcName in namespace ns
+// once no VirtualServer, TransportServer or LB Service of the namespace refers it anymore
+func (ctlr *Controller) deleteEffectivePolicy(ns string, plcName string) {
+	if plcName == "" {
+		return
+	}
+	for _, vs := range ctlr.getAllVirtualServers(ns) {
+		if vs.Spec.PolicyName == plcName {
+			return
+		}
+	}
+	for _, ts := range ctlr.getAllTransportServers(ns) {
+		if ts.Spec.PolicyName == plcName {
+			return
+		}
+	}
+	for _, svc := range ctlr.getAllLBServices(ns) {
+		if svc.Annotations[LBServicePolicyNameAnnotation] == plcName {
+			return
+		}
+	}
+	ctlr.effectivePolicyMutex.Lock()
+	delete(ctlr.resources.effectivePolicyMap, ns+"/"+plcName)
+	ctlr.effectivePolicyMutex.Unlock()
+}
+
+// deleteEffectivePoliciesForPolicy removes the effective Policies the deleted Policy is a part of
+func (ctlr *Controller) deleteEffectivePoliciesForPolicy(plc *cisapiv1.Policy) {
+	plcKey := plc.Namespace + "/" + plc.Name
+	ctlr.effectivePolicyMutex.Lock()
+	defer ctlr.effectivePolicyMutex.Unlock()
+	for key, effective := range ctlr.resources.effectivePolicyMap {
+		if key == plcKey {
+			delete(ctlr.resources.effectivePolicyMap, key)
+			continue
+		}
+		for _, chainKey := range effective.Chain {
+			if chainKey == plcKey {
+				delete(ctlr.resources.effectivePolicyMap, key)
+				break
+			}
+		}
+	}
+}
+
+// appendPolicy adds the Policy to the chain unless it is already the last one in it
+func appendPolicy(chain []*cisapiv1.Policy, plc *cisapiv1.Policy) []*cisapiv1.Policy {
+	if len(chain) > 0 {
+		last := chain[len(chain)-1]
+		if last.Namespace == plc.Namespace && last.Name == plc.Name {
+			return chain
+		}
+	}
+	return append(chain, plc)
+}
+
+// getClusterDefaultPolicy returns the Policy provided by --default-policy
+func (ctlr *Controller) getClusterDefaultPolicy() *cisapiv1.Policy {
+	if ctlr.defaultPolicy == "" {
+		return nil
+	}
+	splits := strings.Split(ctlr.defaultPolicy, "/")
+	if len(splits) != 2 {
+		log.Errorf("Invalid default Policy: %v", ctlr.defaultPolicy)
+		return nil
+	}
+	// A missing cluster default Policy should not block the resources
+	plc, err := ctlr.getPolicy(splits[0], splits[1])
+	if err != nil {
+		log.Warningf("Ignoring cluster default Policy %v: %v", ctlr.defaultPolicy, err)
+		return nil
+	}
+	return plc
+}
+
+// getNamespaceDefaultPolicy returns the Policy in the namespace annotated as default.
+// When more than one Policy is annotated, the oldest one is considered.
+func (ctlr *Controller) getNamespaceDefaultPolicy(ns string) *cisapiv1.Policy {
+	comInf, ok := ctlr.getNamespacedCommonInformer(ns)
+	if !ok || comInf.plcInformer == nil {
+		return nil
+	}
+	objs, err := comInf.plcInformer.GetIndexer().ByIndex("namespace", ns)
+	if err != nil {
+		log.Errorf("Unable to get list of Policies for namespace '%v': %v", ns, err)
+		return nil
+	}
+
+	var nsDefault *cisapiv1.Policy
+	for _, obj := range objs {
+		plc := obj.(*cisapiv1.Policy)
+		if !isNamespaceDefaultPolicy(plc) {
+			continue
+		}
+		if nsDefault == nil {
+			nsDefault = plc
+			continue
+		}
+		log.Warningf("Multiple default Policies found in namespace %v: %v, %v",
+			ns, nsDefault.Name, plc.Name)
+		if plc.CreationTimestamp.Before(&nsDefault.CreationTimestamp) {
+			nsDefault = plc
+		}
+	}
+	return nsDefault
+}
+
+// isNamespaceDefaultPolicy returns true if the Policy is annotated as namespace default
+func isNamespaceDefaultPolicy(plc *cisapiv1.Policy) bool {
+	return strings.ToLower(plc.Annotations[DefaultPolicyAnnotation]) == "true"
+}
+
+// isClusterDefaultPolicy returns true if the Policy is the one provided by --default-policy
+func (ctlr *Controller) isClusterDefaultPolicy(plc *cisapiv1.Policy) bool {
+	return ctlr.defaultPolicy != "" && ctlr.defaultPolicy == plc.Namespace+"/"+plc.Name
+}
+
+// getPolicyScopeNamespaces returns the namespaces having resources affected by the Policy
+func (ctlr *Controller) getPolicyScopeNamespaces(plc *cisapiv1.Policy) []string {
+	if !ctlr.isClusterDefaultPolicy(plc) {
+		return []string{plc.Namespace}
+	}
+	if ctlr.watchingAllNamespaces() {
+		return []string{""}
+	}
+	var namespaces []string
+	for ns := range ctlr.namespaces {
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces
+}
+
+// isPolicyInherited returns true if all the resources in the scope of Policy inherit it
+func (ctlr *Controller) isPolicyInherited(plc *cisapiv1.Policy) bool {
+	return ctlr.isClusterDefaultPolicy(plc) || isNamespaceDefaultPolicy(plc)
+}
+
+// mergePolicySpec returns the PolicySpec with non-empty fields of override applied over base
+func mergePolicySpec(base, override cisapiv1.PolicySpec) cisapiv1.PolicySpec {
+	merged := base
+
+	mergeString(&merged.L7Policies.WAF, override.L7Policies.WAF)
+
+	mergeString(&merged.L3Policies.DOS, override.L3Policies.DOS)
+	mergeString(&merged.L3Policies.BotDefense, override.L3Policies.BotDefense)
+	mergeString(&merged.L3Policies.FirewallPolicy, override.L3Policies.FirewallPolicy)
+	mergeStringList(&merged.L3Policies.AllowSourceRange, override.L3Policies.AllowSourceRange)
+	mergeStringList(&merged.L3Policies.AllowVlans, override.L3Policies.AllowVlans)
+
+	mergeString(&merged.LtmPolicies.Secure, override.LtmPolicies.Secure)
+	mergeString(&merged.LtmPolicies.InSecure, override.LtmPolicies.InSecure)
+	mergeString(&merged.LtmPolicies.Priority, override.LtmPolicies.Priority)
+
+	mergeString(&merged.IRules.Secure, override.IRules.Secure)
+	mergeString(&merged.IRules.InSecure, override.IRules.InSecure)
+	mergeString(&merged.IRules.Priority, override.IRules.Priority)
+
+	mergeString(&merged.Profiles.TCP.Client, override.Profiles.TCP.Client)
+	mergeString(&merged.Profiles.TCP.Server, override.Profiles.TCP.Server)
+	mergeString(&merged.Profiles.UDP, override.Profiles.UDP)
+	mergeString(&merged.Profiles.HTTP, override.Profiles.HTTP)
+	mergeString(&merged.Profiles.HTTP2, override.Profiles.HTTP2)
+	mergeString(&merged.Profiles.RewriteProfile, override.Profiles.RewriteProfile)
+	mergeString(&merged.Profiles.PersistenceProfile, override.Profiles.PersistenceProfile)
+	mergeStringList(&merged.Profiles.LogProfiles, override.Profiles.LogProfiles)
+	mergeString(&merged.Profiles.ProfileL4, override.Profiles.ProfileL4)
+	mergeString(&merged.Profiles.ProfileMultiplex, override.Profiles.ProfileMultiplex)
+	if override.Profiles.HTTPOptions != nil {
+		merged.Profiles.HTTPOptions = override.Profiles.HTTPOptions.DeepCopy()
+	}
+
+	mergeString(&merged.SNAT, override.SNAT)
+
+	return merged
+}
+
+func mergeString(base *string, override string) {
+	if override != "" {
+		*base = override
+	}
+}
+
+func mergeStringList(base *[]string, override []string) {
+	if len(override) > 0 {
+		*base = append([]string{}, override...)
+	}
+}
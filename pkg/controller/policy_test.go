@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"time"
+
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+	crdfake "github.com/F5Networks/k8s-bigip-ctlr/v2/config/client/clientset/versioned/fake"
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Policy Inheritance", func() {
+	var mockCtlr *mockController
+	var clusterPlc, nsPlc, vsPlc *cisapiv1.Policy
+	namespace := "default"
+
+	BeforeEach(func() {
+		mockCtlr = newMockController()
+		mockCtlr.mode = CustomResourceMode
+		mockCtlr.namespaces = make(map[string]bool)
+		mockCtlr.namespaces[namespace] = true
+		mockCtlr.namespaces["platform"] = true
+		mockCtlr.kubeCRClient = crdfake.NewSimpleClientset()
+		mockCtlr.kubeClient = k8sfake.NewSimpleClientset()
+		mockCtlr.crInformers = make(map[string]*CRInformer)
+		mockCtlr.comInformers = make(map[string]*CommonInformer)
+		mockCtlr.nrInformers = make(map[string]*NRInformer)
+		mockCtlr.resources = NewResourceStore()
+		mockCtlr.customResourceSelector, _ = createLabelSelector(DefaultCustomResourceLabel)
+		_ = mockCtlr.addNamespacedInformers(namespace, false)
+		_ = mockCtlr.addNamespacedInformers("platform", false)
+
+		clusterPlc = test.NewPolicy("cluster-default", "platform",
+			cisapiv1.PolicySpec{
+				L7Policies: cisapiv1.L7PolicySpec{WAF: "/Common/WAF_Default"},
+				Profiles: cisapiv1.ProfileSpec{
+					LogProfiles: []string{"/Common/local-dos"},
+				},
+				SNAT: "auto",
+			},
+		)
+		nsPlc = test.NewPolicy("ns-default", namespace,
+			cisapiv1.PolicySpec{
+				L3Policies: cisapiv1.L3PolicySpec{FirewallPolicy: "/Common/AFM_Policy"},
+				SNAT:       "/Common/snatpool",
+			},
+		)
+		nsPlc.Annotations = map[string]string{DefaultPolicyAnnotation: "true"}
+		vsPlc = test.NewPolicy("vs-policy", namespace,
+			cisapiv1.PolicySpec{
+				L7Policies: cisapiv1.L7PolicySpec{WAF: "/Common/WAF_Team"},
+			},
+		)
+	})
+
+	It("Merge Policy Specs", func() {
+		merged := mergePolicySpec(clusterPlc.Spec, vsPlc.Spec)
+		Expect(merged.L7Policies.WAF).To(Equal("/Common/WAF_Team"), "Resource Policy should override WAF")
+		Expect(merged.Profiles.LogProfiles).To(Equal([]string{"/Common/local-dos"}),
+			"Log Profiles should be inherited")
+		Expect(merged.SNAT).To(Equal("auto"), "SNAT should be inherited")
+	})
+
+	It("No Policies", func() {
+		plc, err := mockCtlr.getEffectivePolicy(namespace, "")
+		Expect(err).To(BeNil())
+		Expect(plc).To(BeNil(), "Effective Policy should be nil")
+	})
+
+	It("Cluster, Namespace and Resource level Policies", func() {
+		mockCtlr.defaultPolicy = "platform/cluster-default"
+		mockCtlr.addPolicy(clusterPlc)
+
+		plc, err := mockCtlr.getEffectivePolicy(namespace, "")
+		Expect(err).To(BeNil())
+		Expect(plc.Spec.L7Policies.WAF).To(Equal("/Common/WAF_Default"))
+
+		mockCtlr.addPolicy(nsPlc)
+		plc, err = mockCtlr.getEffectivePolicy(namespace, "")
+		Expect(err).To(BeNil())
+		Expect(plc.Name).To(Equal("ns-default"))
+		Expect(plc.Spec.L7Policies.WAF).To(Equal("/Common/WAF_Default"))
+		Expect(plc.Spec.L3Policies.FirewallPolicy).To(Equal("/Common/AFM_Policy"))
+		Expect(plc.Spec.SNAT).To(Equal("/Common/snatpool"))
+
+		// Policy referred by resource is missing
+		_, err = mockCtlr.getEffectivePolicy(namespace, "vs-policy")
+		Expect(err).NotTo(BeNil(), "Missing resource Policy should fail")
+
+		mockCtlr.addPolicy(vsPlc)
+		plc, err = mockCtlr.getEffectivePolicy(namespace, "vs-policy")
+		Expect(err).To(BeNil())
+		Expect(plc.Name).To(Equal("vs-policy"))
+		Expect(plc.Spec.L7Policies.WAF).To(Equal("/Common/WAF_Team"))
+		Expect(plc.Spec.L3Policies.FirewallPolicy).To(Equal("/Common/AFM_Policy"))
+		Expect(plc.Spec.Profiles.LogProfiles).To(Equal([]string{"/Common/local-dos"}))
+
+		effective, ok := mockCtlr.resources.effectivePolicyMap[namespace+"/vs-policy"]
+		Expect(ok).To(BeTrue(), "Effective Policy not recorded")
+		Expect(effective.Chain).To(Equal([]string{
+			"platform/cluster-default", "default/ns-default", "default/vs-policy"}))
+		Expect(mockCtlr.resources.effectivePolicyMap).NotTo(HaveKey(namespace+"/"),
+			"Inherited Policies should not be recorded without resource Policy")
+	})
+
+	It("Prune effective Policies", func() {
+		mockCtlr.addPolicy(nsPlc)
+		mockCtlr.addPolicy(vsPlc)
+		vs := test.NewVirtualServer("vs1", namespace,
+			cisapiv1.VirtualServerSpec{Host: "test.com", PolicyName: "vs-policy"})
+		mockCtlr.addVirtualServer(vs)
+		_, err := mockCtlr.getEffectivePolicy(namespace, "vs-policy")
+		Expect(err).To(BeNil())
+
+		// Policy is still referred by a VirtualServer
+		mockCtlr.deleteEffectivePolicy(namespace, "vs-policy")
+		Expect(mockCtlr.resources.effectivePolicyMap).To(HaveKey(namespace + "/vs-policy"))
+
+		mockCtlr.deleteVirtualServer(vs)
+		mockCtlr.deleteEffectivePolicy(namespace, "vs-policy")
+		Expect(mockCtlr.resources.effectivePolicyMap).NotTo(HaveKey(namespace + "/vs-policy"))
+
+		// Deleted Policy removes the effective Policies it is a part of
+		_, err = mockCtlr.getEffectivePolicy(namespace, "vs-policy")
+		Expect(err).To(BeNil())
+		mockCtlr.deleteEffectivePoliciesForPolicy(nsPlc)
+		Expect(mockCtlr.resources.effectivePolicyMap).To(BeEmpty())
+	})
+
+	It("Oldest namespace default Policy", func() {
+		nsPlc.CreationTimestamp = metav1.NewTime(time.Now())
+		olderPlc := test.NewPolicy("older-default", namespace, cisapiv1.PolicySpec{SNAT: "none"})
+		olderPlc.Annotations = map[string]string{DefaultPolicyAnnotation: "true"}
+		olderPlc.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+		mockCtlr.addPolicy(nsPlc)
+		mockCtlr.addPolicy(olderPlc)
+
+		plc := mockCtlr.getNamespaceDefaultPolicy(namespace)
+		Expect(plc).NotTo(BeNil())
+		Expect(plc.Name).To(Equal("older-default"))
+	})
+
+	It("Resources affected by default Policies", func() {
+		vs := test.NewVirtualServer("vs1", namespace, cisapiv1.VirtualServerSpec{Host: "test.com"})
+		vsWithPlc := test.NewVirtualServer("vs2", namespace,
+			cisapiv1.VirtualServerSpec{Host: "test2.com", PolicyName: "vs-policy"})
+		mockCtlr.addVirtualServer(vs)
+		mockCtlr.addVirtualServer(vsWithPlc)
+
+		Expect(len(mockCtlr.getVirtualsForCustomPolicy(vsPlc))).To(Equal(1))
+		Expect(len(mockCtlr.getVirtualsForCustomPolicy(nsPlc))).To(Equal(2))
+
+		mockCtlr.defaultPolicy = "platform/cluster-default"
+		Expect(mockCtlr.getPolicyScopeNamespaces(clusterPlc)).To(ConsistOf(namespace, "platform"))
+		Expect(len(mockCtlr.getVirtualsForCustomPolicy(clusterPlc))).To(Equal(2))
+	})
+})
@@ -0,0 +1,116 @@
+/*-
+ * Copyright (c) 2019-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// setPoolTopology sets the topology and the min active members of the pool, the pool
+// balances by the member ratio when the topology sets the ratio
+func setPoolTopology(pool *Pool, pl cisapiv1.Pool) {
+	pool.MinActiveMembers = pl.MinActiveMembers
+	if pl.Topology == nil || pl.Topology.Label == "" {
+		return
+	}
+	pool.Topology = pl.Topology
+	for _, group := range pl.Topology.Groups {
+		if group.Ratio > 0 && pool.Balance == "" {
+			pool.Balance = "ratio-member"
+			break
+		}
+	}
+}
+
+// setMemberLocation sets the node and the pod of the endpoint member
+func setMemberLocation(member *PoolMember, addr v1.EndpointAddress) {
+	if addr.NodeName != nil {
+		member.NodeName = *addr.NodeName
+	}
+	if addr.TargetRef != nil && addr.TargetRef.Kind == "Pod" {
+		member.PodName = addr.TargetRef.Namespace + "/" + addr.TargetRef.Name
+	}
+}
+
+// updatePoolMembersTopology sets the ratio and the priority group of the members of this
+// cluster by the label value of their node, or of their pod if the node is not labeled.
+// The node of the endpoint members is known by name and the node members by address.
+// The ratio of the group multiplies the endpoint count ratio of the endpoint node members.
+func (ctlr *Controller) updatePoolMembersTopology(rsCfg *ResourceConfig) {
+	var nodeLabels map[string]map[string]string
+	for index, pool := range rsCfg.Pools {
+		if pool.Topology == nil || len(pool.Members) == 0 {
+			continue
+		}
+		if nodeLabels == nil {
+			// members of the nodeport modes are the node addresses
+			nodeLabels = make(map[string]map[string]string)
+			for _, node := range ctlr.getNodesFromCache() {
+				nodeLabels[node.Name] = node.Labels
+				nodeLabels[node.Addr] = node.Labels
+			}
+		}
+		groups := make(map[string]cisapiv1.TopologyGroup)
+		for _, group := range pool.Topology.Groups {
+			groups[group.Value] = group
+		}
+		// members are shared with the pool member cache of the service
+		members := make([]PoolMember, len(pool.Members))
+		for i, member := range pool.Members {
+			members[i] = member
+			if member.Cluster != "" {
+				continue
+			}
+			node := member.NodeName
+			if node == "" {
+				node = member.Address
+			}
+			value, found := nodeLabels[node][pool.Topology.Label]
+			if !found && member.PodName != "" {
+				value, found = ctlr.getPodLabel(member.PodName, pool.Topology.Label)
+			}
+			group, ok := groups[value]
+			if !found || !ok {
+				continue
+			}
+			if group.Ratio > 0 {
+				// ratio of the endpoint node members is weighted by the ratio of the zone
+				if members[i].Ratio > 0 {
+					members[i].Ratio *= group.Ratio
+				} else {
+					members[i].Ratio = group.Ratio
+				}
+			}
+			members[i].PriorityGroup = group.PriorityGroup
+		}
+		rsCfg.Pools[index].Members = members
+	}
+}
+
+// getPodLabel returns the value of the label of the pod, pods are known only when the pod
+// informer is enabled for NodePortLocal mode or pod readiness gates
+func (ctlr *Controller) getPodLabel(podKey string, label string) (string, bool) {
+	namespace, name, _ := cache.SplitMetaNamespaceKey(podKey)
+	pod := ctlr.getPod(namespace, name)
+	if pod == nil {
+		return "", false
+	}
+	value, found := pod.Labels[label]
+	return value, found
+}
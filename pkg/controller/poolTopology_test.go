@@ -0,0 +1,101 @@
+package controller
+
+import (
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+	crdfake "github.com/F5Networks/k8s-bigip-ctlr/v2/config/client/clientset/versioned/fake"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Pool Topology Tests", func() {
+	var mockCtlr *mockController
+	namespace := "default"
+	zoneLabel := "topology.kubernetes.io/zone"
+	topology := &cisapiv1.PoolTopology{
+		Label: zoneLabel,
+		Groups: []cisapiv1.TopologyGroup{
+			{Value: "zone-a", Ratio: 3, PriorityGroup: 10},
+			{Value: "zone-b", PriorityGroup: 5},
+		},
+	}
+
+	BeforeEach(func() {
+		mockCtlr = newMockController()
+		mockCtlr.mode = CustomResourceMode
+		mockCtlr.PoolMemberType = Cluster
+		mockCtlr.kubeCRClient = crdfake.NewSimpleClientset()
+		mockCtlr.kubeClient = k8sfake.NewSimpleClientset()
+		mockCtlr.crInformers = make(map[string]*CRInformer)
+		mockCtlr.comInformers = make(map[string]*CommonInformer)
+		mockCtlr.namespaces = map[string]bool{namespace: true}
+		// pod informer is enabled by the pod readiness gates
+		mockCtlr.podReadinessGate = true
+		_ = mockCtlr.addNamespacedInformers(namespace, false)
+		mockCtlr.oldNodes = []Node{
+			{Name: "node1", Addr: "10.0.0.1", Labels: map[string]string{zoneLabel: "zone-a"}},
+			{Name: "node2", Addr: "10.0.0.2", Labels: map[string]string{zoneLabel: "zone-b"}},
+			{Name: "node3", Addr: "10.0.0.3", Labels: map[string]string{}},
+		}
+	})
+
+	It("Sets the topology of the pool", func() {
+		pool := Pool{}
+		setPoolTopology(&pool, cisapiv1.Pool{Topology: topology, MinActiveMembers: 2})
+		Expect(pool.Topology).To(Equal(topology))
+		Expect(pool.MinActiveMembers).To(BeEquivalentTo(2))
+		Expect(pool.Balance).To(Equal("ratio-member"), "Topology with ratio should use ratio-member")
+
+		pool = Pool{Balance: "least-connections-member"}
+		setPoolTopology(&pool, cisapiv1.Pool{Topology: topology})
+		Expect(pool.Balance).To(Equal("least-connections-member"))
+	})
+
+	It("Sets the ratio and the priority group of the members by the labels", func() {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name: "pod4", Namespace: namespace, Labels: map[string]string{zoneLabel: "zone-b"},
+		}}
+		mockCtlr.addPod(pod)
+		cached := []PoolMember{
+			{Address: "10.1.1.1", Port: 8080, NodeName: "node1"},
+			{Address: "10.1.1.2", Port: 8080, NodeName: "node2"},
+			{Address: "10.1.1.3", Port: 8080, NodeName: "node3"},
+			{Address: "10.1.1.4", Port: 8080, NodeName: "node3", PodName: namespace + "/pod4"},
+			{Address: "10.0.0.2", Port: 30080},
+			{Address: "10.2.1.1", Port: 8080, NodeName: "node1", Cluster: "cluster2", Ratio: 30},
+		}
+		rsCfg := &ResourceConfig{}
+		rsCfg.Pools = Pools{{Name: "pool", Topology: topology, MinActiveMembers: 1, Members: cached}}
+		mockCtlr.updatePoolMembersTopology(rsCfg)
+
+		members := rsCfg.Pools[0].Members
+		Expect(members[0].Ratio).To(Equal(3))
+		Expect(members[0].PriorityGroup).To(Equal(10))
+		Expect(members[1].Ratio).To(BeZero())
+		Expect(members[1].PriorityGroup).To(Equal(5))
+		Expect(members[2].PriorityGroup).To(BeZero(), "Member without the label should keep the defaults")
+		Expect(members[3].PriorityGroup).To(Equal(5), "Pod label should be used when the node is not labeled")
+		Expect(members[4].PriorityGroup).To(Equal(5), "Node member should be located by the address")
+		Expect(members[5].Ratio).To(Equal(30), "Members of the additional clusters should be skipped")
+		Expect(cached[0].PriorityGroup).To(BeZero(), "Cached members should not be modified")
+
+		sharedApp := as3Application{}
+		createPoolDecl(rsCfg, sharedApp, false, "test")
+		as3Pool := sharedApp["pool"].(*as3Pool)
+		Expect(as3Pool.MinimumMembersActive).To(BeEquivalentTo(1))
+		Expect(as3Pool.Members[0].Ratio).To(Equal(3))
+		Expect(as3Pool.Members[0].PriorityGroup).To(Equal(10))
+	})
+
+	It("Skips the pod labels without the pod informer", func() {
+		mockCtlr.comInformers[namespace].podInformer = nil
+		rsCfg := &ResourceConfig{}
+		rsCfg.Pools = Pools{{Name: "pool", Topology: topology, Members: []PoolMember{
+			{Address: "10.1.1.4", Port: 8080, NodeName: "node3", PodName: namespace + "/pod4"},
+		}}}
+		mockCtlr.updatePoolMembersTopology(rsCfg)
+		Expect(rsCfg.Pools[0].Members[0].PriorityGroup).To(BeZero())
+	})
+})
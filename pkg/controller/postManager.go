@@ -28,6 +28,8 @@ import (
 	"time"
 
 	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 const (
@@ -92,7 +94,7 @@ func (postMgr *PostManager) publishConfig(cfg agentConfig) {
 		_ = <-time.After(time.Duration(postMgr.AS3PostDelay) * time.Second)
 	}
 
-	log.Debug("[AS3] PostManager Accepted the configuration")
+	log.WithFields(log.Fields{"id": cfg.id, "tenants": cfg.tenants}).Debug("[AS3] PostManager Accepted the configuration")
 
 	// postConfig updates the tenantResponseMap with response codes
 	postMgr.postConfig(&cfg)
@@ -100,18 +102,23 @@ func (postMgr *PostManager) publishConfig(cfg agentConfig) {
 
 func (postMgr *PostManager) postConfig(cfg *agentConfig) {
 	httpReqBody := bytes.NewBuffer([]byte(cfg.data))
+	reqLog := log.WithFields(log.Fields{"id": cfg.id, "tenants": cfg.tenants})
+	_, span := startSpan(cfg.traceCtx, "as3Post", attribute.Int("request.id", cfg.id))
+	defer span.End()
 	req, err := http.NewRequest("POST", cfg.as3APIURL, httpReqBody)
 	if err != nil {
-		log.Errorf("[AS3] Creating new HTTP request error: %v ", err)
+		reqLog.Errorf("[AS3] Creating new HTTP request error: %v ", err)
 		return
 	}
-	log.Debugf("[AS3] posting request to %v", cfg.as3APIURL)
+	reqLog.Debugf("[AS3] posting request to %v", cfg.as3APIURL)
 	req.SetBasicAuth(postMgr.BIGIPUsername, postMgr.BIGIPPassword)
 
 	httpResp, responseMap := postMgr.httpPOST(req)
 	if httpResp == nil || responseMap == nil {
+		span.SetStatus(codes.Error, "AS3 post failed")
 		return
 	}
+	span.SetAttributes(attribute.Int("http.status_code", httpResp.StatusCode))
 
 	if postMgr.firstPost {
 		postMgr.firstPost = false
@@ -151,7 +158,7 @@ func (postMgr *PostManager) httpPOST(request *http.Request) (*http.Response, map
 	err = json.Unmarshal(body, &response)
 	if err != nil {
 		log.Errorf("[AS3] Response body unmarshal failed: %v\n", err)
-		if postMgr.LogResponse {
+		if postMgr.logAS3Response() {
 			log.Errorf("[AS3] Raw response from Big-IP: %v", string(body))
 		}
 		return nil, nil
@@ -159,6 +166,20 @@ func (postMgr *PostManager) httpPOST(request *http.Request) (*http.Response, map
 	return httpResp, response
 }
 
+// logAS3Response returns whether the AS3 responses are logged
+func (postMgr *PostManager) logAS3Response() bool {
+	postMgr.logResponseMutex.RLock()
+	defer postMgr.logResponseMutex.RUnlock()
+	return postMgr.LogResponse
+}
+
+// setLogAS3Response enables or disables the logging of the AS3 responses
+func (postMgr *PostManager) setLogAS3Response(logResponse bool) {
+	postMgr.logResponseMutex.Lock()
+	defer postMgr.logResponseMutex.Unlock()
+	postMgr.LogResponse = logResponse
+}
+
 func (postMgr *PostManager) updateTenantResponse(code int, id string, tenant string) {
 	// Update status for a specific tenant if mentioned, else update the response for all tenants
 	if tenant != "" {
@@ -170,12 +191,35 @@ func (postMgr *PostManager) updateTenantResponse(code int, id string, tenant str
 	}
 }
 
+// recordTenantError keeps the message, response and errors of a failed tenant result,
+// which identify the objects of the tenant rejected by BIG-IP
+func (postMgr *PostManager) recordTenantError(result map[string]interface{}) {
+	tenant, _ := result["tenant"].(string)
+	if tenant == "" {
+		return
+	}
+	if postMgr.tenantErrorMap == nil {
+		postMgr.tenantErrorMap = make(map[string]string)
+	}
+	if code, _ := result["code"].(float64); code == http.StatusOK {
+		delete(postMgr.tenantErrorMap, tenant)
+		return
+	}
+	var errs []string
+	for _, key := range []string{"message", "response", "errors"} {
+		if val, ok := result[key]; ok && val != nil {
+			errs = append(errs, fmt.Sprintf("%v", val))
+		}
+	}
+	postMgr.tenantErrorMap[tenant] = strings.Join(errs, ": ")
+}
+
 func (postMgr *PostManager) handleResponseStatusOK(responseMap map[string]interface{}) {
 	//traverse all response results
 	results := (responseMap["results"]).([]interface{})
 	for _, value := range results {
 		v := value.(map[string]interface{})
-		log.Debugf("[AS3] Response from BIG-IP: code: %v --- tenant:%v --- message: %v", v["code"], v["tenant"], v["message"])
+		log.WithFields(log.Fields{"tenant": v["tenant"], "code": v["code"]}).Debugf("[AS3] Response from BIG-IP: message: %v", v["message"])
 		postMgr.updateTenantResponse(int(v["code"].(float64)), "", v["tenant"].(string))
 	}
 }
@@ -204,10 +248,11 @@ func (postMgr *PostManager) getTenantConfigStatus(id string) {
 			} else {
 				// reset task id, so that any failed tenants will go to post call in the next retry
 				postMgr.updateTenantResponse(int(v["code"].(float64)), "", v["tenant"].(string))
+				postMgr.recordTenantError(v)
 				if _, ok := v["response"]; ok {
-					log.Debugf("[AS3] Response from BIG-IP: code: %v --- tenant:%v --- message: %v %v", v["code"], v["tenant"], v["message"], v["response"])
+					log.WithFields(log.Fields{"tenant": v["tenant"], "code": v["code"]}).Debugf("[AS3] Response from BIG-IP: message: %v %v", v["message"], v["response"])
 				} else {
-					log.Debugf("[AS3] Response from BIG-IP: code: %v --- tenant:%v --- message: %v", v["code"], v["tenant"], v["message"])
+					log.WithFields(log.Fields{"tenant": v["tenant"], "code": v["code"]}).Debugf("[AS3] Response from BIG-IP: message: %v", v["message"])
 				}
 			}
 		}
@@ -223,11 +268,12 @@ func (postMgr *PostManager) handleMultiStatus(responseMap map[string]interface{}
 		for _, value := range results {
 			v := value.(map[string]interface{})
 			postMgr.updateTenantResponse(int(v["code"].(float64)), "", v["tenant"].(string))
+			postMgr.recordTenantError(v)
 
 			if v["code"].(float64) != 200 {
-				log.Errorf("[AS3] Error response from BIG-IP: code: %v --- tenant:%v --- message: %v", v["code"], v["tenant"], v["message"])
+				log.WithFields(log.Fields{"tenant": v["tenant"], "code": v["code"]}).Errorf("[AS3] Error response from BIG-IP: message: %v", v["message"])
 			} else {
-				log.Debugf("[AS3] Response from BIG-IP: code: %v --- tenant:%v --- message: %v", v["code"], v["tenant"], v["message"])
+				log.WithFields(log.Fields{"tenant": v["tenant"], "code": v["code"]}).Debugf("[AS3] Response from BIG-IP: message: %v", v["message"])
 			}
 		}
 	}
@@ -255,21 +301,22 @@ func (postMgr *PostManager) handleResponseStatusNotFound(responseMap map[string]
 	} else {
 		log.Errorf("[AS3] Big-IP Responded with error code: %v", http.StatusNotFound)
 	}
-	if postMgr.LogResponse {
+	if postMgr.logAS3Response() {
 		log.Errorf("[AS3] Raw response from Big-IP: %v ", responseMap)
 	}
 	postMgr.updateTenantResponse(http.StatusNotFound, "", "")
 }
 
 func (postMgr *PostManager) handleResponseOthers(responseMap map[string]interface{}, cfg *agentConfig) {
-	if postMgr.LogResponse {
+	if postMgr.logAS3Response() {
 		log.Errorf("[AS3] Raw response from Big-IP: %v %v", responseMap, cfg.data)
 	}
 	if results, ok := (responseMap["results"]).([]interface{}); ok {
 		for _, value := range results {
 			v := value.(map[string]interface{})
-			log.Errorf("[AS3] Response from BIG-IP: code: %v --- tenant:%v --- message: %v", v["code"], v["tenant"], v["message"])
+			log.WithFields(log.Fields{"tenant": v["tenant"], "code": v["code"]}).Errorf("[AS3] Response from BIG-IP: message: %v", v["message"])
 			postMgr.updateTenantResponse(int(v["code"].(float64)), "", v["tenant"].(string))
+			postMgr.recordTenantError(v)
 		}
 	} else if err, ok := (responseMap["error"]).(map[string]interface{}); ok {
 		log.Errorf("[AS3] Big-IP Responded with error code: %v", err["code"])
@@ -365,7 +412,7 @@ func (postMgr *PostManager) httpReq(request *http.Request) (*http.Response, map[
 	err = json.Unmarshal(body, &response)
 	if err != nil {
 		log.Errorf("Response body unmarshal failed: %v\n", err)
-		if postMgr.LogResponse {
+		if postMgr.logAS3Response() {
 			log.Errorf("Raw response from Big-IP: %v", string(body))
 		}
 		return nil, nil
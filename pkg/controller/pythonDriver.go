@@ -30,11 +30,118 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/health"
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/netconfig"
 	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/writer"
 
 	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
 )
 
+// NewNetAgent creates the config writer of the network config, the tunnel FDB records,
+// ARP entries and static routes, and of the GTM config of the cccl agent, and starts the
+// python config driver or the network config driver which configure them on BIG-IP.
+// The NetAgent is independent of the backend which deploys the LTM config.
+func NewNetAgent(params AgentParams) *NetAgent {
+	DEFAULT_PARTITION = params.Partition
+	configWriter, err := writer.NewConfigWriter()
+	if nil != err {
+		log.Fatalf("Failed creating ConfigWriter tool: %v", err)
+	}
+	netAgent := &NetAgent{
+		ConfigWriter: configWriter,
+		EventChan:    make(chan interface{}),
+		HttpAddress:  params.HttpAddress,
+		EnableIPV6:   params.EnableIPV6,
+	}
+
+	// If running in VXLAN mode, extract the partition name from the tunnel
+	// to be used in configuring a net instance of CCCL for that partition
+	var vxlanPartition string
+	if len(params.VXLANName) > 0 {
+		cleanPath := strings.TrimLeft(params.VXLANName, "/")
+		slashPos := strings.Index(cleanPath, "/")
+		if slashPos == -1 {
+			// No partition
+			vxlanPartition = "Common"
+		} else {
+			// Partition and name
+			vxlanPartition = cleanPath[:slashPos]
+		}
+	}
+
+	gs := globalSection{
+		LogLevel:       params.LogLevel,
+		VerifyInterval: params.VerifyInterval,
+		VXLANPartition: vxlanPartition,
+		DisableLTM:     true,
+		GTM:            params.CCCLGTMAgent,
+		DisableARP:     params.DisableARP,
+	}
+
+	bs := bigIPSection{
+		BigIPUsername:   params.PostParams.BIGIPUsername,
+		BigIPPassword:   params.PostParams.BIGIPPassword,
+		BigIPURL:        params.PostParams.BIGIPURL,
+		BigIPPartitions: []string{params.Partition},
+	}
+
+	var gtm gtmBigIPSection
+	if len(params.GTMParams.GTMBigIpUrl) == 0 || len(params.GTMParams.GTMBigIpUsername) == 0 || len(params.GTMParams.GTMBigIpPassword) == 0 {
+		// gs.GTM = false
+		gtm = gtmBigIPSection{
+			GtmBigIPUsername: params.PostParams.BIGIPUsername,
+			GtmBigIPPassword: params.PostParams.BIGIPPassword,
+			GtmBigIPURL:      params.PostParams.BIGIPURL,
+		}
+		log.Warning("Creating GTM with default bigip credentials as GTM BIGIP Url or GTM BIGIP Username or GTM BIGIP Password is missing on CIS args.")
+	} else {
+		gtm = gtmBigIPSection{
+			GtmBigIPUsername: params.GTMParams.GTMBigIpUsername,
+			GtmBigIPPassword: params.GTMParams.GTMBigIpPassword,
+			GtmBigIPURL:      params.GTMParams.GTMBigIpUrl,
+		}
+	}
+	if params.NetConfigDriver == netconfig.Go {
+		netDriver, err := netconfig.NewDriver(netconfig.Params{
+			BigIPURL:       params.PostParams.BIGIPURL,
+			BigIPUsername:  params.PostParams.BIGIPUsername,
+			BigIPPassword:  params.PostParams.BIGIPPassword,
+			TrustedCerts:   params.PostParams.TrustedCerts,
+			SSLInsecure:    params.PostParams.SSLInsecure,
+			Partition:      vxlanPartition,
+			VerifyInterval: time.Duration(params.VerifyInterval) * time.Second,
+			DisableARP:     params.DisableARP,
+		}, configWriter)
+		if nil != err {
+			log.Fatalf("Failed creating network config driver: %v", err)
+		}
+		// The python driver does not manage the network config written to the network config driver
+		gs.VXLANPartition = ""
+		netAgent.netDriver = netDriver
+		netAgent.ConfigWriter = netDriver
+	}
+	//For IPV6 net config is not required. f5-sdk doesnt support ipv6
+	// The python driver is required only for GTM with the network config driver
+	if !(params.EnableIPV6) && (netAgent.netDriver == nil || gs.GTM) {
+		netAgent.startPythonDriver(
+			gs,
+			bs,
+			gtm,
+			params.PythonBaseDir,
+		)
+	} else if netAgent.netDriver != nil {
+		go netAgent.healthCheckPythonDriver()
+	}
+	return netAgent
+}
+
+// Stop stops the config writer and the python config driver
+func (netAgent *NetAgent) Stop() {
+	netAgent.ConfigWriter.Stop()
+	if !(netAgent.EnableIPV6) && netAgent.PythonDriverPID != 0 {
+		netAgent.stopPythonDriver()
+	}
+}
+
 func initializeDriverConfig(
 	configWriter writer.Writer,
 	global globalSection,
@@ -147,7 +254,7 @@ func runBigIPDriver(pid chan<- int, cmd *exec.Cmd) {
 }
 
 // Start called to run the python driver
-func (agent *Agent) startPythonDriver(
+func (netAgent *NetAgent) startPythonDriver(
 	global globalSection,
 	bigIP bigIPSection,
 	gtmBigIP gtmBigIPSection,
@@ -155,7 +262,7 @@ func (agent *Agent) startPythonDriver(
 ) {
 	var pyCmd string
 
-	err := initializeDriverConfig(agent.ConfigWriter, global, bigIP, gtmBigIP)
+	err := initializeDriverConfig(netAgent.ConfigWriter, global, bigIP, gtmBigIP)
 	if nil != err {
 		log.Fatalf("Could not initialize subprocess configuration: %v", err)
 		return
@@ -169,41 +276,44 @@ func (agent *Agent) startPythonDriver(
 		pyCmd = "bigipconfigdriver.py"
 	}
 	cmd := createDriverCmd(
-		agent.ConfigWriter.GetOutputFilename(),
+		netAgent.ConfigWriter.GetOutputFilename(),
 		pyCmd,
 	)
 	go runBigIPDriver(subPidCh, cmd)
 
 	subPid := <-subPidCh
-	agent.PythonDriverPID = subPid
+	netAgent.PythonDriverPID = subPid
 	//Enable "/health" and "/metrics" endpoint with controller
-	go agent.healthCheckPythonDriver()
+	go netAgent.healthCheckPythonDriver()
 
 	return
 }
 
-func (agent *Agent) stopPythonDriver() {
-	if 0 != agent.PythonDriverPID {
+func (netAgent *NetAgent) stopPythonDriver() {
+	if 0 != netAgent.PythonDriverPID {
 		var proc *os.Process
-		proc, err := os.FindProcess(agent.PythonDriverPID)
+		proc, err := os.FindProcess(netAgent.PythonDriverPID)
 		if nil != err {
 			log.Warningf("Failed to find sub-process on exit: %v", err)
 		}
 		err = proc.Signal(os.Interrupt)
 		if nil != err {
-			log.Warningf("Could not stop sub-process on exit: %d - %v", agent.PythonDriverPID, err)
+			log.Warningf("Could not stop sub-process on exit: %d - %v", netAgent.PythonDriverPID, err)
 		}
 	}
 }
 
-func (agent *Agent) healthCheckPythonDriver() {
+func (netAgent *NetAgent) healthCheckPythonDriver() {
 	// Expose Prometheus metrics
 	http.Handle("/metrics", promhttp.Handler())
 	// Add health check to track whether Python process still alive
 	hc := &health.HealthChecker{
-		SubPID: agent.PythonDriverPID,
+		SubPID: netAgent.PythonDriverPID,
+	}
+	if netAgent.netDriver != nil {
+		hc.NetDriver = netAgent.netDriver
 	}
 	http.Handle("/health", hc.HealthCheckHandler())
 	bigIPPrometheus.RegisterMetrics()
-	log.Fatal(http.ListenAndServe(agent.HttpAddress, nil).Error())
+	log.Fatal(http.ListenAndServe(netAgent.HttpAddress, nil).Error())
 }
@@ -59,6 +59,9 @@ func (rs *ResourceStore) Init() {
 	rs.svcResourceCache = make(map[string]map[string]struct{})
 	rs.ipamContext = make(map[string]ficV1.IPSpec)
 	rs.processedNativeResources = make(map[resourceRef]struct{})
+	rs.effectivePolicyMap = make(map[string]effectivePolicy)
+	rs.poolDrainCache = make(map[string]*poolDrainState)
+	rs.routeGroupCache = make(map[string]ExtendedRouteGroupConfig)
 }
 
 const (
@@ -92,6 +95,14 @@ const (
 	HttpsRedirectDgName = "https_redirect_dg"
 	TLSIRuleName        = "tls_irule"
 	ABPathIRuleName     = "ab_deployment_path_irule"
+	HTTPHeaderIRuleName = "http_header_irule"
+	// ProxyProtocolIRuleName sends the Proxy Protocol header to the ingress controller of IngressLink
+	ProxyProtocolIRuleName = "proxy_protocol_irule"
+	// PortRangeIRuleName selects the pool of the port range virtual of TransportServers by the
+	// destination port
+	PortRangeIRuleName = "port_range_irule"
+	// PortRangesVirtualSuffix names the wildcard port virtual shared by the TransportServer port ranges
+	PortRangesVirtualSuffix = "port_ranges"
 )
 
 // constants for TLS references
@@ -263,6 +274,75 @@ func formatVirtualServerName(ip string, port int32) string {
 	return fmt.Sprintf("crd_%s_%d", ip, port)
 }
 
+// parseTransportServerPorts returns the ports of the virtuals of the TransportServer and
+// the port ranges it serves on the port range virtual of its address
+func parseTransportServerPorts(ts *cisapiv1.TransportServer) ([]int32, []portRange, error) {
+	if len(ts.Spec.VirtualServerPorts) == 0 {
+		return []int32{ts.Spec.VirtualServerPort}, nil, nil
+	}
+	var ports []int32
+	var ranges []portRange
+	seen := make(map[int32]bool)
+	for _, entry := range ts.Spec.VirtualServerPorts {
+		bounds := strings.SplitN(entry, "-", 2)
+		var rng portRange
+		for i, bound := range bounds {
+			port, err := strconv.ParseUint(strings.TrimSpace(bound), 10, 16)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid port %q", entry)
+			}
+			if i == 0 {
+				rng.low = int32(port)
+			}
+			rng.high = int32(port)
+		}
+		if len(bounds) == 1 || rng.low == rng.high {
+			if !seen[rng.low] {
+				seen[rng.low] = true
+				ports = append(ports, rng.low)
+			}
+			continue
+		}
+		if rng.low == 0 || rng.low > rng.high {
+			return nil, nil, fmt.Errorf("invalid port range %q", entry)
+		}
+		ranges = append(ranges, rng)
+	}
+	if seen[0] {
+		// any port of the wildcard port virtual covers the ranges
+		ranges = nil
+	}
+	return ports, ranges, nil
+}
+
+// overlaps returns true if the port ranges share a port
+func (rng portRange) overlaps(other portRange) bool {
+	return rng.low <= other.high && other.low <= rng.high
+}
+
+// String returns the port range as "low-high"
+func (rng portRange) String() string {
+	return fmt.Sprintf("%d-%d", rng.low, rng.high)
+}
+
+// getPortRangeVirtualName returns the name of the wildcard port virtual shared by the port
+// ranges of the TransportServers on the address and protocol
+func getPortRangeVirtualName(ts *cisapiv1.TransportServer, ip string) string {
+	name := "crd_" + AS3NameFormatter(strings.Trim(ip, "[]"))
+	if ts.Spec.VirtualServerName != "" {
+		name = AS3NameFormatter(ts.Spec.VirtualServerName)
+	}
+	return fmt.Sprintf("%s_%s_%s", name, getTransportServerProtocol(ts), PortRangesVirtualSuffix)
+}
+
+// getTransportServerProtocol returns the type of the TransportServer, which defaults to tcp
+func getTransportServerProtocol(ts *cisapiv1.TransportServer) string {
+	if ts.Spec.Type == "" {
+		return "tcp"
+	}
+	return ts.Spec.Type
+}
+
 // format the virtual server name for an VirtualServer
 func formatCustomVirtualServerName(name string, port int32) string {
 	// Replace special characters ". : /"
@@ -326,6 +406,48 @@ func formatMonitorName(namespace, svc string, monitorType string, port int32, ho
 	return AS3NameFormatter(monitorName)
 }
 
+// isHTTPMonitor returns true for the monitor types which require send string
+func isHTTPMonitor(monitorType string) bool {
+	return monitorType == "http" || monitorType == "https"
+}
+
+// getMonitorOptions returns the protocol specific options of the monitor
+// along with the client certificate fetched from the secret in namespace
+func (ctlr *Controller) getMonitorOptions(namespace string, monitor cisapiv1.Monitor) MonitorOptions {
+	opts := MonitorOptions{
+		UpInterval:  monitor.UpInterval,
+		TimeUntilUp: monitor.TimeUntilUp,
+		Reverse:     monitor.Reverse,
+		ServerName:  monitor.ServerName,
+		QueryName:   monitor.QueryName,
+		QueryType:   monitor.QueryType,
+		Script:      monitor.Script,
+		Arguments:   monitor.Arguments,
+		// inband monitor
+		Failures:        monitor.Failures,
+		FailureInterval: monitor.FailureInterval,
+		ResponseTime:    monitor.ResponseTime,
+		RetryTime:       monitor.RetryTime,
+	}
+	if monitor.ClientCertificate != "" && monitor.Type == "https" {
+		comInf, ok := ctlr.getNamespacedCommonInformer(namespace)
+		if !ok || comInf.secretsInformer == nil {
+			log.Errorf("Informer not found for namespace: %v", namespace)
+			return opts
+		}
+		obj, found, err := comInf.secretsInformer.GetIndexer().GetByKey(namespace + "/" + monitor.ClientCertificate)
+		if err != nil || !found {
+			log.Errorf("Unable to find client certificate %v/%v for https monitor: %v",
+				namespace, monitor.ClientCertificate, err)
+			return opts
+		}
+		secret := obj.(*v1.Secret)
+		opts.ClientCert = string(secret.Data["tls.crt"])
+		opts.ClientKey = string(secret.Data["tls.key"])
+	}
+	return opts
+}
+
 // format the policy name for VirtualServer
 func formatPolicyName(hostname, hostGroup, name string) string {
 	host := hostname
@@ -453,23 +575,28 @@ func (ctlr *Controller) prepareRSConfigFromVirtualServer(
 			Balance:           pl.Balance,
 			ReselectTries:     pl.ReselectTries,
 			ServiceDownAction: pl.ServiceDownAction,
+			MinimumMonitors:   pl.MinimumMonitors,
+			Weight:            pl.Weight,
 		}
+		ctlr.setMultiClusterServices(&pool, pl, svcNamespace)
+		setPoolTopology(&pool, pl)
 		if pl.Monitor.Name != "" && pl.Monitor.Reference == "bigip" {
 			pool.MonitorNames = append(pool.MonitorNames, MonitorName{Name: pl.Monitor.Name, Reference: pl.Monitor.Reference})
-		} else if pl.Monitor.Send != "" && pl.Monitor.Type != "" {
+		} else if pl.Monitor.Type != "" && (pl.Monitor.Send != "" || !isHTTPMonitor(pl.Monitor.Type)) {
 			if pl.Name == "" {
 				monitorName = formatMonitorName(vs.ObjectMeta.Namespace, pl.Service, pl.Monitor.Type, pl.ServicePort, vs.Spec.Host, pl.Path)
 			}
 			pool.MonitorNames = append(pool.MonitorNames, MonitorName{Name: JoinBigipPath(rsCfg.Virtual.Partition, monitorName)})
 			monitor := Monitor{
-				Name:       monitorName,
-				Partition:  rsCfg.Virtual.Partition,
-				Type:       pl.Monitor.Type,
-				Interval:   pl.Monitor.Interval,
-				Send:       pl.Monitor.Send,
-				Recv:       pl.Monitor.Recv,
-				Timeout:    pl.Monitor.Timeout,
-				TargetPort: pl.Monitor.TargetPort,
+				Name:           monitorName,
+				Partition:      rsCfg.Virtual.Partition,
+				Type:           pl.Monitor.Type,
+				Interval:       pl.Monitor.Interval,
+				Send:           pl.Monitor.Send,
+				Recv:           pl.Monitor.Recv,
+				Timeout:        pl.Monitor.Timeout,
+				TargetPort:     pl.Monitor.TargetPort,
+				MonitorOptions: ctlr.getMonitorOptions(vs.Namespace, pl.Monitor),
 			}
 			monitors = append(monitors, monitor)
 		} else if pl.Monitors != nil {
@@ -488,14 +615,15 @@ func (ctlr *Controller) prepareRSConfigFromVirtualServer(
 					}
 					pool.MonitorNames = append(pool.MonitorNames, MonitorName{Name: JoinBigipPath(rsCfg.Virtual.Partition, monitorName)})
 					monitor := Monitor{
-						Name:       monitorName,
-						Partition:  rsCfg.Virtual.Partition,
-						Type:       monitor.Type,
-						Interval:   monitor.Interval,
-						Send:       monitor.Send,
-						Recv:       monitor.Recv,
-						Timeout:    monitor.Timeout,
-						TargetPort: monitor.TargetPort,
+						Name:           monitorName,
+						Partition:      rsCfg.Virtual.Partition,
+						Type:           monitor.Type,
+						Interval:       monitor.Interval,
+						Send:           monitor.Send,
+						Recv:           monitor.Recv,
+						Timeout:        monitor.Timeout,
+						TargetPort:     monitor.TargetPort,
+						MonitorOptions: ctlr.getMonitorOptions(vs.Namespace, monitor),
 					}
 					rsCfg.Monitors = append(rsCfg.Monitors, monitor)
 				}
@@ -917,35 +1045,40 @@ func (ctlr *Controller) handleVirtualServerTLS(
 // validate TLSProfile
 // validation includes valid parameters for the type of termination(edge, re-encrypt and Pass-through)
 func validateTLSProfile(tls *cisapiv1.TLSProfile) bool {
+	if err := checkTLSProfile(tls); err != nil {
+		log.Errorf("%v", err)
+		return false
+	}
+	return true
+}
+
+// checkTLSProfile returns the error in the SSL profiles for the type of termination
+func checkTLSProfile(tls *cisapiv1.TLSProfile) error {
 	//validation for re-encrypt termination
 	if tls.Spec.TLS.Termination == "reencrypt" {
 		// Should contain both client and server SSL profiles
 		if (tls.Spec.TLS.ClientSSL == "" || tls.Spec.TLS.ServerSSL == "") && (len(tls.Spec.TLS.ClientSSLs) == 0 || len(tls.Spec.TLS.ServerSSLs) == 0) {
-			log.Errorf("TLSProfile %s of type re-encrypt termination should contain both "+
+			return fmt.Errorf("TLSProfile %s of type re-encrypt termination should contain both "+
 				"ClientSSLs and ServerSSLs", tls.ObjectMeta.Name)
-			return false
 		}
 	} else if tls.Spec.TLS.Termination == "edge" {
 		// Should contain only client SSL
 		if tls.Spec.TLS.ClientSSL == "" && len(tls.Spec.TLS.ClientSSLs) == 0 {
-			log.Errorf("TLSProfile %s of type edge termination should contain ClientSSLs",
+			return fmt.Errorf("TLSProfile %s of type edge termination should contain ClientSSLs",
 				tls.ObjectMeta.Name)
-			return false
 		}
 		if tls.Spec.TLS.ServerSSL != "" || len(tls.Spec.TLS.ServerSSLs) != 0 {
-			log.Errorf("TLSProfile %s of type edge termination should NOT contain ServerSSLs",
+			return fmt.Errorf("TLSProfile %s of type edge termination should NOT contain ServerSSLs",
 				tls.ObjectMeta.Name)
-			return false
 		}
 	} else {
 		// Pass-through
 		if (tls.Spec.TLS.ClientSSL != "") || (tls.Spec.TLS.ServerSSL != "") || len(tls.Spec.TLS.ClientSSLs) != 0 || len(tls.Spec.TLS.ServerSSLs) != 0 {
-			log.Errorf("TLSProfile %s of type Pass-through termination should NOT contain either "+
+			return fmt.Errorf("TLSProfile %s of type Pass-through termination should NOT contain either "+
 				"ClientSSLs or ServerSSLs", tls.ObjectMeta.Name)
-			return false
 		}
 	}
-	return true
+	return nil
 }
 
 // ConvertStringToProfileRef converts strings to profile references
@@ -1170,6 +1303,28 @@ func (rs *ResourceStore) deleteVirtualServer(partition, rsName string) {
 	delete(rs.getPartitionResourceMap(partition), rsName)
 }
 
+// prunePoolDrainCache removes the drain state of the pools which are no longer in the
+// ltmConfig, so that a pool recreated with the same name does not drain stale members
+func (rs *ResourceStore) prunePoolDrainCache() {
+	if len(rs.poolDrainCache) == 0 {
+		return
+	}
+	pools := make(map[string]struct{})
+	for _, partitionConfig := range rs.ltmConfig {
+		for _, rsCfg := range partitionConfig.ResourceMap {
+			for _, pool := range rsCfg.Pools {
+				pools[pool.Partition+"/"+pool.Name] = struct{}{}
+			}
+		}
+	}
+	for poolKey := range rs.poolDrainCache {
+		if _, found := pools[poolKey]; !found {
+			log.Debugf("Removing the drain state of deleted pool %v", poolKey)
+			delete(rs.poolDrainCache, poolKey)
+		}
+	}
+}
+
 // Update the tenant priority in ltmConfigCache
 func (rs *ResourceStore) updatePartitionPriority(partition string, priority int) {
 	if _, ok := rs.ltmConfig[partition]; ok {
@@ -1239,6 +1394,8 @@ func (rc *ResourceConfig) copyConfig(cfg *ResourceConfig) {
 	//AllowVLANS
 	rc.Virtual.AllowVLANs = make([]string, len(cfg.Virtual.AllowVLANs))
 	copy(rc.Virtual.AllowVLANs, cfg.Virtual.AllowVLANs)
+	//HTTPOptions
+	rc.Virtual.HTTPOptions = cfg.Virtual.HTTPOptions.DeepCopy()
 
 	// Pools
 	rc.Pools = make(Pools, len(cfg.Pools))
@@ -1566,6 +1723,61 @@ func (ctlr *Controller) getVirtualServer(partition, rsName string) *ResourceConf
 	return res
 }
 
+// getResourcePartition returns the BIG-IP partition of a VirtualServer, TransportServer
+// or IngressLink. The partition of the resource spec takes precedence over the
+// partition annotation of its namespace, which in turn overrides --bigip-partition.
+func (ctlr *Controller) getResourcePartition(namespace, partition string) string {
+	if partition != "" {
+		return partition
+	}
+	if nsPartition := ctlr.getNamespacePartition(namespace); nsPartition != "" {
+		return nsPartition
+	}
+	return ctlr.Partition
+}
+
+// getNamespacePartition returns the partition annotation of the namespace
+func (ctlr *Controller) getNamespacePartition(namespace string) string {
+	for _, nsInf := range ctlr.nsInformers {
+		obj, exists, err := nsInf.nsInformer.GetIndexer().GetByKey(namespace)
+		if err == nil && exists {
+			return obj.(*v1.Namespace).Annotations[PartitionAnnotation]
+		}
+	}
+	if ctlr.nsPartitionInformer != nil {
+		obj, exists, err := ctlr.nsPartitionInformer.nsInformer.GetIndexer().GetByKey(namespace)
+		if err == nil && exists {
+			return obj.(*v1.Namespace).Annotations[PartitionAnnotation]
+		}
+	}
+	return ""
+}
+
+// deleteVirtualServerFromOtherPartitions removes the virtual of a resource which moved to
+// another partition. The old partition gets a higher priority so that its tenant is
+// posted first and releases the virtual address. An empty rscKey skips the ownership
+// check, for the virtuals whose name is derived from the virtual address.
+func (ctlr *Controller) deleteVirtualServerFromOtherPartitions(partition, rsName, rscKey string) {
+	for prtn, partitionConfig := range ctlr.resources.ltmConfig {
+		if prtn == partition {
+			continue
+		}
+		rsCfg, ok := partitionConfig.ResourceMap[rsName]
+		if !ok {
+			continue
+		}
+		if rscKey != "" {
+			if _, ok := rsCfg.MetaData.baseResources[rscKey]; !ok {
+				continue
+			}
+		}
+		log.Debugf("Moving virtual %v from partition %v to %v", rsName, prtn, partition)
+		ctlr.deleteSvcDepResource(rsName, rsCfg)
+		ctlr.deleteVirtualServer(prtn, rsName)
+		ctlr.resources.updatePartitionPriority(prtn, 1)
+	}
+}
+
 // Prepares resource config based on VirtualServer resource config
 func (ctlr *Controller) prepareRSConfigFromTransportServer(
 	rsCfg *ResourceConfig,
@@ -1599,7 +1811,9 @@ func (ctlr *Controller) prepareRSConfigFromTransportServer(
 		Balance:           vs.Spec.Pool.Balance,
 		ReselectTries:     vs.Spec.Pool.ReselectTries,
 		ServiceDownAction: vs.Spec.Pool.ServiceDownAction,
+		MinimumMonitors:   vs.Spec.Pool.MinimumMonitors,
 	}
+	setPoolTopology(&pool, vs.Spec.Pool)
 	if vs.Spec.Pool.Monitor.Name != "" && vs.Spec.Pool.Monitor.Reference == BIGIP {
 		pool.MonitorNames = append(pool.MonitorNames, MonitorName{Name: monitorName, Reference: vs.Spec.Pool.Monitor.Reference})
 	} else if vs.Spec.Pool.Monitor.Type != "" {
@@ -1609,14 +1823,15 @@ func (ctlr *Controller) prepareRSConfigFromTransportServer(
 		pool.MonitorNames = append(pool.MonitorNames, MonitorName{Name: JoinBigipPath(rsCfg.Virtual.Partition, monitorName)})
 
 		monitor := Monitor{
-			Name:       monitorName,
-			Partition:  rsCfg.Virtual.Partition,
-			Type:       vs.Spec.Pool.Monitor.Type,
-			Interval:   vs.Spec.Pool.Monitor.Interval,
-			Send:       "",
-			Recv:       "",
-			Timeout:    vs.Spec.Pool.Monitor.Timeout,
-			TargetPort: vs.Spec.Pool.Monitor.TargetPort,
+			Name:           monitorName,
+			Partition:      rsCfg.Virtual.Partition,
+			Type:           vs.Spec.Pool.Monitor.Type,
+			Interval:       vs.Spec.Pool.Monitor.Interval,
+			Send:           vs.Spec.Pool.Monitor.Send,
+			Recv:           vs.Spec.Pool.Monitor.Recv,
+			Timeout:        vs.Spec.Pool.Monitor.Timeout,
+			TargetPort:     vs.Spec.Pool.Monitor.TargetPort,
+			MonitorOptions: ctlr.getMonitorOptions(vs.Namespace, vs.Spec.Pool.Monitor),
 		}
 		rsCfg.Monitors = append(rsCfg.Monitors, monitor)
 	} else if vs.Spec.Pool.Monitors != nil {
@@ -1637,14 +1852,15 @@ func (ctlr *Controller) prepareRSConfigFromTransportServer(
 				}
 				pool.MonitorNames = append(pool.MonitorNames, MonitorName{Name: JoinBigipPath(rsCfg.Virtual.Partition, monitorName)})
 				monitor := Monitor{
-					Name:       monitorName,
-					Partition:  rsCfg.Virtual.Partition,
-					Type:       monitor.Type,
-					Interval:   monitor.Interval,
-					Send:       "",
-					Recv:       "",
-					Timeout:    monitor.Timeout,
-					TargetPort: monitor.TargetPort,
+					Name:           monitorName,
+					Partition:      rsCfg.Virtual.Partition,
+					Type:           monitor.Type,
+					Interval:       monitor.Interval,
+					Send:           monitor.Send,
+					Recv:           monitor.Recv,
+					Timeout:        monitor.Timeout,
+					TargetPort:     monitor.TargetPort,
+					MonitorOptions: ctlr.getMonitorOptions(vs.Namespace, monitor),
 				}
 				rsCfg.Monitors = append(rsCfg.Monitors, monitor)
 			}
@@ -1653,15 +1869,20 @@ func (ctlr *Controller) prepareRSConfigFromTransportServer(
 
 	rsCfg.Virtual.Mode = vs.Spec.Mode
 	rsCfg.Virtual.IpProtocol = vs.Spec.Type
-	rsCfg.Virtual.PoolName = pool.Name
-	rsCfg.Pools = append(rsCfg.Pools, pool)
+	// forwarding virtuals route the connections to their destination without a pool
+	if vs.Spec.Mode != "forwarding" {
+		rsCfg.Virtual.PoolName = pool.Name
+		rsCfg.Pools = append(rsCfg.Pools, pool)
+	}
 
 	if vs.Spec.ProfileL4 != "" {
 		rsCfg.Virtual.ProfileL4 = vs.Spec.ProfileL4
 	}
 	// Replace SNAT set from policy CR to the one defined by user in the TS spec
 	if vs.Spec.SNAT == "" {
-		if rsCfg.Virtual.SNAT == "" {
+		if rsCfg.Virtual.SNAT == "" && vs.Spec.Mode == "forwarding" {
+			rsCfg.Virtual.SNAT = "none"
+		} else if rsCfg.Virtual.SNAT == "" {
 			rsCfg.Virtual.SNAT = DEFAULT_SNAT
 		}
 	} else {
@@ -1771,6 +1992,9 @@ func (ctlr *Controller) handleVSResourceConfigForPolicy(
 	rsCfg *ResourceConfig,
 	plc *cisapiv1.Policy,
 ) error {
+	if err := validatePolicyHTTPProfile(plc); err != nil {
+		return err
+	}
 	rsCfg.Virtual.WAF = plc.Spec.L7Policies.WAF
 	rsCfg.Virtual.Firewall = plc.Spec.L3Policies.FirewallPolicy
 	rsCfg.Virtual.PersistenceProfile = plc.Spec.Profiles.PersistenceProfile
@@ -1825,6 +2049,16 @@ func (ctlr *Controller) handleVSResourceConfigForPolicy(
 		rsCfg.Virtual.SNAT = plc.Spec.SNAT
 	}
 
+	if plc.Spec.Profiles.HTTPOptions != nil {
+		rsCfg.Virtual.HTTPOptions = plc.Spec.Profiles.HTTPOptions.DeepCopy()
+		// Header manipulation is handled by CIS generated iRule
+		if iRuleCode := httpHeaderIRule(rsCfg.Virtual.HTTPOptions); iRuleCode != "" {
+			iRuleName := getRSCfgResName(rsCfg.Virtual.Name, HTTPHeaderIRuleName)
+			rsCfg.addIRule(iRuleName, rsCfg.Virtual.Partition, iRuleCode)
+			rsCfg.Virtual.AddIRule(JoinBigipPath(rsCfg.Virtual.Partition, iRuleName))
+		}
+	}
+
 	return nil
 }
 
@@ -1832,6 +2066,9 @@ func (ctlr *Controller) handleTSResourceConfigForPolicy(
 	rsCfg *ResourceConfig,
 	plc *cisapiv1.Policy,
 ) error {
+	if err := validatePolicyHTTPProfile(plc); err != nil {
+		return err
+	}
 	rsCfg.Virtual.WAF = plc.Spec.L7Policies.WAF
 	rsCfg.Virtual.Firewall = plc.Spec.L3Policies.FirewallPolicy
 	rsCfg.Virtual.PersistenceProfile = plc.Spec.Profiles.PersistenceProfile
@@ -1888,9 +2125,14 @@ func (rs *ResourceStore) getExtendedRouteSpec(routeGroup string) (*ExtendedRoute
 
 	if extdSpec.override && extdSpec.local != nil {
 		ergc := &ExtendedRouteGroupSpec{
-			VServerName:   extdSpec.global.VServerName,
-			VServerAddr:   extdSpec.global.VServerAddr,
-			AllowOverride: extdSpec.global.AllowOverride,
+			VServerName:            extdSpec.global.VServerName,
+			VServerAddr:            extdSpec.global.VServerAddr,
+			AdditionalVServerAddrs: extdSpec.global.AdditionalVServerAddrs,
+			IPAMLabel:              extdSpec.global.IPAMLabel,
+			HTTPPort:               extdSpec.global.HTTPPort,
+			HTTPSPort:              extdSpec.global.HTTPSPort,
+			SNAT:                   extdSpec.global.SNAT,
+			AllowOverride:          extdSpec.global.AllowOverride,
 		}
 
 		if extdSpec.local.VServerName != "" {
@@ -1902,6 +2144,21 @@ func (rs *ResourceStore) getExtendedRouteSpec(routeGroup string) (*ExtendedRoute
 		if extdSpec.local.Policy != "" {
 			ergc.Policy = extdSpec.local.Policy
 		}
+		if len(extdSpec.local.AdditionalVServerAddrs) > 0 {
+			ergc.AdditionalVServerAddrs = extdSpec.local.AdditionalVServerAddrs
+		}
+		if extdSpec.local.IPAMLabel != "" {
+			ergc.IPAMLabel = extdSpec.local.IPAMLabel
+		}
+		if extdSpec.local.HTTPPort != 0 {
+			ergc.HTTPPort = extdSpec.local.HTTPPort
+		}
+		if extdSpec.local.HTTPSPort != 0 {
+			ergc.HTTPSPort = extdSpec.local.HTTPSPort
+		}
+		if extdSpec.local.SNAT != "" {
+			ergc.SNAT = extdSpec.local.SNAT
+		}
 
 		return ergc, extdSpec.partition
 	}
@@ -1915,6 +2172,7 @@ func (ctlr *Controller) handleRouteTLS(
 	rsCfg *ResourceConfig,
 	route *routeapi.Route,
 	vServerAddr string,
+	httpsPort int32,
 	servicePort intstr.IntOrString) bool {
 
 	if route.Spec.TLS == nil {
@@ -1959,9 +2217,9 @@ func (ctlr *Controller) handleRouteTLS(
 			bigIPSSLProfiles.destinationCACertificate = route.Spec.TLS.DestinationCACertificate
 		}
 		// Set DependsOnTLS to true in case of route certificate and defaultSSLProfile
-		if ctlr.resources.baseRouteConfig != (BaseRouteConfig{}) {
+		if !reflect.DeepEqual(ctlr.resources.baseRouteConfig, BaseRouteConfig{}) {
 			//set for default routegroup
-			if ctlr.resources.baseRouteConfig.DefaultRouteGroupConfig != (DefaultRouteGroupConfig{}) {
+			if !reflect.DeepEqual(ctlr.resources.baseRouteConfig.DefaultRouteGroupConfig, DefaultRouteGroupConfig{}) {
 				//Flag to track the route groups which are using TLS profiles.
 				ctlr.resources.extdSpecMap[ctlr.resources.supplementContextCache.invertedNamespaceLabelMap[route.Namespace]].defaultrg.Meta = Meta{
 					DependsOnTLS: true,
@@ -1988,9 +2246,9 @@ func (ctlr *Controller) handleRouteTLS(
 			bigIPSSLProfiles.serverSSLs = append(bigIPSSLProfiles.serverSSLs, ctlr.resources.baseRouteConfig.DefaultTLS.ServerSSL)
 		}
 		// Set DependsOnTLS to true in case of route certificate and defaultSSLProfile
-		if ctlr.resources.baseRouteConfig != (BaseRouteConfig{}) {
+		if !reflect.DeepEqual(ctlr.resources.baseRouteConfig, BaseRouteConfig{}) {
 			//Flag to track the route groups which are using TLS Ciphers
-			if ctlr.resources.baseRouteConfig.DefaultRouteGroupConfig != (DefaultRouteGroupConfig{}) {
+			if !reflect.DeepEqual(ctlr.resources.baseRouteConfig.DefaultRouteGroupConfig, DefaultRouteGroupConfig{}) {
 				ctlr.resources.extdSpecMap[ctlr.resources.supplementContextCache.invertedNamespaceLabelMap[route.Namespace]].defaultrg.Meta = Meta{
 					DependsOnTLS: true,
 				}
@@ -2030,7 +2288,7 @@ func (ctlr *Controller) handleRouteTLS(
 		}
 	}
 
-	if rsCfg.Virtual.VirtualAddress.Port == DEFAULT_HTTPS_PORT {
+	if rsCfg.Virtual.VirtualAddress.Port == httpsPort {
 		ctlr.updateDataGroupForABRoute(route,
 			getRSCfgResName(rsCfg.Virtual.Name, AbDeploymentDgName),
 			rsCfg.Virtual.Partition,
@@ -2045,18 +2303,48 @@ func (ctlr *Controller) handleRouteTLS(
 		}
 	}
 
-	return ctlr.handleTLS(rsCfg, TLSContext{route.ObjectMeta.Name,
+	processed := ctlr.handleTLS(rsCfg, TLSContext{route.ObjectMeta.Name,
 		route.ObjectMeta.Namespace,
 		Route,
 		tlsReferenceType,
 		route.Spec.Host,
-		DEFAULT_HTTPS_PORT,
+		httpsPort,
 		vServerAddr,
 		string(route.Spec.TLS.Termination),
 		strings.ToLower(string(route.Spec.TLS.InsecureEdgeTerminationPolicy)),
 		poolPathRefs,
 		bigIPSSLProfiles,
 	})
+	if processed && tlsReferenceType == Certificate && bigIPSSLProfiles.destinationCACertificate != "" {
+		ctlr.handleRouteSecureServerSSL(rsCfg, route, bigIPSSLProfiles)
+	}
+	return processed
+}
+
+// handleRouteSecureServerSSL makes the serverssl profile of the destination CA certificate
+// validate the server certificate when the secure-serverssl annotation is set to true
+func (ctlr *Controller) handleRouteSecureServerSSL(
+	rsCfg *ResourceConfig,
+	route *routeapi.Route,
+	bigIPSSLProfiles BigIPSSLProfiles,
+) {
+	secure, _ := strconv.ParseBool(route.ObjectMeta.Annotations[resource.F5ServerSslSecureAnnotation])
+	if !secure {
+		return
+	}
+	// the serverssl profile name is in accordance with handleTLS
+	name := fmt.Sprintf("%s-serverssl", route.ObjectMeta.Name)
+	if bigIPSSLProfiles.caCertificate != "" {
+		name = route.ObjectMeta.Name
+	}
+	// a profile with the "-ca" suffix marks the serverssl profile to validate the certificate
+	skey := SecretKey{Name: name + "-ca"}
+	rsCfg.customProfiles[skey] = CustomProfile{
+		Name:         skey.Name,
+		Partition:    rsCfg.Virtual.Partition,
+		Context:      CustomProfileServer,
+		PeerCertMode: PeerCertRequired,
+	}
 }
 
 /*
@@ -2072,7 +2360,7 @@ func (ctlr *Controller) getSSLProfileOption(route *routeapi.Route) string {
 		sslProfileOption = AnnotationSSLOption
 	} else if route.Spec.TLS != nil && route.Spec.TLS.Key != "" && route.Spec.TLS.Certificate != "" {
 		sslProfileOption = RouteCertificateSSLOption
-	} else if ctlr.resources != nil && ctlr.resources.baseRouteConfig != (BaseRouteConfig{}) &&
+	} else if ctlr.resources != nil && !reflect.DeepEqual(ctlr.resources.baseRouteConfig, BaseRouteConfig{}) &&
 		ctlr.resources.baseRouteConfig.DefaultTLS != (DefaultSSLProfile{}) &&
 		ctlr.resources.baseRouteConfig.DefaultTLS.Reference == BIGIP {
 		sslProfileOption = DefaultSSLOption
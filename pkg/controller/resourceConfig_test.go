@@ -6,10 +6,12 @@ import (
 
 	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
 	crdfake "github.com/F5Networks/k8s-bigip-ctlr/v2/config/client/clientset/versioned/fake"
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/teem"
 	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/test"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 )
 
@@ -366,6 +368,55 @@ var _ = Describe("Resource Config Tests", func() {
 			Expect(err).To(BeNil(), "Failed to Prepare Resource Config from TransportServer")
 		})
 
+		It("Prepare Resource Config from a TransportServer with protocol monitors", func() {
+			mockCtlr.comInformers[namespace].secretsInformer.GetStore().Add(
+				test.NewSecret("monitor-cert", namespace, "cert", "key"))
+			ts := test.NewTransportServer(
+				"SampleTS",
+				namespace,
+				cisapiv1.TransportServerSpec{
+					Pool: cisapiv1.Pool{
+						Service:         "svc1",
+						ServicePort:     80,
+						MinimumMonitors: 2,
+						Monitors: []cisapiv1.Monitor{
+							{
+								Type:     "icmp",
+								Timeout:  10,
+								Interval: 5,
+							},
+							{
+								Type:              "https",
+								Timeout:           10,
+								Interval:          5,
+								UpInterval:        30,
+								Reverse:           true,
+								ClientCertificate: "monitor-cert",
+								ServerName:        "db.example.com",
+								TargetPort:        8443,
+							},
+						},
+					},
+				},
+			)
+			err := mockCtlr.prepareRSConfigFromTransportServer(rsCfg, ts)
+			Expect(err).To(BeNil(), "Failed to Prepare Resource Config from TransportServer")
+			Expect(rsCfg.Pools[0].MinimumMonitors).To(Equal(2), "Invalid minimum monitors")
+			Expect(len(rsCfg.Monitors)).To(Equal(2), "Invalid monitors")
+			Expect(rsCfg.Monitors[1].UpInterval).To(Equal(30))
+			Expect(rsCfg.Monitors[1].Reverse).To(BeTrue())
+			Expect(rsCfg.Monitors[1].ServerName).To(Equal("db.example.com"))
+			Expect(rsCfg.Monitors[1].ClientCert).To(Equal("cert"), "Client certificate not fetched")
+			Expect(rsCfg.Monitors[1].ClientKey).To(Equal("key"), "Client key not fetched")
+
+			// the TransportServer is re-processed on an update of the client certificate
+			mockCtlr.addTransportServer(ts)
+			Expect(mockCtlr.getTransportServersForMonitorSecret(
+				test.NewSecret("monitor-cert", namespace, "cert", "key"))).To(Equal([]*cisapiv1.TransportServer{ts}))
+			Expect(mockCtlr.getTransportServersForMonitorSecret(
+				test.NewSecret("other-cert", namespace, "cert", "key"))).To(BeEmpty())
+		})
+
 		It("Prepare Resource Config from a Service", func() {
 			svcPort := v1.ServicePort{
 				Name:     "port1",
@@ -764,6 +815,82 @@ var _ = Describe("Resource Config Tests", func() {
 		})
 	})
 
+	Describe("Namespace Partitions", func() {
+		var mockCtlr *mockController
+
+		BeforeEach(func() {
+			mockCtlr = newMockController()
+			mockCtlr.mode = CustomResourceMode
+			mockCtlr.Partition = "test"
+			mockCtlr.resources = NewResourceStore()
+			mockCtlr.nsInformers = make(map[string]*NSInformer)
+			mockCtlr.kubeClient = k8sfake.NewSimpleClientset()
+			mockCtlr.nsPartitionInformer = mockCtlr.newPartitionNamespaceInformer()
+			_ = mockCtlr.nsPartitionInformer.nsInformer.GetIndexer().Add(&v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "team-a",
+					Annotations: map[string]string{PartitionAnnotation: "teamA"},
+				},
+			})
+			_ = mockCtlr.nsPartitionInformer.nsInformer.GetIndexer().Add(&v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-b"},
+			})
+		})
+
+		It("Get Resource Partition", func() {
+			Expect(mockCtlr.getResourcePartition("team-a", "")).To(Equal("teamA"),
+				"Namespace partition annotation not honoured")
+			Expect(mockCtlr.getResourcePartition("team-a", "teamC")).To(Equal("teamC"),
+				"Resource partition should override namespace annotation")
+			Expect(mockCtlr.getResourcePartition("team-b", "")).To(Equal("test"),
+				"Default partition expected for namespace without annotation")
+			Expect(mockCtlr.getResourcePartition("unknown", "")).To(Equal("test"),
+				"Default partition expected for unknown namespace")
+		})
+
+		It("Move Virtual Server to another Partition", func() {
+			rsCfg := &ResourceConfig{}
+			rsCfg.Virtual.Name = "crd_1_2_3_4_80"
+			rsCfg.MetaData.baseResources = map[string]string{"team-a/vs": VirtualServer}
+			mockCtlr.resources.getPartitionResourceMap("test")[rsCfg.Virtual.Name] = rsCfg
+
+			// virtual of another resource is retained
+			mockCtlr.deleteVirtualServerFromOtherPartitions("teamA", rsCfg.Virtual.Name, "team-b/vs")
+			Expect(mockCtlr.getVirtualServer("test", rsCfg.Virtual.Name)).NotTo(BeNil())
+
+			mockCtlr.deleteVirtualServerFromOtherPartitions("teamA", rsCfg.Virtual.Name, "team-a/vs")
+			Expect(mockCtlr.getVirtualServer("test", rsCfg.Virtual.Name)).To(BeNil(),
+				"Virtual not removed from old partition")
+			Expect(mockCtlr.resources.ltmConfig["test"].Priority).To(Equal(1),
+				"Old partition should be prioritized")
+		})
+
+		It("Delete IngressLink from the Partition of its Virtuals", func() {
+			mockCtlr.TeemData = &teem.TeemsData{
+				ResourceType: teem.ResourceTypes{IngressLink: make(map[string]int)},
+			}
+			ingLink := &cisapiv1.IngressLink{
+				ObjectMeta: metav1.ObjectMeta{Name: "il", Namespace: "team-a"},
+				Spec:       cisapiv1.IngressLinkSpec{VirtualServerAddress: "1.2.3.4"},
+			}
+			// the virtual was created before the partition annotation was added to the namespace
+			rsCfg := &ResourceConfig{}
+			rsCfg.Virtual.Name = "ingress_link_crd_1_2_3_4_443"
+			rsCfg.MetaData.baseResources = map[string]string{"team-a/il": IngressLink}
+			mockCtlr.resources.getPartitionResourceMap("test")[rsCfg.Virtual.Name] = rsCfg
+			other := &ResourceConfig{}
+			other.Virtual.Name = "ingress_link_crd_1_2_3_4_80"
+			other.MetaData.baseResources = map[string]string{"team-b/il": IngressLink}
+			mockCtlr.resources.getPartitionResourceMap("test")[other.Virtual.Name] = other
+
+			Expect(mockCtlr.processIngressLink(ingLink, true)).To(BeNil())
+			Expect(mockCtlr.getVirtualServer("test", rsCfg.Virtual.Name)).To(BeNil(),
+				"Virtual not removed from its partition")
+			Expect(mockCtlr.getVirtualServer("test", other.Virtual.Name)).NotTo(BeNil(),
+				"Virtual of another IngressLink removed")
+		})
+	})
+
 	Describe("Handle Virtual Server TLS", func() {
 		var mockCtlr *mockController
 		var vs *cisapiv1.VirtualServer
@@ -1054,6 +1181,39 @@ var _ = Describe("Resource Config Tests", func() {
 
 		})
 
+		It("Verifies HTTP options in policy CRD", func() {
+			rsCfg.Virtual.Name = "crd_vs_1.2.3.4"
+			rsCfg.Virtual.Partition = "test"
+			rsCfg.IRulesMap = make(IRulesMap)
+			plc.Spec.Profiles.HTTPOptions = &cisapiv1.HTTPOptions{
+				XForwardedFor: true,
+				RequestHeaders: cisapiv1.HeaderManipulation{
+					Insert: []cisapiv1.HTTPHeader{{Name: "X-Team", Value: "[blue]"}},
+				},
+				ServerHeader: "none",
+			}
+			err := mockCtlr.handleVSResourceConfigForPolicy(rsCfg, plc)
+			Expect(err).To(BeNil(), "Failed to handle VirtualServer for policy")
+			Expect(rsCfg.Virtual.HTTPOptions.XForwardedFor).To(BeTrue(), "HTTP options not set")
+			Expect(rsCfg.Virtual.IRules).To(ContainElement("/test/crd_vs_1.2.3.4_http_header_irule"))
+
+			iRule, ok := rsCfg.IRulesMap[NameRef{Name: "crd_vs_1.2.3.4_http_header_irule", Partition: "test"}]
+			Expect(ok).To(BeTrue(), "HTTP header iRule not created")
+			Expect(iRule.Code).To(ContainSubstring(`HTTP::header insert "X-Team" "\[blue\]"`))
+			Expect(iRule.Code).To(ContainSubstring("HTTP::header remove Server"))
+		})
+
+		It("Rejects HTTP options with an http profile in policy CRD", func() {
+			plc.Spec.Profiles.HTTP = "/Common/http"
+			plc.Spec.Profiles.HTTPOptions = &cisapiv1.HTTPOptions{HSTS: &cisapiv1.HSTS{Period: 300}}
+			err := mockCtlr.handleVSResourceConfigForPolicy(rsCfg, plc)
+			Expect(err).NotTo(BeNil(), "http profile with hsts should be rejected")
+
+			plc.Spec.Profiles.HTTPOptions = &cisapiv1.HTTPOptions{Compression: &cisapiv1.HTTPCompression{GzipLevel: 5}}
+			err = mockCtlr.handleVSResourceConfigForPolicy(rsCfg, plc)
+			Expect(err).To(BeNil(), "http profile with compression should be allowed")
+		})
+
 		It("Verifies SNAT whether is set properly for TransportServer", func() {
 			err := mockCtlr.handleTSResourceConfigForPolicy(rsCfg, plc)
 			Expect(err).To(BeNil(), "Failed to handle TransportServer for policy")
@@ -0,0 +1,589 @@
+/*-
+ * Copyright (c) 2019-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FailedStatus is the status of a resource rejected by BIG-IP and excluded from its tenant
+const FailedStatus = "Failed"
+
+// AS3 supports the dry-run action from this version
+const (
+	as3DryRunMajorVersion = 3
+	as3DryRunMinorVersion = 30
+)
+
+// failedResource is a resource rejected by BIG-IP, which is excluded from its tenant till it
+// is modified or a dry-run of the resource succeeds
+type failedResource struct {
+	// decl is the tenant declaration of the resource alone
+	decl as3Tenant
+	// baseResources are the kubernetes resources of the resource, keyed by namespace/name
+	baseResources map[string]string
+	message       string
+}
+
+// excludeFailedResources returns a copy of the resources of the tenant without the failed resources.
+// A failed resource which is modified or deleted is not failed anymore, so that it is posted again.
+func (agent *Agent) excludeFailedResources(tenant string, rsMap ResourceMap, config ResourceConfigRequest) ResourceMap {
+	validRsMap, modified := agent.filterFailedResources(tenant, rsMap, config)
+	for _, name := range modified {
+		log.Debugf("[AS3] Resource %v of tenant %v is modified, posting it again", name, tenant)
+		delete(agent.failedResources[tenant], name)
+	}
+	if failed, ok := agent.failedResources[tenant]; ok && len(failed) == 0 {
+		delete(agent.failedResources, tenant)
+	}
+	return validRsMap
+}
+
+// filterFailedResources returns a copy of the resources of the tenant without the unmodified
+// failed resources, along with the failed resources which are modified or deleted
+func (agent *Agent) filterFailedResources(
+	tenant string,
+	rsMap ResourceMap,
+	config ResourceConfigRequest,
+) (ResourceMap, []string) {
+	validRsMap := make(ResourceMap)
+	for name, rsCfg := range rsMap {
+		validRsMap[name] = rsCfg
+	}
+	var modified []string
+	for name, fr := range agent.failedResources[tenant] {
+		rsCfg, found := rsMap[name]
+		if found && reflect.DeepEqual(fr.decl, createTenantDecl(ResourceMap{name: rsCfg}, config, tenant)) {
+			delete(validRsMap, name)
+			continue
+		}
+		modified = append(modified, name)
+	}
+	return validRsMap, modified
+}
+
+// isolatedTenant is the snapshot of a tenant rejected by BIG-IP, whose failed resources are
+// identified by the isolation worker
+type isolatedTenant struct {
+	name   string
+	rsMap  ResourceMap
+	config ResourceConfigRequest
+	err    string
+	params *tenantParams
+	// started is set when the isolation worker takes the tenant
+	started bool
+	failed  map[string]string
+}
+
+// queueFailedResourceIsolation takes a snapshot of the tenants of the resource config rejected by
+// BIG-IP for the isolation worker. It is called with the declUpdate lock held.
+func (agent *Agent) queueFailedResourceIsolation(rsConfig ResourceConfigRequest) {
+	queued := false
+	for tenant, params := range agent.retryTenantDeclMap {
+		if params.agentResponseCode != http.StatusUnprocessableEntity || params.taskId != "" {
+			continue
+		}
+		partitionConfig, ok := rsConfig.ltmConfig[tenant]
+		if !ok || len(partitionConfig.ResourceMap) == 0 {
+			continue
+		}
+		if agent.isolatingTenants == nil {
+			agent.isolatingTenants = make(map[string]*isolatedTenant)
+		}
+		agent.isolatingTenants[tenant] = &isolatedTenant{
+			name:   tenant,
+			rsMap:  agent.excludeFailedResources(tenant, partitionConfig.ResourceMap, rsConfig),
+			config: rsConfig,
+			err:    agent.tenantErrorMap[tenant],
+			params: params,
+		}
+		// the tenant is not retried as a whole while its failed resources are identified
+		delete(agent.retryTenantDeclMap, tenant)
+		queued = true
+	}
+	if queued {
+		select {
+		case agent.isolationChan <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// failedResourceIsolationWorker isolates the failed resources of the queued tenants till the
+// agent is stopped, so that the agent worker does not wait for the dry-run declarations
+func (agent *Agent) failedResourceIsolationWorker() {
+	for {
+		select {
+		case <-agent.stopCh:
+			return
+		case <-agent.isolationChan:
+			agent.isolateFailedResources()
+		}
+	}
+}
+
+// isolateFailedResources identifies the resources of the queued tenants rejected by BIG-IP, and
+// posts the tenants without them so that the other resources of the tenants are configured.
+// The declUpdate lock is not held while the dry-run declarations are posted.
+func (agent *Agent) isolateFailedResources() {
+	var tenants []*isolatedTenant
+	agent.declUpdate.Lock()
+	for _, it := range agent.isolatingTenants {
+		if !it.started {
+			it.started = true
+			tenants = append(tenants, it)
+		}
+	}
+	agent.declUpdate.Unlock()
+	if len(tenants) == 0 {
+		return
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].name < tenants[j].name })
+
+	for _, it := range tenants {
+		it.failed = agent.findFailedResources(it.name, it.rsMap, it.config, it.err)
+	}
+
+	agent.declUpdate.Lock()
+	defer agent.declUpdate.Unlock()
+	retry := false
+	posted := false
+	for _, it := range tenants {
+		// the tenant is posted again with a later resource config
+		if agent.isolatingTenants[it.name] != it {
+			continue
+		}
+		delete(agent.isolatingTenants, it.name)
+		posted = true
+		if len(it.failed) == 0 {
+			log.Debugf("[AS3] Unable to identify the failed resources of tenant %v", it.name)
+			agent.retryTenantDeclMap[it.name] = it.params
+			retry = true
+			continue
+		}
+		if agent.failedResources == nil {
+			agent.failedResources = make(map[string]map[string]*failedResource)
+		}
+		if _, ok := agent.failedResources[it.name]; !ok {
+			agent.failedResources[it.name] = make(map[string]*failedResource)
+		}
+		for name, message := range it.failed {
+			rsCfg := it.rsMap[name]
+			log.WithFields(log.Fields{
+				"tenant":    it.name,
+				"resource":  name,
+				"namespace": rsCfg.MetaData.namespace,
+			}).Errorf("[AS3] Excluding resource rejected by BIG-IP from the tenant: %v", message)
+			agent.failedResources[it.name][name] = &failedResource{
+				decl:          createTenantDecl(ResourceMap{name: rsCfg}, it.config, it.name),
+				baseResources: copyBaseResources(rsCfg),
+				message:       message,
+			}
+			delete(it.rsMap, name)
+		}
+
+		tenantDecl := createTenantDecl(it.rsMap, it.config, it.name)
+		log.WithFields(log.Fields{"id": it.config.reqId, "tenants": []string{it.name}}).Info(
+			"[AS3] Posting tenant without the failed resources")
+		_, span := startSpan(it.config.traceCtx, "as3Post", attribute.Int("request.id", it.config.reqId))
+		resp, message, err := agent.postTenant(it.name, tenantDecl, false)
+		span.End()
+		switch {
+		case err != nil:
+			log.Errorf("[AS3] %v", err)
+		case resp.agentResponseCode == http.StatusOK:
+			agent.cachedTenantDeclMap[it.name] = tenantDecl
+		case resp.taskId == "":
+			log.WithFields(log.Fields{"tenant": it.name, "code": resp.agentResponseCode}).Errorf(
+				"[AS3] Error response from BIG-IP: %v", message)
+		}
+		agent.updateRetryMap(it.name, resp, tenantDecl)
+		if resp.agentResponseCode != http.StatusOK {
+			retry = true
+		}
+	}
+	if retry {
+		// the retry worker polls the accepted tenants and posts the failed tenants again
+		select {
+		case agent.retryChan <- struct{}{}:
+		default:
+		}
+	}
+	if posted {
+		agent.notifyRscStatusHandler(context.Background(), 0, false)
+	}
+}
+
+// findFailedResources returns the resources of the tenant rejected by BIG-IP with their error.
+// The resources owning the objects in the error of the tenant are failed, otherwise the
+// resources are bisected with dry-run declarations.
+func (agent *Agent) findFailedResources(
+	tenant string,
+	rsMap ResourceMap,
+	config ResourceConfigRequest,
+	tenantErr string,
+) map[string]string {
+	var names []string
+	for name := range rsMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failed := make(map[string]string)
+	if tenantErr != "" {
+		// objects are referred as /tenant/Shared/object in the AS3 errors
+		objectPath := regexp.MustCompile("/" + regexp.QuoteMeta(tenant) + "/" + as3SharedApplication + `/([^/\s"',:\[\]]+)`)
+		objects := make(map[string]struct{})
+		for _, match := range objectPath.FindAllStringSubmatch(tenantErr, -1) {
+			objects[match[1]] = struct{}{}
+		}
+		for _, name := range names {
+			rsDecl := createTenantDecl(ResourceMap{name: rsMap[name]}, config, tenant)
+			for object := range rsDecl[as3SharedApplication].(as3Application) {
+				if _, ok := objects[object]; ok {
+					failed[name] = tenantErr
+					break
+				}
+			}
+		}
+		if len(failed) > 0 {
+			return failed
+		}
+	}
+
+	if !agent.dryRunSupported() {
+		return failed
+	}
+	failed, err := agent.bisectResources(tenant, names, rsMap, config)
+	if err != nil {
+		log.Errorf("[AS3] Unable to identify the failed resources of tenant %v: %v", tenant, err)
+		return nil
+	}
+	return failed
+}
+
+// bisectResources returns the resources which fail the dry-run of the tenant
+func (agent *Agent) bisectResources(
+	tenant string,
+	names []string,
+	rsMap ResourceMap,
+	config ResourceConfigRequest,
+) (map[string]string, error) {
+	subset := make(ResourceMap)
+	for _, name := range names {
+		subset[name] = rsMap[name]
+	}
+	valid, message, err := agent.dryRunTenant(tenant, createTenantDecl(subset, config, tenant))
+	if err != nil {
+		return nil, err
+	}
+	failed := make(map[string]string)
+	if valid {
+		return failed, nil
+	}
+	if len(names) == 1 {
+		failed[names[0]] = message
+		return failed, nil
+	}
+	for _, half := range [][]string{names[:len(names)/2], names[len(names)/2:]} {
+		halfFailed, err := agent.bisectResources(tenant, half, rsMap, config)
+		if err != nil {
+			return nil, err
+		}
+		for name, message := range halfFailed {
+			failed[name] = message
+		}
+	}
+	return failed, nil
+}
+
+// dryRunSupported returns true when the AS3 of BIG-IP supports the dry-run action
+func (agent *Agent) dryRunSupported() bool {
+	version := strings.Split(agent.AS3VersionInfo.as3Version, ".")
+	if len(version) < 2 {
+		return false
+	}
+	major, err := strconv.Atoi(version[0])
+	if err != nil {
+		return false
+	}
+	minor, err := strconv.Atoi(version[1])
+	if err != nil {
+		return false
+	}
+	return major > as3DryRunMajorVersion || (major == as3DryRunMajorVersion && minor >= as3DryRunMinorVersion)
+}
+
+// dryRunTenant posts the tenant declaration with the dry-run action, which validates the
+// declaration on BIG-IP without configuring it, and returns whether the tenant is valid
+func (agent *Agent) dryRunTenant(tenant string, tenantDecl as3Tenant) (bool, string, error) {
+	log.Debugf("[AS3] Posting dry-run declaration of tenant %v", tenant)
+	resp, message, err := agent.postTenant(tenant, tenantDecl, true)
+	if err != nil {
+		return false, "", err
+	}
+	switch resp.agentResponseCode {
+	case http.StatusOK:
+		return true, "", nil
+	case http.StatusUnprocessableEntity:
+		return false, message, nil
+	}
+	return false, "", fmt.Errorf("dry-run of tenant %v responded with code %v", tenant, resp.agentResponseCode)
+}
+
+// postTenant posts the tenant declaration, with the dry-run action when dryRun is set, and returns
+// the response of the tenant along with the error of a rejected tenant. The response is not
+// recorded in the tenantResponseMap.
+func (agent *Agent) postTenant(tenant string, tenantDecl as3Tenant, dryRun bool) (tenantResponse, string, error) {
+	var as3Config map[string]interface{}
+	if err := json.Unmarshal([]byte(agent.createAS3Declaration(map[string]as3Tenant{tenant: tenantDecl})), &as3Config); err != nil {
+		return tenantResponse{}, "", err
+	}
+	if dryRun {
+		as3Config["action"] = "dry-run"
+	}
+	data, err := json.Marshal(as3Config)
+	if err != nil {
+		return tenantResponse{}, "", err
+	}
+	req, err := http.NewRequest("POST", agent.getAS3APIURL([]string{tenant}), bytes.NewBuffer(data))
+	if err != nil {
+		return tenantResponse{}, "", err
+	}
+	req.SetBasicAuth(agent.BIGIPUsername, agent.BIGIPPassword)
+
+	httpResp, responseMap := agent.httpPOST(req)
+	if httpResp == nil || responseMap == nil {
+		return tenantResponse{}, "", fmt.Errorf("post of tenant %v failed", tenant)
+	}
+	results, _ := responseMap["results"].([]interface{})
+	for _, value := range results {
+		v, ok := value.(map[string]interface{})
+		if !ok || v["tenant"] != tenant {
+			continue
+		}
+		if !dryRun {
+			agent.recordTenantError(v)
+		}
+		code, _ := v["code"].(float64)
+		if int(code) == http.StatusOK {
+			return tenantResponse{agentResponseCode: http.StatusOK}, "", nil
+		}
+		return tenantResponse{agentResponseCode: int(code)}, fmt.Sprintf("%v: %v", v["message"], v["response"]), nil
+	}
+	switch httpResp.StatusCode {
+	case http.StatusCreated, http.StatusAccepted:
+		if id, ok := responseMap["id"].(string); ok {
+			return tenantResponse{agentResponseCode: http.StatusAccepted, taskId: id}, "", nil
+		}
+	case http.StatusUnprocessableEntity:
+		return tenantResponse{agentResponseCode: httpResp.StatusCode}, fmt.Sprintf("%v", responseMap["errors"]), nil
+	}
+	return tenantResponse{agentResponseCode: httpResp.StatusCode}, "", nil
+}
+
+// failedResourceWorker periodically retries the failed resources till the agent is stopped
+func (agent *Agent) failedResourceWorker() {
+	ticker := time.NewTicker(timeoutLarge)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-agent.stopCh:
+			return
+		case <-ticker.C:
+			agent.retryFailedResources()
+		}
+	}
+}
+
+// failedResourceRetry is the dry-run of a tenant with all of its failed resources
+type failedResourceRetry struct {
+	tenant     string
+	cachedDecl as3Tenant
+	mergedDecl as3Tenant
+	failed     map[string]*failedResource
+}
+
+// retryFailedResources dry-runs each configured tenant with all of its failed resources, the
+// failed resources are added back to the tenant with the retry of the tenant when the dry-run
+// succeeds. The declUpdate lock is not held while the dry-run declarations are posted.
+func (agent *Agent) retryFailedResources() {
+	if !agent.dryRunSupported() {
+		return
+	}
+	var retries []*failedResourceRetry
+	agent.declUpdate.Lock()
+	for tenant, failed := range agent.failedResources {
+		cachedDecl, ok := agent.cachedTenantDeclMap[tenant]
+		if _, retrying := agent.retryTenantDeclMap[tenant]; !ok || retrying || len(failed) == 0 {
+			continue
+		}
+		if _, isolating := agent.isolatingTenants[tenant]; isolating {
+			continue
+		}
+		var names []string
+		for name := range failed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fr := &failedResourceRetry{
+			tenant:     tenant,
+			cachedDecl: cachedDecl,
+			mergedDecl: cachedDecl,
+			failed:     make(map[string]*failedResource),
+		}
+		for _, name := range names {
+			fr.mergedDecl = mergeTenantDecl(fr.mergedDecl, failed[name].decl)
+			fr.failed[name] = failed[name]
+		}
+		retries = append(retries, fr)
+	}
+	agent.declUpdate.Unlock()
+	if len(retries) == 0 {
+		return
+	}
+
+	valid := make(map[*failedResourceRetry]bool)
+	for _, fr := range retries {
+		ok, message, err := agent.dryRunTenant(fr.tenant, fr.mergedDecl)
+		if err != nil {
+			log.Debugf("[AS3] Unable to retry the failed resources of tenant %v: %v", fr.tenant, err)
+			continue
+		}
+		if !ok {
+			agent.declUpdate.Lock()
+			for _, failed := range fr.failed {
+				failed.message = message
+			}
+			agent.declUpdate.Unlock()
+			continue
+		}
+		valid[fr] = true
+	}
+
+	agent.declUpdate.Lock()
+	defer agent.declUpdate.Unlock()
+	retry := false
+	for _, fr := range retries {
+		if !valid[fr] || !agent.failedResourcesUnchanged(fr) {
+			continue
+		}
+		for name := range fr.failed {
+			log.Infof("[AS3] Resource %v of tenant %v is valid, adding it to the tenant", name, fr.tenant)
+		}
+		delete(agent.failedResources, fr.tenant)
+		agent.retryTenantDeclMap[fr.tenant] = &tenantParams{fr.mergedDecl, tenantResponse{}}
+		retry = true
+	}
+	if retry {
+		select {
+		case agent.retryChan <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// failedResourcesUnchanged returns whether the tenant and its failed resources are not
+// updated since the dry-run of the retry
+func (agent *Agent) failedResourcesUnchanged(fr *failedResourceRetry) bool {
+	if _, retrying := agent.retryTenantDeclMap[fr.tenant]; retrying {
+		return false
+	}
+	if _, isolating := agent.isolatingTenants[fr.tenant]; isolating {
+		return false
+	}
+	if !reflect.DeepEqual(agent.cachedTenantDeclMap[fr.tenant], fr.cachedDecl) {
+		return false
+	}
+	failed := agent.failedResources[fr.tenant]
+	if len(failed) != len(fr.failed) {
+		return false
+	}
+	for name, resource := range fr.failed {
+		if failed[name] != resource {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeTenantDecl returns the tenant declaration with the objects of the resource declaration
+func mergeTenantDecl(tenantDecl, rsDecl as3Tenant) as3Tenant {
+	merged := as3Tenant{}
+	for key, val := range tenantDecl {
+		merged[key] = val
+	}
+	sharedApp := as3Application{}
+	if app, ok := tenantDecl[as3SharedApplication].(as3Application); ok {
+		for key, val := range app {
+			sharedApp[key] = val
+		}
+	} else {
+		sharedApp["class"] = "Application"
+		sharedApp["template"] = "shared"
+	}
+	if app, ok := rsDecl[as3SharedApplication].(as3Application); ok {
+		for key, val := range app {
+			if key != "class" && key != "template" {
+				sharedApp[key] = val
+			}
+		}
+	}
+	if _, ok := merged["defaultRouteDomain"]; !ok {
+		merged["defaultRouteDomain"] = rsDecl["defaultRouteDomain"]
+	}
+	merged[as3SharedApplication] = sharedApp
+	return merged
+}
+
+// copyBaseResources returns a copy of the kubernetes resources of the resource config
+func copyBaseResources(rsCfg *ResourceConfig) map[string]string {
+	baseResources := make(map[string]string)
+	for key, kind := range rsCfg.MetaData.baseResources {
+		baseResources[key] = kind
+	}
+	return baseResources
+}
+
+// failedResourceStatus returns the error of the kubernetes resources of the resources rejected
+// by BIG-IP or excluded by the schema validation, keyed by kind/namespace/name
+func (agent *Agent) failedResourceStatus() map[string]string {
+	status := make(map[string]string)
+	for _, resources := range []map[string]map[string]*failedResource{agent.invalidResources, agent.failedResources} {
+		for _, failed := range resources {
+			for _, fr := range failed {
+				for key, kind := range fr.baseResources {
+					status[kind+"/"+key] = fr.message
+				}
+			}
+		}
+	}
+	return status
+}
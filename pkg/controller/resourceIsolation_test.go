@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Failed Resource Isolation Tests", func() {
+	var agent *Agent
+	var server *httptest.Server
+	var mutex sync.Mutex
+	var posts, dryRuns int
+	var badObject, errorResponse string
+	var config ResourceConfigRequest
+
+	newVirtual := func(name, destination string) *ResourceConfig {
+		rsCfg := &ResourceConfig{}
+		rsCfg.MetaData.Active = true
+		rsCfg.MetaData.ResourceType = VirtualServer
+		rsCfg.MetaData.namespace = "default"
+		rsCfg.MetaData.baseResources = map[string]string{"default/" + name: VirtualServer}
+		rsCfg.Virtual.Name = name
+		rsCfg.Virtual.Destination = destination
+		rsCfg.Virtual.Enabled = true
+		rsCfg.Virtual.PoolName = name + "_pool"
+		rsCfg.Pools = Pools{
+			Pool{
+				Name:    name + "_pool",
+				Members: []PoolMember{{Address: "10.244.1.2", Port: 8080}},
+			},
+		}
+		return rsCfg
+	}
+
+	sharedApp := func(tenantDecl as3Tenant) as3Application {
+		return tenantDecl[as3SharedApplication].(as3Application)
+	}
+
+	BeforeEach(func() {
+		posts, dryRuns = 0, 0
+		badObject = "crd_vs_bad"
+		errorResponse = ""
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			var as3Config map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&as3Config)
+			dryRun := as3Config["action"] == "dry-run"
+			if dryRun {
+				dryRuns++
+			} else {
+				posts++
+			}
+			tenant := as3Config["declaration"].(map[string]interface{})["test"].(map[string]interface{})
+			app, _ := tenant[as3SharedApplication].(map[string]interface{})
+			if _, bad := app[badObject]; bad {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				_, _ = fmt.Fprintf(w, `{"results":[{"code":422,"tenant":"test","message":"declaration failed","response":"%v"}]}`,
+					errorResponse)
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"results":[{"code":200,"tenant":"test","message":"success","dryRun":%v}]}`, dryRun)
+		}))
+
+		agent = newMockAgent(nil)
+		// the pool members are not sent to the vxlan manager
+		agent.EventChan = nil
+		agent.PostManager = &PostManager{
+			httpClient:        server.Client(),
+			tenantResponseMap: make(map[string]tenantResponse),
+			PostParams:        PostParams{BIGIPURL: server.URL},
+		}
+		agent.AS3VersionInfo = as3VersionInfo{
+			as3Version:       defaultAS3Version,
+			as3SchemaVersion: defaultAS3Version,
+			as3Release:       defaultAS3Version + "-" + defaultAS3Build,
+		}
+		agent.cachedTenantDeclMap = make(map[string]as3Tenant)
+		agent.incomingTenantDeclMap = make(map[string]as3Tenant)
+		agent.retryTenantDeclMap = make(map[string]*tenantParams)
+		agent.tenantPriorityMap = make(map[string]int)
+		agent.retryChan = make(chan struct{}, 1)
+		agent.isolationChan = make(chan struct{}, 1)
+		agent.respChan = make(chan resourceStatusMeta, 1)
+
+		config = ResourceConfigRequest{ltmConfig: make(LTMConfig), reqId: 1}
+		config.ltmConfig["test"] = &PartitionConfig{make(ResourceMap), 0}
+		for _, name := range []string{"crd_vs_1", "crd_vs_2", "crd_vs_3"} {
+			config.ltmConfig["test"].ResourceMap[name] = newVirtual(name, "/test/10.1.1.1:80")
+		}
+		config.ltmConfig["test"].ResourceMap["crd_vs_bad"] = newVirtual("crd_vs_bad", "/test/10.1.1.2:80")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	post := func() resourceStatusMeta {
+		decl := agent.createTenantAS3Declaration(config)
+		agent.declUpdate.Lock()
+		agent.tenantResponseMap = map[string]tenantResponse{"test": {}}
+		agent.postTenantsDeclaration(decl, config, []string{"test"})
+		agent.declUpdate.Unlock()
+		var status resourceStatusMeta
+		Eventually(agent.respChan).Should(Receive(&status))
+		Expect(status.failedTenants).To(HaveKey("test"), "Tenant should be failed while its resources are isolated")
+		Expect(agent.retryTenantDeclMap).To(BeEmpty(), "Isolated tenant should not be retried as a whole")
+
+		// the isolation worker posts the tenant without the failed resources
+		Expect(agent.isolationChan).To(Receive())
+		agent.isolateFailedResources()
+		Eventually(agent.respChan).Should(Receive(&status))
+		return status
+	}
+
+	It("Identifies the failed resource from the AS3 error", func() {
+		errorResponse = "01070734:3: Configuration error: /test/Shared/crd_vs_bad references a missing profile"
+		status := post()
+		Expect(dryRuns).To(Equal(0))
+		Expect(posts).To(Equal(2))
+		Expect(status.failedTenants).To(BeEmpty())
+		Expect(status.failedResources).To(HaveKey("VirtualServer/default/crd_vs_bad"))
+		Expect(status.failedResources["VirtualServer/default/crd_vs_bad"]).To(ContainSubstring("missing profile"))
+		Expect(agent.retryTenantDeclMap).To(BeEmpty())
+		Expect(sharedApp(agent.cachedTenantDeclMap["test"])).To(HaveKey("crd_vs_1"))
+		Expect(sharedApp(agent.cachedTenantDeclMap["test"])).ToNot(HaveKey("crd_vs_bad"))
+
+		// the unchanged failed resource is not posted again
+		Expect(sharedApp(agent.createAS3LTMConfigADC(config)["test"].(as3Tenant))).ToNot(HaveKey("crd_vs_bad"))
+		// the modified failed resource is posted again
+		config.ltmConfig["test"].ResourceMap["crd_vs_bad"] = newVirtual("crd_vs_bad", "/test/10.1.1.3:80")
+		Expect(sharedApp(agent.createAS3LTMConfigADC(config)["test"].(as3Tenant))).To(HaveKey("crd_vs_bad"))
+		Expect(agent.failedResources).To(BeEmpty())
+	})
+
+	It("Bisects the resources with dry-run declarations", func() {
+		errorResponse = "declaration failed"
+		status := post()
+		Expect(dryRuns).To(BeNumerically(">", 0))
+		Expect(status.failedTenants).To(BeEmpty())
+		Expect(status.failedResources).To(HaveLen(1))
+		Expect(status.failedResources).To(HaveKey("VirtualServer/default/crd_vs_bad"))
+		Expect(sharedApp(agent.cachedTenantDeclMap["test"])).To(HaveKey("crd_vs_3"))
+		Expect(sharedApp(agent.cachedTenantDeclMap["test"])).ToNot(HaveKey("crd_vs_bad"))
+	})
+
+	It("Retries only the failed resources", func() {
+		errorResponse = "/test/Shared/crd_vs_bad"
+		post()
+		Expect(agent.failedResources["test"]).To(HaveKey("crd_vs_bad"))
+
+		// the failed resource is retained while its dry-run fails
+		dryRuns = 0
+		agent.retryFailedResources()
+		Expect(dryRuns).To(Equal(1))
+		Expect(agent.failedResources["test"]).To(HaveKey("crd_vs_bad"))
+		Expect(agent.retryTenantDeclMap).To(BeEmpty())
+
+		// the failed resource is added to the tenant when its dry-run succeeds
+		badObject = "none"
+		agent.retryFailedResources()
+		Expect(agent.failedResources).To(BeEmpty())
+		Expect(agent.retryTenantDeclMap).To(HaveKey("test"))
+		retryDecl := agent.retryTenantDeclMap["test"].as3Decl.(as3Tenant)
+		Expect(sharedApp(retryDecl)).To(HaveKey("crd_vs_bad"))
+		Expect(sharedApp(retryDecl)).To(HaveKey("crd_vs_1"))
+		Expect(agent.retryChan).To(Receive())
+	})
+
+	It("Dry-runs the tenant once with all of its failed resources", func() {
+		errorResponse = "/test/Shared/crd_vs_bad"
+		post()
+		agent.failedResources["test"]["crd_vs_bad2"] = &failedResource{
+			decl: createTenantDecl(ResourceMap{"crd_vs_bad2": newVirtual("crd_vs_bad2", "/test/10.1.1.4:80")}, config, "test"),
+		}
+		badObject = "none"
+		dryRuns = 0
+		agent.retryFailedResources()
+		Expect(dryRuns).To(Equal(1))
+		Expect(agent.failedResources).To(BeEmpty())
+		retryDecl := agent.retryTenantDeclMap["test"].as3Decl.(as3Tenant)
+		Expect(sharedApp(retryDecl)).To(HaveKey("crd_vs_bad"))
+		Expect(sharedApp(retryDecl)).To(HaveKey("crd_vs_bad2"))
+	})
+
+	It("Does not replace the tenant responses of the agent", func() {
+		errorResponse = "/test/Shared/crd_vs_bad"
+		agent.retryTenantDeclMap["test"] = &tenantParams{as3Tenant{}, tenantResponse{agentResponseCode: http.StatusUnprocessableEntity}}
+		agent.tenantErrorMap = map[string]string{"test": errorResponse}
+		responses := map[string]tenantResponse{"other": {agentResponseCode: http.StatusOK}}
+		agent.tenantResponseMap = responses
+		agent.declUpdate.Lock()
+		agent.queueFailedResourceIsolation(config)
+		agent.declUpdate.Unlock()
+		agent.isolateFailedResources()
+		Expect(agent.tenantResponseMap).To(Equal(responses))
+		Expect(agent.retryTenantDeclMap).To(BeEmpty())
+		Expect(sharedApp(agent.cachedTenantDeclMap["test"])).ToNot(HaveKey("crd_vs_bad"))
+	})
+
+	It("Skips the isolation superseded by a later declaration", func() {
+		agent.retryTenantDeclMap["test"] = &tenantParams{as3Tenant{}, tenantResponse{agentResponseCode: http.StatusUnprocessableEntity}}
+		agent.tenantErrorMap = map[string]string{"test": "/test/Shared/crd_vs_bad"}
+		agent.declUpdate.Lock()
+		agent.queueFailedResourceIsolation(config)
+		agent.declUpdate.Unlock()
+		Expect(agent.isolatingTenants).To(HaveKey("test"))
+
+		// the tenant is posted again before the isolation worker runs
+		badObject = "none"
+		post := posts
+		decl := agent.createTenantAS3Declaration(config)
+		agent.declUpdate.Lock()
+		agent.tenantResponseMap = map[string]tenantResponse{"test": {}}
+		agent.postTenantsDeclaration(decl, config, []string{"test"})
+		agent.declUpdate.Unlock()
+		Expect(agent.isolatingTenants).To(BeEmpty())
+		agent.isolateFailedResources()
+		Expect(posts).To(Equal(post+1), "Superseded isolation should not post the tenant")
+		Expect(agent.failedResources).To(BeEmpty())
+	})
+
+	It("Compares the AS3 version for the dry-run support", func() {
+		for version, supported := range map[string]bool{
+			"3.9.0": false, "3.29.1": false, "3.30.0": true, "3.41.0": true, "4.0.0": true, "3": false, "": false,
+		} {
+			agent.AS3VersionInfo.as3Version = version
+			Expect(agent.dryRunSupported()).To(Equal(supported), version)
+		}
+	})
+})
@@ -2,11 +2,14 @@ package controller
 
 import (
 	"container/list"
+	"context"
 	"strings"
 	"sync"
 
 	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+	"go.opentelemetry.io/otel/attribute"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
 )
@@ -27,9 +30,12 @@ func (ctlr *Controller) enqueueReq(config ResourceConfigRequest) int {
 				rm.meta[key] = val
 				rm.partition = partition
 			}
+			if ctlr.podReadinessGate {
+				ctlr.addGatedPods(&rm, partition, cfg)
+			}
 		}
 	}
-	if len(rm.meta) > 0 {
+	if len(rm.meta) > 0 || len(rm.gatedPods) > 0 {
 		ctlr.requestQueue.Lock()
 		ctlr.requestQueue.PushBack(rm)
 		ctlr.requestQueue.Unlock()
@@ -42,6 +48,8 @@ func (ctlr *Controller) responseHandler(respChan chan resourceStatusMeta) {
 	ctlr.requestQueue = &requestQueue{sync.Mutex{}, list.New()}
 	for rscUpdateMeta := range respChan {
 
+		_, span := startSpan(rscUpdateMeta.traceCtx, "statusUpdate",
+			attribute.Int("request.id", rscUpdateMeta.id), attribute.Int("tenants.failed", len(rscUpdateMeta.failedTenants)))
 		rm := ctlr.dequeueReq(rscUpdateMeta.id, len(rscUpdateMeta.failedTenants))
 		partition := rm.partition
 		for rscKey, kind := range rm.meta {
@@ -64,6 +72,11 @@ func (ctlr *Controller) responseHandler(respChan chan resourceStatusMeta) {
 					continue
 				}
 				virtual := obj.(*cisapiv1.VirtualServer)
+				if message, failed := rscUpdateMeta.failedResources[kind+"/"+rscKey]; failed {
+					log.Errorf("VirtualServer %v is excluded from its tenant: %v", rscKey, message)
+					ctlr.updateVirtualServerStatus(virtual, virtual.Status.VSAddress, FailedStatus)
+					continue
+				}
 				if virtual.Namespace+"/"+virtual.Name == rscKey {
 					ctlr.updateVirtualServerStatus(virtual, virtual.Status.VSAddress, "Ok")
 				}
@@ -91,20 +104,98 @@ func (ctlr *Controller) responseHandler(respChan chan resourceStatusMeta) {
 					continue
 				}
 				virtual := obj.(*cisapiv1.TransportServer)
+				if message, failed := rscUpdateMeta.failedResources[kind+"/"+rscKey]; failed {
+					log.Errorf("TransportServer %v is excluded from its tenant: %v", rscKey, message)
+					ctlr.updateTransportServerStatus(virtual, virtual.Status.VSAddress, FailedStatus)
+					continue
+				}
 				if virtual.Namespace+"/"+virtual.Name == rscKey {
 					ctlr.updateTransportServerStatus(virtual, virtual.Status.VSAddress, "Ok")
 				}
 			case Route:
-				if _, found := rscUpdateMeta.failedTenants[partition]; found {
+				if message, failed := rscUpdateMeta.failedResources[kind+"/"+rscKey]; failed {
+					go ctlr.updateRouteAdmitStatus(rscKey, "ConfigRejected", message, v1.ConditionFalse)
+				} else if _, found := rscUpdateMeta.failedTenants[partition]; found {
 					// TODO : distinguish between a 503 and an actual failure
 					go ctlr.updateRouteAdmitStatus(rscKey, "Failure while updating config", "Please check logs for more information", v1.ConditionFalse)
 				} else {
 					// updating the tenant priority back to zero if it's not in failed tenants
+					ctlr.resourcesMutex.Lock()
 					ctlr.resources.updatePartitionPriority(partition, 0)
+					ctlr.resourcesMutex.Unlock()
 					go ctlr.updateRouteAdmitStatus(rscKey, "", "", v1.ConditionTrue)
 				}
 			}
 		}
+		// Pool members are added to BIG-IP for the tenants posted successfully
+		for gatedPartition, pods := range rm.gatedPods {
+			if _, found := rscUpdateMeta.failedTenants[gatedPartition]; found {
+				continue
+			}
+			go ctlr.setPodReadinessGates(pods)
+		}
+		span.End()
+	}
+}
+
+// addGatedPods records the pool members of cfg waiting for the pod readiness gate in the request
+func (ctlr *Controller) addGatedPods(rm *requestMeta, partition string, cfg *ResourceConfig) {
+	for _, pool := range cfg.Pools {
+		pmi, ok := ctlr.resources.poolMemCache[pool.ServiceNamespace+"/"+pool.ServiceName]
+		if !ok || len(pmi.gatedPods) == 0 {
+			continue
+		}
+		for _, mem := range pool.Members {
+			podKey, found := pmi.gatedPods[mem.Address]
+			if !found {
+				continue
+			}
+			if rm.gatedPods == nil {
+				rm.gatedPods = make(map[string][]string)
+			}
+			rm.gatedPods[partition] = append(rm.gatedPods[partition], podKey)
+		}
+	}
+}
+
+// setPodReadinessGates sets the readiness gate condition of the pods to True
+func (ctlr *Controller) setPodReadinessGates(pods []string) {
+	for _, podKey := range pods {
+		splits := strings.Split(podKey, "/")
+		if len(splits) != 2 {
+			continue
+		}
+		pod, err := ctlr.kubeClient.CoreV1().Pods(splits[0]).Get(context.TODO(), splits[1], metav1.GetOptions{})
+		if err != nil {
+			log.Debugf("Unable to fetch Pod %v for readiness gate: %v", podKey, err)
+			continue
+		}
+		if isPodConditionTrue(pod, PodReadinessGateConditionType) {
+			continue
+		}
+		condition := v1.PodCondition{
+			Type:               PodReadinessGateConditionType,
+			Status:             v1.ConditionTrue,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "PoolMemberAdded",
+			Message:            "Pod is a BIG-IP pool member",
+		}
+		updated := false
+		for i := range pod.Status.Conditions {
+			if pod.Status.Conditions[i].Type == PodReadinessGateConditionType {
+				pod.Status.Conditions[i] = condition
+				updated = true
+			}
+		}
+		if !updated {
+			pod.Status.Conditions = append(pod.Status.Conditions, condition)
+		}
+		_, err = ctlr.kubeClient.CoreV1().Pods(pod.Namespace).UpdateStatus(context.TODO(), pod, metav1.UpdateOptions{})
+		if err != nil {
+			log.Errorf("Error while setting readiness gate of Pod %v: %v", podKey, err)
+			continue
+		}
+		log.Debugf("Readiness gate %v set for Pod %v", PodReadinessGateConditionType, podKey)
 	}
 }
 
@@ -0,0 +1,333 @@
+/*-
+ * Copyright (c) 2019-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getGlobalExtendedRouteConfigMap returns the extended route spec configmap, an
+// empty configmap is returned when it does not exist and RouteGroups are watched
+func (ctlr *Controller) getGlobalExtendedRouteConfigMap() (*v1.ConfigMap, error) {
+	splits := strings.Split(ctlr.routeSpecCMKey, "/")
+	ns, cmName := splits[0], splits[1]
+	cm, err := ctlr.kubeClient.CoreV1().ConfigMaps(ns).Get(context.TODO(), cmName, metav1.GetOptions{})
+	if err != nil {
+		if ctlr.rgInformer == nil || !k8serrors.IsNotFound(err) {
+			return nil, err
+		}
+		cm = &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: cmName, Namespace: ns}}
+	}
+	return cm, nil
+}
+
+// processRouteGroup processes the extended route spec again with the modified RouteGroup
+func (ctlr *Controller) processRouteGroup(rg *cisapiv1.RouteGroup) error {
+	cm, err := ctlr.getGlobalExtendedRouteConfigMap()
+	if err != nil {
+		return fmt.Errorf("unable to get extended route spec configmap %v for RouteGroup %v/%v: %v",
+			ctlr.routeSpecCMKey, rg.Namespace, rg.Name, err)
+	}
+	if err = ctlr.setNamespaceLabelMode(cm); err != nil {
+		return err
+	}
+	err, _ = ctlr.processConfigMap(cm, false)
+	return err
+}
+
+// mergeRouteGroups adds the route groups of the RouteGroups to the route groups of
+// the extended route spec configmap, a RouteGroup takes precedence over the configmap.
+// An invalid RouteGroup keeps its last valid config, so that a mistake in a RouteGroup
+// does not delete the virtuals of the route group.
+func (ctlr *Controller) mergeRouteGroups(
+	cmConfigs []ExtendedRouteGroupConfig,
+) ([]ExtendedRouteGroupConfig, []routeGroupResult) {
+	if ctlr.rgInformer == nil {
+		return cmConfigs, nil
+	}
+	var rgs []*cisapiv1.RouteGroup
+	for _, obj := range ctlr.rgInformer.rgInformer.GetIndexer().List() {
+		rgs = append(rgs, obj.(*cisapiv1.RouteGroup))
+	}
+	// the oldest RouteGroup wins when several RouteGroups define the same route group
+	sort.Slice(rgs, func(i, j int) bool {
+		if rgs[i].CreationTimestamp.Equal(&rgs[j].CreationTimestamp) {
+			return rgs[i].Name < rgs[j].Name
+		}
+		return rgs[i].CreationTimestamp.Before(&rgs[j].CreationTimestamp)
+	})
+
+	// route groups are either namespaces or namespace labels
+	var labelMode, modeSet bool
+	if len(cmConfigs) > 0 {
+		labelMode, modeSet = len(cmConfigs[0].NamespaceLabel) > 0, true
+	}
+
+	var rgConfigs []ExtendedRouteGroupConfig
+	var results []routeGroupResult
+	// key is route group, value is the RouteGroup defining it
+	claimed := make(map[string]string)
+	rgKeys := make(map[string]struct{})
+	for _, rg := range rgs {
+		rgKey := rg.Namespace + "/" + rg.Name
+		rgKeys[rgKey] = struct{}{}
+		ergc, err := ctlr.getRouteGroupConfig(rg)
+		if err == nil {
+			isLabel := len(ergc.NamespaceLabel) > 0
+			if modeSet && isLabel != labelMode {
+				err = fmt.Errorf("can not specify both namespace and namespaceLabel route groups")
+			} else if key, ok := claimed[getRouteGroupName(ergc)]; ok {
+				err = fmt.Errorf("route group %v is already defined by RouteGroup %v", getRouteGroupName(ergc), key)
+			} else {
+				labelMode, modeSet = isLabel, true
+			}
+		}
+		result := routeGroupResult{routeGroup: rg, err: err}
+		if err == nil {
+			ctlr.resources.routeGroupCache[rgKey] = ergc
+		} else {
+			log.Errorf("Invalid RouteGroup %v: %v", rgKey, err)
+			last, ok := ctlr.resources.routeGroupCache[rgKey]
+			if !ok {
+				results = append(results, result)
+				continue
+			}
+			if _, claimedByOther := claimed[getRouteGroupName(last)]; claimedByOther {
+				delete(ctlr.resources.routeGroupCache, rgKey)
+				results = append(results, result)
+				continue
+			}
+			result.err = fmt.Errorf("%v, using the last valid spec", err)
+			ergc = last
+		}
+		claimed[getRouteGroupName(ergc)] = rgKey
+		rgConfigs = append(rgConfigs, ergc)
+		result.config = &ergc
+		results = append(results, result)
+	}
+	for rgKey := range ctlr.resources.routeGroupCache {
+		if _, ok := rgKeys[rgKey]; !ok {
+			delete(ctlr.resources.routeGroupCache, rgKey)
+		}
+	}
+
+	var configs []ExtendedRouteGroupConfig
+	for _, ergc := range cmConfigs {
+		if rgKey, ok := claimed[getRouteGroupName(ergc)]; ok {
+			log.Warningf("RouteGroup %v overrides route group %v of extended route spec configmap %v",
+				rgKey, getRouteGroupName(ergc), ctlr.routeSpecCMKey)
+			continue
+		}
+		configs = append(configs, ergc)
+	}
+	return append(configs, rgConfigs...), results
+}
+
+// getRouteGroupConfig validates the RouteGroup and returns its route group config
+func (ctlr *Controller) getRouteGroupConfig(rg *cisapiv1.RouteGroup) (ExtendedRouteGroupConfig, error) {
+	spec := rg.Spec
+	if (len(spec.Namespace) > 0) == (len(spec.NamespaceLabel) > 0) {
+		return ExtendedRouteGroupConfig{}, fmt.Errorf("one of namespace and namespaceLabel is required")
+	}
+	if len(spec.NamespaceLabel) > 0 && ctlr.namespaceLabel == "" {
+		return ExtendedRouteGroupConfig{}, fmt.Errorf("--namespace-label deployment parameter is required with namespaceLabel")
+	}
+	if spec.VServerAddr == "" && spec.IPAMLabel == "" {
+		return ExtendedRouteGroupConfig{}, fmt.Errorf("one of vserverAddr and ipamLabel is required")
+	}
+	if spec.VServerAddr != "" && net.ParseIP(spec.VServerAddr) == nil {
+		return ExtendedRouteGroupConfig{}, fmt.Errorf("invalid vserverAddr %q", spec.VServerAddr)
+	}
+	for _, addr := range spec.AdditionalVServerAddrs {
+		if net.ParseIP(addr) == nil {
+			return ExtendedRouteGroupConfig{}, fmt.Errorf("invalid additionalVserverAddrs address %q", addr)
+		}
+	}
+	for _, port := range []int32{spec.HTTPPort, spec.HTTPSPort} {
+		if port < 0 || port > 65535 {
+			return ExtendedRouteGroupConfig{}, fmt.Errorf("invalid port %v", port)
+		}
+	}
+	ergc := ExtendedRouteGroupConfig{
+		Namespace:      spec.Namespace,
+		NamespaceLabel: spec.NamespaceLabel,
+		BigIpPartition: spec.BigIpPartition,
+		ExtendedRouteGroupSpec: ExtendedRouteGroupSpec{
+			VServerName:            spec.VServerName,
+			VServerAddr:            spec.VServerAddr,
+			AdditionalVServerAddrs: spec.AdditionalVServerAddrs,
+			IPAMLabel:              spec.IPAMLabel,
+			HTTPPort:               spec.HTTPPort,
+			HTTPSPort:              spec.HTTPSPort,
+			SNAT:                   spec.SNAT,
+			AllowOverride:          strconv.FormatBool(spec.AllowOverride),
+			Policy:                 spec.Policy,
+		},
+	}
+	ports := getBasicVirtualPorts(&ergc.ExtendedRouteGroupSpec)
+	if ports[0].port == ports[1].port {
+		return ExtendedRouteGroupConfig{}, fmt.Errorf("vserverHTTPPort and vserverHTTPSPort must differ")
+	}
+	return ergc, nil
+}
+
+// updateRouteGroupStatus updates the status of the RouteGroups with the route group config in effect
+func (ctlr *Controller) updateRouteGroupStatus(results []routeGroupResult) {
+	for _, result := range results {
+		status := cisapiv1.RouteGroupStatus{StatusOk: "Ok"}
+		if result.config != nil {
+			status.Partition = result.config.BigIpPartition
+			if status.Partition == "" {
+				status.Partition = ctlr.Partition
+			}
+			status.VSAddress = result.config.VServerAddr
+			if spec, ok := ctlr.resources.extdSpecMap[getRouteGroupName(*result.config)]; ok && len(spec.namespaces) > 0 {
+				status.Namespaces = spec.namespaces
+			}
+		}
+		if result.err != nil {
+			status.StatusOk = "Error"
+			status.Error = result.err.Error()
+		}
+		if reflect.DeepEqual(result.routeGroup.Status, status) {
+			continue
+		}
+		rg := result.routeGroup.DeepCopy()
+		rg.Status = status
+		log.Debugf("Updating RouteGroup Status with %v for resource name:%v , namespace: %v", status, rg.Name, rg.Namespace)
+		_, err := ctlr.kubeCRClient.CisV1().RouteGroups(rg.Namespace).UpdateStatus(context.TODO(), rg, metav1.UpdateOptions{})
+		if err != nil {
+			log.Debugf("Error while updating RouteGroup status:%v", err)
+		}
+	}
+}
+
+// getRouteGroupVirtuals returns the addresses of the virtuals of the route group, the address
+// is requested from IPAM when the route group has an ipamLabel instead of a vserverAddr.
+// Without request the address already allocated by IPAM is used.
+func (ctlr *Controller) getRouteGroupVirtuals(
+	routeGroup string,
+	extdSpec *ExtendedRouteGroupSpec,
+	request bool,
+) ([]routeGroupVirtual, error) {
+	addr := extdSpec.VServerAddr
+	if addr == "" && extdSpec.IPAMLabel != "" && ctlr.ipamCli != nil {
+		key := routeGroup + "_rg"
+		if request {
+			var status int
+			addr, status = ctlr.requestIP(extdSpec.IPAMLabel, "", key)
+			switch status {
+			case NotEnabled:
+				log.Debug("IPAM Custom Resource Not Available")
+				return nil, nil
+			case InvalidInput:
+				log.Debugf("IPAM Invalid IPAM Label: %v for RouteGroup/Namespace: %v", extdSpec.IPAMLabel, routeGroup)
+				return nil, nil
+			case NotRequested:
+				return nil, fmt.Errorf("unable to make IPAM Request for RouteGroup/Namespace: %v, will be re-requested soon", routeGroup)
+			case Requested:
+				log.Debugf("IP address requested for RouteGroup/Namespace: %v", routeGroup)
+				return nil, nil
+			}
+			log.Debugf("[ipam] requested IP for RouteGroup/Namespace %v is: %v", routeGroup, addr)
+		} else if ipamCR := ctlr.getIPAMCR(); ipamCR != nil {
+			for _, ipst := range ipamCR.Status.IPStatus {
+				if ipst.IPAMLabel == extdSpec.IPAMLabel && ipst.Key == key {
+					addr = ipst.IP
+				}
+			}
+		}
+	}
+
+	var virtuals []routeGroupVirtual
+	if addr != "" || extdSpec.IPAMLabel == "" {
+		virtuals = append(virtuals, routeGroupVirtual{name: extdSpec.VServerName, addr: addr})
+	}
+	for _, additionalAddr := range extdSpec.AdditionalVServerAddrs {
+		rgVirtual := routeGroupVirtual{addr: additionalAddr}
+		if extdSpec.VServerName != "" {
+			rgVirtual.name = extdSpec.VServerName + "_" + additionalAddr
+		}
+		virtuals = append(virtuals, rgVirtual)
+	}
+	return virtuals, nil
+}
+
+// getRouteGroupVirtualPorts returns every port on every address of the route group
+func getRouteGroupVirtualPorts(virtuals []routeGroupVirtual, ports []portStruct) []routeGroupVirtualPort {
+	var vsPorts []routeGroupVirtualPort
+	for _, rgVirtual := range virtuals {
+		for _, port := range ports {
+			vsPorts = append(vsPorts, routeGroupVirtualPort{virtual: rgVirtual, port: port})
+		}
+	}
+	return vsPorts
+}
+
+// releaseRouteGroupIP releases the IPAM address of the route group when the new spec
+// of the route group no longer uses it, newSpec is nil for a deleted route group
+func (ctlr *Controller) releaseRouteGroupIP(routeGroup string, newSpec *ExtendedRouteGroupSpec) {
+	spec, ok := ctlr.resources.extdSpecMap[routeGroup]
+	if !ok || ctlr.ipamCli == nil {
+		return
+	}
+	extdSpec := spec.global
+	if routeGroup == defaultRouteGroupName {
+		extdSpec = spec.defaultrg
+	}
+	if extdSpec == nil || extdSpec.VServerAddr != "" || extdSpec.IPAMLabel == "" {
+		return
+	}
+	if newSpec != nil && newSpec.VServerAddr == "" && newSpec.IPAMLabel != "" {
+		return
+	}
+	ctlr.releaseIP(extdSpec.IPAMLabel, "", routeGroup+"_rg")
+}
+
+// isRouteGroupVirtualModified checks whether the virtuals of the route group get other
+// names or addresses with the new spec
+func isRouteGroupVirtualModified(oldSpec, newSpec *ExtendedRouteGroupSpec) bool {
+	if oldSpec == nil || newSpec == nil {
+		return oldSpec != newSpec
+	}
+	return oldSpec.VServerName != newSpec.VServerName ||
+		oldSpec.VServerAddr != newSpec.VServerAddr ||
+		oldSpec.IPAMLabel != newSpec.IPAMLabel ||
+		oldSpec.HTTPPort != newSpec.HTTPPort ||
+		oldSpec.HTTPSPort != newSpec.HTTPSPort ||
+		!reflect.DeepEqual(oldSpec.AdditionalVServerAddrs, newSpec.AdditionalVServerAddrs)
+}
+
+// getRouteGroupName returns the namespace or namespace label identifying the route group
+func getRouteGroupName(ergc ExtendedRouteGroupConfig) string {
+	if len(ergc.NamespaceLabel) > 0 {
+		return ergc.NamespaceLabel
+	}
+	return ergc.Namespace
+}
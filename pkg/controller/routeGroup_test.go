@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+	crdfake "github.com/F5Networks/k8s-bigip-ctlr/v2/config/client/clientset/versioned/fake"
+	cisinfv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/client/informers/externalversions/cis/v1"
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/teem"
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	fakeRouteClient "github.com/openshift/client-go/route/clientset/versioned/fake"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+var _ = Describe("RouteGroup Tests", func() {
+	var mockCtlr *mockController
+	var cm *v1.ConfigMap
+	cmNamespace := "system"
+
+	newRouteGroup := func(name string, age time.Duration, spec cisapiv1.RouteGroupSpec) *cisapiv1.RouteGroup {
+		return &cisapiv1.RouteGroup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         cmNamespace,
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			},
+			Spec: spec,
+		}
+	}
+
+	addRouteGroup := func(rg *cisapiv1.RouteGroup) {
+		_, _ = mockCtlr.kubeCRClient.CisV1().RouteGroups(rg.Namespace).Create(context.TODO(), rg, metav1.CreateOptions{})
+		_ = mockCtlr.rgInformer.rgInformer.GetIndexer().Add(rg)
+	}
+
+	getStatus := func(name string) cisapiv1.RouteGroupStatus {
+		rg, err := mockCtlr.kubeCRClient.CisV1().RouteGroups(cmNamespace).Get(context.TODO(), name, metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		return rg.Status
+	}
+
+	BeforeEach(func() {
+		mockCtlr = newMockController()
+		mockCtlr.mode = OpenShiftMode
+		mockCtlr.Partition = "test"
+		mockCtlr.routeSpecCMKey = cmNamespace + "/escm"
+		mockCtlr.routeClientV1 = fakeRouteClient.NewSimpleClientset().RouteV1()
+		mockCtlr.kubeClient = k8sfake.NewSimpleClientset()
+		mockCtlr.kubeCRClient = crdfake.NewSimpleClientset()
+		mockCtlr.namespaces = map[string]bool{"default": true}
+		mockCtlr.nrInformers = make(map[string]*NRInformer)
+		mockCtlr.comInformers = make(map[string]*CommonInformer)
+		mockCtlr.resources = NewResourceStore()
+		mockCtlr.processedHostPath = &ProcessedHostPath{processedHostPathMap: make(map[string]metav1.Time)}
+		mockCtlr.TeemData = &teem.TeemsData{
+			ResourceType: teem.ResourceTypes{
+				RouteGroups:  make(map[string]int),
+				NativeRoutes: make(map[string]int),
+			},
+		}
+		mockCtlr.rgInformer = &RGInformer{
+			namespace: cmNamespace,
+			stopCh:    make(chan struct{}),
+			rgInformer: cisinfv1.NewFilteredRouteGroupInformer(mockCtlr.kubeCRClient, cmNamespace, 0,
+				cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, nil),
+		}
+		cm = test.NewConfigMap("escm", "v1", cmNamespace, map[string]string{"extendedSpec": `
+extendedRouteSpec:
+    - namespace: default
+      vserverAddr: 10.8.3.11
+      allowOverride: false
+    - namespace: new
+      vserverAddr: 10.8.3.12
+      allowOverride: false
+`})
+	})
+
+	It("Merges the RouteGroups with the extended configmap", func() {
+		addRouteGroup(newRouteGroup("new", time.Minute, cisapiv1.RouteGroupSpec{
+			Namespace: "new", VServerAddr: "10.8.3.22", BigIpPartition: "dev"}))
+		addRouteGroup(newRouteGroup("prod", time.Minute, cisapiv1.RouteGroupSpec{
+			Namespace: "prod", VServerAddr: "10.8.3.23"}))
+		// defines the same route group as the older RouteGroup
+		addRouteGroup(newRouteGroup("prod-copy", time.Second, cisapiv1.RouteGroupSpec{
+			Namespace: "prod", VServerAddr: "10.8.3.24"}))
+
+		err, ok := mockCtlr.processConfigMap(cm, false)
+		Expect(err).To(BeNil())
+		Expect(ok).To(BeTrue())
+		Expect(mockCtlr.resources.extdSpecMap).To(HaveLen(3))
+		Expect(mockCtlr.resources.extdSpecMap["default"].global.VServerAddr).To(Equal("10.8.3.11"))
+		Expect(mockCtlr.resources.extdSpecMap["new"].global.VServerAddr).To(Equal("10.8.3.22"))
+		Expect(mockCtlr.resources.extdSpecMap["new"].partition).To(Equal("dev"))
+		Expect(mockCtlr.resources.extdSpecMap["prod"].global.VServerAddr).To(Equal("10.8.3.23"))
+
+		Expect(getStatus("new")).To(Equal(cisapiv1.RouteGroupStatus{
+			Partition: "dev", VSAddress: "10.8.3.22", Namespaces: []string{"new"}, StatusOk: "Ok"}))
+		Expect(getStatus("prod").Partition).To(Equal("test"))
+		status := getStatus("prod-copy")
+		Expect(status.StatusOk).To(Equal("Error"))
+		Expect(status.Error).To(ContainSubstring("already defined by RouteGroup system/prod"))
+	})
+
+	It("Keeps the last valid spec of an invalid RouteGroup", func() {
+		rg := newRouteGroup("prod", time.Minute, cisapiv1.RouteGroupSpec{Namespace: "prod", VServerAddr: "10.8.3.23"})
+		addRouteGroup(rg)
+		err, _ := mockCtlr.processConfigMap(cm, false)
+		Expect(err).To(BeNil())
+
+		rg = rg.DeepCopy()
+		rg.Spec.VServerAddr = "10.8.3"
+		_ = mockCtlr.rgInformer.rgInformer.GetIndexer().Update(rg)
+		err, _ = mockCtlr.processConfigMap(cm, false)
+		Expect(err).To(BeNil())
+		Expect(mockCtlr.resources.extdSpecMap["prod"].global.VServerAddr).To(Equal("10.8.3.23"))
+		status := getStatus("prod")
+		Expect(status.StatusOk).To(Equal("Error"))
+		Expect(status.Error).To(ContainSubstring("using the last valid spec"))
+		Expect(status.VSAddress).To(Equal("10.8.3.23"))
+
+		// a RouteGroup without a valid spec is skipped
+		addRouteGroup(newRouteGroup("both", time.Second, cisapiv1.RouteGroupSpec{
+			Namespace: "both", NamespaceLabel: "app=both", VServerAddr: "10.8.3.25"}))
+		err, _ = mockCtlr.processConfigMap(cm, false)
+		Expect(err).To(BeNil())
+		Expect(mockCtlr.resources.extdSpecMap).NotTo(HaveKey("both"))
+		Expect(getStatus("both").Error).To(Equal("one of namespace and namespaceLabel is required"))
+	})
+
+	It("Validates the addresses and ports of the RouteGroups", func() {
+		ergc, err := mockCtlr.getRouteGroupConfig(newRouteGroup("ipam", 0, cisapiv1.RouteGroupSpec{
+			Namespace: "ipam", IPAMLabel: "Prod", AdditionalVServerAddrs: []string{"2001:db8::11"},
+			HTTPPort: 8080, HTTPSPort: 8443, SNAT: "none"}))
+		Expect(err).To(BeNil())
+		Expect(ergc.ExtendedRouteGroupSpec).To(Equal(ExtendedRouteGroupSpec{
+			IPAMLabel: "Prod", AdditionalVServerAddrs: []string{"2001:db8::11"},
+			HTTPPort: 8080, HTTPSPort: 8443, SNAT: "none", AllowOverride: "false"}))
+
+		_, err = mockCtlr.getRouteGroupConfig(newRouteGroup("noaddr", 0, cisapiv1.RouteGroupSpec{Namespace: "noaddr"}))
+		Expect(err).To(MatchError("one of vserverAddr and ipamLabel is required"))
+		_, err = mockCtlr.getRouteGroupConfig(newRouteGroup("badaddr", 0, cisapiv1.RouteGroupSpec{
+			Namespace: "badaddr", VServerAddr: "10.8.3.11", AdditionalVServerAddrs: []string{"10.8"}}))
+		Expect(err).To(MatchError(`invalid additionalVserverAddrs address "10.8"`))
+		_, err = mockCtlr.getRouteGroupConfig(newRouteGroup("sameport", 0, cisapiv1.RouteGroupSpec{
+			Namespace: "sameport", VServerAddr: "10.8.3.11", HTTPPort: 443}))
+		Expect(err).To(MatchError("vserverHTTPPort and vserverHTTPSPort must differ"))
+	})
+
+	It("Keeps the RouteGroups on deletion of the extended configmap", func() {
+		addRouteGroup(newRouteGroup("prod", time.Minute, cisapiv1.RouteGroupSpec{Namespace: "prod", VServerAddr: "10.8.3.23"}))
+		err, _ := mockCtlr.processConfigMap(cm, false)
+		Expect(err).To(BeNil())
+		Expect(mockCtlr.resources.extdSpecMap).To(HaveLen(3))
+
+		err, _ = mockCtlr.processConfigMap(cm, true)
+		Expect(err).To(BeNil())
+		Expect(mockCtlr.resources.extdSpecMap).To(HaveLen(1))
+		Expect(mockCtlr.resources.extdSpecMap).To(HaveKey("prod"))
+
+		// the missing configmap is processed as an empty configmap
+		emptyCM, err := mockCtlr.getGlobalExtendedRouteConfigMap()
+		Expect(err).To(BeNil())
+		Expect(emptyCM.Name).To(Equal("escm"))
+		Expect(mockCtlr.processRouteGroup(newRouteGroup("prod", 0, cisapiv1.RouteGroupSpec{}))).To(BeNil())
+		Expect(mockCtlr.resources.extdSpecMap).To(HaveKey("prod"))
+	})
+})
@@ -553,6 +553,117 @@ func httpRedirectIRule(port int32, rsVSName string, partition string) string {
 	return iRuleCode
 }
 
+// httpHeaderIRule inserts and removes the HTTP headers of requests and responses
+// as defined in httpOptions, returns empty string if there is nothing to manipulate.
+func httpHeaderIRule(httpOptions *cisapiv1.HTTPOptions) string {
+	var reqCode, respCode string
+	for _, name := range httpOptions.RequestHeaders.Remove {
+		reqCode += fmt.Sprintf("\n\t\t\tHTTP::header remove %s", tclQuote(name))
+	}
+	for _, hdr := range httpOptions.RequestHeaders.Insert {
+		reqCode += fmt.Sprintf("\n\t\t\tHTTP::header insert %s %s", tclQuote(hdr.Name), tclQuote(hdr.Value))
+	}
+	for _, name := range httpOptions.ResponseHeaders.Remove {
+		respCode += fmt.Sprintf("\n\t\t\tHTTP::header remove %s", tclQuote(name))
+	}
+	for _, hdr := range httpOptions.ResponseHeaders.Insert {
+		respCode += fmt.Sprintf("\n\t\t\tHTTP::header insert %s %s", tclQuote(hdr.Name), tclQuote(hdr.Value))
+	}
+	// Mask the Server header of responses, "none" removes it
+	switch httpOptions.ServerHeader {
+	case "":
+	case "none":
+		respCode += "\n\t\t\tHTTP::header remove Server"
+	default:
+		respCode += fmt.Sprintf("\n\t\t\tHTTP::header replace Server %s", tclQuote(httpOptions.ServerHeader))
+	}
+
+	var iRuleCode string
+	if reqCode != "" {
+		iRuleCode += fmt.Sprintf("\n\t\twhen HTTP_REQUEST {%s\n\t\t}", reqCode)
+	}
+	if respCode != "" {
+		iRuleCode += fmt.Sprintf("\n\t\twhen HTTP_RESPONSE {%s\n\t\t}", respCode)
+	}
+	return iRuleCode
+}
+
+// proxyProtocolIRule sends the Proxy Protocol v1 or v2 header with the client address
+// on the server side connection
+func proxyProtocolIRule(version string) string {
+	// the route domain suffix %rd of the addresses is not valid in the header
+	if version == ProxyProtocolV1 {
+		return `when SERVER_CONNECTED {
+			set src [lindex [split [IP::client_addr] "%"] 0]
+			set dst [lindex [split [clientside {IP::local_addr}] "%"] 0]
+			TCP::respond "PROXY TCP[IP::version] $src $dst [TCP::client_port] [clientside {TCP::local_port}]\r\n"
+		}`
+	}
+	// v2 header is binary: signature, version and command, family, length, addresses and ports
+	return `proc addr_bin {addr} {
+			set addr [lindex [split $addr "%"] 0]
+			if {[string first ":" $addr] < 0} {
+				return [binary format c4 [split $addr "."]]
+			}
+			set groups [split [string map {"::" "|"} $addr] "|"]
+			set head [split [lindex $groups 0] ":"]
+			set tail [split [lindex $groups 1] ":"]
+			set words $head
+			for {set i [expr {[llength $head] + [llength $tail]}]} {$i < 8} {incr i} {
+				lappend words 0
+			}
+			set bin ""
+			foreach word [concat $words $tail] {
+				append bin [binary format S [expr 0x$word]]
+			}
+			return $bin
+		}
+		when SERVER_CONNECTED {
+			set src [IP::client_addr]
+			set dst [clientside {IP::local_addr}]
+			if {[string first ":" [lindex [split $src "%"] 0]] < 0} {
+				set family 0x11
+				set length 12
+			} else {
+				set family 0x21
+				set length 36
+			}
+			TCP::respond [binary format a12ccSa*a*SS "\r\n\r\n\x00\r\nQUIT\n" 0x21 $family $length \
+				[call addr_bin $src] [call addr_bin $dst] [TCP::client_port] [clientside {TCP::local_port}]]
+		}`
+}
+
+// portRangeIRule selects the pool of the port range virtual by the destination port of the
+// connection and rejects the connections to the ports outside the ranges
+func portRangeIRule(protocol string, partition string, poolRanges []poolPortRanges) string {
+	command := "TCP::local_port"
+	if protocol == "udp" {
+		command = "UDP::local_port"
+	}
+	var branches []string
+	for _, poolRng := range poolRanges {
+		var conditions []string
+		for _, rng := range poolRng.ranges {
+			conditions = append(conditions, fmt.Sprintf("($port >= %d && $port <= %d)", rng.low, rng.high))
+		}
+		branches = append(branches, fmt.Sprintf(`if { %s } {
+				pool /%s/%s/%s
+			}`, strings.Join(conditions, " || "), partition, Shared, poolRng.poolName))
+	}
+	return fmt.Sprintf(`when CLIENT_ACCEPTED {
+			set port [%s]
+			%s else {
+				reject
+			}
+		}`, command, strings.Join(branches, " else"))
+}
+
+// tclQuote returns the value as TCL double quoted string without substitutions
+func tclQuote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, `[`, `\[`, `]`, `\]`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
 func (ctlr *Controller) GetPathBasedABDeployIRule(rsVSName string, partition string) string {
 	dgPath := strings.Join([]string{partition, Shared}, "/")
 
@@ -602,91 +713,7 @@ func (ctlr *Controller) GetPathBasedABDeployIRule(rsVSName string, partition str
 func (ctlr *Controller) getTLSIRule(rsVSName string, partition string, allowSourceRange []string) string {
 	dgPath := strings.Join([]string{partition, Shared}, "/")
 
-	iRule := fmt.Sprintf(`
-		when CLIENT_DATA {
-			# Byte 0 is the content type.
-			# Bytes 1-2 are the TLS version.
-			# Bytes 3-4 are the TLS payload length.
-			# Bytes 5-$tls_payload_len are the TLS payload.
-			binary scan [TCP::payload] cSS tls_content_type tls_version tls_payload_len
-			if { ! [ expr { [info exists tls_content_type] && [string is integer -strict $tls_content_type] } ] }  { reject ; event disable all; return; }
-			if { ! [ expr { [info exists tls_version] && [string is integer -strict $tls_version] } ] }  { reject ; event disable all; return; }
-			switch -exact $tls_version {
-				"769" -
-				"770" -
-				"771" {
-					# Content type of 22 indicates the TLS payload contains a handshake.
-					if { $tls_content_type == 22 } {
-						# Byte 5 (the first byte of the handshake) indicates the handshake
-						# record type, and a value of 1 signifies that the handshake record is
-						# a ClientHello.
-						binary scan [TCP::payload] @5c tls_handshake_record_type
-						if { ! [ expr { [info exists tls_handshake_record_type] && [string is integer -strict $tls_handshake_record_type] } ] }  { reject ; event disable all; return; }
-						if { $tls_handshake_record_type == 1 } {
-							# Bytes 6-8 are the handshake length (which we ignore).
-							# Bytes 9-10 are the TLS version (which we ignore).
-							# Bytes 11-42 are random data (which we ignore).
-
-							# Byte 43 is the session ID length.  Following this are three
-							# variable-length fields which we shall skip over.
-							set record_offset 43
-
-							# Skip the session ID.
-							binary scan [TCP::payload] @${record_offset}c tls_session_id_len
-							if { ! [ expr { [info exists tls_session_id_len] && [string is integer -strict $tls_session_id_len] } ] }  { reject ; event disable all; return; }
-							incr record_offset [expr {1 + $tls_session_id_len}]
-
-							# Skip the cipher_suites field.
-							binary scan [TCP::payload] @${record_offset}S tls_cipher_suites_len
-							if { ! [ expr { [info exists tls_cipher_suites_len] && [string is integer -strict $tls_cipher_suites_len] } ] }  { reject ; event disable all; return; }
-							incr record_offset [expr {2 + $tls_cipher_suites_len}]
-
-							# Skip the compression_methods field.
-							binary scan [TCP::payload] @${record_offset}c tls_compression_methods_len
-							if { ! [ expr { [info exists tls_compression_methods_len] && [string is integer -strict $tls_compression_methods_len] } ] }  { reject ; event disable all; return; }
-							incr record_offset [expr {1 + $tls_compression_methods_len}]
-
-							# Get the number of extensions, and store the extensions.
-							binary scan [TCP::payload] @${record_offset}S tls_extensions_len
-							if { ! [ expr { [info exists tls_extensions_len] && [string is integer -strict $tls_extensions_len] } ] }  { reject ; event disable all; return; }
-							incr record_offset 2
-							binary scan [TCP::payload] @${record_offset}a* tls_extensions
-							if { ! [info exists tls_extensions] }  { reject ; event disable all; return; }
-							for { set extension_start 0 }
-									{ $tls_extensions_len - $extension_start == abs($tls_extensions_len - $extension_start) }
-									{ incr extension_start 4 } {
-								# Bytes 0-1 of the extension are the extension type.
-								# Bytes 2-3 of the extension are the extension length.
-								binary scan $tls_extensions @${extension_start}SS extension_type extension_len
-								if { ! [ expr { [info exists extension_type] && [string is integer -strict $extension_type] } ] }  { reject ; event disable all; return; }
-								if { ! [ expr { [info exists extension_len] && [string is integer -strict $extension_len] } ] }  { reject ; event disable all; return; }
-
-								# Extension type 00 is the ServerName extension.
-								if { $extension_type == "00" } {
-									# Bytes 4-5 of the extension are the SNI length (we ignore this).
-
-									# Byte 6 of the extension is the SNI type.
-									set sni_type_offset [expr {$extension_start + 6}]
-									binary scan $tls_extensions @${sni_type_offset}S sni_type
-									if { ! [ expr { [info exists sni_type] && [string is integer -strict $sni_type] } ] }  { reject ; event disable all; return; }
-
-									# Type 0 is host_name.
-									if { $sni_type == "0" } {
-										# Bytes 7-8 of the extension are the SNI data (host_name)
-										# length.
-										set sni_len_offset [expr {$extension_start + 7}]
-										binary scan $tls_extensions @${sni_len_offset}S sni_len
-										if { ! [ expr { [info exists sni_len] && [string is integer -strict $sni_len] } ] }  { reject ; event disable all; return; } 
-
-										# Bytes 9-$sni_len are the SNI data (host_name).
-										set sni_start [expr {$extension_start + 9}]
-										binary scan $tls_extensions @${sni_start}A${sni_len} tls_servername
-									}
-								}
-
-								incr extension_start $extension_len
-							}
-							if { [info exists tls_servername] } {
+	iRule := fmt.Sprintf(clientHelloIRule(`							if { [info exists tls_servername] } {
 								set passthru_class "/%[1]s/%[2]s_ssl_passthrough_servername_dg"
 								if { [class exists $passthru_class] } {
 									set servername_lower [string tolower $tls_servername]
@@ -717,14 +744,7 @@ func (ctlr *Controller) getTLSIRule(rsVSName string, partition string, allowSour
 									}
 								}
 							}
-						}
-					}
-				}
-			}
-
-			TCP::release
-		}
-
+`)+`
 		when CLIENTSSL_HANDSHAKE {
  			SSL::collect
 		}
@@ -887,6 +907,130 @@ func (ctlr *Controller) getTLSIRule(rsVSName string, partition string, allowSour
 	return iRuleCode
 }
 
+// clientHelloIRule collects the TLS ClientHello in the CLIENT_DATA event, parses the
+// server name into tls_servername and runs the serverNameHandler
+func clientHelloIRule(serverNameHandler string) string {
+	return `
+		when CLIENT_DATA {
+			# Byte 0 is the content type.
+			# Bytes 1-2 are the TLS version.
+			# Bytes 3-4 are the TLS payload length.
+			# Bytes 5-$tls_payload_len are the TLS payload.
+			binary scan [TCP::payload] cSS tls_content_type tls_version tls_payload_len
+			if { ! [ expr { [info exists tls_content_type] && [string is integer -strict $tls_content_type] } ] }  { reject ; event disable all; return; }
+			if { ! [ expr { [info exists tls_version] && [string is integer -strict $tls_version] } ] }  { reject ; event disable all; return; }
+			switch -exact $tls_version {
+				"769" -
+				"770" -
+				"771" {
+					# Content type of 22 indicates the TLS payload contains a handshake.
+					if { $tls_content_type == 22 } {
+						# Byte 5 (the first byte of the handshake) indicates the handshake
+						# record type, and a value of 1 signifies that the handshake record is
+						# a ClientHello.
+						binary scan [TCP::payload] @5c tls_handshake_record_type
+						if { ! [ expr { [info exists tls_handshake_record_type] && [string is integer -strict $tls_handshake_record_type] } ] }  { reject ; event disable all; return; }
+						if { $tls_handshake_record_type == 1 } {
+							# Bytes 6-8 are the handshake length (which we ignore).
+							# Bytes 9-10 are the TLS version (which we ignore).
+							# Bytes 11-42 are random data (which we ignore).
+
+							# Byte 43 is the session ID length.  Following this are three
+							# variable-length fields which we shall skip over.
+							set record_offset 43
+
+							# Skip the session ID.
+							binary scan [TCP::payload] @${record_offset}c tls_session_id_len
+							if { ! [ expr { [info exists tls_session_id_len] && [string is integer -strict $tls_session_id_len] } ] }  { reject ; event disable all; return; }
+							incr record_offset [expr {1 + $tls_session_id_len}]
+
+							# Skip the cipher_suites field.
+							binary scan [TCP::payload] @${record_offset}S tls_cipher_suites_len
+							if { ! [ expr { [info exists tls_cipher_suites_len] && [string is integer -strict $tls_cipher_suites_len] } ] }  { reject ; event disable all; return; }
+							incr record_offset [expr {2 + $tls_cipher_suites_len}]
+
+							# Skip the compression_methods field.
+							binary scan [TCP::payload] @${record_offset}c tls_compression_methods_len
+							if { ! [ expr { [info exists tls_compression_methods_len] && [string is integer -strict $tls_compression_methods_len] } ] }  { reject ; event disable all; return; }
+							incr record_offset [expr {1 + $tls_compression_methods_len}]
+
+							# Get the number of extensions, and store the extensions.
+							binary scan [TCP::payload] @${record_offset}S tls_extensions_len
+							if { ! [ expr { [info exists tls_extensions_len] && [string is integer -strict $tls_extensions_len] } ] }  { reject ; event disable all; return; }
+							incr record_offset 2
+							binary scan [TCP::payload] @${record_offset}a* tls_extensions
+							if { ! [info exists tls_extensions] }  { reject ; event disable all; return; }
+							for { set extension_start 0 }
+									{ $tls_extensions_len - $extension_start == abs($tls_extensions_len - $extension_start) }
+									{ incr extension_start 4 } {
+								# Bytes 0-1 of the extension are the extension type.
+								# Bytes 2-3 of the extension are the extension length.
+								binary scan $tls_extensions @${extension_start}SS extension_type extension_len
+								if { ! [ expr { [info exists extension_type] && [string is integer -strict $extension_type] } ] }  { reject ; event disable all; return; }
+								if { ! [ expr { [info exists extension_len] && [string is integer -strict $extension_len] } ] }  { reject ; event disable all; return; }
+
+								# Extension type 00 is the ServerName extension.
+								if { $extension_type == "00" } {
+									# Bytes 4-5 of the extension are the SNI length (we ignore this).
+
+									# Byte 6 of the extension is the SNI type.
+									set sni_type_offset [expr {$extension_start + 6}]
+									binary scan $tls_extensions @${sni_type_offset}S sni_type
+									if { ! [ expr { [info exists sni_type] && [string is integer -strict $sni_type] } ] }  { reject ; event disable all; return; }
+
+									# Type 0 is host_name.
+									if { $sni_type == "0" } {
+										# Bytes 7-8 of the extension are the SNI data (host_name)
+										# length.
+										set sni_len_offset [expr {$extension_start + 7}]
+										binary scan $tls_extensions @${sni_len_offset}S sni_len
+										if { ! [ expr { [info exists sni_len] && [string is integer -strict $sni_len] } ] }  { reject ; event disable all; return; } 
+
+										# Bytes 9-$sni_len are the SNI data (host_name).
+										set sni_start [expr {$extension_start + 9}]
+										binary scan $tls_extensions @${sni_start}A${sni_len} tls_servername
+									}
+								}
+
+								incr extension_start $extension_len
+							}
+` + serverNameHandler + `						}
+					}
+				}
+			}
+
+			TCP::release
+		}
+`
+}
+
+// getTSPassthroughIRule selects the pool of the TransportServer by the server name of the
+// ClientHello, the connections with an unknown server name are rejected
+func (ctlr *Controller) getTSPassthroughIRule(rsVSName string, partition string) string {
+	dgPath := strings.Join([]string{partition, Shared}, "/")
+
+	iRule := fmt.Sprintf(clientHelloIRule(`							if { [info exists tls_servername] } {
+								set passthru_class "/%[1]s/%[2]s_ssl_passthrough_servername_dg"
+								set servername_lower [string tolower $tls_servername]
+								set passthru_pool [class match -value $servername_lower equals $passthru_class]
+								# Check for wildcard domain
+								if { $passthru_pool equals "" } {
+									set domain_length [llength [split $servername_lower "."]]
+									set wc_host ".[domain $servername_lower [expr {$domain_length - 1}]]"
+									set passthru_pool [class match -value $wc_host equals $passthru_class]
+								}
+								if { $passthru_pool equals "" } {
+									reject ; event disable all; return;
+								}
+								pool $passthru_pool
+							} else {
+								reject ; event disable all; return;
+							}
+`), dgPath, rsVSName)
+
+	return fmt.Sprintf("%s\n\n%s", ctlr.selectClientAcceptediRule(rsVSName, dgPath, nil), iRule)
+}
+
 func (ctlr *Controller) selectClientAcceptediRule(rsVSName string, dgPath string, allowSourceRange []string) string {
 
 	iRulePrefix := fmt.Sprintf(`when CLIENT_ACCEPTED { TCP::collect }`)
@@ -0,0 +1,101 @@
+/*-
+ * Copyright (c) 2019-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// maxRequestSpanLinks limits the processed resources linked to a request span
+const maxRequestSpanLinks = 128
+
+// tracedQueue records the time a resource is enqueued, so that the time spent
+// in the resourceQueue is part of the resource span
+type tracedQueue struct {
+	workqueue.RateLimitingInterface
+}
+
+func newTracedQueue(queue workqueue.RateLimitingInterface) workqueue.RateLimitingInterface {
+	return &tracedQueue{queue}
+}
+
+func (q *tracedQueue) Add(item interface{}) {
+	setEnqueueTime(item)
+	q.RateLimitingInterface.Add(item)
+}
+
+func (q *tracedQueue) AddAfter(item interface{}, duration time.Duration) {
+	setEnqueueTime(item)
+	q.RateLimitingInterface.AddAfter(item, duration)
+}
+
+func (q *tracedQueue) AddRateLimited(item interface{}) {
+	setEnqueueTime(item)
+	q.RateLimitingInterface.AddRateLimited(item)
+}
+
+func setEnqueueTime(item interface{}) {
+	if key, ok := item.(*rqKey); ok && key.enqueueTime.IsZero() {
+		key.enqueueTime = time.Now()
+	}
+}
+
+// startResourceSpan starts the span of a resource taken from the resourceQueue
+func (ctlr *Controller) startResourceSpan(rKey *rqKey) trace.Span {
+	start := rKey.enqueueTime
+	if start.IsZero() {
+		start = time.Now()
+	}
+	_, span := tracing.Tracer().Start(context.Background(), "processResource",
+		trace.WithTimestamp(start),
+		trace.WithAttributes(
+			attribute.String("resource.kind", rKey.kind),
+			attribute.String("resource.namespace", rKey.namespace),
+			attribute.String("resource.name", rKey.rscName),
+			attribute.String("resource.event", rKey.event),
+		))
+	span.AddEvent("dequeued")
+	if span.SpanContext().IsSampled() && len(ctlr.requestSpanLinks) < maxRequestSpanLinks {
+		ctlr.requestSpanLinks = append(ctlr.requestSpanLinks, trace.Link{SpanContext: span.SpanContext()})
+	}
+	return span
+}
+
+// startRequestSpan starts the span of a resource config request linked to the
+// resources processed for the request, the returned context is carried along
+// with the request to the agent.
+func (ctlr *Controller) startRequestSpan(reqId int) (context.Context, trace.Span) {
+	ctx, span := tracing.Tracer().Start(context.Background(), "resourceConfigRequest",
+		trace.WithLinks(ctlr.requestSpanLinks...),
+		trace.WithAttributes(attribute.Int("request.id", reqId)))
+	ctlr.requestSpanLinks = nil
+	return ctx, span
+}
+
+// startSpan starts a child span of the request context, ctx may be nil
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return tracing.Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
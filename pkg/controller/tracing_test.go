@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/client-go/util/workqueue"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type spanRecorder struct {
+	sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (r *spanRecorder) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	r.Lock()
+	defer r.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *spanRecorder) Shutdown(context.Context) error { return nil }
+
+func (r *spanRecorder) span(name string) sdktrace.ReadOnlySpan {
+	r.Lock()
+	defer r.Unlock()
+	for _, span := range r.spans {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
+
+var _ = Describe("Tracing Tests", func() {
+	var mockCtlr *mockController
+	var recorder *spanRecorder
+	var provider trace.TracerProvider
+
+	BeforeEach(func() {
+		mockCtlr = newMockController()
+		recorder = &spanRecorder{}
+		provider = otel.GetTracerProvider()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(
+			sdktrace.WithSyncer(recorder)))
+	})
+
+	AfterEach(func() {
+		otel.SetTracerProvider(provider)
+	})
+
+	It("Records the enqueue time of resources", func() {
+		queue := newTracedQueue(workqueue.NewNamedRateLimitingQueue(
+			workqueue.DefaultControllerRateLimiter(), "tracing-test"))
+		defer queue.ShutDown()
+		key := &rqKey{kind: Service, namespace: "default", rscName: "svc1", event: Create}
+		queue.Add(key)
+		enqueueTime := key.enqueueTime
+		Expect(enqueueTime.IsZero()).To(BeFalse())
+		// re-enqueueing keeps the first enqueue time
+		queue.AddRateLimited(key)
+		Expect(key.enqueueTime).To(Equal(enqueueTime))
+	})
+
+	It("Links the resource spans to the request span", func() {
+		key := &rqKey{kind: Service, namespace: "default", rscName: "svc1", event: Create,
+			enqueueTime: time.Now().Add(-time.Second)}
+		span := mockCtlr.startResourceSpan(key)
+		span.End()
+		Expect(mockCtlr.requestSpanLinks).To(HaveLen(1))
+
+		ctx, reqSpan := mockCtlr.startRequestSpan(7)
+		_, postSpan := startSpan(ctx, "as3Post")
+		postSpan.End()
+		reqSpan.End()
+		Expect(mockCtlr.requestSpanLinks).To(BeEmpty())
+
+		rscSpan := recorder.span("processResource")
+		Expect(rscSpan).NotTo(BeNil())
+		Expect(rscSpan.StartTime()).To(Equal(key.enqueueTime))
+		Expect(rscSpan.Attributes()).To(ContainElement(attribute.String("resource.name", "svc1")))
+
+		req := recorder.span("resourceConfigRequest")
+		Expect(req).NotTo(BeNil())
+		Expect(req.Attributes()).To(ContainElement(attribute.Int("request.id", 7)))
+		Expect(req.Links()).To(HaveLen(1))
+		Expect(req.Links()[0].SpanContext.SpanID()).To(Equal(rscSpan.SpanContext().SpanID()))
+
+		post := recorder.span("as3Post")
+		Expect(post).NotTo(BeNil())
+		Expect(post.Parent().SpanID()).To(Equal(req.SpanContext().SpanID()))
+	})
+})
@@ -18,10 +18,13 @@ package controller
 
 import (
 	"container/list"
+	"context"
 	ficV1 "github.com/F5Networks/f5-ipam-controller/pkg/ipamapis/apis/fic/v1"
 	"net/http"
 	"sync"
+	"time"
 
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -31,8 +34,10 @@ import (
 	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/teem"
 
 	"github.com/F5Networks/f5-ipam-controller/pkg/ipammachinery"
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
 	"github.com/F5Networks/k8s-bigip-ctlr/v2/config/client/clientset/versioned"
 	apm "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/appmanager"
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/netconfig"
 	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/pollers"
 	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/writer"
 	v1 "k8s.io/api/core/v1"
@@ -57,46 +62,82 @@ type (
 		customResourceSelector labels.Selector
 		namespacesMutex        sync.Mutex
 		namespaces             map[string]bool
+		effectivePolicyMutex   sync.Mutex
 		initialSvcCount        int
 		resourceQueue          workqueue.RateLimitingInterface
 		Partition              string
 		Agent                  *Agent
-		PoolMemberType         string
-		nodePoller             pollers.Poller
-		oldNodes               []Node
-		UseNodeInternal        bool
-		initState              bool
-		dgPath                 string
-		shareNodes             bool
-		ipamCli                *ipammachinery.IPAMClient
-		ipamCR                 string
-		defaultRouteDomain     int
-		TeemData               *teem.TeemsData
-		requestQueue           *requestQueue
-		namespaceLabel         string
-		ipamHostSpecEmpty      bool
+		// backend is the target of the resource config, the AS3 Agent when not set
+		backend Backend
+		// netAgent writes the network config and the GTM config of the cccl agent
+		netAgent *NetAgent
+		// bigIPURL is the BIG-IP of the LTM config, it names the GTM pools of the BIG-IP
+		bigIPURL              string
+		ccclGTMAgent          bool
+		PoolMemberType        string
+		nodePoller            pollers.Poller
+		oldNodes              []Node
+		UseNodeInternal       bool
+		initState             bool
+		dgPath                string
+		shareNodes            bool
+		ipamCli               *ipammachinery.IPAMClient
+		ipamCR                string
+		defaultRouteDomain    int
+		TeemData              *teem.TeemsData
+		requestQueue          *requestQueue
+		namespaceLabel        string
+		ipamHostSpecEmpty     bool
+		defaultPolicy         string
+		podReadinessGate      bool
+		drainPeriod           time.Duration
+		drainAdminState       string
+		nodePortEndpointNodes bool
+		staticRoutingMode     bool
+		// lastConfig is the last processed config served by the debug endpoints
+		lastConfig      ResourceConfigRequest
+		lastConfigMutex sync.Mutex
+		// resourcesMutex guards the resource store and the informers read by the debug endpoints and the admission webhook
+		resourcesMutex sync.RWMutex
+		// requestSpanLinks are the spans of the resources processed for the next request
+		requestSpanLinks []trace.Link
+		// conflicts are the resources discarded in favour of older resources
+		conflicts map[string]resourceConflict
+		// multiClusters are the additional clusters serving the pool members, keyed by cluster name
+		multiClusters map[string]*MultiClusterInformer
+		// multiClusterSecrets watch the kubeconfig Secrets of the additional clusters, keyed by namespace/name
+		multiClusterSecrets map[string]*MultiClusterSecretInformer
 		resourceContext
 	}
 	resourceContext struct {
-		resourceQueue      workqueue.RateLimitingInterface
-		routeClientV1      routeclient.RouteV1Interface
-		comInformers       map[string]*CommonInformer
-		nrInformers        map[string]*NRInformer
-		crInformers        map[string]*CRInformer
-		nsInformers        map[string]*NSInformer
-		routeSpecCMKey     string
-		routeLabel         string
-		namespaceLabelMode bool
-		processedHostPath  *ProcessedHostPath
+		resourceQueue workqueue.RateLimitingInterface
+		routeClientV1 routeclient.RouteV1Interface
+		comInformers  map[string]*CommonInformer
+		nrInformers   map[string]*NRInformer
+		crInformers   map[string]*CRInformer
+		nsInformers   map[string]*NSInformer
+		// nsPartitionInformer watches the partition annotation of the namespaces without a namespace label
+		nsPartitionInformer *NSInformer
+		rgInformer          *RGInformer
+		routeSpecCMKey      string
+		routeLabel          string
+		namespaceLabelMode  bool
+		processedHostPath   *ProcessedHostPath
 	}
 
 	// Params defines parameters
 	Params struct {
-		Config             *rest.Config
-		Namespaces         []string
-		NamespaceLabel     string
-		Partition          string
-		Agent              *Agent
+		Config         *rest.Config
+		Namespaces     []string
+		NamespaceLabel string
+		Partition      string
+		Agent          *Agent
+		// Backend is the target of the resource config, the AS3 Agent when not set
+		Backend Backend
+		// NetAgent writes the network config and the GTM config of the cccl agent
+		NetAgent           *NetAgent
+		BigIPURL           string
+		CCCLGTMAgent       bool
 		PoolMemberType     string
 		VXLANName          string
 		VXLANMode          string
@@ -109,6 +150,20 @@ type (
 		Mode               ControllerMode
 		RouteSpecConfigmap string
 		RouteLabel         string
+		DefaultPolicy      string
+		PodReadinessGate   bool
+		DrainPeriod        time.Duration
+		DrainAdminState    string
+		// NodePortEndpointNodes limits the nodeport members to the nodes hosting the endpoints
+		NodePortEndpointNodes bool
+		// StaticRoutingMode adds the static routes to the pod CIDRs of the nodes
+		StaticRoutingMode bool
+		DebugTokenFile    string
+		WebhookAddress    string
+		WebhookCertFile   string
+		WebhookKeyFile    string
+		// MultiClusterSecrets are the namespace/name of the kubeconfig Secrets of the additional clusters
+		MultiClusterSecrets []string
 	}
 
 	// CRInformer defines the structure of Custom Resource Informer
@@ -130,6 +185,7 @@ type (
 		plcInformer     cache.SharedIndexInformer
 		podInformer     cache.SharedIndexInformer
 		secretsInformer cache.SharedIndexInformer
+		epSliceInformer cache.SharedIndexInformer
 	}
 
 	// NRInformer is informer context for Native Resources of Kubernetes/Openshift
@@ -144,12 +200,38 @@ type (
 		stopCh     chan struct{}
 		nsInformer cache.SharedIndexInformer
 	}
+
+	// RGInformer watches the RouteGroups in the namespace of the extended route spec ConfigMap
+	RGInformer struct {
+		namespace  string
+		stopCh     chan struct{}
+		rgInformer cache.SharedIndexInformer
+	}
+
+	// MultiClusterInformer watches the services, endpoints and nodes of an additional cluster
+	MultiClusterInformer struct {
+		clusterName  string
+		kubeConfig   []byte
+		stopCh       chan struct{}
+		svcInformer  cache.SharedIndexInformer
+		epsInformer  cache.SharedIndexInformer
+		nodeInformer cache.SharedIndexInformer
+		// synced is set once the informers are synced, read atomically
+		synced int32
+	}
+
+	// MultiClusterSecretInformer watches the kubeconfig Secret of an additional cluster
+	MultiClusterSecretInformer struct {
+		stopCh         chan struct{}
+		secretInformer cache.SharedIndexInformer
+	}
 	rqKey struct {
-		namespace string
-		kind      string
-		rscName   string
-		rsc       interface{}
-		event     string
+		namespace   string
+		kind        string
+		rscName     string
+		rsc         interface{}
+		event       string
+		enqueueTime time.Time
 	}
 
 	metaData struct {
@@ -201,6 +283,7 @@ type (
 		PersistenceProfile     string                `json:"persistenceProfile,omitempty"`
 		TLSTermination         string                `json:"-"`
 		AllowSourceRange       []string              `json:"allowSourceRange,omitempty"`
+		HTTPOptions            *cisapiv1.HTTPOptions `json:"-"`
 	}
 	// Virtuals is slice of virtuals
 	Virtuals []Virtual
@@ -322,11 +405,16 @@ type (
 		gtmConfig          GTMConfig
 		defaultRouteDomain int
 		reqId              int
+		// traceCtx carries the request span to the agent
+		traceCtx context.Context
 	}
 
 	resourceStatusMeta struct {
 		id            int
 		failedTenants map[string]struct{}
+		// failedResources are the resources excluded from their tenant, keyed by kind/namespace/name
+		failedResources map[string]string
+		traceCtx        context.Context
 	}
 
 	resourceRef struct {
@@ -348,6 +436,12 @@ type (
 		MonitorNames      []MonitorName      `json:"monitors,omitempty"`
 		ReselectTries     int32              `json:"reselectTries,omitempty"`
 		ServiceDownAction string             `json:"serviceDownAction,omitempty"`
+		MinimumMonitors   int                `json:"minimumMonitors,omitempty"`
+		// Weight is the ratio of the members in this cluster
+		Weight               int                                     `json:"-"`
+		MultiClusterServices []cisapiv1.MultiClusterServiceReference `json:"-"`
+		Topology             *cisapiv1.PoolTopology                  `json:"-"`
+		MinActiveMembers     int32                                   `json:"minActiveMembers,omitempty"`
 	}
 	// Pools is slice of pool
 	Pools []Pool
@@ -360,6 +454,10 @@ type (
 		svcType   v1.ServiceType
 		portSpec  []v1.ServicePort
 		memberMap map[portRef][]PoolMember
+		// pods waiting for the readiness gate, keyed by pod IP
+		gatedPods map[string]string
+		// service routes the external traffic only to the endpoints of the node
+		localTraffic bool
 	}
 
 	// Monitor is Pool health monitor
@@ -373,6 +471,26 @@ type (
 		Timeout    int    `json:"timeout,omitempty"`
 		TargetPort int32  `json:"targetPort,omitempty"`
 		Path       string `json:"path,omitempty"`
+		MonitorOptions
+	}
+	// MonitorOptions are the protocol specific options of a monitor
+	MonitorOptions struct {
+		UpInterval  int    `json:"upInterval,omitempty"`
+		TimeUntilUp int    `json:"timeUntilUp,omitempty"`
+		Reverse     bool   `json:"reverse,omitempty"`
+		ServerName  string `json:"serverName,omitempty"`
+		QueryName   string `json:"queryName,omitempty"`
+		QueryType   string `json:"queryType,omitempty"`
+		Script      string `json:"script,omitempty"`
+		Arguments   string `json:"arguments,omitempty"`
+		// Options of inband monitor
+		Failures        int `json:"failures,omitempty"`
+		FailureInterval int `json:"failureInterval,omitempty"`
+		ResponseTime    int `json:"responseTime,omitempty"`
+		RetryTime       int `json:"retryTime,omitempty"`
+		// Client certificate and key of https monitor
+		ClientCert string `json:"-"`
+		ClientKey  string `json:"-"`
 	}
 	MonitorName struct {
 		Name string `json:"name"`
@@ -392,6 +510,37 @@ type (
 		// key of the map is IPSpec.Key
 		ipamContext              map[string]ficV1.IPSpec
 		processedNativeResources map[resourceRef]struct{}
+		// key is namespace/policyName referred by the resource
+		effectivePolicyMap map[string]effectivePolicy
+		// key is partition/poolName
+		poolDrainCache map[string]*poolDrainState
+		// last valid route group config of a RouteGroup, key is namespace/name
+		routeGroupCache map[string]ExtendedRouteGroupConfig
+	}
+
+	// routeGroupResult is the outcome of merging a RouteGroup into the extended spec
+	routeGroupResult struct {
+		routeGroup *cisapiv1.RouteGroup
+		// config is the route group config in effect, nil if there is none
+		config *ExtendedRouteGroupConfig
+		err    error
+	}
+
+	// poolDrainState tracks the members of a pool that are being drained
+	poolDrainState struct {
+		active   []PoolMember
+		draining map[string]drainingMember
+	}
+
+	drainingMember struct {
+		member PoolMember
+		expiry time.Time
+	}
+
+	// effectivePolicy is the result of merging the inherited Policies
+	effectivePolicy struct {
+		Chain []string            `json:"chain"`
+		Spec  cisapiv1.PolicySpec `json:"spec"`
 	}
 
 	// key is group identifier
@@ -439,6 +588,7 @@ type (
 		Reset     bool   `json:"reset,omitempty"`
 		Select    bool   `json:"select,omitempty"`
 		Value     string `json:"value,omitempty"`
+		WAF       string `json:"waf,omitempty"`
 	}
 
 	// condition config for a Rule
@@ -560,6 +710,30 @@ type (
 		port     int32
 	}
 
+	// portRange is a range of the ports served by the port range virtual of a TransportServer
+	portRange struct {
+		low  int32
+		high int32
+	}
+
+	// poolPortRanges are the port ranges of a TransportServer served by its pool
+	poolPortRanges struct {
+		poolName string
+		ranges   []portRange
+	}
+
+	// routeGroupVirtual is an address of a route group and the name of its virtuals
+	routeGroupVirtual struct {
+		name string
+		addr string
+	}
+
+	// routeGroupVirtualPort is a listener port on an address of a route group
+	routeGroupVirtualPort struct {
+		virtual routeGroupVirtual
+		port    portStruct
+	}
+
 	requestQueue struct {
 		sync.Mutex
 		*list.List
@@ -569,6 +743,8 @@ type (
 		meta      map[string]string
 		partition string
 		id        int
+		// pods with readiness gate pending, keyed by partition
+		gatedPods map[string][]string
 	}
 
 	Node struct {
@@ -603,20 +779,47 @@ type (
 )
 
 type (
-	Agent struct {
-		*PostManager
-		Partition       string
-		ConfigWriter    writer.Writer
-		postChan        chan ResourceConfigRequest
+	// Backend is a target which the controller configures with the resource config.
+	// The AS3 Agent is the BIG-IP backend, other targets are added by implementing Backend.
+	Backend interface {
+		// BuildDeclaration returns the declaration of the resource config for the target
+		BuildDeclaration(config ResourceConfigRequest) ([]byte, error)
+		// PostConfig posts the declaration of the resource config to the target asynchronously
+		PostConfig(config ResourceConfigRequest)
+		// StatusChan returns the channel of the status of the posted resource configs, the
+		// backend polls the target until the status of the accepted tenants is known
+		StatusChan() chan resourceStatusMeta
+		// Stop stops the backend
+		Stop()
+	}
+
+	// NetAgent writes the network config and the GTM config of the cccl agent to the
+	// python config driver or the network config driver, whichever backend is used
+	NetAgent struct {
+		ConfigWriter writer.Writer
+		// EventChan receives the pool members of the posted configs for the ARP entries
 		EventChan       chan interface{}
-		retryChan       chan struct{}
-		respChan        chan resourceStatusMeta
 		PythonDriverPID int
-		userAgent       string
-		AS3VersionInfo  as3VersionInfo
 		HttpAddress     string
 		EnableIPV6      bool
-		declUpdate      sync.Mutex
+		// netDriver configures the network config when the python driver is not used for it
+		netDriver *netconfig.Driver
+	}
+
+	Agent struct {
+		*PostManager
+		Partition      string
+		ConfigWriter   writer.Writer
+		postChan       chan ResourceConfigRequest
+		EventChan      chan interface{}
+		retryChan      chan struct{}
+		respChan       chan resourceStatusMeta
+		stopCh         chan struct{}
+		stopOnce       sync.Once
+		userAgent      string
+		AS3VersionInfo as3VersionInfo
+		EnableIPV6     bool
+		declUpdate     sync.Mutex
 		// cachedTenantDeclMap,incomingTenantDeclMap hold tenant names and corresponding AS3 config
 		cachedTenantDeclMap   map[string]as3Tenant
 		incomingTenantDeclMap map[string]as3Tenant
@@ -625,6 +828,18 @@ type (
 		// retryTenantDeclMap holds tenant name and its agent Config,tenant details
 		retryTenantDeclMap map[string]*tenantParams
 		ccclGTMAgent       bool
+		// as3Validator validates the tenant declarations against the AS3 schema before posting
+		as3Validator *as3Validator
+		// failedResources are the resources rejected by BIG-IP and excluded from their tenant,
+		// keyed by tenant and resource name
+		failedResources map[string]map[string]*failedResource
+		// invalidResources are the resources excluded from their tenant by the schema validation,
+		// keyed by tenant and resource name
+		invalidResources map[string]map[string]*failedResource
+		// isolatingTenants are the tenants rejected by BIG-IP whose failed resources are being
+		// identified by the isolation worker, isolationChan notifies the worker
+		isolatingTenants map[string]*isolatedTenant
+		isolationChan    chan struct{}
 	}
 
 	AgentParams struct {
@@ -641,13 +856,22 @@ type (
 		EnableIPV6     bool
 		DisableARP     bool
 		CCCLGTMAgent   bool
+		// NetConfigDriver is the driver of the FDB records, ARP entries and static routes
+		NetConfigDriver string
+		// AS3Validation validates the declaration against the AS3 schema in SchemaLocal
+		AS3Validation bool
+		SchemaLocal   string
 	}
 
 	PostManager struct {
 		httpClient        *http.Client
 		tenantResponseMap map[string]tenantResponse
+		// tenantErrorMap holds the error of the last failed response of each tenant
+		tenantErrorMap map[string]string
 		PostParams
 		firstPost bool
+		// logResponseMutex guards LogResponse, which is toggled by the debug endpoints
+		logResponseMutex sync.RWMutex
 	}
 
 	PostParams struct {
@@ -681,6 +905,8 @@ type (
 		data      string
 		as3APIURL string
 		id        int
+		tenants   []string
+		traceCtx  context.Context
 	}
 
 	globalSection struct {
@@ -806,6 +1032,9 @@ type (
 		Monitors          []as3ResourcePointer `json:"monitors,omitempty"`
 		ServiceDownAction string               `json:"serviceDownAction,omitempty"`
 		ReselectTries     int32                `json:"reselectTries,omitempty"`
+		MinimumMonitors   int                  `json:"minimumMonitors,omitempty"`
+		// MinimumMembersActive is the min-active-members of priority group activation
+		MinimumMembersActive int32 `json:"minimumMembersActive,omitempty"`
 	}
 
 	// as3PoolMember maps to Pool_Member in AS3 Resources
@@ -814,6 +1043,9 @@ type (
 		ServerAddresses  []string `json:"serverAddresses,omitempty"`
 		ServicePort      int32    `json:"servicePort,omitempty"`
 		ShareNodes       bool     `json:"shareNodes,omitempty"`
+		AdminState       string   `json:"adminState,omitempty"`
+		Ratio            int      `json:"ratio,omitempty"`
+		PriorityGroup    int      `json:"priorityGroup,omitempty"`
 	}
 
 	// as3ResourcePointer maps to following in AS3 Resources
@@ -832,34 +1064,38 @@ type (
 	// - Service_HTTPS
 	// - Service_TCP
 	// - Service_UDP
+	// - Service_Forwarding
 	as3Service struct {
-		Layer4                 string               `json:"layer4,omitempty"`
-		Source                 string               `json:"source,omitempty"`
-		TranslateServerAddress bool                 `json:"translateServerAddress,omitempty"`
-		TranslateServerPort    bool                 `json:"translateServerPort,omitempty"`
-		Class                  string               `json:"class,omitempty"`
-		VirtualAddresses       []as3MultiTypeParam  `json:"virtualAddresses,omitempty"`
-		VirtualPort            int                  `json:"virtualPort,omitempty"`
-		SNAT                   as3MultiTypeParam    `json:"snat,omitempty"`
-		PolicyEndpoint         as3MultiTypeParam    `json:"policyEndpoint,omitempty"`
-		ClientTLS              as3MultiTypeParam    `json:"clientTLS,omitempty"`
-		ServerTLS              as3MultiTypeParam    `json:"serverTLS,omitempty"`
-		IRules                 as3MultiTypeParam    `json:"iRules,omitempty"`
-		Redirect80             *bool                `json:"redirect80,omitempty"`
-		Pool                   string               `json:"pool,omitempty"`
-		WAF                    as3MultiTypeParam    `json:"policyWAF,omitempty"`
-		Firewall               as3MultiTypeParam    `json:"policyFirewallEnforced,omitempty"`
-		LogProfiles            []as3ResourcePointer `json:"securityLogProfiles,omitempty"`
-		ProfileL4              as3MultiTypeParam    `json:"profileL4,omitempty"`
-		AllowVLANs             []as3ResourcePointer `json:"allowVlans,omitempty"`
-		PersistenceMethods     *[]as3MultiTypeParam `json:"persistenceMethods,omitempty"`
-		ProfileTCP             as3MultiTypeParam    `json:"profileTCP,omitempty"`
-		ProfileUDP             as3MultiTypeParam    `json:"profileUDP,omitempty"`
-		ProfileHTTP            as3MultiTypeParam    `json:"profileHTTP,omitempty"`
-		ProfileHTTP2           as3MultiTypeParam    `json:"profileHTTP2,omitempty"`
-		ProfileMultiplex       as3MultiTypeParam    `json:"profileMultiplex,omitempty"`
-		ProfileDOS             as3MultiTypeParam    `json:"profileDOS,omitempty"`
-		ProfileBotDefense      as3MultiTypeParam    `json:"profileBotDefense,omitempty"`
+		Layer4                  string               `json:"layer4,omitempty"`
+		Source                  string               `json:"source,omitempty"`
+		TranslateServerAddress  bool                 `json:"translateServerAddress,omitempty"`
+		TranslateServerPort     *bool                `json:"translateServerPort,omitempty"`
+		Class                   string               `json:"class,omitempty"`
+		ForwardingType          string               `json:"forwardingType,omitempty"`
+		VirtualAddresses        []as3MultiTypeParam  `json:"virtualAddresses,omitempty"`
+		VirtualPort             int                  `json:"virtualPort"`
+		SNAT                    as3MultiTypeParam    `json:"snat,omitempty"`
+		PolicyEndpoint          as3MultiTypeParam    `json:"policyEndpoint,omitempty"`
+		ClientTLS               as3MultiTypeParam    `json:"clientTLS,omitempty"`
+		ServerTLS               as3MultiTypeParam    `json:"serverTLS,omitempty"`
+		IRules                  as3MultiTypeParam    `json:"iRules,omitempty"`
+		Redirect80              *bool                `json:"redirect80,omitempty"`
+		Pool                    string               `json:"pool,omitempty"`
+		WAF                     as3MultiTypeParam    `json:"policyWAF,omitempty"`
+		Firewall                as3MultiTypeParam    `json:"policyFirewallEnforced,omitempty"`
+		LogProfiles             []as3ResourcePointer `json:"securityLogProfiles,omitempty"`
+		ProfileL4               as3MultiTypeParam    `json:"profileL4,omitempty"`
+		AllowVLANs              []as3ResourcePointer `json:"allowVlans,omitempty"`
+		PersistenceMethods      *[]as3MultiTypeParam `json:"persistenceMethods,omitempty"`
+		ProfileTCP              as3MultiTypeParam    `json:"profileTCP,omitempty"`
+		ProfileUDP              as3MultiTypeParam    `json:"profileUDP,omitempty"`
+		ProfileHTTP             as3MultiTypeParam    `json:"profileHTTP,omitempty"`
+		ProfileHTTP2            as3MultiTypeParam    `json:"profileHTTP2,omitempty"`
+		ProfileMultiplex        as3MultiTypeParam    `json:"profileMultiplex,omitempty"`
+		ProfileDOS              as3MultiTypeParam    `json:"profileDOS,omitempty"`
+		ProfileBotDefense       as3MultiTypeParam    `json:"profileBotDefense,omitempty"`
+		ProfileHTTPCompression  as3MultiTypeParam    `json:"profileHTTPCompression,omitempty"`
+		ProfileHTTPAcceleration as3MultiTypeParam    `json:"profileHTTPAcceleration,omitempty"`
 	}
 
 	// as3ServiceAddress maps to VirtualAddress in AS3 Resources
@@ -873,24 +1109,71 @@ type (
 		SpanningEnabled    bool   `json:"spanningEnabled"`
 	}
 
+	// as3HTTPProfile maps to HTTP_Profile in AS3 Resources
+	as3HTTPProfile struct {
+		Class                 string `json:"class"`
+		XForwardedFor         bool   `json:"xForwardedFor"`
+		HSTSInsert            bool   `json:"hstsInsert,omitempty"`
+		HSTSPeriod            int    `json:"hstsPeriod,omitempty"`
+		HSTSIncludeSubdomains bool   `json:"hstsIncludeSubdomains,omitempty"`
+		HSTSPreload           bool   `json:"hstsPreload,omitempty"`
+	}
+
+	// as3HTTPCompress maps to HTTP_Compress in AS3 Resources
+	as3HTTPCompress struct {
+		Class               string   `json:"class"`
+		ContentTypeIncludes []string `json:"contentTypeIncludes,omitempty"`
+		ContentTypeExcludes []string `json:"contentTypeExcludes,omitempty"`
+		MinimumSize         int      `json:"minimumSize,omitempty"`
+		GzipLevel           int      `json:"gzipLevel,omitempty"`
+	}
+
+	// as3HTTPAcceleration maps to HTTP_Acceleration_Profile in AS3 Resources
+	as3HTTPAcceleration struct {
+		Class             string   `json:"class"`
+		MaximumAge        int      `json:"maximumAge,omitempty"`
+		MaximumEntries    int      `json:"maximumEntries,omitempty"`
+		MaximumObjectSize int      `json:"maximumObjectSize,omitempty"`
+		MinimumObjectSize int      `json:"minimumObjectSize,omitempty"`
+		URIIncludeList    []string `json:"uriIncludeList,omitempty"`
+		URIExcludeList    []string `json:"uriExcludeList,omitempty"`
+	}
+
 	// as3Monitor maps to the following in AS3 Resources
 	// - Monitor
 	// - Monitor_HTTP
 	// - Monitor_HTTPS
 	as3Monitor struct {
-		Class             string  `json:"class,omitempty"`
-		Interval          int     `json:"interval,omitempty"`
-		MonitorType       string  `json:"monitorType,omitempty"`
-		TargetAddress     *string `json:"targetAddress,omitempty"`
-		Timeout           int     `json:"timeout,omitempty"`
-		TimeUnitilUp      *int    `json:"timeUntilUp,omitempty"`
-		Adaptive          *bool   `json:"adaptive,omitempty"`
-		Dscp              *int    `json:"dscp,omitempty"`
-		Receive           string  `json:"receive"`
-		Send              string  `json:"send"`
-		TargetPort        int32   `json:"targetPort,omitempty"`
-		ClientCertificate string  `json:"clientCertificate,omitempty"`
-		Ciphers           string  `json:"ciphers,omitempty"`
+		Class             string              `json:"class,omitempty"`
+		Interval          int                 `json:"interval,omitempty"`
+		MonitorType       string              `json:"monitorType,omitempty"`
+		TargetAddress     *string             `json:"targetAddress,omitempty"`
+		Timeout           int                 `json:"timeout,omitempty"`
+		TimeUnitilUp      *int                `json:"timeUntilUp,omitempty"`
+		Adaptive          *bool               `json:"adaptive,omitempty"`
+		Dscp              *int                `json:"dscp,omitempty"`
+		Receive           string              `json:"receive"`
+		Send              string              `json:"send"`
+		TargetPort        int32               `json:"targetPort,omitempty"`
+		ClientCertificate string              `json:"clientCertificate,omitempty"`
+		Ciphers           string              `json:"ciphers,omitempty"`
+		UpInterval        int                 `json:"upInterval,omitempty"`
+		Reverse           bool                `json:"reverse,omitempty"`
+		ClientTLS         *as3ResourcePointer `json:"clientTLS,omitempty"`
+		QueryName         string              `json:"queryName,omitempty"`
+		QueryType         string              `json:"queryType,omitempty"`
+		Pathname          string              `json:"pathname,omitempty"`
+		Arguments         string              `json:"arguments,omitempty"`
+	}
+
+	// as3InbandMonitor maps to Monitor of type inband in AS3 Resources
+	as3InbandMonitor struct {
+		Class           string `json:"class,omitempty"`
+		MonitorType     string `json:"monitorType,omitempty"`
+		Failures        int    `json:"failures,omitempty"`
+		FailureInterval int    `json:"failureInterval,omitempty"`
+		ResponseTime    int    `json:"responseTime,omitempty"`
+		RetryTime       int    `json:"retryTime,omitempty"`
 	}
 
 	// as3CABundle maps to CA_Bundle in AS3 Resources
@@ -929,6 +1212,8 @@ type (
 		Ciphers             string              `json:"ciphers,omitempty"`
 		CipherGroup         *as3ResourcePointer `json:"cipherGroup,omitempty"`
 		TLS1_3Enabled       bool                `json:"tls1_3Enabled,omitempty"`
+		ServerName          string              `json:"serverName,omitempty"`
+		ClientCertificate   string              `json:"clientCertificate,omitempty"`
 	}
 
 	// as3DataGroup maps to Data_Group in AS3 Resources
@@ -955,6 +1240,13 @@ type (
 		Port    int32  `json:"port"`
 		SvcPort int32  `json:"svcPort,omitempty"`
 		Session string `json:"session,omitempty"`
+		Ratio   int    `json:"ratio,omitempty"`
+		// Cluster is the additional cluster of the member, empty for this cluster
+		Cluster       string `json:"cluster,omitempty"`
+		PriorityGroup int    `json:"priorityGroup,omitempty"`
+		// NodeName and PodName locate the labels of the member for the pool topology
+		NodeName string `json:"-"`
+		PodName  string `json:"-"`
 	}
 )
 
@@ -1074,11 +1366,16 @@ type (
 	}
 
 	ExtendedRouteGroupSpec struct {
-		VServerName   string `yaml:"vserverName"`
-		VServerAddr   string `yaml:"vserverAddr"`
-		AllowOverride string `yaml:"allowOverride"`
-		Policy        string `yaml:"policyCR,omitempty"`
-		Meta          Meta
+		VServerName            string   `yaml:"vserverName"`
+		VServerAddr            string   `yaml:"vserverAddr"`
+		AdditionalVServerAddrs []string `yaml:"additionalVserverAddrs,omitempty"`
+		IPAMLabel              string   `yaml:"ipamLabel,omitempty"`
+		HTTPPort               int32    `yaml:"vserverHTTPPort,omitempty"`
+		HTTPSPort              int32    `yaml:"vserverHTTPSPort,omitempty"`
+		SNAT                   string   `yaml:"snat,omitempty"`
+		AllowOverride          string   `yaml:"allowOverride"`
+		Policy                 string   `yaml:"policyCR,omitempty"`
+		Meta                   Meta
 	}
 
 	Meta struct {
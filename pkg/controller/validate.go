@@ -17,12 +17,42 @@
 package controller
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
 
 	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/resource"
 	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+	routeapi "github.com/openshift/api/route/v1"
 )
 
+// balanceModes are the load balancing modes supported by the balance annotation
+var balanceModes = map[string]struct{}{
+	"dynamic-ratio-member":              {},
+	"dynamic-ratio-node":                {},
+	"fastest-app-response":              {},
+	"fastest-node":                      {},
+	"least-connections-member":          {},
+	"least-connections-node":            {},
+	"least-sessions":                    {},
+	"observed-member":                   {},
+	"observed-node":                     {},
+	"predictive-member":                 {},
+	"predictive-node":                   {},
+	"ratio-least-connections-member":    {},
+	"ratio-least-connections-node":      {},
+	"ratio-member":                      {},
+	"ratio-node":                        {},
+	"ratio-session":                     {},
+	"round-robin":                       {},
+	"weighted-least-connections-member": {},
+	"weighted-least-connections-node":   {},
+}
+
 func (ctlr *Controller) checkValidVirtualServer(
 	vsResource *cisapiv1.VirtualServer,
 ) bool {
@@ -42,24 +72,41 @@ func (ctlr *Controller) checkValidVirtualServer(
 		log.Infof("VirtualServer %s is invalid", vsName)
 		return false
 	}
+	if err := ctlr.validateVirtualServerSpec(vsResource); err != nil {
+		log.Infof("%v", err)
+		return false
+	}
+
+	return true
+}
+
+// validateVirtualServerSpec validates the VirtualServer independent of the other resources
+func (ctlr *Controller) validateVirtualServerSpec(vsResource *cisapiv1.VirtualServer) error {
+	vsName := vsResource.ObjectMeta.Name
 	bindAddr := vsResource.Spec.VirtualServerAddress
 	if ctlr.ipamCli == nil {
-
-		// This ensures that pool-only mode only logs the message below the first
-		// time we see a config.
 		if bindAddr == "" {
-			log.Infof("No IP was specified for the virtual server %s", vsName)
-			return false
+			return fmt.Errorf("No IP was specified for the virtual server %s", vsName)
 		}
 	} else {
 		ipamLabel := vsResource.Spec.IPAMLabel
 		if ipamLabel == "" && bindAddr == "" {
-			log.Infof("No ipamLabel was specified for the virtual server %s", vsName)
-			return false
+			return fmt.Errorf("No ipamLabel was specified for the virtual server %s", vsName)
 		}
 	}
-
-	return true
+	for _, pool := range vsResource.Spec.Pools {
+		for _, mcSvc := range pool.MultiClusterServices {
+			if mcSvc.SvcName == "" {
+				return fmt.Errorf("No service was specified for the cluster %s in the virtual server %s",
+					mcSvc.ClusterName, vsName)
+			}
+			if _, ok := ctlr.multiClusters[mcSvc.ClusterName]; !ok {
+				return fmt.Errorf("Cluster %s of the virtual server %s is not configured",
+					mcSvc.ClusterName, vsName)
+			}
+		}
+	}
+	return nil
 }
 
 func (ctlr *Controller) checkValidTransportServer(
@@ -82,31 +129,84 @@ func (ctlr *Controller) checkValidTransportServer(
 		return false
 	}
 
+	if err := ctlr.validateTransportServerSpec(tsResource); err != nil {
+		log.Errorf("%v", err)
+		return false
+	}
+
+	if tsResource.Spec.Type == "" {
+		tsResource.Spec.Type = "tcp"
+	}
+
+	return true
+}
+
+// validateTransportServerSpec validates the TransportServer independent of the other resources
+func (ctlr *Controller) validateTransportServerSpec(tsResource *cisapiv1.TransportServer) error {
+	vsName := tsResource.ObjectMeta.Name
 	bindAddr := tsResource.Spec.VirtualServerAddress
 
 	if ctlr.ipamCli == nil {
-		// This ensures that pool-only mode only logs the message below the first
-		// time we see a config.
 		if bindAddr == "" {
-			log.Infof("No IP was specified for the transport server %s", vsName)
-			return false
+			return fmt.Errorf("No IP was specified for the transport server %s", vsName)
 		}
 	} else {
 		ipamLabel := tsResource.Spec.IPAMLabel
 		if ipamLabel == "" && bindAddr == "" {
-			log.Infof("No ipamLabel was specified for the transport server %s", vsName)
-			return false
+			return fmt.Errorf("No ipamLabel was specified for the transport server %s", vsName)
 		}
 	}
 
-	if tsResource.Spec.Type == "" {
-		tsResource.Spec.Type = "tcp"
-	} else if !(tsResource.Spec.Type == "udp" || tsResource.Spec.Type == "tcp" || tsResource.Spec.Type == "sctp") {
-		log.Errorf("Invalid type value for transport server %s. Supported values are tcp, udp and sctp only", vsName)
-		return false
+	switch tsResource.Spec.Type {
+	case "", "tcp", "udp", "sctp":
+	case "any":
+		if tsResource.Spec.Mode != "forwarding" {
+			return fmt.Errorf("Type any of transport server %s is supported in forwarding mode only", vsName)
+		}
+	default:
+		return fmt.Errorf("Invalid type value for transport server %s. Supported values are tcp, udp, sctp and any only", vsName)
+	}
+	if tsResource.Spec.TLSPassthrough {
+		if tsResource.Spec.Host == "" {
+			return fmt.Errorf("No host was specified for the TLS passthrough transport server %s", vsName)
+		}
+		if tsResource.Spec.Type != "" && tsResource.Spec.Type != "tcp" ||
+			tsResource.Spec.Mode == "performance" || tsResource.Spec.Mode == "forwarding" {
+			return fmt.Errorf("TLS passthrough transport server %s requires tcp type and standard mode", vsName)
+		}
+		if len(tsResource.Spec.VirtualServerPorts) > 0 {
+			return fmt.Errorf("TLS passthrough transport server %s does not support virtualServerPorts", vsName)
+		}
 	}
 
-	return true
+	ports, ranges, err := parseTransportServerPorts(tsResource)
+	if err != nil {
+		return fmt.Errorf("Invalid virtualServerPorts for transport server %s: %v", vsName, err)
+	}
+	if len(ranges) > 0 && tsResource.Spec.Type != "" && tsResource.Spec.Type != "tcp" && tsResource.Spec.Type != "udp" {
+		return fmt.Errorf("Port ranges of transport server %s require tcp or udp type", vsName)
+	}
+	if tsResource.Spec.Mode == "forwarding" {
+		if len(ranges) > 0 {
+			return fmt.Errorf("Port ranges of transport server %s are not supported in forwarding mode", vsName)
+		}
+		if tsResource.Spec.Pool.Service != "" {
+			return fmt.Errorf("Forwarding transport server %s can not have a pool", vsName)
+		}
+		return nil
+	}
+	if tsResource.Spec.Pool.Service == "" {
+		return fmt.Errorf("No pool service was specified for transport server %s", vsName)
+	}
+	for _, port := range ports {
+		if port == 0 && tsResource.Spec.Pool.ServicePort == 0 {
+			return fmt.Errorf("No pool servicePort was specified for the wildcard port of transport server %s", vsName)
+		}
+	}
+	if len(ranges) > 0 && tsResource.Spec.Pool.ServicePort == 0 {
+		return fmt.Errorf("No pool servicePort was specified for the port ranges of transport server %s", vsName)
+	}
+	return nil
 }
 
 func (ctlr *Controller) checkValidIngressLink(
@@ -129,19 +229,299 @@ func (ctlr *Controller) checkValidIngressLink(
 		return false
 	}
 
+	if err := ctlr.validateIngressLinkSpec(il); err != nil {
+		log.Infof("%v", err)
+		return false
+	}
+	return true
+}
+
+// validateIngressLinkSpec validates the IngressLink independent of the other resources
+func (ctlr *Controller) validateIngressLinkSpec(il *cisapiv1.IngressLink) error {
+	ilName := il.ObjectMeta.Name
 	bindAddr := il.Spec.VirtualServerAddress
 
 	if ctlr.ipamCli == nil {
 		if bindAddr == "" {
-			log.Infof("No IP was specified for ingresslink %s", ilName)
-			return false
+			return fmt.Errorf("No IP was specified for ingresslink %s", ilName)
 		}
 	} else {
 		ipamLabel := il.Spec.IPAMLabel
 		if ipamLabel == "" && bindAddr == "" {
-			log.Infof("No ipamLabel was specified for the il server %s", ilName)
-			return false
+			return fmt.Errorf("No ipamLabel was specified for the il server %s", ilName)
 		}
 	}
-	return true
+	if preset := il.Spec.IngressController; preset != "" {
+		if _, ok := ingressLinkMonitors[preset]; !ok {
+			return fmt.Errorf("Invalid ingressController %s for ingresslink %s", preset, ilName)
+		}
+	}
+	if il.Spec.Monitor != nil {
+		switch il.Spec.Monitor.Type {
+		case "", "http", "https", "tcp":
+		default:
+			return fmt.Errorf("Invalid monitor type %s for ingresslink %s", il.Spec.Monitor.Type, ilName)
+		}
+	}
+	switch il.Spec.ProxyProtocol {
+	case "", ProxyProtocolV1, ProxyProtocolV2:
+	default:
+		return fmt.Errorf("Invalid proxyProtocol %s for ingresslink %s", il.Spec.ProxyProtocol, ilName)
+	}
+	return nil
+}
+
+// validateRouteAnnotations validates the F5 annotations of the Route
+func validateRouteAnnotations(route *routeapi.Route) error {
+	annotations := route.ObjectMeta.Annotations
+	if balance, ok := annotations[resource.F5VsBalanceAnnotation]; ok {
+		if _, found := balanceModes[balance]; !found {
+			return fmt.Errorf("invalid %v annotation value %q", resource.F5VsBalanceAnnotation, balance)
+		}
+	}
+	if hmStr, ok := annotations[LegacyHealthMonitorAnnotation]; ok {
+		var monitors Monitors
+		if err := json.Unmarshal([]byte(hmStr), &monitors); err != nil {
+			return fmt.Errorf("invalid %v annotation value: %v", LegacyHealthMonitorAnnotation, err)
+		}
+	}
+	if urlRewrite, ok := annotations[resource.F5VsURLRewriteAnnotation]; ok {
+		if _, err := parseRouteURLRewrite(urlRewrite); err != nil {
+			return err
+		}
+	}
+	if appRoot, ok := annotations[resource.F5VsAppRootAnnotation]; ok {
+		if route.Spec.Path != "" && route.Spec.Path != "/" {
+			return fmt.Errorf("%v annotation can not be used with the path %v", resource.F5VsAppRootAnnotation, route.Spec.Path)
+		}
+		if _, err := parseRouteAppRoot(appRoot); err != nil {
+			return err
+		}
+	}
+	if _, err := getRouteAllowSourceRange(route); err != nil {
+		return err
+	}
+	if waf, ok := annotations[resource.F5VsWAFPolicy]; ok {
+		if !strings.HasPrefix(waf, "/") || len(strings.Split(waf, "/")) < 3 {
+			return fmt.Errorf("invalid %v annotation value %q, the WAF policy must be a BIG-IP path like /Common/WAF_Policy",
+				resource.F5VsWAFPolicy, waf)
+		}
+	}
+	if secure, ok := annotations[resource.F5ServerSslSecureAnnotation]; ok {
+		if _, err := strconv.ParseBool(secure); err != nil {
+			return fmt.Errorf("invalid %v annotation value %q", resource.F5ServerSslSecureAnnotation, secure)
+		}
+	}
+	return nil
+}
+
+// parseRouteURLRewrite returns the host and path of the rewrite-target-url annotation value
+func parseRouteURLRewrite(urlRewrite string) (*url.URL, error) {
+	if strings.ContainsAny(urlRewrite, ",=") {
+		return nil, fmt.Errorf("%v annotation does not support targeted values for routes", resource.F5VsURLRewriteAnnotation)
+	}
+	u := resource.ParseAnnotationURL(urlRewrite)
+	if u == nil || (u.Host == "" && u.Path == "") {
+		return nil, fmt.Errorf("invalid %v annotation value %q", resource.F5VsURLRewriteAnnotation, urlRewrite)
+	}
+	return u, nil
+}
+
+// parseRouteAppRoot returns the path of the rewrite-app-root annotation value
+func parseRouteAppRoot(appRoot string) (string, error) {
+	if strings.ContainsAny(appRoot, ",=") {
+		return "", fmt.Errorf("%v annotation does not support targeted values for routes", resource.F5VsAppRootAnnotation)
+	}
+	u := resource.ParseAnnotationURL(appRoot)
+	if u == nil || u.Host != "" || u.Path == "" || u.Path == "/" {
+		return "", fmt.Errorf("invalid %v annotation value %q, the value must be a path", resource.F5VsAppRootAnnotation, appRoot)
+	}
+	return u.Path, nil
+}
+
+// getRouteAllowSourceRange returns the CIDRs of the whitelist-source-range or
+// allow-source-range annotation, whitelist-source-range takes precedence
+func getRouteAllowSourceRange(route *routeapi.Route) ([]string, error) {
+	annotation := resource.F5VsWhitelistSourceRangeAnnotation
+	sourceRange, ok := route.ObjectMeta.Annotations[annotation]
+	if !ok {
+		annotation = resource.F5VsAllowSourceRangeAnnotation
+		if sourceRange, ok = route.ObjectMeta.Annotations[annotation]; !ok {
+			return nil, nil
+		}
+	}
+	var cidrs []string
+	for _, cidr := range strings.Split(sourceRange, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return nil, fmt.Errorf("invalid %v annotation value %q, expected comma separated CIDRs", annotation, cidr)
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return cidrs, nil
+}
+
+// checkVirtualServerConflicts validates the TLSProfile and Policy of the VirtualServer and
+// the hostGroup, host, port and path it shares with the other VirtualServers
+func (ctlr *Controller) checkVirtualServerConflicts(vs *cisapiv1.VirtualServer) error {
+	if vs.Spec.TLSProfileName != "" {
+		crInf, ok := ctlr.getNamespacedCRInformer(vs.Namespace)
+		if !ok {
+			return fmt.Errorf("Informer not found for namespace: %v", vs.Namespace)
+		}
+		tlsKey := vs.Namespace + "/" + vs.Spec.TLSProfileName
+		obj, found, _ := crInf.tlsInformer.GetIndexer().GetByKey(tlsKey)
+		if !found {
+			return fmt.Errorf("TLSProfile %s does not exist", tlsKey)
+		}
+		if err := checkTLSProfile(obj.(*cisapiv1.TLSProfile)); err != nil {
+			return err
+		}
+	}
+	if vs.Spec.PolicyName != "" {
+		plc, err := ctlr.getPolicy(vs.Namespace, vs.Spec.PolicyName)
+		if err != nil {
+			return err
+		}
+		if err := validatePolicyHTTPProfile(plc); err != nil {
+			return err
+		}
+	}
+
+	ports := make(map[int32]struct{})
+	for _, portStruct := range ctlr.virtualPorts(vs) {
+		ports[portStruct.port] = struct{}{}
+	}
+
+	ctlr.namespacesMutex.Lock()
+	allVirtuals := ctlr.getAllVSFromMonitoredNamespaces()
+	ctlr.namespacesMutex.Unlock()
+
+	for _, vrt := range allVirtuals {
+		if vrt.Namespace == vs.Namespace && vrt.Name == vs.Name {
+			continue
+		}
+		vrtKey := vrt.Namespace + "/" + vrt.Name
+		if vs.Spec.HostGroup != "" && vrt.Spec.HostGroup == vs.Spec.HostGroup &&
+			(vrt.Spec.VirtualServerAddress != vs.Spec.VirtualServerAddress || vrt.Spec.IPAMLabel != vs.Spec.IPAMLabel) {
+			return fmt.Errorf("hostGroup %v is configured with virtualServerAddress %q and ipamLabel %q in VirtualServer %v",
+				vs.Spec.HostGroup, vrt.Spec.VirtualServerAddress, vrt.Spec.IPAMLabel, vrtKey)
+		}
+		if vrt.Spec.HostGroup != vs.Spec.HostGroup || vrt.Spec.Host != vs.Spec.Host {
+			continue
+		}
+		if vrt.Spec.VirtualServerAddress != vs.Spec.VirtualServerAddress || vrt.Spec.IPAMLabel != vs.Spec.IPAMLabel {
+			if vs.Spec.Host != "" && vs.Spec.HostGroup == "" {
+				return fmt.Errorf("host %v is configured with virtualServerAddress %q and ipamLabel %q in VirtualServer %v",
+					vs.Spec.Host, vrt.Spec.VirtualServerAddress, vrt.Spec.IPAMLabel, vrtKey)
+			}
+			continue
+		}
+		for _, portStruct := range ctlr.virtualPorts(vrt) {
+			if _, ok := ports[portStruct.port]; !ok {
+				continue
+			}
+			for _, pool := range vs.Spec.Pools {
+				for _, vrtPool := range vrt.Spec.Pools {
+					if pool.Path == vrtPool.Path {
+						return fmt.Errorf("host %q path %q on port %v is already used by VirtualServer %v",
+							vs.Spec.Host, pool.Path, portStruct.port, vrtKey)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validatePolicyHTTPProfile rejects a Policy with both an http profile and the httpOptions
+// which need the HTTP profile created by CIS, as a virtual has a single HTTP profile
+func validatePolicyHTTPProfile(plc *cisapiv1.Policy) error {
+	httpOptions := plc.Spec.Profiles.HTTPOptions
+	if plc.Spec.Profiles.HTTP == "" || httpOptions == nil || (!httpOptions.XForwardedFor && httpOptions.HSTS == nil) {
+		return nil
+	}
+	return fmt.Errorf("Policy %v/%v sets both the http profile %v and xForwardedFor or hsts in httpOptions, "+
+		"which need an HTTP profile created by CIS", plc.Namespace, plc.Name, plc.Spec.Profiles.HTTP)
+}
+
+// checkTransportServerConflicts validates the Policy of the TransportServer and the
+// hostGroup, address and port it shares with the other TransportServers
+func (ctlr *Controller) checkTransportServerConflicts(ts *cisapiv1.TransportServer) error {
+	if ts.Spec.PolicyName != "" {
+		if _, err := ctlr.getPolicy(ts.Namespace, ts.Spec.PolicyName); err != nil {
+			return err
+		}
+	}
+
+	ports := make(map[int32]struct{})
+	tsPorts, tsRanges, _ := parseTransportServerPorts(ts)
+	for _, port := range tsPorts {
+		ports[port] = struct{}{}
+	}
+
+	ctlr.namespacesMutex.Lock()
+	allVirtuals := ctlr.getAllTSFromMonitoredNamespaces()
+	ctlr.namespacesMutex.Unlock()
+
+	for _, vrt := range allVirtuals {
+		if vrt.Namespace == ts.Namespace && vrt.Name == ts.Name {
+			continue
+		}
+		vrtKey := vrt.Namespace + "/" + vrt.Name
+		if ts.Spec.HostGroup != "" && vrt.Spec.HostGroup == ts.Spec.HostGroup &&
+			(vrt.Spec.VirtualServerAddress != ts.Spec.VirtualServerAddress || vrt.Spec.IPAMLabel != ts.Spec.IPAMLabel) {
+			return fmt.Errorf("hostGroup %v is configured with virtualServerAddress %q and ipamLabel %q in TransportServer %v",
+				ts.Spec.HostGroup, vrt.Spec.VirtualServerAddress, vrt.Spec.IPAMLabel, vrtKey)
+		}
+		// TLS passthrough TransportServers share the address and port unless the hosts match
+		if ts.Spec.TLSPassthrough && vrt.Spec.TLSPassthrough && !strings.EqualFold(ts.Spec.Host, vrt.Spec.Host) {
+			continue
+		}
+		if ts.Spec.VirtualServerAddress == "" || vrt.Spec.VirtualServerAddress != ts.Spec.VirtualServerAddress {
+			continue
+		}
+		vrtPorts, vrtRanges, _ := parseTransportServerPorts(vrt)
+		for _, vrtPort := range vrtPorts {
+			if _, ok := ports[vrtPort]; ok {
+				return fmt.Errorf("address %v port %v is already used by TransportServer %v",
+					ts.Spec.VirtualServerAddress, vrtPort, vrtKey)
+			}
+		}
+		// the port ranges of the address share a virtual, which selects the pool by the port
+		if getTransportServerProtocol(vrt) != getTransportServerProtocol(ts) {
+			continue
+		}
+		for _, rng := range tsRanges {
+			for _, vrtRng := range vrtRanges {
+				if rng.overlaps(vrtRng) {
+					return fmt.Errorf("address %v port range %v overlaps the port range %v of TransportServer %v",
+						ts.Spec.VirtualServerAddress, rng, vrtRng, vrtKey)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkIngressLinkConflicts validates the address the IngressLink shares with the other IngressLinks
+func (ctlr *Controller) checkIngressLinkConflicts(il *cisapiv1.IngressLink) error {
+	if il.Spec.VirtualServerAddress == "" {
+		return nil
+	}
+
+	ctlr.namespacesMutex.Lock()
+	allIngLinks := ctlr.getAllIngLinkFromMonitoredNamespaces()
+	ctlr.namespacesMutex.Unlock()
+
+	for _, ingLink := range allIngLinks {
+		if ingLink.Namespace == il.Namespace && ingLink.Name == il.Name {
+			continue
+		}
+		if ingLink.Spec.VirtualServerAddress == il.Spec.VirtualServerAddress {
+			return fmt.Errorf("address %v is already used by IngressLink %v/%v",
+				il.Spec.VirtualServerAddress, ingLink.Namespace, ingLink.Name)
+		}
+	}
+	return nil
 }
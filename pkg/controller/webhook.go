@@ -0,0 +1,139 @@
+/*-
+ * Copyright (c) 2019-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// AdmissionWebhookPath is the path of the ValidatingAdmissionWebhook
+const AdmissionWebhookPath = "/validate"
+
+// startAdmissionWebhook serves the ValidatingAdmissionWebhook over HTTPS on the address
+func (ctlr *Controller) startAdmissionWebhook(address, certFile, keyFile string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(AdmissionWebhookPath, ctlr.admissionHandler)
+	log.Infof("Serving the admission webhook on %v", address)
+	log.Fatal(http.ListenAndServeTLS(address, certFile, keyFile, mux).Error())
+}
+
+// admissionHandler validates the AdmissionReview of the CIS custom resources and
+// denies the request with the validation error
+func (ctlr *Controller) admissionHandler(w http.ResponseWriter, r *http.Request) {
+	var review admissionv1.AdmissionReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil || review.Request == nil {
+		http.Error(w, "invalid AdmissionReview", http.StatusBadRequest)
+		return
+	}
+	req := review.Request
+	review.Request = nil
+	review.Response = &admissionv1.AdmissionResponse{
+		UID:     req.UID,
+		Allowed: true,
+	}
+	if err := ctlr.validateAdmissionRequest(req); err != nil {
+		log.Infof("[Webhook] Denied %v %v/%v: %v", req.Kind.Kind, req.Namespace, req.Name, err)
+		review.Response.Allowed = false
+		review.Response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		log.Errorf("[Webhook] Unable to write the AdmissionReview: %v", err)
+	}
+}
+
+// validateAdmissionRequest runs the processing time validations and the cross resource
+// validations of the VirtualServer, TransportServer, IngressLink and TLSProfile
+func (ctlr *Controller) validateAdmissionRequest(req *admissionv1.AdmissionRequest) error {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return nil
+	}
+	// the informers and the resource store are updated by the resource worker
+	ctlr.resourcesMutex.RLock()
+	defer ctlr.resourcesMutex.RUnlock()
+	switch req.Kind.Kind {
+	case VirtualServer:
+		vs := &cisapiv1.VirtualServer{}
+		if err := json.Unmarshal(req.Object.Raw, vs); err != nil {
+			return fmt.Errorf("unable to decode VirtualServer: %v", err)
+		}
+		if !ctlr.isAdmissionInScope(vs.ObjectMeta) {
+			return nil
+		}
+		if err := ctlr.validateVirtualServerSpec(vs); err != nil {
+			return err
+		}
+		return ctlr.checkVirtualServerConflicts(vs)
+	case TransportServer:
+		ts := &cisapiv1.TransportServer{}
+		if err := json.Unmarshal(req.Object.Raw, ts); err != nil {
+			return fmt.Errorf("unable to decode TransportServer: %v", err)
+		}
+		if !ctlr.isAdmissionInScope(ts.ObjectMeta) {
+			return nil
+		}
+		if err := ctlr.validateTransportServerSpec(ts); err != nil {
+			return err
+		}
+		return ctlr.checkTransportServerConflicts(ts)
+	case IngressLink:
+		il := &cisapiv1.IngressLink{}
+		if err := json.Unmarshal(req.Object.Raw, il); err != nil {
+			return fmt.Errorf("unable to decode IngressLink: %v", err)
+		}
+		if !ctlr.isAdmissionInScope(il.ObjectMeta) {
+			return nil
+		}
+		if err := ctlr.validateIngressLinkSpec(il); err != nil {
+			return err
+		}
+		return ctlr.checkIngressLinkConflicts(il)
+	case TLSProfile:
+		tls := &cisapiv1.TLSProfile{}
+		if err := json.Unmarshal(req.Object.Raw, tls); err != nil {
+			return fmt.Errorf("unable to decode TLSProfile: %v", err)
+		}
+		if !ctlr.isAdmissionInScope(tls.ObjectMeta) {
+			return nil
+		}
+		return checkTLSProfile(tls)
+	}
+	return nil
+}
+
+// isAdmissionInScope returns true for the custom resources CIS processes, i.e. the
+// ones with the f5cr label in the monitored namespaces
+func (ctlr *Controller) isAdmissionInScope(meta metav1.ObjectMeta) bool {
+	if !ctlr.customResourceSelector.Matches(labels.Set(meta.Labels)) {
+		return false
+	}
+	_, ok := ctlr.getNamespacedCRInformer(meta.Namespace)
+	return ok
+}
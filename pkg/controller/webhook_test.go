@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+	crdfake "github.com/F5Networks/k8s-bigip-ctlr/v2/config/client/clientset/versioned/fake"
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Admission Webhook Tests", func() {
+	var mockCtlr *mockController
+	namespace := "default"
+
+	review := func(kind string, obj interface{}) *admissionv1.AdmissionResponse {
+		raw, _ := json.Marshal(obj)
+		body, _ := json.Marshal(admissionv1.AdmissionReview{
+			TypeMeta: metav1.TypeMeta{Kind: "AdmissionReview", APIVersion: "admission.k8s.io/v1"},
+			Request: &admissionv1.AdmissionRequest{
+				UID:       "uid",
+				Kind:      metav1.GroupVersionKind{Group: "cis.f5.com", Version: "v1", Kind: kind},
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		})
+		rec := httptest.NewRecorder()
+		mockCtlr.admissionHandler(rec, httptest.NewRequest(http.MethodPost, AdmissionWebhookPath, bytes.NewReader(body)))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		var resp admissionv1.AdmissionReview
+		Expect(json.Unmarshal(rec.Body.Bytes(), &resp)).To(BeNil())
+		Expect(resp.Response.UID).To(BeEquivalentTo("uid"))
+		return resp.Response
+	}
+
+	newVS := func(name string, spec cisapiv1.VirtualServerSpec) *cisapiv1.VirtualServer {
+		vs := test.NewVirtualServer(name, namespace, spec)
+		vs.Labels = map[string]string{"f5cr": "true"}
+		return vs
+	}
+
+	BeforeEach(func() {
+		mockCtlr = newMockController()
+		mockCtlr.mode = CustomResourceMode
+		mockCtlr.kubeCRClient = crdfake.NewSimpleClientset()
+		mockCtlr.kubeClient = k8sfake.NewSimpleClientset()
+		mockCtlr.crInformers = make(map[string]*CRInformer)
+		mockCtlr.comInformers = make(map[string]*CommonInformer)
+		mockCtlr.namespaces = map[string]bool{namespace: true}
+		mockCtlr.customResourceSelector, _ = createLabelSelector(DefaultCustomResourceLabel)
+		_ = mockCtlr.addNamespacedInformers(namespace, false)
+	})
+
+	It("Rejects invalid requests", func() {
+		rec := httptest.NewRecorder()
+		mockCtlr.admissionHandler(rec, httptest.NewRequest(http.MethodPost, AdmissionWebhookPath, bytes.NewReader([]byte("{"))))
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("Validates the VirtualServer", func() {
+		spec := cisapiv1.VirtualServerSpec{
+			Host:  "foo.com",
+			Pools: []cisapiv1.Pool{{Path: "/foo", Service: "svc", ServicePort: 80}},
+		}
+		resp := review(VirtualServer, newVS("vs1", spec))
+		Expect(resp.Allowed).To(BeFalse(), "VirtualServer without address should be denied")
+		Expect(resp.Result.Message).To(ContainSubstring("No IP was specified"))
+
+		// resources without the f5cr label are not processed by CIS
+		Expect(review(VirtualServer, test.NewVirtualServer("vs1", namespace, spec)).Allowed).To(BeTrue())
+
+		spec.VirtualServerAddress = "10.1.1.1"
+		Expect(review(VirtualServer, newVS("vs1", spec)).Allowed).To(BeTrue())
+
+		spec.TLSProfileName = "tls"
+		resp = review(VirtualServer, newVS("vs1", spec))
+		Expect(resp.Allowed).To(BeFalse(), "VirtualServer with missing TLSProfile should be denied")
+		Expect(resp.Result.Message).To(ContainSubstring("TLSProfile default/tls does not exist"))
+
+		spec.TLSProfileName = ""
+		spec.PolicyName = "plc"
+		resp = review(VirtualServer, newVS("vs1", spec))
+		Expect(resp.Allowed).To(BeFalse(), "VirtualServer with missing Policy should be denied")
+		mockCtlr.addPolicy(test.NewPolicy("plc", namespace, cisapiv1.PolicySpec{}))
+		Expect(review(VirtualServer, newVS("vs1", spec)).Allowed).To(BeTrue())
+	})
+
+	It("Validates while the resource worker is not updating the resources", func() {
+		spec := cisapiv1.VirtualServerSpec{
+			Host:                 "foo.com",
+			VirtualServerAddress: "10.1.1.1",
+			Pools:                []cisapiv1.Pool{{Path: "/foo", Service: "svc", ServicePort: 80}},
+		}
+		done := make(chan bool)
+		mockCtlr.resourcesMutex.Lock()
+		go func() {
+			defer GinkgoRecover()
+			done <- review(VirtualServer, newVS("vs1", spec)).Allowed
+		}()
+		Consistently(done, "200ms").ShouldNot(Receive())
+		mockCtlr.resourcesMutex.Unlock()
+		Eventually(done).Should(Receive(BeTrue()))
+	})
+
+	It("Validates the VirtualServer against other VirtualServers", func() {
+		mockCtlr.addVirtualServer(newVS("vs1", cisapiv1.VirtualServerSpec{
+			Host:                 "foo.com",
+			VirtualServerAddress: "10.1.1.1",
+			HostGroup:            "hg",
+			Pools:                []cisapiv1.Pool{{Path: "/foo", Service: "svc", ServicePort: 80}},
+		}))
+		mockCtlr.addVirtualServer(newVS("vs2", cisapiv1.VirtualServerSpec{
+			Host:                 "bar.com",
+			VirtualServerAddress: "10.1.1.2",
+			Pools:                []cisapiv1.Pool{{Path: "/bar", Service: "svc", ServicePort: 80}},
+		}))
+
+		spec := cisapiv1.VirtualServerSpec{
+			Host:                 "bar.com",
+			VirtualServerAddress: "10.1.1.2",
+			Pools:                []cisapiv1.Pool{{Path: "/bar", Service: "svc", ServicePort: 80}},
+		}
+		resp := review(VirtualServer, newVS("vs3", spec))
+		Expect(resp.Allowed).To(BeFalse(), "Duplicate host path should be denied")
+		Expect(resp.Result.Message).To(ContainSubstring("already used by VirtualServer default/vs2"))
+
+		// update of the same VirtualServer
+		Expect(review(VirtualServer, newVS("vs2", spec)).Allowed).To(BeTrue())
+
+		spec.VirtualServerHTTPPort = 8080
+		Expect(review(VirtualServer, newVS("vs3", spec)).Allowed).To(BeTrue(), "Different port should be allowed")
+
+		spec.VirtualServerHTTPPort = 0
+		spec.Pools[0].Path = "/baz"
+		Expect(review(VirtualServer, newVS("vs3", spec)).Allowed).To(BeTrue(), "Different path should be allowed")
+
+		spec.VirtualServerAddress = "10.1.1.3"
+		resp = review(VirtualServer, newVS("vs3", spec))
+		Expect(resp.Allowed).To(BeFalse(), "Same host with other address should be denied")
+
+		resp = review(VirtualServer, newVS("vs3", cisapiv1.VirtualServerSpec{
+			Host:                 "baz.com",
+			VirtualServerAddress: "10.1.1.4",
+			HostGroup:            "hg",
+		}))
+		Expect(resp.Allowed).To(BeFalse(), "Conflicting hostGroup should be denied")
+		Expect(resp.Result.Message).To(ContainSubstring("hostGroup hg"))
+	})
+
+	It("Validates the TransportServer", func() {
+		ts := test.NewTransportServer("ts1", namespace, cisapiv1.TransportServerSpec{
+			VirtualServerAddress: "10.1.1.1",
+			VirtualServerPort:    1600,
+			Type:                 "tcp",
+			Pool:                 cisapiv1.Pool{Service: "svc1", ServicePort: 80},
+		})
+		ts.Labels = map[string]string{"f5cr": "true"}
+		mockCtlr.addTransportServer(ts)
+
+		ts2 := ts.DeepCopy()
+		ts2.Name = "ts2"
+		resp := review(TransportServer, ts2)
+		Expect(resp.Allowed).To(BeFalse(), "Duplicate address and port should be denied")
+		Expect(resp.Result.Message).To(ContainSubstring("already used by TransportServer default/ts1"))
+
+		ts2.Spec.VirtualServerPort = 1601
+		Expect(review(TransportServer, ts2).Allowed).To(BeTrue())
+
+		ts2.Spec.Type = "http"
+		Expect(review(TransportServer, ts2).Allowed).To(BeFalse(), "Invalid type should be denied")
+	})
+
+	It("Validates the IngressLink and TLSProfile", func() {
+		il := test.NewIngressLink("il1", namespace, "1", cisapiv1.IngressLinkSpec{})
+		il.Labels = map[string]string{"f5cr": "true"}
+		Expect(review(IngressLink, il).Allowed).To(BeFalse(), "IngressLink without address should be denied")
+		il.Spec.VirtualServerAddress = "10.1.1.1"
+		Expect(review(IngressLink, il).Allowed).To(BeTrue())
+
+		tls := test.NewTLSProfile("tls", namespace, cisapiv1.TLSProfileSpec{
+			TLS: cisapiv1.TLS{Termination: "edge", Reference: "bigip"},
+		})
+		tls.Labels = map[string]string{"f5cr": "true"}
+		resp := review(TLSProfile, tls)
+		Expect(resp.Allowed).To(BeFalse(), "Edge TLSProfile without client SSL should be denied")
+		Expect(resp.Result.Message).To(ContainSubstring("should contain ClientSSLs"))
+		tls.Spec.TLS.ClientSSL = "/Common/clientssl"
+		Expect(review(TLSProfile, tls).Allowed).To(BeTrue())
+	})
+})
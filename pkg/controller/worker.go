@@ -32,13 +32,34 @@ import (
 	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
 	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
 	routeapi "github.com/openshift/api/route/v1"
+	"go.opentelemetry.io/otel/trace"
 	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 )
 
-const nginxMonitorPort int32 = 8081
+// Presets of IngressLink.spec.ingressController
+const (
+	NginxIngressController   = "nginx"
+	IstioIngressController   = "istio"
+	ContourIngressController = "contour"
+	TraefikIngressController = "traefik"
+)
+
+// ingressLinkMonitors are the readiness monitors of the ingress controller presets
+var ingressLinkMonitors = map[string]cisapiv1.IngressLinkMonitor{
+	NginxIngressController:   {Type: "http", Path: "/nginx-ready", Port: 8081},
+	IstioIngressController:   {Type: "http", Path: "/healthz/ready", Port: 15021},
+	ContourIngressController: {Type: "http", Path: "/ready", Port: 8002},
+	TraefikIngressController: {Type: "http", Path: "/ping", Port: 9000},
+}
+
+// Constants for IngressLink.spec.proxyProtocol
+const (
+	ProxyProtocolV1 = "v1"
+	ProxyProtocolV2 = "v2"
+)
 
 const (
 	NotEnabled = iota
@@ -54,7 +75,9 @@ func (ctlr *Controller) nextGenResourceWorker() {
 	ctlr.setInitialServiceCount()
 	ctlr.migrateIPAM()
 	if ctlr.mode == OpenShiftMode {
+		ctlr.resourcesMutex.Lock()
 		ctlr.processGlobalExtendedRouteConfig()
+		ctlr.resourcesMutex.Unlock()
 	}
 	for ctlr.processResources() {
 	}
@@ -93,8 +116,18 @@ func (ctlr *Controller) processResources() bool {
 	var isRetryableError bool
 
 	defer ctlr.resourceQueue.Done(key)
+	ctlr.resourcesMutex.Lock()
+	defer ctlr.resourcesMutex.Unlock()
 	rKey := key.(*rqKey)
-	log.Debugf("Processing Key: %v", rKey)
+	rscLog := log.WithFields(log.Fields{
+		"kind":      rKey.kind,
+		"namespace": rKey.namespace,
+		"name":      rKey.rscName,
+		"event":     rKey.event,
+	})
+	rscLog.Debug("[CORE] Processing Key")
+	span := ctlr.startResourceSpan(rKey)
+	defer span.End()
 
 	// During Init time, just accumulate all the poolMembers by processing only services
 	if ctlr.initState && rKey.kind != Namespace {
@@ -113,6 +146,16 @@ func (ctlr *Controller) processResources() bool {
 		rscDelete = true
 	}
 
+	if ctlr.mode == CustomResourceMode && isAddressClaimant(rKey) {
+		// A deleted resource is processed before resolving the conflicts, so that
+		// the virtuals of a conflicted resource are left to the older resource
+		if rscDelete {
+			defer ctlr.updateConflicts()
+		} else {
+			ctlr.updateConflicts()
+		}
+	}
+
 	// Check the type of resource and process accordingly.
 	switch rKey.kind {
 	case Route:
@@ -140,12 +183,13 @@ func (ctlr *Controller) processResources() bool {
 			})
 			// Delete the route entry from hostPath Map
 			ctlr.deleteHostPathMapEntry(route)
+			ctlr.clearConflict(Route, route.Namespace+"/"+route.Name)
 		}
 		if routeGroup, ok := ctlr.resources.invertedNamespaceLabelMap[route.Namespace]; ok {
 			err := ctlr.processRoutes(routeGroup, false)
 			if err != nil {
 				// TODO
-				utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+				rscLog.Errorf("[CORE] Sync failed with %v", err)
 				isRetryableError = true
 			}
 		}
@@ -157,13 +201,22 @@ func (ctlr *Controller) processResources() bool {
 		cm := rKey.rsc.(*v1.ConfigMap)
 		err, ok := ctlr.processConfigMap(cm, rscDelete)
 		if err != nil {
-			utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+			rscLog.Errorf("[CORE] Sync failed with %v", err)
 			break
 		}
 
 		if !ok {
 			isRetryableError = true
 		}
+	case RouteGroup:
+		if ctlr.mode != OpenShiftMode {
+			break
+		}
+		err := ctlr.processRouteGroup(rKey.rsc.(*cisapiv1.RouteGroup))
+		if err != nil {
+			rscLog.Errorf("[CORE] Sync failed with %v", err)
+			isRetryableError = true
+		}
 	case VirtualServer:
 		if ctlr.mode == OpenShiftMode || ctlr.mode == KubernetesMode {
 			break
@@ -186,9 +239,12 @@ func (ctlr *Controller) processResources() bool {
 		err := ctlr.processVirtualServers(virtual, rscDelete)
 		if err != nil {
 			// TODO
-			utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+			rscLog.Errorf("[CORE] Sync failed with %v", err)
 			isRetryableError = true
 		}
+		if rscDelete {
+			ctlr.deleteEffectivePolicy(virtual.Namespace, virtual.Spec.PolicyName)
+		}
 	case TLSProfile:
 		if ctlr.mode == OpenShiftMode || ctlr.mode == KubernetesMode {
 			break
@@ -203,7 +259,7 @@ func (ctlr *Controller) processResources() bool {
 			err := ctlr.processVirtualServers(virtual, false)
 			if err != nil {
 				// TODO
-				utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+				rscLog.Errorf("[CORE] Sync failed with %v", err)
 				isRetryableError = true
 			}
 		}
@@ -216,6 +272,21 @@ func (ctlr *Controller) processResources() bool {
 				ctlr.processRoutes(routeGroup, false)
 			}
 		default:
+			// resources with an https monitor using the secret as client certificate
+			for _, virtual := range ctlr.getVirtualServersForMonitorSecret(secret) {
+				err := ctlr.processVirtualServers(virtual, false)
+				if err != nil {
+					rscLog.Errorf("[CORE] Sync failed with %v", err)
+					isRetryableError = true
+				}
+			}
+			for _, virtual := range ctlr.getTransportServersForMonitorSecret(secret) {
+				err := ctlr.processTransportServers(virtual, false)
+				if err != nil {
+					rscLog.Errorf("[CORE] Sync failed with %v", err)
+					isRetryableError = true
+				}
+			}
 			tlsProfiles := ctlr.getTLSProfilesForSecret(secret)
 			for _, tlsProfile := range tlsProfiles {
 				virtuals := ctlr.getVirtualsForTLSProfile(tlsProfile)
@@ -227,7 +298,7 @@ func (ctlr *Controller) processResources() bool {
 					err := ctlr.processVirtualServers(virtual, false)
 					if err != nil {
 						// TODO
-						utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+						rscLog.Errorf("[CORE] Sync failed with %v", err)
 						isRetryableError = true
 					}
 				}
@@ -242,9 +313,12 @@ func (ctlr *Controller) processResources() bool {
 		err := ctlr.processTransportServers(virtual, rscDelete)
 		if err != nil {
 			// TODO
-			utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+			rscLog.Errorf("[CORE] Sync failed with %v", err)
 			isRetryableError = true
 		}
+		if rscDelete {
+			ctlr.deleteEffectivePolicy(virtual.Namespace, virtual.Spec.PolicyName)
+		}
 	case IngressLink:
 		if ctlr.mode == OpenShiftMode || ctlr.mode == KubernetesMode {
 			break
@@ -255,7 +329,7 @@ func (ctlr *Controller) processResources() bool {
 		err := ctlr.processIngressLink(ingLink, rscDelete)
 		if err != nil {
 			// TODO
-			utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+			rscLog.Errorf("[CORE] Sync failed with %v", err)
 			isRetryableError = true
 		}
 	case ExternalDNS:
@@ -270,6 +344,9 @@ func (ctlr *Controller) processResources() bool {
 
 	case CustomPolicy:
 		cp := rKey.rsc.(*cisapiv1.Policy)
+		if rscDelete {
+			ctlr.deleteEffectivePoliciesForPolicy(cp)
+		}
 		switch ctlr.mode {
 		case OpenShiftMode:
 			routeGroups := ctlr.getRouteGroupForCustomPolicy(cp.Namespace + "/" + cp.Name)
@@ -283,7 +360,7 @@ func (ctlr *Controller) processResources() bool {
 				err := ctlr.processVirtualServers(virtual, false)
 				if err != nil {
 					// TODO
-					utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+					rscLog.Errorf("[CORE] Sync failed with %v", err)
 					isRetryableError = true
 				}
 			}
@@ -293,7 +370,7 @@ func (ctlr *Controller) processResources() bool {
 				err := ctlr.processTransportServers(virtual, false)
 				if err != nil {
 					// TODO
-					utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+					rscLog.Errorf("[CORE] Sync failed with %v", err)
 					isRetryableError = true
 				}
 			}
@@ -303,7 +380,7 @@ func (ctlr *Controller) processResources() bool {
 				err := ctlr.processLBServices(lbService, false)
 				if err != nil {
 					// TODO
-					utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+					rscLog.Errorf("[CORE] Sync failed with %v", err)
 					isRetryableError = true
 				}
 			}
@@ -317,9 +394,12 @@ func (ctlr *Controller) processResources() bool {
 			err := ctlr.processLBServices(svc, rscDelete)
 			if err != nil {
 				// TODO
-				utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+				rscLog.Errorf("[CORE] Sync failed with %v", err)
 				isRetryableError = true
 			}
+			if rscDelete {
+				ctlr.deleteEffectivePolicy(svc.Namespace, svc.Annotations[LBServicePolicyNameAnnotation])
+			}
 			break
 		}
 		if ctlr.initState {
@@ -336,7 +416,7 @@ func (ctlr *Controller) processResources() bool {
 					err := ctlr.processVirtualServers(virtual, false)
 					if err != nil {
 						// TODO
-						utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+						rscLog.Errorf("[CORE] Sync failed with %v", err)
 						isRetryableError = true
 					}
 				}
@@ -348,7 +428,7 @@ func (ctlr *Controller) processResources() bool {
 					err := ctlr.processTransportServers(virtual, false)
 					if err != nil {
 						// TODO
-						utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+						rscLog.Errorf("[CORE] Sync failed with %v", err)
 						isRetryableError = true
 					}
 				}
@@ -361,10 +441,10 @@ func (ctlr *Controller) processResources() bool {
 					err := ctlr.processIngressLink(ingLink, rscDelete)
 					if err != nil {
 						if rscDelete {
-							utilruntime.HandleError(fmt.Errorf("Deleting IngresLink %v failed with %v", ingLink.Name, err))
+							rscLog.Errorf("[CORE] Deleting IngresLink %v failed with %v", ingLink.Name, err)
 						} else {
 							// TODO
-							utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+							rscLog.Errorf("[CORE] Sync failed with %v", err)
 						}
 						isRetryableError = true
 					}
@@ -386,9 +466,12 @@ func (ctlr *Controller) processResources() bool {
 			err := ctlr.processLBServices(svc, rscDelete)
 			if err != nil {
 				// TODO
-				utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+				rscLog.Errorf("[CORE] Sync failed with %v", err)
 				isRetryableError = true
 			}
+			if rscDelete {
+				ctlr.deleteEffectivePolicy(svc.Namespace, svc.Annotations[LBServicePolicyNameAnnotation])
+			}
 			break
 		}
 		switch ctlr.mode {
@@ -399,6 +482,31 @@ func (ctlr *Controller) processResources() bool {
 			ctlr.updatePoolMembersForVirtuals(svc)
 		}
 
+	case MultiClusterService:
+		if ctlr.mode != CustomResourceMode {
+			break
+		}
+		// just update the pool members of the cluster instead of processing the virtuals entirely
+		ctlr.updatePoolMembersForMultiClusterService(rKey.rsc.(cisapiv1.MultiClusterServiceReference))
+
+	case MultiClusterSecret:
+		if ctlr.mode != CustomResourceMode {
+			break
+		}
+		secret := rKey.rsc.(*v1.Secret)
+		if !ctlr.updateMultiCluster(secret, rscDelete) {
+			break
+		}
+		// the VirtualServers of the cluster are validated again when the cluster is added or removed
+		ref := cisapiv1.MultiClusterServiceReference{ClusterName: secret.Name}
+		for _, virtual := range ctlr.getVirtualServersForMultiClusterService(ref) {
+			err := ctlr.processVirtualServers(virtual, false)
+			if err != nil {
+				rscLog.Errorf("[CORE] Sync failed with %v", err)
+				isRetryableError = true
+			}
+		}
+
 	case Pod:
 		pod := rKey.rsc.(*v1.Pod)
 		_ = ctlr.processPod(pod, rscDelete)
@@ -411,9 +519,12 @@ func (ctlr *Controller) processResources() bool {
 			err := ctlr.processLBServices(svc, rscDelete)
 			if err != nil {
 				// TODO
-				utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+				rscLog.Errorf("[CORE] Sync failed with %v", err)
 				isRetryableError = true
 			}
+			if rscDelete {
+				ctlr.deleteEffectivePolicy(svc.Namespace, svc.Annotations[LBServicePolicyNameAnnotation])
+			}
 			break
 		}
 		switch ctlr.mode {
@@ -425,7 +536,7 @@ func (ctlr *Controller) processResources() bool {
 				err := ctlr.processVirtualServers(virtual, false)
 				if err != nil {
 					// TODO
-					utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+					rscLog.Errorf("[CORE] Sync failed with %v", err)
 					isRetryableError = true
 				}
 			}
@@ -436,7 +547,7 @@ func (ctlr *Controller) processResources() bool {
 					err := ctlr.processTransportServers(virtual, false)
 					if err != nil {
 						// TODO
-						utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+						rscLog.Errorf("[CORE] Sync failed with %v", err)
 						isRetryableError = true
 					}
 				}
@@ -448,7 +559,7 @@ func (ctlr *Controller) processResources() bool {
 					err := ctlr.processIngressLink(ingLink, false)
 					if err != nil {
 						// TODO
-						utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+						rscLog.Errorf("[CORE] Sync failed with %v", err)
 						isRetryableError = true
 					}
 				}
@@ -498,7 +609,7 @@ func (ctlr *Controller) processResources() bool {
 					err := ctlr.processVirtualServers(vrt, true)
 					if err != nil {
 						// TODO
-						utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+						rscLog.Errorf("[CORE] Sync failed with %v", err)
 						isRetryableError = true
 					}
 				}
@@ -507,7 +618,7 @@ func (ctlr *Controller) processResources() bool {
 					err := ctlr.processTransportServers(ts, true)
 					if err != nil {
 						// TODO
-						utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+						rscLog.Errorf("[CORE] Sync failed with %v", err)
 						isRetryableError = true
 					}
 				}
@@ -518,6 +629,31 @@ func (ctlr *Controller) processResources() bool {
 				delete(ctlr.namespaces, nsName)
 				ctlr.namespacesMutex.Unlock()
 				log.Debugf("Removed Namespace: '%v' from CIS scope", nsName)
+			} else if rKey.event == Update {
+				// partition annotation is updated, move the virtuals to the new partition
+				for _, vrt := range ctlr.getAllVirtualServers(nsName) {
+					err := ctlr.processVirtualServers(vrt, false)
+					if err != nil {
+						rscLog.Errorf("[CORE] Sync failed with %v", err)
+						isRetryableError = true
+					}
+				}
+
+				for _, ts := range ctlr.getAllTransportServers(nsName) {
+					err := ctlr.processTransportServers(ts, false)
+					if err != nil {
+						rscLog.Errorf("[CORE] Sync failed with %v", err)
+						isRetryableError = true
+					}
+				}
+
+				for _, ingLink := range ctlr.getAllIngressLinks(nsName) {
+					err := ctlr.processIngressLink(ingLink, false)
+					if err != nil {
+						rscLog.Errorf("[CORE] Sync failed with %v", err)
+						isRetryableError = true
+					}
+				}
 			} else {
 				ctlr.namespacesMutex.Lock()
 				ctlr.namespaces[nsName] = true
@@ -527,7 +663,7 @@ func (ctlr *Controller) processResources() bool {
 			}
 		}
 	default:
-		log.Errorf("Unknown resource Kind: %v", rKey.kind)
+		rscLog.Errorf("[CORE] Unknown resource Kind: %v", rKey.kind)
 	}
 
 	if isRetryableError {
@@ -537,6 +673,7 @@ func (ctlr *Controller) processResources() bool {
 	}
 
 	if ctlr.resourceQueue.Len() == 0 && ctlr.resources.isConfigUpdated() {
+		ctlr.resources.prunePoolDrainCache()
 		config := ResourceConfigRequest{
 			ltmConfig:          ctlr.resources.getLTMConfigDeepCopy(),
 			shareNodes:         ctlr.shareNodes,
@@ -545,7 +682,14 @@ func (ctlr *Controller) processResources() bool {
 		}
 		go ctlr.TeemData.PostTeemsData()
 		config.reqId = ctlr.enqueueReq(config)
-		ctlr.Agent.PostConfig(config)
+		var reqSpan trace.Span
+		config.traceCtx, reqSpan = ctlr.startRequestSpan(config.reqId)
+		log.WithFields(log.Fields{"id": config.reqId}).Debug("[CORE] Posting resource config request")
+		ctlr.lastConfigMutex.Lock()
+		ctlr.lastConfig = config
+		ctlr.lastConfigMutex.Unlock()
+		ctlr.getBackend().PostConfig(config)
+		reqSpan.End()
 		ctlr.initState = false
 		ctlr.resources.updateCaches()
 	}
@@ -579,26 +723,28 @@ func (ctlr *Controller) updatePoolMembersForVirtuals(svc *v1.Service) {
 	namespace := svc.Namespace
 	svcName := svc.Name
 	svcDepRscKey := namespace + "_" + svcName
-	partition := ctlr.Partition
 
 	for rsName := range ctlr.getSvcDepResources(svcDepRscKey) {
-		rsCfg := ctlr.getVirtualServer(partition, rsName)
-		if rsCfg == nil {
-			continue
-		}
+		// virtuals are spread across the partitions of their namespaces
+		for partition := range ctlr.resources.ltmConfig {
+			rsCfg := ctlr.getVirtualServer(partition, rsName)
+			if rsCfg == nil {
+				continue
+			}
 
-		freshRsCfg := &ResourceConfig{}
-		freshRsCfg.copyConfig(rsCfg)
+			freshRsCfg := &ResourceConfig{}
+			freshRsCfg.copyConfig(rsCfg)
 
-		if ctlr.PoolMemberType == NodePort {
-			ctlr.updatePoolMembersForNodePort(freshRsCfg, namespace)
-		} else if ctlr.PoolMemberType == NodePortLocal {
-			//supported with antrea cni.
-			ctlr.updatePoolMembersForNPL(freshRsCfg, namespace)
-		} else {
-			ctlr.updatePoolMembersForCluster(freshRsCfg, namespace)
+			if ctlr.PoolMemberType == NodePort {
+				ctlr.updatePoolMembersForNodePort(freshRsCfg, namespace)
+			} else if ctlr.PoolMemberType == NodePortLocal {
+				//supported with antrea cni.
+				ctlr.updatePoolMembersForNPL(freshRsCfg, namespace)
+			} else {
+				ctlr.updatePoolMembersForCluster(freshRsCfg, namespace)
+			}
+			_ = ctlr.resources.setResourceConfig(partition, rsName, freshRsCfg)
 		}
-		_ = ctlr.resources.setResourceConfig(partition, rsName, freshRsCfg)
 	}
 }
 
@@ -645,17 +791,21 @@ func (ctlr *Controller) getVirtualsForTLSProfile(tls *cisapiv1.TLSProfile) []*ci
 }
 
 func (ctlr *Controller) getVirtualsForCustomPolicy(plc *cisapiv1.Policy) []*cisapiv1.VirtualServer {
-	nsVirtuals := ctlr.getAllVirtualServers(plc.Namespace)
+	var nsVirtuals []*cisapiv1.VirtualServer
+	for _, ns := range ctlr.getPolicyScopeNamespaces(plc) {
+		nsVirtuals = append(nsVirtuals, ctlr.getAllVirtualServers(ns)...)
+	}
 	if nil == nsVirtuals {
 		log.Infof("No VirtualServers found in namespace %s",
 			plc.Namespace)
 		return nil
 	}
 
+	inherited := ctlr.isPolicyInherited(plc)
 	var plcVSs []*cisapiv1.VirtualServer
 	var plcVSNames []string
 	for _, vs := range nsVirtuals {
-		if vs.Spec.PolicyName == plc.Name {
+		if inherited || vs.Spec.PolicyName == plc.Name {
 			plcVSs = append(plcVSs, vs)
 			plcVSNames = append(plcVSNames, vs.Name)
 		}
@@ -668,17 +818,21 @@ func (ctlr *Controller) getVirtualsForCustomPolicy(plc *cisapiv1.Policy) []*cisa
 }
 
 func (ctlr *Controller) getTransportServersForCustomPolicy(plc *cisapiv1.Policy) []*cisapiv1.TransportServer {
-	nsVirtuals := ctlr.getAllTransportServers(plc.Namespace)
+	var nsVirtuals []*cisapiv1.TransportServer
+	for _, ns := range ctlr.getPolicyScopeNamespaces(plc) {
+		nsVirtuals = append(nsVirtuals, ctlr.getAllTransportServers(ns)...)
+	}
 	if nil == nsVirtuals {
 		log.Infof("No VirtualServers found in namespace %s",
 			plc.Namespace)
 		return nil
 	}
 
+	inherited := ctlr.isPolicyInherited(plc)
 	var plcVSs []*cisapiv1.TransportServer
 	var plcVSNames []string
 	for _, vs := range nsVirtuals {
-		if vs.Spec.PolicyName == plc.Name {
+		if inherited || vs.Spec.PolicyName == plc.Name {
 			plcVSs = append(plcVSs, vs)
 			plcVSNames = append(plcVSNames, vs.Name)
 		}
@@ -692,17 +846,21 @@ func (ctlr *Controller) getTransportServersForCustomPolicy(plc *cisapiv1.Policy)
 
 // getLBServicesForCustomPolicy gets all services of type LB affected by the policy
 func (ctlr *Controller) getLBServicesForCustomPolicy(plc *cisapiv1.Policy) []*v1.Service {
-	LBServices := ctlr.getAllLBServices(plc.Namespace)
+	var LBServices []*v1.Service
+	for _, ns := range ctlr.getPolicyScopeNamespaces(plc) {
+		LBServices = append(LBServices, ctlr.getAllLBServices(ns)...)
+	}
 	if nil == LBServices {
 		log.Infof("No LB service found in namespace %s",
 			plc.Namespace)
 		return nil
 	}
 
+	inherited := ctlr.isPolicyInherited(plc)
 	var plcSvcs []*v1.Service
 	var plcSvcNames []string
 	for _, svc := range LBServices {
-		if plcName, found := svc.Annotations[LBServicePolicyNameAnnotation]; found && plcName == plc.Name {
+		if plcName, found := svc.Annotations[LBServicePolicyNameAnnotation]; inherited || (found && plcName == plc.Name) {
 			plcSvcs = append(plcSvcs, svc)
 			plcSvcNames = append(plcSvcNames, svc.Name)
 		}
@@ -1028,6 +1186,13 @@ func (ctlr *Controller) processVirtualServers(
 			}
 		}
 	}
+	// The virtuals of a conflicted VirtualServer belong to an older resource
+	if ctlr.isConflicted(VirtualServer, virtual.Namespace+"/"+virtual.Name) {
+		log.Debugf("Skipping the conflicted VirtualServer %v/%v", virtual.Namespace, virtual.Name)
+		return nil
+	}
+	partition := ctlr.getResourcePartition(virtual.Namespace, virtual.Spec.Partition)
+
 	// Depending on the ports defined, TLS type or Unsecured we will populate the resource config.
 	portStructs := ctlr.virtualPorts(virtual)
 
@@ -1049,19 +1214,21 @@ func (ctlr *Controller) processVirtualServers(
 			)
 		}
 
+		ctlr.deleteVirtualServerFromOtherPartitions(partition, rsName, virtual.Namespace+"/"+virtual.Name)
+
 		// Delete rsCfg if no corresponding virtuals exist
 		// Delete rsCfg if it is HTTP rsCfg and the CR VirtualServer does not handle HTTPTraffic
 		if (len(virtuals) == 0) ||
 			(portStruct.protocol == HTTP && !doVSHandleHTTP(virtuals, virtual)) ||
 			(isVSDeleted && portStruct.protocol == HTTPS && !doVSUseSameHTTPSPort(virtuals, virtual)) {
 			var hostnames []string
-			rsMap := ctlr.resources.getPartitionResourceMap(ctlr.Partition)
+			rsMap := ctlr.resources.getPartitionResourceMap(partition)
 
 			if _, ok := rsMap[rsName]; ok {
 				hostnames = rsMap[rsName].MetaData.hosts
 			}
 			ctlr.deleteSvcDepResource(rsName, rsMap[rsName])
-			ctlr.deleteVirtualServer(ctlr.Partition, rsName)
+			ctlr.deleteVirtualServer(partition, rsName)
 			if len(hostnames) > 0 {
 				ctlr.ProcessAssociatedExternalDNS(hostnames)
 			}
@@ -1069,7 +1236,7 @@ func (ctlr *Controller) processVirtualServers(
 		}
 
 		rsCfg := &ResourceConfig{}
-		rsCfg.Virtual.Partition = ctlr.Partition
+		rsCfg.Virtual.Partition = partition
 		rsCfg.MetaData.ResourceType = VirtualServer
 		rsCfg.Virtual.Enabled = true
 		rsCfg.Virtual.Name = rsName
@@ -1090,6 +1257,7 @@ func (ctlr *Controller) processVirtualServers(
 			err := ctlr.handleVSResourceConfigForPolicy(rsCfg, plc)
 			if err != nil {
 				processingError = true
+				log.Errorf("%v", err)
 				break
 			}
 		}
@@ -1172,7 +1340,7 @@ func (ctlr *Controller) processVirtualServers(
 
 	if !processingError {
 		var hostnames []string
-		rsMap := ctlr.resources.getPartitionResourceMap(ctlr.Partition)
+		rsMap := ctlr.resources.getPartitionResourceMap(partition)
 
 		// Update ltmConfig with ResourceConfigs created for the current virtuals
 		for rsName, rsCfg := range vsMap {
@@ -1240,6 +1408,11 @@ func (ctlr *Controller) getAssociatedVirtualServers(
 			continue
 		}
 
+		// skip the virtuals discarded in favour of older resources
+		if ctlr.isConflicted(VirtualServer, vrt.Namespace+"/"+vrt.Name) {
+			continue
+		}
+
 		// skip the virtuals in other HostGroups
 		if vrt.Spec.HostGroup != currentVS.Spec.HostGroup {
 			continue
@@ -1320,26 +1493,7 @@ func (ctlr *Controller) getPolicyFromVirtuals(virtuals []*cisapiv1.VirtualServer
 			plcName = vrt.Spec.PolicyName
 		}
 	}
-	if plcName == "" {
-		return nil, nil
-	}
-	crInf, ok := ctlr.getNamespacedCommonInformer(ns)
-	if !ok {
-		return nil, fmt.Errorf("Informer not found for namespace: %v", ns)
-	}
-
-	key := ns + "/" + plcName
-
-	obj, exist, err := crInf.plcInformer.GetIndexer().GetByKey(key)
-	if err != nil {
-		return nil, fmt.Errorf("Error while fetching Policy: %v: %v", key, err)
-	}
-
-	if !exist {
-		return nil, fmt.Errorf("Policy Not Found: %v", key)
-	}
-
-	return obj.(*cisapiv1.Policy), nil
+	return ctlr.getEffectivePolicy(ns, plcName)
 }
 
 func (ctlr *Controller) getPolicyFromTransportServer(virtual *cisapiv1.TransportServer) (*cisapiv1.Policy, error) {
@@ -1349,12 +1503,7 @@ func (ctlr *Controller) getPolicyFromTransportServer(virtual *cisapiv1.Transport
 		return nil, nil
 	}
 
-	plcName := virtual.Spec.PolicyName
-	if plcName == "" {
-		return nil, nil
-	}
-	ns := virtual.Namespace
-	return ctlr.getPolicy(ns, plcName)
+	return ctlr.getEffectivePolicy(virtual.Namespace, virtual.Spec.PolicyName)
 }
 
 // getPolicy fetches the policy CR
@@ -1698,6 +1847,14 @@ func (ctlr *Controller) updatePoolMembersForNodePort(
 				rsCfg.MetaData.Active = true
 				rsCfg.Pools[index].Members =
 					ctlr.getEndpointsForNodePort(svcPort.NodePort, pool.NodeMemberLabel)
+				if ctlr.nodePortEndpointNodes {
+					rsCfg.Pools[index].Members =
+						ctlr.getEndpointNodeMembers(rsCfg.Pools[index].Members, poolMemInfo, svcPort)
+					// node members are weighted by their endpoints unless the pool sets the balance
+					if rsCfg.Pools[index].Balance == "" {
+						rsCfg.Pools[index].Balance = "ratio-member"
+					}
+				}
 			}
 		}
 		//check if endpoints are found
@@ -1705,6 +1862,9 @@ func (ctlr *Controller) updatePoolMembersForNodePort(
 			log.Errorf("[CORE]Endpoints could not be fetched for service %v with targetPort %v", svcName, pool.ServicePort.IntVal)
 		}
 	}
+	ctlr.updatePoolMembersForMultiCluster(rsCfg)
+	ctlr.updatePoolMembersTopology(rsCfg)
+	ctlr.drainPoolMembers(rsCfg)
 }
 
 // updatePoolMembersForCluster updates the pool with pool members for a
@@ -1736,6 +1896,9 @@ func (ctlr *Controller) updatePoolMembersForCluster(
 			log.Errorf("[CORE]Endpoints could not be fetched for service %v with targetPort %v", svcName, pool.ServicePort.IntVal)
 		}
 	}
+	ctlr.updatePoolMembersForMultiCluster(rsCfg)
+	ctlr.updatePoolMembersTopology(rsCfg)
+	ctlr.drainPoolMembers(rsCfg)
 }
 
 // updatePoolMembersForNodePortLocal updates the pool with pool members for a
@@ -1772,6 +1935,170 @@ func (ctlr *Controller) updatePoolMembersForNPL(
 			}
 		}
 	}
+	ctlr.updatePoolMembersTopology(rsCfg)
+	ctlr.drainPoolMembers(rsCfg)
+}
+
+// drainPoolMembers keeps the members removed from the pools of rsCfg with the
+// drain admin state until the drain period expires, so that the existing
+// connections to a terminating endpoint complete before it is deleted.
+func (ctlr *Controller) drainPoolMembers(rsCfg *ResourceConfig) {
+	if ctlr.drainPeriod <= 0 {
+		return
+	}
+	session := MemberSessionDisabled
+	if ctlr.drainAdminState == "offline" {
+		session = MemberSessionOffline
+	}
+	now := time.Now()
+	for index, pool := range rsCfg.Pools {
+		poolKey := pool.Partition + "/" + pool.Name
+		state, ok := ctlr.resources.poolDrainCache[poolKey]
+		if !ok {
+			state = &poolDrainState{draining: make(map[string]drainingMember)}
+			ctlr.resources.poolDrainCache[poolKey] = state
+		}
+
+		var members, terminating []PoolMember
+		active := make(map[string]struct{})
+		terminatingKeys := make(map[string]struct{})
+		for _, mem := range pool.Members {
+			switch mem.Session {
+			case MemberSessionDisabled, MemberSessionOffline:
+				// Members already in the draining state are derived from the cache
+				continue
+			case MemberSessionTerminating:
+				// Terminating members are drained for as long as they are serving
+				key := fmt.Sprintf("%v:%v", mem.Address, mem.Port)
+				terminatingKeys[key] = struct{}{}
+				delete(state.draining, key)
+				mem.Session = session
+				terminating = append(terminating, mem)
+				continue
+			}
+			key := fmt.Sprintf("%v:%v", mem.Address, mem.Port)
+			active[key] = struct{}{}
+			delete(state.draining, key)
+			members = append(members, mem)
+		}
+		for _, mem := range state.active {
+			key := fmt.Sprintf("%v:%v", mem.Address, mem.Port)
+			if _, found := active[key]; found {
+				continue
+			}
+			if _, found := terminatingKeys[key]; found {
+				continue
+			}
+			if _, found := state.draining[key]; found {
+				continue
+			}
+			mem.Session = session
+			state.draining[key] = drainingMember{member: mem, expiry: now.Add(ctlr.drainPeriod)}
+			log.Debugf("Draining pool member %v of pool %v for %v", key, poolKey, ctlr.drainPeriod)
+			ctlr.enqueueDrainExpiry(pool.ServiceNamespace, pool.ServiceName, ctlr.drainPeriod)
+		}
+		state.active = members
+
+		var draining []PoolMember
+		for key, dm := range state.draining {
+			if !now.Before(dm.expiry) {
+				log.Debugf("Drain period expired for pool member %v of pool %v", key, poolKey)
+				delete(state.draining, key)
+				continue
+			}
+			draining = append(draining, dm.member)
+		}
+		sort.Slice(draining, func(i, j int) bool {
+			if draining[i].Address != draining[j].Address {
+				return draining[i].Address < draining[j].Address
+			}
+			return draining[i].Port < draining[j].Port
+		})
+		if len(state.active) == 0 && len(state.draining) == 0 {
+			delete(ctlr.resources.poolDrainCache, poolKey)
+		}
+		if len(draining) > 0 || len(terminating) > 0 || len(members) != len(pool.Members) {
+			rsCfg.Pools[index].Members = append(append(append([]PoolMember{}, members...), terminating...), draining...)
+		}
+	}
+}
+
+// enqueueDrainExpiry re-processes the Endpoints of a service once the drain
+// period of its members has expired.
+func (ctlr *Controller) enqueueDrainExpiry(namespace, svcName string, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		ctlr.enqueueServiceEndpoints(namespace, svcName)
+	})
+}
+
+// getTerminatingMembers returns the members of the service port from the EndpointSlices
+// of the service which are terminating but still serving.
+func (ctlr *Controller) getTerminatingMembers(svc *v1.Service, port v1.EndpointPort, nodes []Node) []PoolMember {
+	comInf, ok := ctlr.getNamespacedCommonInformer(svc.Namespace)
+	if !ok || comInf.epSliceInformer == nil {
+		return nil
+	}
+	objs, err := comInf.epSliceInformer.GetIndexer().ByIndex(endpointSliceServiceIndex, svc.Namespace+"/"+svc.Name)
+	if err != nil {
+		log.Errorf("Unable to get EndpointSlices of service %v/%v: %v", svc.Namespace, svc.Name, err)
+		return nil
+	}
+	var members []PoolMember
+	for _, obj := range objs {
+		slice := obj.(*discoveryv1.EndpointSlice)
+		if slice.AddressType == discoveryv1.AddressTypeFQDN || !hasEndpointSlicePort(slice, port) {
+			continue
+		}
+		for _, ep := range slice.Endpoints {
+			if !isEndpointTerminating(ep) {
+				continue
+			}
+			// Checking for headless services
+			if svc.Spec.ClusterIP != "None" && (ep.NodeName == nil || !containsNode(nodes, *ep.NodeName)) {
+				continue
+			}
+			for _, address := range ep.Addresses {
+				member := PoolMember{
+					Address: address,
+					Port:    port.Port,
+					Session: MemberSessionTerminating,
+				}
+				setMemberLocation(&member, v1.EndpointAddress{NodeName: ep.NodeName, TargetRef: ep.TargetRef})
+				members = append(members, member)
+			}
+		}
+	}
+	return members
+}
+
+// hasEndpointSlicePort returns true if the EndpointSlice has the endpoints port
+func hasEndpointSlicePort(slice *discoveryv1.EndpointSlice, port v1.EndpointPort) bool {
+	for _, p := range slice.Ports {
+		name := ""
+		if p.Name != nil {
+			name = *p.Name
+		}
+		if p.Port != nil && *p.Port == port.Port && name == port.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// isEndpointTerminating returns true if the endpoint is terminating and still serving
+func isEndpointTerminating(ep discoveryv1.Endpoint) bool {
+	return ep.Conditions.Terminating != nil && *ep.Conditions.Terminating &&
+		ep.Conditions.Serving != nil && *ep.Conditions.Serving
+}
+
+// hasTerminatingEndpoints returns true if the EndpointSlice has terminating endpoints which are serving
+func hasTerminatingEndpoints(slice *discoveryv1.EndpointSlice) bool {
+	for _, ep := range slice.Endpoints {
+		if isEndpointTerminating(ep) {
+			return true
+		}
+	}
+	return false
 }
 
 // getEndpointsForNodePort returns members.
@@ -1790,7 +2117,7 @@ func (ctlr *Controller) getEndpointsForNodePort(
 		member := PoolMember{
 			Address: v.Addr,
 			Port:    nodePort,
-			Session: "user-enabled",
+			Session: MemberSessionEnabled,
 		}
 		members = append(members, member)
 	}
@@ -1798,6 +2125,56 @@ func (ctlr *Controller) getEndpointsForNodePort(
 	return members
 }
 
+// getEndpointNodeMembers returns the node members hosting the ready endpoints of the
+// service port, the ratio of a node member is the number of its endpoints. All the node
+// members are returned when no node is known, unless the service routes the external
+// traffic only to the endpoints of the node.
+func (ctlr *Controller) getEndpointNodeMembers(
+	nodeMembers []PoolMember,
+	pmi poolMembersInfo,
+	svcPort v1.ServicePort,
+) []PoolMember {
+	endpoints := make(map[string]int)
+	terminating := make(map[string]struct{})
+	for ref, mems := range pmi.memberMap {
+		if ref.name != svcPort.Name {
+			continue
+		}
+		for _, mem := range mems {
+			if _, gated := pmi.gatedPods[mem.Address]; gated || mem.NodeName == "" {
+				continue
+			}
+			if mem.Session == MemberSessionTerminating {
+				terminating[mem.NodeName] = struct{}{}
+				continue
+			}
+			endpoints[mem.NodeName]++
+		}
+	}
+	if len(endpoints) == 0 && len(terminating) == 0 && !pmi.localTraffic {
+		return nodeMembers
+	}
+	nodeNames := make(map[string]string)
+	for _, node := range ctlr.getNodesFromCache() {
+		nodeNames[node.Addr] = node.Name
+	}
+	var members []PoolMember
+	for _, member := range nodeMembers {
+		count, found := endpoints[nodeNames[member.Address]]
+		if !found {
+			// nodes hosting only terminating endpoints are drained
+			if _, found = terminating[nodeNames[member.Address]]; found {
+				member.Session = MemberSessionTerminating
+				members = append(members, member)
+			}
+			continue
+		}
+		member.Ratio = count
+		members = append(members, member)
+	}
+	return members
+}
+
 // getEndpointsForNPL returns members.
 func (ctlr *Controller) getEndpointsForNPL(
 	targetPort intstr.IntOrString,
@@ -1805,6 +2182,11 @@ func (ctlr *Controller) getEndpointsForNPL(
 ) []PoolMember {
 	var members []PoolMember
 	for _, pod := range pods.Items {
+		session := MemberSessionEnabled
+		// Terminating pods are drained when draining is enabled
+		if pod.DeletionTimestamp != nil && ctlr.drainPeriod > 0 {
+			session = MemberSessionTerminating
+		}
 		anns, found := ctlr.resources.nplStore[pod.Namespace+"/"+pod.Name]
 		if !found {
 			continue
@@ -1831,7 +2213,7 @@ func (ctlr *Controller) getEndpointsForNPL(
 				member := PoolMember{
 					Address: annotation.NodeIP,
 					Port:    annotation.NodePort,
-					Session: "user-enabled",
+					Session: session,
 				}
 				members = append(members, member)
 			}
@@ -1923,28 +2305,54 @@ func (ctlr *Controller) processTransportServers(
 		ip = virtual.Spec.VirtualServerAddress
 	}
 
-	var rsName string
-	if virtual.Spec.VirtualServerName != "" {
-		rsName = formatCustomVirtualServerName(
-			virtual.Spec.VirtualServerName,
-			virtual.Spec.VirtualServerPort,
-		)
-	} else {
-		rsName = formatVirtualServerName(
-			ip,
-			virtual.Spec.VirtualServerPort,
-		)
+	// The virtual of a conflicted TransportServer belongs to an older resource
+	if ctlr.isConflicted(TransportServer, virtual.Namespace+"/"+virtual.Name) {
+		log.Debugf("Skipping the conflicted TransportServer %v/%v", virtual.Namespace, virtual.Name)
+		return nil
 	}
 
-	if isTSDeleted {
-		rsMap := ctlr.resources.getPartitionResourceMap(ctlr.Partition)
-		ctlr.deleteSvcDepResource(rsName, rsMap[rsName])
-		ctlr.deleteVirtualServer(ctlr.Partition, rsName)
-		return nil
+	partition := ctlr.getResourcePartition(virtual.Namespace, virtual.Spec.Partition)
+	if virtual.Spec.TLSPassthrough {
+		rsName := getTransportServerVirtualName(virtual, ip, virtual.Spec.VirtualServerPort)
+		ctlr.deleteVirtualServerFromOtherPartitions(partition, rsName, virtual.Namespace+"/"+virtual.Name)
+		return ctlr.processPassthroughTransportServers(virtual, ip, rsName, partition, isTSDeleted)
+	}
+
+	// ports of a deleted TransportServer are not validated, invalid entries are skipped
+	ports, _, _ := parseTransportServerPorts(virtual)
+	for _, port := range ports {
+		rsName := getTransportServerVirtualName(virtual, ip, port)
+		ctlr.deleteVirtualServerFromOtherPartitions(partition, rsName, virtual.Namespace+"/"+virtual.Name)
+
+		if isTSDeleted {
+			rsMap := ctlr.resources.getPartitionResourceMap(partition)
+			ctlr.deleteSvcDepResource(rsName, rsMap[rsName])
+			ctlr.deleteVirtualServer(partition, rsName)
+			continue
+		}
+		ctlr.processTransportServerPort(virtual, ip, rsName, partition, port)
+	}
+	return ctlr.processPortRangeTransportServers(virtual, ip, partition, isTSDeleted)
+}
+
+// getTransportServerVirtualName returns the name of the virtual of the TransportServer port
+func getTransportServerVirtualName(virtual *cisapiv1.TransportServer, ip string, port int32) string {
+	if virtual.Spec.VirtualServerName != "" {
+		return formatCustomVirtualServerName(virtual.Spec.VirtualServerName, port)
 	}
+	return formatVirtualServerName(ip, port)
+}
 
+// processTransportServerPort builds the virtual of a port of the TransportServer
+func (ctlr *Controller) processTransportServerPort(
+	virtual *cisapiv1.TransportServer,
+	ip string,
+	rsName string,
+	partition string,
+	port int32,
+) {
 	rsCfg := &ResourceConfig{}
-	rsCfg.Virtual.Partition = ctlr.Partition
+	rsCfg.Virtual.Partition = partition
 	rsCfg.MetaData.ResourceType = TransportServer
 	rsCfg.Virtual.Enabled = true
 	rsCfg.Virtual.Name = rsName
@@ -1954,33 +2362,38 @@ func (ctlr *Controller) processTransportServers(
 	rsCfg.MetaData.baseResources = make(map[string]string)
 	rsCfg.Virtual.SetVirtualAddress(
 		ip,
-		virtual.Spec.VirtualServerPort,
+		port,
 	)
 	plc, err := ctlr.getPolicyFromTransportServer(virtual)
 	if plc != nil {
 		err := ctlr.handleTSResourceConfigForPolicy(rsCfg, plc)
 		if err != nil {
 			log.Errorf("%v", err)
-			return nil
+			return
 		}
 	}
 	if err != nil {
 		log.Errorf("%v", err)
-		return nil
+		return
 	}
 
 	log.Debugf("Processing Transport Server %s for port %v",
-		virtual.ObjectMeta.Name, virtual.Spec.VirtualServerPort)
+		virtual.ObjectMeta.Name, port)
 	rsCfg.MetaData.baseResources[virtual.ObjectMeta.Namespace+"/"+virtual.ObjectMeta.Name] = TransportServer
+	ts := virtual
+	if virtual.Spec.Pool.ServicePort == 0 && port != 0 {
+		// ports of a multi-port service are served by the pools of the matching service ports
+		ts = virtual.DeepCopy()
+		ts.Spec.Pool.ServicePort = port
+	}
 	err = ctlr.prepareRSConfigFromTransportServer(
 		rsCfg,
-		virtual,
+		ts,
 	)
 	if err != nil {
 		log.Errorf("Cannot Publish TransportServer %s", virtual.ObjectMeta.Name)
-		return nil
+		return
 	}
-
 	ctlr.updateSvcDepResources(rsName, rsCfg)
 
 	if ctlr.PoolMemberType == NodePort {
@@ -1989,12 +2402,283 @@ func (ctlr *Controller) processTransportServers(
 		ctlr.updatePoolMembersForCluster(rsCfg, virtual.ObjectMeta.Namespace)
 	}
 
-	rsMap := ctlr.resources.getPartitionResourceMap(ctlr.Partition)
+	rsMap := ctlr.resources.getPartitionResourceMap(partition)
+	rsMap[rsName] = rsCfg
+}
+
+// processPortRangeTransportServers builds the port range virtual of the TransportServer and
+// rebuilds the port range virtuals it has left, without it
+func (ctlr *Controller) processPortRangeTransportServers(
+	virtual *cisapiv1.TransportServer,
+	ip string,
+	partition string,
+	isTSDeleted bool,
+) error {
+	tsKey := virtual.Namespace + "/" + virtual.Name
+	type rangeVirtual struct {
+		name      string
+		ip        string
+		partition string
+	}
+	var rangeVirtuals []rangeVirtual
+	if _, ranges, _ := parseTransportServerPorts(virtual); !isTSDeleted && len(ranges) > 0 {
+		rangeVirtuals = append(rangeVirtuals, rangeVirtual{getPortRangeVirtualName(virtual, ip), ip, partition})
+	}
+	for prtn, partitionConfig := range ctlr.resources.ltmConfig {
+		for rsName, rsCfg := range partitionConfig.ResourceMap {
+			if _, ok := rsCfg.MetaData.baseResources[tsKey]; !ok || rsCfg.MetaData.ResourceType != TransportServer ||
+				!strings.HasSuffix(rsName, "_"+PortRangesVirtualSuffix) ||
+				len(rangeVirtuals) > 0 && rangeVirtuals[0].name == rsName && rangeVirtuals[0].partition == prtn {
+				continue
+			}
+			address, _ := extractVirtualAddressAndPort(rsCfg.Virtual.Destination)
+			rangeVirtuals = append(rangeVirtuals, rangeVirtual{rsName, address, prtn})
+		}
+	}
+	for _, rngVirtual := range rangeVirtuals {
+		if err := ctlr.processPortRangeVirtual(virtual, rngVirtual.ip, rngVirtual.name, rngVirtual.partition,
+			isTSDeleted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processPortRangeVirtual builds the wildcard port virtual shared by the port ranges of the
+// TransportServers on the address and protocol, which selects the pool by the destination port
+func (ctlr *Controller) processPortRangeVirtual(
+	virtual *cisapiv1.TransportServer,
+	ip string,
+	rsName string,
+	partition string,
+	isTSDeleted bool,
+) error {
+	virtuals := ctlr.getColocatedTransportServers(virtual, ip, partition, isTSDeleted,
+		func(ts *cisapiv1.TransportServer, address string) bool {
+			if ts.Spec.TLSPassthrough || getPortRangeVirtualName(ts, address) != rsName {
+				return false
+			}
+			_, ranges, _ := parseTransportServerPorts(ts)
+			return len(ranges) > 0
+		})
+
+	rsMap := ctlr.resources.getPartitionResourceMap(partition)
+	if len(virtuals) == 0 {
+		ctlr.deleteSvcDepResource(rsName, rsMap[rsName])
+		ctlr.deleteVirtualServer(partition, rsName)
+		return nil
+	}
+
+	rsCfg := &ResourceConfig{}
+	rsCfg.Virtual.Partition = partition
+	rsCfg.MetaData.ResourceType = TransportServer
+	rsCfg.Virtual.Enabled = true
+	rsCfg.Virtual.Name = rsName
+	rsCfg.MetaData.namespace = virtuals[0].Namespace
+	rsCfg.MetaData.baseResources = make(map[string]string)
+	rsCfg.IRulesMap = make(IRulesMap)
+	// the TransportServers sharing the virtual have the same type
+	rsCfg.Virtual.IpProtocol = getTransportServerProtocol(virtuals[0])
+	rsCfg.Virtual.SetVirtualAddress(
+		ip,
+		0,
+	)
+	plc, err := ctlr.getPolicyFromTransportServer(virtuals[0])
+	if err != nil {
+		return err
+	}
+	if plc != nil {
+		if err := ctlr.handleTSResourceConfigForPolicy(rsCfg, plc); err != nil {
+			return err
+		}
+	}
+
+	var poolRanges []poolPortRanges
+	for i, ts := range virtuals {
+		tsCfg := rsCfg
+		if i > 0 {
+			tsCfg = &ResourceConfig{}
+			tsCfg.Virtual.Partition = partition
+		}
+		if err := ctlr.prepareRSConfigFromTransportServer(tsCfg, ts); err != nil {
+			log.Errorf("Cannot Publish TransportServer %s", ts.ObjectMeta.Name)
+			continue
+		}
+		if i > 0 {
+		pools:
+			for _, pool := range tsCfg.Pools {
+				// TransportServers of the same service share the pool
+				for _, rsPool := range rsCfg.Pools {
+					if rsPool.Name == pool.Name {
+						continue pools
+					}
+				}
+				rsCfg.Pools = append(rsCfg.Pools, pool)
+			}
+			rsCfg.Monitors = append(rsCfg.Monitors, tsCfg.Monitors...)
+		}
+		_, ranges, _ := parseTransportServerPorts(ts)
+		poolRanges = append(poolRanges, poolPortRanges{poolName: tsCfg.Virtual.PoolName, ranges: ranges})
+		rsCfg.MetaData.baseResources[ts.Namespace+"/"+ts.Name] = TransportServer
+	}
+	// connections to the ports outside the ranges are rejected instead of a default pool
+	rsCfg.Virtual.PoolName = ""
+	iRuleName := getRSCfgResName(rsName, PortRangeIRuleName)
+	rsCfg.addIRule(iRuleName, partition, portRangeIRule(rsCfg.Virtual.IpProtocol, partition, poolRanges))
+	rsCfg.Virtual.IRules = append([]string{JoinBigipPath(partition, iRuleName)}, rsCfg.Virtual.IRules...)
+	log.Debugf("Processing Transport Servers for port ranges of %v", rsName)
+
+	ctlr.updateSvcDepResources(rsName, rsCfg)
+
+	if ctlr.PoolMemberType == NodePort {
+		ctlr.updatePoolMembersForNodePort(rsCfg, rsCfg.MetaData.namespace)
+	} else {
+		ctlr.updatePoolMembersForCluster(rsCfg, rsCfg.MetaData.namespace)
+	}
+	rsMap[rsName] = rsCfg
+
+	return nil
+}
+
+// processPassthroughTransportServers builds the virtual shared by the TLS passthrough
+// TransportServers of the address and port, which selects the pool by the server name
+func (ctlr *Controller) processPassthroughTransportServers(
+	virtual *cisapiv1.TransportServer,
+	ip string,
+	rsName string,
+	partition string,
+	isTSDeleted bool,
+) error {
+	virtuals := ctlr.getColocatedTransportServers(virtual, ip, partition, isTSDeleted,
+		func(ts *cisapiv1.TransportServer, address string) bool {
+			return ts.Spec.TLSPassthrough && address == ip &&
+				ts.Spec.VirtualServerPort == virtual.Spec.VirtualServerPort &&
+				ts.Spec.VirtualServerName == virtual.Spec.VirtualServerName
+		})
+
+	rsMap := ctlr.resources.getPartitionResourceMap(partition)
+	if len(virtuals) == 0 {
+		ctlr.deleteSvcDepResource(rsName, rsMap[rsName])
+		ctlr.deleteVirtualServer(partition, rsName)
+		return nil
+	}
+
+	rsCfg := &ResourceConfig{}
+	rsCfg.Virtual.Partition = partition
+	rsCfg.MetaData.ResourceType = TransportServer
+	rsCfg.Virtual.Enabled = true
+	rsCfg.Virtual.Name = rsName
+	rsCfg.MetaData.namespace = virtuals[0].Namespace
+	rsCfg.MetaData.baseResources = make(map[string]string)
+	rsCfg.IntDgMap = make(InternalDataGroupMap)
+	rsCfg.IRulesMap = make(IRulesMap)
+	rsCfg.Virtual.SetVirtualAddress(
+		ip,
+		virtual.Spec.VirtualServerPort,
+	)
+	plc, err := ctlr.getPolicyFromTransportServer(virtuals[0])
+	if err != nil {
+		return err
+	}
+	if plc != nil {
+		if err := ctlr.handleTSResourceConfigForPolicy(rsCfg, plc); err != nil {
+			return err
+		}
+	}
+
+	dgName := getRSCfgResName(rsName, PassthroughHostsDgName)
+	for i, ts := range virtuals {
+		tsCfg := rsCfg
+		if i > 0 {
+			tsCfg = &ResourceConfig{}
+			tsCfg.Virtual.Partition = partition
+		}
+		if err := ctlr.prepareRSConfigFromTransportServer(tsCfg, ts); err != nil {
+			log.Errorf("Cannot Publish TransportServer %s", ts.ObjectMeta.Name)
+			continue
+		}
+		if i > 0 {
+		pools:
+			for _, pool := range tsCfg.Pools {
+				// TransportServers of the same service share the pool
+				for _, rsPool := range rsCfg.Pools {
+					if rsPool.Name == pool.Name {
+						continue pools
+					}
+				}
+				rsCfg.Pools = append(rsCfg.Pools, pool)
+			}
+			rsCfg.Monitors = append(rsCfg.Monitors, tsCfg.Monitors...)
+		}
+		rsCfg.MetaData.hosts = append(rsCfg.MetaData.hosts, ts.Spec.Host)
+		rsCfg.MetaData.baseResources[ts.Namespace+"/"+ts.Name] = TransportServer
+		updateDataGroup(rsCfg.IntDgMap, dgName, partition, ts.Namespace,
+			strings.ToLower(ts.Spec.Host), tsCfg.Virtual.PoolName, DataGroupType)
+	}
+	// connections with an unknown server name are rejected instead of a default pool
+	rsCfg.Virtual.PoolName = ""
+	// TLS passthrough is validated for tcp, the type of the oldest TransportServer may be unset
+	rsCfg.Virtual.IpProtocol = "tcp"
+	iRuleName := getRSCfgResName(rsName, TLSIRuleName)
+	rsCfg.addIRule(iRuleName, partition, ctlr.getTSPassthroughIRule(rsName, partition))
+	rsCfg.Virtual.IRules = append([]string{JoinBigipPath(partition, iRuleName)}, rsCfg.Virtual.IRules...)
+	log.Debugf("Processing TLS passthrough Transport Servers %v for port %v",
+		rsCfg.MetaData.hosts, virtual.Spec.VirtualServerPort)
+
+	ctlr.updateSvcDepResources(rsName, rsCfg)
+
+	if ctlr.PoolMemberType == NodePort {
+		ctlr.updatePoolMembersForNodePort(rsCfg, rsCfg.MetaData.namespace)
+	} else {
+		ctlr.updatePoolMembersForCluster(rsCfg, rsCfg.MetaData.namespace)
+	}
 	rsMap[rsName] = rsCfg
 
 	return nil
 }
 
+// getColocatedTransportServers returns the valid TransportServers of the partition which share
+// the virtual of the TransportServer as selected by colocated with their virtual address, the
+// TransportServer is included unless it is deleted. They are ordered by creation time, the
+// virtual settings are taken from the oldest TransportServer.
+func (ctlr *Controller) getColocatedTransportServers(
+	virtual *cisapiv1.TransportServer,
+	ip string,
+	partition string,
+	isTSDeleted bool,
+	colocated func(ts *cisapiv1.TransportServer, address string) bool,
+) []*cisapiv1.TransportServer {
+	tsKey := virtual.Namespace + "/" + virtual.Name
+	var virtuals []*cisapiv1.TransportServer
+	for _, ts := range ctlr.getAllTSFromMonitoredNamespaces() {
+		key := ts.Namespace + "/" + ts.Name
+		if key == tsKey {
+			continue
+		}
+		address := ts.Spec.VirtualServerAddress
+		if address == "" {
+			address = ts.Status.VSAddress
+		}
+		// the other TransportServers are validated without defaulting their spec in the informer cache
+		if !colocated(ts, address) || ctlr.getResourcePartition(ts.Namespace, ts.Spec.Partition) != partition ||
+			ctlr.isConflicted(TransportServer, key) || ctlr.validateTransportServerSpec(ts) != nil {
+			continue
+		}
+		virtuals = append(virtuals, ts)
+	}
+	if !isTSDeleted && colocated(virtual, ip) &&
+		ctlr.getResourcePartition(virtual.Namespace, virtual.Spec.Partition) == partition {
+		virtuals = append(virtuals, virtual)
+	}
+	sort.Slice(virtuals, func(i, j int) bool {
+		if !virtuals[i].CreationTimestamp.Equal(&virtuals[j].CreationTimestamp) {
+			return virtuals[i].CreationTimestamp.Before(&virtuals[j].CreationTimestamp)
+		}
+		return virtuals[i].Namespace+"/"+virtuals[i].Name < virtuals[j].Namespace+"/"+virtuals[j].Name
+	})
+	return virtuals
+}
+
 // getAllTSFromMonitoredNamespaces returns list of all valid TransportServers in monitored namespaces.
 func (ctlr *Controller) getAllTSFromMonitoredNamespaces() []*cisapiv1.TransportServer {
 	var allVirtuals []*cisapiv1.TransportServer
@@ -2262,10 +2946,19 @@ func (ctlr *Controller) processLBServices(
 		}
 	}
 
+	// The virtuals of a conflicted Service belong to an older resource
+	conflicted := ctlr.isConflicted(Service, svc.Namespace+"/"+svc.Name)
 	if !isSVCDeleted {
+		if conflicted {
+			log.Debugf("Skipping the conflicted Service %v/%v", svc.Namespace, svc.Name)
+			return nil
+		}
 		ctlr.setLBServiceIngressStatus(svc, ip)
 	} else {
 		ctlr.unSetLBServiceIngressStatus(svc, ip)
+		if conflicted {
+			return nil
+		}
 	}
 
 	for _, portSpec := range svc.Spec.Ports {
@@ -2286,6 +2979,9 @@ func (ctlr *Controller) processLBServices(
 		rsCfg.Virtual.IpProtocol = strings.ToLower(string(portSpec.Protocol))
 		rsCfg.MetaData.ResourceType = TransportServer
 		rsCfg.MetaData.namespace = svc.ObjectMeta.Namespace
+		rsCfg.MetaData.baseResources = map[string]string{
+			svc.Namespace + "/" + svc.Name: Service,
+		}
 		rsCfg.Virtual.Enabled = true
 		rsCfg.Virtual.Name = rsName
 		rsCfg.Virtual.SetVirtualAddress(
@@ -2333,6 +3029,54 @@ func (ctlr *Controller) processLBServices(
 	return nil
 }
 
+// isPodReadinessGatePending returns true if the endpoint is a pod which is ready
+// except for the CIS readiness gate
+func (ctlr *Controller) isPodReadinessGatePending(addr v1.EndpointAddress) bool {
+	if addr.TargetRef == nil || addr.TargetRef.Kind != "Pod" {
+		return false
+	}
+	pod := ctlr.getPod(addr.TargetRef.Namespace, addr.TargetRef.Name)
+	if pod == nil || pod.DeletionTimestamp != nil {
+		return false
+	}
+	return hasPodReadinessGate(pod) && isPodConditionTrue(pod, v1.ContainersReady) &&
+		!isPodConditionTrue(pod, PodReadinessGateConditionType)
+}
+
+// hasPodReadinessGate returns true if the pod has the CIS readiness gate
+func hasPodReadinessGate(pod *v1.Pod) bool {
+	for _, gate := range pod.Spec.ReadinessGates {
+		if gate.ConditionType == PodReadinessGateConditionType {
+			return true
+		}
+	}
+	return false
+}
+
+// getPod returns the pod from the pod informer of the namespace, nil if the pod is not found
+func (ctlr *Controller) getPod(namespace, name string) *v1.Pod {
+	comInf, ok := ctlr.getNamespacedCommonInformer(namespace)
+	if !ok || comInf.podInformer == nil {
+		return nil
+	}
+	obj, found, err := comInf.podInformer.GetIndexer().GetByKey(namespace + "/" + name)
+	if err != nil || !found {
+		log.Debugf("Unable to find Pod %v/%v: %v", namespace, name, err)
+		return nil
+	}
+	return obj.(*v1.Pod)
+}
+
+// isPodConditionTrue returns true if the pod has the condition with status True
+func isPodConditionTrue(pod *v1.Pod, conditionType v1.PodConditionType) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
 func (ctlr *Controller) processService(
 	svc *v1.Service,
 	eps *v1.Endpoints,
@@ -2360,9 +3104,11 @@ func (ctlr *Controller) processService(
 	}
 
 	pmi := poolMembersInfo{
-		svcType:   svc.Spec.Type,
-		portSpec:  svc.Spec.Ports,
-		memberMap: make(map[portRef][]PoolMember),
+		svcType:      svc.Spec.Type,
+		portSpec:     svc.Spec.Ports,
+		memberMap:    make(map[portRef][]PoolMember),
+		gatedPods:    make(map[string]string),
+		localTraffic: svc.Spec.ExternalTrafficPolicy == v1.ServiceExternalTrafficPolicyTypeLocal,
 	}
 
 	nodes := ctlr.getNodesFromCache()
@@ -2375,9 +3121,31 @@ func (ctlr *Controller) processService(
 					member := PoolMember{
 						Address: addr.IP,
 						Port:    p.Port,
-						Session: "user-enabled",
+						Session: MemberSessionEnabled,
+					}
+					setMemberLocation(&member, addr)
+					members = append(members, member)
+				}
+			}
+			// Terminating endpoints which are still serving are drained
+			if ctlr.drainPeriod > 0 {
+				members = append(members, ctlr.getTerminatingMembers(svc, p, nodes)...)
+			}
+			// Pods waiting for the readiness gate are not ready until they are added to BIG-IP
+			if ctlr.podReadinessGate && ctlr.PoolMemberType == Cluster {
+				for _, addr := range subset.NotReadyAddresses {
+					if (svc.Spec.ClusterIP != "None" && (addr.NodeName == nil || !containsNode(nodes, *addr.NodeName))) ||
+						!ctlr.isPodReadinessGatePending(addr) {
+						continue
+					}
+					member := PoolMember{
+						Address: addr.IP,
+						Port:    p.Port,
+						Session: MemberSessionEnabled,
 					}
+					setMemberLocation(&member, addr)
 					members = append(members, member)
+					pmi.gatedPods[addr.IP] = addr.TargetRef.Namespace + "/" + addr.TargetRef.Name
 				}
 			}
 			portKey := portRef{name: p.Name, port: p.Port}
@@ -2433,16 +3201,11 @@ func (ctlr *Controller) processExternalDNS(edns *cisapiv1.ExternalDNS, isDelete
 
 	log.Debugf("Processing WideIP: %v", edns.Spec.DomainName)
 
-	var partitions []string
-	switch ctlr.mode {
-	case OpenShiftMode:
-		partitions = ctlr.resources.GetLTMPartitions()
-	default:
-		partitions = append(partitions, DEFAULT_PARTITION)
-	}
+	// virtuals of both modes can be spread across multiple partitions
+	partitions := ctlr.resources.GetLTMPartitions()
 
 	for _, pl := range edns.Spec.Pools {
-		UniquePoolName := edns.Spec.DomainName + "_" + AS3NameFormatter(strings.TrimPrefix(ctlr.Agent.BIGIPURL, "https://")) + "_" + ctlr.Partition
+		UniquePoolName := edns.Spec.DomainName + "_" + AS3NameFormatter(strings.TrimPrefix(ctlr.bigIPURL, "https://")) + "_" + ctlr.Partition
 		log.Debugf("Processing WideIP Pool: %v", UniquePoolName)
 		pool := GSLBPool{
 			Name:          UniquePoolName,
@@ -2475,7 +3238,7 @@ func (ctlr *Controller) processExternalDNS(edns *cisapiv1.ExternalDNS, isDelete
 						continue
 					}
 					preGTMServerName := ""
-					if ctlr.Agent.ccclGTMAgent {
+					if ctlr.ccclGTMAgent {
 						preGTMServerName = fmt.Sprintf("%v:", pl.DataServerName)
 					}
 					// add only one VS member to pool.
@@ -2484,7 +3247,7 @@ func (ctlr *Controller) processExternalDNS(edns *cisapiv1.ExternalDNS, isDelete
 							pool.Members[0] = fmt.Sprintf("%v/%v/Shared/%v", preGTMServerName, partition, vsName)
 							if partition != ctlr.Partition {
 								// Modify pool name to partition containing VS
-								pool.Name = edns.Spec.DomainName + "_" + AS3NameFormatter(strings.TrimPrefix(ctlr.Agent.BIGIPURL, "https://")) + "_" + partition
+								pool.Name = edns.Spec.DomainName + "_" + AS3NameFormatter(strings.TrimPrefix(ctlr.bigIPURL, "https://")) + "_" + partition
 							}
 						}
 						continue
@@ -2494,7 +3257,7 @@ func (ctlr *Controller) processExternalDNS(edns *cisapiv1.ExternalDNS, isDelete
 					// Modify pool name to partition containing VS
 					if partition != ctlr.Partition {
 						// Modify pool name to partition containing VS
-						pool.Name = edns.Spec.DomainName + "_" + AS3NameFormatter(strings.TrimPrefix(ctlr.Agent.BIGIPURL, "https://")) + "_" + partition
+						pool.Name = edns.Spec.DomainName + "_" + AS3NameFormatter(strings.TrimPrefix(ctlr.bigIPURL, "https://")) + "_" + partition
 					}
 					pool.Members = append(
 						pool.Members,
@@ -2670,7 +3433,7 @@ func (ctlr *Controller) processIPAM(ipam *ficV1.IPAM) error {
 		var crInf *CRInformer
 		var comInf *CommonInformer
 		var ns string
-		if rscKind != "hg" {
+		if rscKind != "hg" && rscKind != "rg" {
 			splits := strings.Split(pKey, "/")
 			ns = splits[0]
 			var ok bool
@@ -2756,6 +3519,17 @@ func (ctlr *Controller) processIPAM(ipam *ficV1.IPAM) error {
 			if err != nil {
 				log.Errorf("Unable to process IPAM entry: %v", pKey)
 			}
+		case "rg":
+			// For Route Group
+			routeGroup := pKey[:idx]
+			if _, ok := ctlr.resources.extdSpecMap[routeGroup]; !ok {
+				log.Errorf("Unable to process IPAM entry: %v", pKey)
+				continue
+			}
+			err := ctlr.processRoutes(routeGroup, false)
+			if err != nil {
+				log.Errorf("Unable to process IPAM entry: %v", pKey)
+			}
 		case "svc":
 			item, exists, err := comInf.svcInformer.GetIndexer().GetByKey(pKey[:idx])
 			if !exists || err != nil {
@@ -2849,30 +3623,32 @@ func (ctlr *Controller) processIngressLink(
 		}
 		ip = ingLink.Spec.VirtualServerAddress
 	}
+	// The virtuals of a conflicted IngressLink belong to an older resource
+	if ctlr.isConflicted(IngressLink, ingLink.Namespace+"/"+ingLink.Name) {
+		log.Debugf("Skipping the conflicted IngressLink %v/%v", ingLink.Namespace, ingLink.Name)
+		return nil
+	}
+	partition := ctlr.getResourcePartition(ingLink.Namespace, ingLink.Spec.Partition)
 	if isILDeleted {
-		var delRes []string
-		rsMap := ctlr.resources.getPartitionResourceMap(ctlr.Partition)
-		for k := range rsMap {
-			rsName := "ingress_link_" + formatVirtualServerName(
-				ip,
-				0,
-			)
-			if strings.HasPrefix(k, rsName[:len(rsName)-1]) {
-				delRes = append(delRes, k)
-			}
-		}
-		for _, rsName := range delRes {
-			var hostnames []string
-			if rsMap[rsName] != nil {
-				rsCfg, err := ctlr.resources.getResourceConfig(ctlr.Partition, rsName)
-				if err == nil {
-					hostnames = rsCfg.MetaData.hosts
+		// the partition may have changed since the virtuals were created, so the virtuals
+		// of the IngressLink are removed from the partitions they were created in
+		rsPrefix := "ingress_link_" + formatVirtualServerName(ip, 0)
+		rsPrefix = rsPrefix[:len(rsPrefix)-1]
+		ilKey := ingLink.Namespace + "/" + ingLink.Name
+		for prtn, partitionConfig := range ctlr.resources.ltmConfig {
+			for rsName, rsCfg := range partitionConfig.ResourceMap {
+				if !strings.HasPrefix(rsName, rsPrefix) {
+					continue
+				}
+				if _, ok := rsCfg.MetaData.baseResources[ilKey]; !ok {
+					continue
+				}
+				hostnames := rsCfg.MetaData.hosts
+				ctlr.deleteSvcDepResource(rsName, rsCfg)
+				ctlr.deleteVirtualServer(prtn, rsName)
+				if len(hostnames) > 0 {
+					ctlr.ProcessAssociatedExternalDNS(hostnames)
 				}
-			}
-			ctlr.deleteSvcDepResource(rsName, rsMap[rsName])
-			ctlr.deleteVirtualServer(ctlr.Partition, rsName)
-			if len(hostnames) > 0 {
-				ctlr.ProcessAssociatedExternalDNS(hostnames)
 			}
 		}
 		ctlr.TeemData.Lock()
@@ -2891,27 +3667,46 @@ func (ctlr *Controller) processIngressLink(
 	if svc == nil {
 		return nil
 	}
-	targetPort := nginxMonitorPort
+	ilMonitor := getIngressLinkMonitor(ingLink)
+	targetPort := ilMonitor.Port
 	if ctlr.PoolMemberType == NodePort {
-		targetPort = getNodeport(svc, nginxMonitorPort)
+		targetPort = getNodeport(svc, ilMonitor.Port)
 		if targetPort == 0 {
-			log.Errorf("Nodeport not found for nginx monitor port: %v", nginxMonitorPort)
+			log.Errorf("Nodeport not found for ingress controller monitor port: %v", ilMonitor.Port)
 		}
 	}
 
-	rsMap := ctlr.resources.getPartitionResourceMap(ctlr.Partition)
-	for _, port := range svc.Spec.Ports {
-		//for nginx health monitor port skip vs creation
-		if port.Port == nginxMonitorPort {
+	rsMap := ctlr.resources.getPartitionResourceMap(partition)
+	ports := getIngressLinkPorts(ingLink, svc, ilMonitor.Port)
+	// remove the virtuals of the ports which are not exposed anymore
+	rsPrefix := "ingress_link_" + formatVirtualServerName(ip, 0)
+	rsPrefix = rsPrefix[:len(rsPrefix)-1]
+	for rsName := range rsMap {
+		if !strings.HasPrefix(rsName, rsPrefix) {
 			continue
 		}
+		exposed := false
+		for _, port := range ports {
+			if rsName == rsPrefix+fmt.Sprintf("%d", port.Port) {
+				exposed = true
+				break
+			}
+		}
+		if !exposed {
+			ctlr.deleteSvcDepResource(rsName, rsMap[rsName])
+			ctlr.deleteVirtualServer(partition, rsName)
+		}
+	}
+	for _, port := range ports {
 		rsName := "ingress_link_" + formatVirtualServerName(
 			ip,
 			port.Port,
 		)
+		// ingress link virtuals are named after the virtual address
+		ctlr.deleteVirtualServerFromOtherPartitions(partition, rsName, "")
 
 		rsCfg := &ResourceConfig{}
-		rsCfg.Virtual.Partition = ctlr.Partition
+		rsCfg.Virtual.Partition = partition
 		rsCfg.MetaData.ResourceType = TransportServer
 		rsCfg.MetaData.hosts = append(rsCfg.MetaData.hosts, ingLink.Spec.Host)
 		rsCfg.Virtual.Mode = "standard"
@@ -2921,8 +3716,17 @@ func (ctlr *Controller) processIngressLink(
 		rsCfg.Virtual.Enabled = true
 		rsCfg.Virtual.Name = rsName
 		rsCfg.Virtual.SNAT = DEFAULT_SNAT
+		rsCfg.MetaData.baseResources = map[string]string{
+			ingLink.Namespace + "/" + ingLink.Name: IngressLink,
+		}
+		rsCfg.IRulesMap = make(IRulesMap)
+		if ingLink.Spec.ProxyProtocol != "" {
+			rsCfg.addIRule(getRSCfgResName(rsName, ProxyProtocolIRuleName), partition,
+				proxyProtocolIRule(ingLink.Spec.ProxyProtocol))
+			rsCfg.Virtual.AddIRule(JoinBigipPath(partition, getRSCfgResName(rsName, ProxyProtocolIRuleName)))
+		}
 		if len(ingLink.Spec.IRules) > 0 {
-			rsCfg.Virtual.IRules = ingLink.Spec.IRules
+			rsCfg.Virtual.IRules = append(rsCfg.Virtual.IRules, ingLink.Spec.IRules...)
 		}
 		rsCfg.Virtual.SetVirtualAddress(
 			ip,
@@ -2943,10 +3747,12 @@ func (ctlr *Controller) processIngressLink(
 			ServiceNamespace: svc.ObjectMeta.Namespace,
 		}
 		monitorName := fmt.Sprintf("%s_monitor", pool.Name)
-		rsCfg.Monitors = append(
-			rsCfg.Monitors,
-			Monitor{Name: monitorName, Partition: rsCfg.Virtual.Partition, Interval: 20,
-				Type: "http", Send: "GET /nginx-ready HTTP/1.1\r\n", Recv: "", Timeout: 10, TargetPort: targetPort})
+		monitor := Monitor{Name: monitorName, Partition: rsCfg.Virtual.Partition, Interval: ilMonitor.Interval,
+			Type: ilMonitor.Type, Recv: ilMonitor.Recv, Timeout: ilMonitor.Timeout, TargetPort: targetPort}
+		if ilMonitor.Type != "tcp" {
+			monitor.Send = fmt.Sprintf("GET %s HTTP/1.1\r\n", ilMonitor.Path)
+		}
+		rsCfg.Monitors = append(rsCfg.Monitors, monitor)
 		pool.MonitorNames = append(pool.MonitorNames, MonitorName{Name: monitorName})
 		rsCfg.Virtual.PoolName = pool.Name
 		rsCfg.Pools = append(rsCfg.Pools, pool)
@@ -2973,6 +3779,63 @@ func (ctlr *Controller) processIngressLink(
 	return nil
 }
 
+// getIngressLinkMonitor returns the monitor of the ingress controller preset, nginx by
+// default, overridden by the monitor of the IngressLink
+func getIngressLinkMonitor(ingLink *cisapiv1.IngressLink) cisapiv1.IngressLinkMonitor {
+	preset := ingLink.Spec.IngressController
+	if preset == "" {
+		preset = NginxIngressController
+	}
+	monitor := ingressLinkMonitors[preset]
+	monitor.Interval = 20
+	monitor.Timeout = 10
+	if override := ingLink.Spec.Monitor; override != nil {
+		if override.Type != "" {
+			monitor.Type = override.Type
+		}
+		if override.Path != "" {
+			monitor.Path = override.Path
+		}
+		if override.Port != 0 {
+			monitor.Port = override.Port
+		}
+		if override.Recv != "" {
+			monitor.Recv = override.Recv
+		}
+		if override.Interval != 0 {
+			monitor.Interval = override.Interval
+		}
+		if override.Timeout != 0 {
+			monitor.Timeout = override.Timeout
+		}
+	}
+	if monitor.Type == "" {
+		monitor.Type = "http"
+	}
+	return monitor
+}
+
+// getIngressLinkPorts returns the service ports in the ports of the IngressLink, or all
+// the service ports except the monitor port
+func getIngressLinkPorts(ingLink *cisapiv1.IngressLink, svc *v1.Service, monitorPort int32) []v1.ServicePort {
+	var ports []v1.ServicePort
+	for _, port := range svc.Spec.Ports {
+		if len(ingLink.Spec.Ports) == 0 {
+			if port.Port != monitorPort {
+				ports = append(ports, port)
+			}
+			continue
+		}
+		for _, ilPort := range ingLink.Spec.Ports {
+			if port.Port == ilPort {
+				ports = append(ports, port)
+				break
+			}
+		}
+	}
+	return ports
+}
+
 func (ctlr *Controller) getAllIngressLinks(namespace string) []*cisapiv1.IngressLink {
 	var allIngLinks []*cisapiv1.IngressLink
 
@@ -3398,12 +4261,8 @@ func (ctlr *Controller) processPod(pod *v1.Pod, ispodDeleted bool) error {
 
 // getPolicyFromLBService gets the policy attached to the service and returns it
 func (ctlr *Controller) getPolicyFromLBService(svc *v1.Service) (*cisapiv1.Policy, error) {
-	plcName, found := svc.Annotations[LBServicePolicyNameAnnotation]
-	if !found || plcName == "" {
-		return nil, nil
-	}
-	ns := svc.Namespace
-	return ctlr.getPolicy(ns, plcName)
+	plcName := svc.Annotations[LBServicePolicyNameAnnotation]
+	return ctlr.getEffectivePolicy(svc.Namespace, plcName)
 }
 
 // skipVirtual return true if virtuals don't have any common HTTP/HTTPS ports, else returns false
@@ -3483,6 +4342,44 @@ func fetchPortString(port intstr.IntOrString) string {
 }
 
 // fetch list of tls profiles for given secret.
+// getVirtualServersForMonitorSecret returns the VirtualServers with a monitor using the secret as client certificate
+func (ctlr *Controller) getVirtualServersForMonitorSecret(secret *v1.Secret) []*cisapiv1.VirtualServer {
+	var virtuals []*cisapiv1.VirtualServer
+	for _, vs := range ctlr.getAllVirtualServers(secret.Namespace) {
+		for _, pool := range vs.Spec.Pools {
+			if isMonitorSecretOfPool(pool, secret.Name) {
+				virtuals = append(virtuals, vs)
+				break
+			}
+		}
+	}
+	return virtuals
+}
+
+// getTransportServersForMonitorSecret returns the TransportServers with a monitor using the secret as client certificate
+func (ctlr *Controller) getTransportServersForMonitorSecret(secret *v1.Secret) []*cisapiv1.TransportServer {
+	var virtuals []*cisapiv1.TransportServer
+	for _, ts := range ctlr.getAllTransportServers(secret.Namespace) {
+		if isMonitorSecretOfPool(ts.Spec.Pool, secret.Name) {
+			virtuals = append(virtuals, ts)
+		}
+	}
+	return virtuals
+}
+
+// isMonitorSecretOfPool returns true if a monitor of the pool uses the secret as client certificate
+func isMonitorSecretOfPool(pool cisapiv1.Pool, secretName string) bool {
+	if pool.Monitor.ClientCertificate == secretName {
+		return true
+	}
+	for _, monitor := range pool.Monitors {
+		if monitor.ClientCertificate == secretName {
+			return true
+		}
+	}
+	return false
+}
+
 func (ctlr *Controller) getTLSProfilesForSecret(secret *v1.Secret) []*cisapiv1.TLSProfile {
 	var allTLSProfiles []*cisapiv1.TLSProfile
 
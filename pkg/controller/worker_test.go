@@ -28,6 +28,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -799,6 +800,138 @@ var _ = Describe("Worker Tests", func() {
 			Expect(len(mems)).To(Equal(0), "Wrong set of Endpoints for NodePort")
 		})
 
+		It("Cluster with pod readiness gate", func() {
+			mockCtlr.PoolMemberType = Cluster
+			mockCtlr.podReadinessGate = true
+			pod := test.NewPod("pod1", namespace, 8080, nil)
+			pod.Spec.ReadinessGates = []v1.PodReadinessGate{{ConditionType: PodReadinessGateConditionType}}
+			pod.Status.Conditions = []v1.PodCondition{{Type: v1.ContainersReady, Status: v1.ConditionTrue}}
+			mockCtlr.kubeClient = k8sfake.NewSimpleClientset(svc1, pod)
+			mockCtlr.comInformers[namespace] = mockCtlr.newNamespacedCommonResourceInformer(namespace)
+			mockCtlr.addPod(pod)
+
+			eps := test.NewEndpoints("svc1", "1", "worker1", namespace,
+				[]string{"10.1.1.1"}, []string{"10.1.1.2", "10.1.1.3"},
+				[]v1.EndpointPort{{Name: "port0", Port: 8080}})
+			eps.Subsets[0].NotReadyAddresses[0].TargetRef = &v1.ObjectReference{
+				Kind: "Pod", Namespace: namespace, Name: "pod1"}
+			Expect(mockCtlr.processService(svc1, eps, false)).To(BeNil())
+
+			pmi := mockCtlr.resources.poolMemCache[namespace+"/svc1"]
+			members := pmi.memberMap[portRef{name: "port0", port: 8080}]
+			Expect(len(members)).To(Equal(2), "Pod waiting for readiness gate should be a pool member")
+			Expect(pmi.gatedPods).To(Equal(map[string]string{"10.1.1.2": namespace + "/pod1"}))
+
+			rsCfg := &ResourceConfig{Pools: Pools{{ServiceName: "svc1", ServiceNamespace: namespace, Members: members}}}
+			rm := requestMeta{}
+			mockCtlr.addGatedPods(&rm, "test", rsCfg)
+			Expect(rm.gatedPods["test"]).To(Equal([]string{namespace + "/pod1"}))
+
+			mockCtlr.setPodReadinessGates(rm.gatedPods["test"])
+			pod, _ = mockCtlr.kubeClient.CoreV1().Pods(namespace).Get(context.TODO(), "pod1", metav1.GetOptions{})
+			Expect(isPodConditionTrue(pod, PodReadinessGateConditionType)).To(BeTrue(), "Readiness gate not set")
+		})
+
+		It("Drain removed pool members", func() {
+			mockCtlr.drainPeriod = time.Hour
+			mockCtlr.drainAdminState = "disable"
+			mem1 := PoolMember{Address: "10.1.1.1", Port: 8080, Session: MemberSessionEnabled}
+			mem2 := PoolMember{Address: "10.1.1.2", Port: 8080, Session: MemberSessionEnabled}
+			rsCfg := &ResourceConfig{Pools: Pools{{Name: "pool1", Partition: "test",
+				ServiceName: "svc1", ServiceNamespace: namespace, Members: []PoolMember{mem1, mem2}}}}
+			mockCtlr.drainPoolMembers(rsCfg)
+			Expect(rsCfg.Pools[0].Members).To(Equal([]PoolMember{mem1, mem2}))
+
+			// Removed member is kept with the drain admin state
+			rsCfg.Pools[0].Members = []PoolMember{mem1}
+			mockCtlr.drainPoolMembers(rsCfg)
+			drained := mem2
+			drained.Session = MemberSessionDisabled
+			Expect(rsCfg.Pools[0].Members).To(Equal([]PoolMember{mem1, drained}))
+
+			// Re-processing keeps the draining member
+			mockCtlr.drainPoolMembers(rsCfg)
+			Expect(rsCfg.Pools[0].Members).To(Equal([]PoolMember{mem1, drained}))
+
+			// Member is removed once the drain period expires
+			state := mockCtlr.resources.poolDrainCache["test/pool1"]
+			dm := state.draining["10.1.1.2:8080"]
+			dm.expiry = time.Now().Add(-time.Second)
+			state.draining["10.1.1.2:8080"] = dm
+			mockCtlr.drainPoolMembers(rsCfg)
+			Expect(rsCfg.Pools[0].Members).To(Equal([]PoolMember{mem1}))
+
+			// Member which comes back during the drain period is enabled again
+			rsCfg.Pools[0].Members = []PoolMember{}
+			mockCtlr.drainPoolMembers(rsCfg)
+			rsCfg.Pools[0].Members = []PoolMember{mem1}
+			mockCtlr.drainPoolMembers(rsCfg)
+			Expect(rsCfg.Pools[0].Members).To(Equal([]PoolMember{mem1}))
+
+			// Drain state is kept while the pool is in the resource store
+			mockCtlr.resources.ltmConfig = LTMConfig{"test": &PartitionConfig{ResourceMap: ResourceMap{"vs1": rsCfg}}}
+			mockCtlr.resources.prunePoolDrainCache()
+			Expect(mockCtlr.resources.poolDrainCache).To(HaveKey("test/pool1"))
+
+			// Drain state is removed with the virtual of the pool
+			mockCtlr.Controller.deleteVirtualServer("test", "vs1")
+			mockCtlr.resources.prunePoolDrainCache()
+			Expect(mockCtlr.resources.poolDrainCache).To(BeEmpty())
+
+			// Pool recreated with the same name does not drain the members of the deleted pool
+			rsCfg.Pools[0].Members = []PoolMember{mem2}
+			mockCtlr.drainPoolMembers(rsCfg)
+			Expect(rsCfg.Pools[0].Members).To(Equal([]PoolMember{mem2}))
+		})
+
+		It("Drain terminating endpoints", func() {
+			mockCtlr.PoolMemberType = Cluster
+			mockCtlr.drainPeriod = time.Hour
+			mockCtlr.drainAdminState = "offline"
+			mockCtlr.comInformers[namespace] = mockCtlr.newNamespacedCommonResourceInformer(namespace)
+			portName, port := "port0", int32(8080)
+			serving, terminating := true, true
+			notServing := false
+			node := "worker2"
+			slice := &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{Name: "svc1-abc", Namespace: namespace,
+					Labels: map[string]string{discoveryv1.LabelServiceName: "svc1"}},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Ports:       []discoveryv1.EndpointPort{{Name: &portName, Port: &port}},
+				Endpoints: []discoveryv1.Endpoint{
+					{Addresses: []string{"10.1.1.2"}, NodeName: &node,
+						Conditions: discoveryv1.EndpointConditions{Serving: &serving, Terminating: &terminating}},
+					{Addresses: []string{"10.1.1.3"}, NodeName: &node,
+						Conditions: discoveryv1.EndpointConditions{Serving: &notServing, Terminating: &terminating}},
+				},
+			}
+			Expect(mockCtlr.comInformers[namespace].epSliceInformer.GetStore().Add(slice)).To(BeNil())
+
+			eps := test.NewEndpoints("svc1", "1", "worker1", namespace,
+				[]string{"10.1.1.1"}, nil, []v1.EndpointPort{{Name: portName, Port: port}})
+			Expect(mockCtlr.processService(svc1, eps, false)).To(BeNil())
+			pmi := mockCtlr.resources.poolMemCache[namespace+"/svc1"]
+			members := pmi.memberMap[portRef{name: portName, port: port}]
+			Expect(members).To(HaveLen(2), "Terminating serving endpoint should be a member")
+			Expect(members[1].Address).To(Equal("10.1.1.2"))
+			Expect(members[1].Session).To(Equal(MemberSessionTerminating))
+
+			rsCfg := &ResourceConfig{Pools: Pools{{Name: "pool1", Partition: "test",
+				ServiceName: "svc1", ServiceNamespace: namespace, Members: members}}}
+			mockCtlr.drainPoolMembers(rsCfg)
+			Expect(rsCfg.Pools[0].Members[0].Session).To(Equal(MemberSessionEnabled))
+			Expect(rsCfg.Pools[0].Members[1].Session).To(Equal(MemberSessionOffline),
+				"Terminating member should be drained")
+
+			// nodes hosting only terminating endpoints are drained in nodeport mode
+			nodeMembers := mockCtlr.getEndpointsForNodePort(30000, "")
+			nodeMembers = mockCtlr.getEndpointNodeMembers(nodeMembers, pmi, v1.ServicePort{Name: portName})
+			Expect(nodeMembers).To(HaveLen(2))
+			Expect(nodeMembers[0].Session).To(Equal(MemberSessionEnabled))
+			Expect(nodeMembers[1].Address).To(Equal("10.10.10.2"))
+			Expect(nodeMembers[1].Session).To(Equal(MemberSessionTerminating))
+		})
+
 	})
 
 	Describe("Processing Resources", func() {
@@ -965,6 +1098,245 @@ var _ = Describe("Worker Tests", func() {
 				"Invalid Resource Config")
 
 		})
+
+		It("Processing IngressLink with ingress controller preset", func() {
+			fooPorts := []v1.ServicePort{
+				{Port: 80, Name: "http2"},
+				{Port: 443, Name: "https"},
+				{Port: 15021, Name: "status-port"},
+			}
+			foo := test.NewService("foo", "1", namespace, v1.ServiceTypeClusterIP, fooPorts)
+			foo.ObjectMeta.Labels = map[string]string{"app": "istio"}
+			IngressLink1 := test.NewIngressLink("ingresslink1", namespace, "1",
+				cisapiv1.IngressLinkSpec{
+					VirtualServerAddress: "1.2.3.4",
+					Selector:             &metav1.LabelSelector{MatchLabels: foo.ObjectMeta.Labels},
+					IngressController:    IstioIngressController,
+					ProxyProtocol:        ProxyProtocolV2,
+				})
+			_ = mockCtlr.crInformers["default"].ilInformer.GetIndexer().Add(IngressLink1)
+			mockCtlr.TeemData = &teem.TeemsData{
+				ResourceType: teem.ResourceTypes{
+					IngressLink: make(map[string]int),
+				},
+			}
+			_, _ = mockCtlr.kubeClient.CoreV1().Services("default").Create(context.Background(), foo,
+				metav1.CreateOptions{})
+			err := mockCtlr.processIngressLink(IngressLink1, false)
+			Expect(err).To(BeNil(), "Failed to process IngressLink while creation")
+			rsMap := mockCtlr.resources.ltmConfig[mockCtlr.Partition].ResourceMap
+			Expect(rsMap).To(HaveLen(2), "Monitor port should not be exposed")
+			rsName := "ingress_link_" + formatVirtualServerName("1.2.3.4", 443)
+			Expect(rsMap).To(HaveKey(rsName))
+			rsCfg := rsMap[rsName]
+			Expect(rsCfg.Monitors[0].Send).To(Equal("GET /healthz/ready HTTP/1.1\r\n"))
+			Expect(rsCfg.Monitors[0].TargetPort).To(BeEquivalentTo(15021))
+			Expect(rsCfg.Virtual.IRules).To(ConsistOf(
+				JoinBigipPath(mockCtlr.Partition, getRSCfgResName(rsName, ProxyProtocolIRuleName))))
+			Expect(rsCfg.IRulesMap).To(HaveLen(1))
+			Expect(proxyProtocolIRule(ProxyProtocolV1)).To(ContainSubstring(`[lindex [split [IP::client_addr] "%"] 0]`),
+				"Route domain should be stripped from the v1 header")
+
+			// explicit ports and monitor
+			IngressLink1.Spec.Ports = []int32{443}
+			IngressLink1.Spec.ProxyProtocol = ""
+			IngressLink1.Spec.Monitor = &cisapiv1.IngressLinkMonitor{Path: "/ready", Port: 8443, Recv: "200 OK"}
+			err = mockCtlr.processIngressLink(IngressLink1, false)
+			Expect(err).To(BeNil(), "Failed to process IngressLink while update")
+			Expect(rsMap).To(HaveLen(1), "Virtuals of removed ports should be deleted")
+			rsCfg = rsMap[rsName]
+			Expect(rsCfg.Monitors[0].Send).To(Equal("GET /ready HTTP/1.1\r\n"))
+			Expect(rsCfg.Monitors[0].Recv).To(Equal("200 OK"))
+			Expect(rsCfg.Monitors[0].TargetPort).To(BeEquivalentTo(8443))
+			Expect(rsCfg.Virtual.IRules).To(BeEmpty())
+
+			IngressLink1.Spec.ProxyProtocol = "v3"
+			Expect(mockCtlr.validateIngressLinkSpec(IngressLink1)).NotTo(BeNil())
+			IngressLink1.Spec.ProxyProtocol = ""
+			IngressLink1.Spec.IngressController = "haproxy"
+			Expect(mockCtlr.validateIngressLinkSpec(IngressLink1)).NotTo(BeNil())
+		})
+
+		It("Processing TLS passthrough TransportServers", func() {
+			mockCtlr.namespaces = map[string]bool{namespace: true}
+			mockCtlr.TeemData = &teem.TeemsData{
+				ResourceType: teem.ResourceTypes{
+					TransportServer: make(map[string]int),
+				},
+			}
+			newTS := func(name, host, svc string) *cisapiv1.TransportServer {
+				ts := test.NewTransportServer(name, namespace, cisapiv1.TransportServerSpec{
+					VirtualServerAddress: "10.1.1.1",
+					VirtualServerPort:    443,
+					Host:                 host,
+					TLSPassthrough:       true,
+					Pool:                 cisapiv1.Pool{Service: svc, ServicePort: 443},
+				})
+				_ = mockCtlr.crInformers["default"].tsInformer.GetIndexer().Add(ts)
+				return ts
+			}
+			ts1 := newTS("ts1", "foo.com", "svc1")
+			ts2 := newTS("ts2", "Bar.com", "svc2")
+			Expect(mockCtlr.processTransportServers(ts2, false)).To(BeNil())
+			Expect(mockCtlr.processTransportServers(ts1, false)).To(BeNil())
+
+			rsName := formatVirtualServerName("10.1.1.1", 443)
+			rsMap := mockCtlr.resources.ltmConfig[mockCtlr.Partition].ResourceMap
+			Expect(rsMap).To(HaveLen(1), "TransportServers should share the virtual")
+			rsCfg := rsMap[rsName]
+			Expect(rsCfg.Pools).To(HaveLen(2))
+			Expect(rsCfg.Virtual.PoolName).To(BeEmpty())
+			Expect(rsCfg.MetaData.baseResources).To(HaveLen(2))
+			Expect(rsCfg.Virtual.IRules).To(ConsistOf(
+				JoinBigipPath(mockCtlr.Partition, getRSCfgResName(rsName, TLSIRuleName))))
+			dg := rsCfg.IntDgMap[NameRef{Name: getRSCfgResName(rsName, PassthroughHostsDgName), Partition: mockCtlr.Partition}]
+			Expect(dg[namespace].Records).To(ConsistOf(
+				InternalDataGroupRecord{Name: "foo.com", Data: rsCfg.Pools[0].Name},
+				InternalDataGroupRecord{Name: "bar.com", Data: rsCfg.Pools[1].Name},
+			))
+			Expect(rsCfg.Virtual.IpProtocol).To(Equal("tcp"))
+
+			// the other TransportServers are validated without modifying them
+			ts3 := newTS("ts3", "baz.com", "svc3")
+			Expect(mockCtlr.processTransportServers(ts1, false)).To(BeNil())
+			Expect(ts3.Spec.Type).To(BeEmpty(), "TransportServer in the informer cache should not be modified")
+			Expect(rsMap[rsName].Pools).To(HaveLen(3))
+			_ = mockCtlr.crInformers["default"].tsInformer.GetIndexer().Delete(ts3)
+
+			// the Policy error of the shared virtual is returned so that the TransportServer is retried
+			ts1.Spec.PolicyName = "missing"
+			Expect(mockCtlr.processTransportServers(ts1, false)).NotTo(BeNil())
+			ts1.Spec.PolicyName = ""
+
+			_ = mockCtlr.crInformers["default"].tsInformer.GetIndexer().Delete(ts1)
+			Expect(mockCtlr.processTransportServers(ts1, true)).To(BeNil())
+			Expect(rsMap[rsName].Pools).To(HaveLen(1), "Pool of the deleted TransportServer should be removed")
+			_ = mockCtlr.crInformers["default"].tsInformer.GetIndexer().Delete(ts2)
+			Expect(mockCtlr.processTransportServers(ts2, true)).To(BeNil())
+			Expect(rsMap).To(BeEmpty())
+
+			ts1.Spec.Host = ""
+			Expect(mockCtlr.validateTransportServerSpec(ts1)).NotTo(BeNil(), "Host should be required")
+		})
+
+		It("Processing TransportServer with multiple ports and port ranges", func() {
+			mockCtlr.namespaces = map[string]bool{namespace: true}
+			mockCtlr.TeemData = &teem.TeemsData{
+				ResourceType: teem.ResourceTypes{
+					TransportServer: make(map[string]int),
+				},
+			}
+			ts := test.NewTransportServer("ts1", namespace, cisapiv1.TransportServerSpec{
+				VirtualServerAddress: "10.1.1.1",
+				VirtualServerPorts:   []string{"5060", "5061", "10000-10100"},
+				Type:                 "udp",
+				Mode:                 "standard",
+				Pool:                 cisapiv1.Pool{Service: "svc1"},
+			})
+			_ = mockCtlr.crInformers["default"].tsInformer.GetIndexer().Add(ts)
+			Expect(mockCtlr.validateTransportServerSpec(ts)).NotTo(BeNil(),
+				"servicePort should be required for the port ranges")
+			ts.Spec.Pool.ServicePort = 5060
+			Expect(mockCtlr.processTransportServers(ts, false)).To(BeNil())
+
+			rsMap := mockCtlr.resources.ltmConfig[mockCtlr.Partition].ResourceMap
+			Expect(rsMap).To(HaveLen(3))
+			Expect(rsMap).To(HaveKey(formatVirtualServerName("10.1.1.1", 5061)))
+			rangeName := getPortRangeVirtualName(ts, "10.1.1.1")
+			Expect(rangeName).To(Equal("crd_10_1_1_1_udp_port_ranges"))
+			Expect(rsMap).To(HaveKey(rangeName))
+			Expect(rsMap[rangeName].Virtual.Destination).To(Equal("/" + mockCtlr.Partition + "/10.1.1.1:0"))
+			Expect(rsMap[rangeName].Virtual.IRules).To(ConsistOf(
+				JoinBigipPath(mockCtlr.Partition, getRSCfgResName(rangeName, PortRangeIRuleName))))
+			iRule := rsMap[rangeName].IRulesMap[NameRef{Name: getRSCfgResName(rangeName, PortRangeIRuleName), Partition: mockCtlr.Partition}]
+			Expect(iRule.Code).To(ContainSubstring("UDP::local_port"))
+			Expect(iRule.Code).To(ContainSubstring("$port >= 10000 && $port <= 10100"))
+
+			sharedApp := as3Application{}
+			createTransportServiceDecl(rsMap[rangeName], sharedApp)
+			svc := sharedApp[rangeName].(*as3Service)
+			Expect(svc.VirtualPort).To(Equal(0))
+			Expect(*svc.TranslateServerPort).To(BeFalse(), "Wildcard port should not be translated")
+			Expect(svc.Pool).To(BeEmpty(), "Port range virtual should select the pool by the port")
+			data, _ := json.Marshal(svc)
+			Expect(string(data)).To(ContainSubstring(`"virtualPort":0`))
+
+			// disjoint port ranges on the address share the port range virtual
+			ts2 := test.NewTransportServer("ts2", namespace, cisapiv1.TransportServerSpec{
+				VirtualServerAddress: "10.1.1.1",
+				VirtualServerPorts:   []string{"20000-20100"},
+				Type:                 "udp",
+				Mode:                 "standard",
+				Pool:                 cisapiv1.Pool{Service: "svc2", ServicePort: 20000},
+			})
+			_ = mockCtlr.crInformers["default"].tsInformer.GetIndexer().Add(ts2)
+			Expect(mockCtlr.processTransportServers(ts2, false)).To(BeNil())
+			Expect(rsMap).To(HaveLen(3))
+			Expect(rsMap[rangeName].MetaData.baseResources).To(HaveLen(2))
+			Expect(rsMap[rangeName].Pools).To(HaveLen(2))
+			iRule = rsMap[rangeName].IRulesMap[NameRef{Name: getRSCfgResName(rangeName, PortRangeIRuleName), Partition: mockCtlr.Partition}]
+			Expect(iRule.Code).To(ContainSubstring("$port >= 20000 && $port <= 20100"))
+			Expect(iRule.Code).To(ContainSubstring("pool /" + mockCtlr.Partition + "/Shared/" + rsMap[rangeName].Pools[1].Name))
+			ts2.Spec.VirtualServerPorts = []string{"10050-10200"}
+			Expect(mockCtlr.checkTransportServerConflicts(ts2)).NotTo(BeNil(), "Overlapping port ranges should be denied")
+
+			// the port range virtual is rebuilt without the ranges removed from the TransportServer
+			ts2.Spec.VirtualServerPorts = []string{"5062"}
+			Expect(mockCtlr.processTransportServers(ts2, false)).To(BeNil())
+			Expect(rsMap).To(HaveLen(4))
+			Expect(rsMap[rangeName].MetaData.baseResources).To(HaveLen(1))
+			_ = mockCtlr.crInformers["default"].tsInformer.GetIndexer().Delete(ts2)
+			Expect(mockCtlr.processTransportServers(ts2, true)).To(BeNil())
+
+			_ = mockCtlr.crInformers["default"].tsInformer.GetIndexer().Delete(ts)
+			Expect(mockCtlr.processTransportServers(ts, true)).To(BeNil())
+			Expect(rsMap).To(BeEmpty())
+
+			// ports map to the matching ports of the multi-port service
+			ts.Spec.VirtualServerPorts = []string{"5060", "5061"}
+			ts.Spec.Pool.ServicePort = 0
+			_ = mockCtlr.crInformers["default"].tsInformer.GetIndexer().Add(ts)
+			Expect(mockCtlr.processTransportServers(ts, false)).To(BeNil())
+			Expect(rsMap).To(HaveLen(2))
+			Expect(rsMap[formatVirtualServerName("10.1.1.1", 5061)].Pools[0].ServicePort.IntVal).To(BeEquivalentTo(5061))
+
+			ts.Spec.VirtualServerPorts = []string{"6000-5000"}
+			Expect(mockCtlr.validateTransportServerSpec(ts)).NotTo(BeNil(), "Invalid range should be denied")
+		})
+
+		It("Processing forwarding TransportServer", func() {
+			mockCtlr.TeemData = &teem.TeemsData{
+				ResourceType: teem.ResourceTypes{
+					TransportServer: make(map[string]int),
+				},
+			}
+			ts := test.NewTransportServer("ts1", namespace, cisapiv1.TransportServerSpec{
+				VirtualServerAddress: "0.0.0.0",
+				VirtualServerPort:    0,
+				Type:                 "any",
+				Mode:                 "forwarding",
+			})
+			_ = mockCtlr.crInformers["default"].tsInformer.GetIndexer().Add(ts)
+			Expect(mockCtlr.validateTransportServerSpec(ts)).To(BeNil())
+			Expect(mockCtlr.processTransportServers(ts, false)).To(BeNil())
+
+			rsName := formatVirtualServerName("0.0.0.0", 0)
+			rsCfg := mockCtlr.resources.ltmConfig[mockCtlr.Partition].ResourceMap[rsName]
+			Expect(rsCfg).NotTo(BeNil())
+			Expect(rsCfg.Pools).To(BeEmpty())
+			Expect(rsCfg.Virtual.SNAT).To(Equal("none"))
+
+			sharedApp := as3Application{}
+			createTransportServiceDecl(rsCfg, sharedApp)
+			svc := sharedApp[rsName].(*as3Service)
+			Expect(svc.Class).To(Equal("Service_Forwarding"))
+			Expect(svc.ForwardingType).To(Equal("ip"))
+			Expect(svc.Layer4).To(Equal("any"))
+			Expect(svc.Pool).To(BeEmpty())
+
+			ts.Spec.Pool = cisapiv1.Pool{Service: "svc1", ServicePort: 80}
+			Expect(mockCtlr.validateTransportServerSpec(ts)).NotTo(BeNil(), "Pool should be denied")
+		})
 	})
 
 	It("get node port", func() {
@@ -1341,6 +1713,73 @@ var _ = Describe("Worker Tests", func() {
 			mockCtlr.updatePoolMembersForNodePort(rsCfg, "default")
 			Expect(len(rsCfg.Pools[0].Members)).To(Equal(2), "Members should be reduced")
 		})
+		It("verify pool members of the endpoint nodes", func() {
+			mockCtlr.nodePortEndpointNodes = true
+			mockCtlr.oldNodes = append(mockCtlr.oldNodes, Node{Name: "node-3", Addr: "10.10.10.3"})
+			memberMap := make(map[portRef][]PoolMember)
+			memberMap[portRef{name: "https", port: 8443}] = []PoolMember{
+				{Address: "10.244.1.1", Port: 8443, NodeName: "node-1"},
+				{Address: "10.244.1.2", Port: 8443, NodeName: "node-1"},
+				{Address: "10.244.3.1", Port: 8443, NodeName: "node-3"},
+			}
+			memberMap[portRef{name: "http", port: 8080}] = []PoolMember{
+				{Address: "10.244.2.1", Port: 8080, NodeName: "node-2"},
+			}
+			pmi := poolMembersInfo{
+				svcType: v1.ServiceTypeNodePort,
+				portSpec: []v1.ServicePort{
+					{Name: "https", Port: 443, NodePort: 32443, TargetPort: intstr.FromInt(443), Protocol: "TCP"},
+					{Name: "http", Port: 80, NodePort: 32080, TargetPort: intstr.FromInt(80), Protocol: "TCP"},
+				},
+				memberMap: memberMap,
+			}
+			mockCtlr.resources.poolMemCache["default/svc-1"] = pmi
+			rsCfg := &ResourceConfig{Pools: []Pool{{ServiceNamespace: "default",
+				ServiceName: "svc-1",
+				ServicePort: intstr.FromInt(443)}}}
+			mockCtlr.updatePoolMembersForNodePort(rsCfg, "default")
+			Expect(rsCfg.Pools[0].Members).To(Equal([]PoolMember{
+				{Address: "10.10.10.1", Port: 32443, Session: "user-enabled", Ratio: 2},
+				{Address: "10.10.10.3", Port: 32443, Session: "user-enabled", Ratio: 1},
+			}), "Only the nodes hosting the endpoints of the port should be members")
+			Expect(rsCfg.Pools[0].Balance).To(Equal("ratio-member"), "Node members should be weighted by their endpoints")
+
+			// the ratio of the zone multiplies the endpoint count
+			mockCtlr.oldNodes[0].Labels = map[string]string{"zone": "zone-a"}
+			rsCfg.Pools[0].Balance = "least-connections-member"
+			rsCfg.Pools[0].Topology = &cisapiv1.PoolTopology{Label: "zone", Groups: []cisapiv1.TopologyGroup{
+				{Value: "zone-a", Ratio: 3},
+			}}
+			mockCtlr.updatePoolMembersForNodePort(rsCfg, "default")
+			Expect(rsCfg.Pools[0].Members[0].Ratio).To(Equal(6))
+			Expect(rsCfg.Pools[0].Members[1].Ratio).To(Equal(1))
+			Expect(rsCfg.Pools[0].Balance).To(Equal("least-connections-member"), "Pool balance should be kept")
+			rsCfg.Pools[0].Topology = nil
+
+			// the weight of the local cluster of a multi-cluster pool multiplies the endpoint count
+			mockCtlr.multiClusters = map[string]*MultiClusterInformer{}
+			rsCfg.Pools[0].Weight = 70
+			rsCfg.Pools[0].MultiClusterServices = []cisapiv1.MultiClusterServiceReference{
+				{ClusterName: "cluster2", SvcName: "svc-1", Namespace: "default", ServicePort: 443, Weight: 30},
+			}
+			mockCtlr.updatePoolMembersForNodePort(rsCfg, "default")
+			Expect(rsCfg.Pools[0].Members[0].Ratio).To(Equal(140))
+			Expect(rsCfg.Pools[0].Members[1].Ratio).To(Equal(70))
+			rsCfg.Pools[0].Weight = 0
+			mockCtlr.updatePoolMembersForNodePort(rsCfg, "default")
+			Expect(rsCfg.Pools[0].Members[0].Ratio).To(Equal(2), "Ratio should be kept without the weight")
+			rsCfg.Pools[0].MultiClusterServices = nil
+
+			// all the nodes are members when the nodes of the endpoints are not known
+			memberMap[portRef{name: "https", port: 8443}] = []PoolMember{{Address: "10.244.1.1", Port: 8443}}
+			mockCtlr.updatePoolMembersForNodePort(rsCfg, "default")
+			Expect(len(rsCfg.Pools[0].Members)).To(Equal(3), "All the nodes should be members")
+
+			pmi.localTraffic = true
+			mockCtlr.resources.poolMemCache["default/svc-1"] = pmi
+			mockCtlr.updatePoolMembersForNodePort(rsCfg, "default")
+			Expect(len(rsCfg.Pools[0].Members)).To(Equal(0), "No node should be a member with the local traffic policy")
+		})
 	})
 	Describe("Processing Custom Resources", func() {
 		var mockPM *mockPostManager
@@ -1707,8 +2146,8 @@ var _ = Describe("Worker Tests", func() {
 				Expect(len(mockCtlr.resources.ltmConfig)).To(Equal(1), "Virtual Server not processed")
 
 				rscUpdateMeta := resourceStatusMeta{
-					0,
-					make(map[string]struct{}),
+					id:            0,
+					failedTenants: make(map[string]struct{}),
 				}
 
 				time.Sleep(10 * time.Millisecond)
@@ -1798,8 +2237,8 @@ var _ = Describe("Worker Tests", func() {
 				Expect(len(mockCtlr.resources.ltmConfig)).To(Equal(1), "Transport Server not processed")
 
 				rscUpdateMeta := resourceStatusMeta{
-					0,
-					make(map[string]struct{}),
+					id:            0,
+					failedTenants: make(map[string]struct{}),
 				}
 
 				mockCtlr.Agent.respChan <- rscUpdateMeta
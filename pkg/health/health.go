@@ -7,14 +7,34 @@ import (
 	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
 )
 
+// Checker reports the health of a config driver
+type Checker interface {
+	Healthy() error
+}
+
 type HealthChecker struct {
 	SubPID int
+	// NetDriver is the network config driver of the controller
+	NetDriver Checker
 }
 
 // TODO: Add additional health checks
 // TODO: add health check if Kubernetes API is still reachable
 func (hc HealthChecker) HealthCheckHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hc.NetDriver != nil {
+			if err := hc.NetDriver.Healthy(); err != nil {
+				log.Errorf(err.Error())
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte("Network config driver is unhealthy"))
+				return
+			}
+			if hc.SubPID == 0 {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("Ok"))
+				return
+			}
+		}
 		if hc.SubPID != 0 {
 			_, err := os.FindProcess(hc.SubPID)
 			if err == nil {
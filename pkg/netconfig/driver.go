@@ -0,0 +1,306 @@
+/*-
+ * Copyright (c) 2019-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	bigIPPrometheus "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/prometheus"
+	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/writer"
+)
+
+const (
+	// Python runs the python config driver for the network config
+	Python = "python"
+	// Go runs the network config driver of the controller
+	Go = "go"
+
+	// FDBSection is the config section of the tunnel FDB records
+	FDBSection = "vxlan-fdb"
+	// ARPSection is the config section of the ARP entries
+	ARPSection = "vxlan-arp"
+	// RouteSection is the config section of the static routes
+	RouteSection = "static-routes"
+
+	// objects created by the controller are prefixed with the controller prefix
+	objectPrefix = "k8s-"
+
+	// a sync running longer is wedged, with the request timeout it completes well
+	// before even if BIG-IP does not respond
+	wedgedSyncTimeout = 10 * time.Minute
+)
+
+type (
+	// Params are the parameters of the network config driver
+	Params struct {
+		BigIPURL       string
+		BigIPUsername  string
+		BigIPPassword  string
+		TrustedCerts   string
+		SSLInsecure    bool
+		Partition      string
+		VerifyInterval time.Duration
+		DisableARP     bool
+	}
+
+	// Driver configures the tunnel FDB records, ARP entries and static routes on BIG-IP
+	// with iControl REST. The driver consumes the network config sections written for
+	// the python config driver and writes any other section to the next writer.
+	Driver struct {
+		Params
+		next       writer.Writer
+		httpClient *http.Client
+		mutex      sync.Mutex
+		fdb        *fdbSection
+		arps       *arpSection
+		routes     *routeSection
+		syncStart  time.Time
+		updateCh   chan struct{}
+		stopCh     chan struct{}
+		stopOnce   sync.Once
+	}
+
+	fdbSection struct {
+		TunnelName string      `json:"name"`
+		Records    []fdbRecord `json:"records"`
+	}
+
+	fdbRecord struct {
+		Name     string `json:"name,omitempty"`
+		Endpoint string `json:"endpoint"`
+	}
+
+	arpSection struct {
+		Entries []arpEntry `json:"arps"`
+	}
+
+	arpEntry struct {
+		Name      string `json:"name"`
+		Partition string `json:"partition,omitempty"`
+		IPAddr    string `json:"ipAddress"`
+		MACAddr   string `json:"macAddress"`
+	}
+
+	routeSection struct {
+		Routes []staticRoute `json:"routes"`
+	}
+
+	staticRoute struct {
+		Name      string `json:"name"`
+		Partition string `json:"partition,omitempty"`
+		Network   string `json:"network"`
+		Gateway   string `json:"gw"`
+	}
+)
+
+// NewDriver returns the network config driver, next is the writer of the other sections
+func NewDriver(params Params, next writer.Writer) (*Driver, error) {
+	if params.BigIPURL == "" {
+		return nil, fmt.Errorf("required parameter BIG-IP URL not supplied")
+	}
+	if params.Partition == "" {
+		params.Partition = "Common"
+	}
+	if params.VerifyInterval <= 0 {
+		params.VerifyInterval = 30 * time.Second
+	}
+
+	rootCAs, _ := x509.SystemCertPool()
+	if rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if ok := rootCAs.AppendCertsFromPEM([]byte(params.TrustedCerts)); !ok {
+		log.Debug("[NET] No certs appended, using only system certs")
+	}
+	driver := &Driver{
+		Params: params,
+		next:   next,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: params.SSLInsecure,
+					RootCAs:            rootCAs,
+				},
+			},
+			Timeout: 30 * time.Second,
+		},
+		updateCh: make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+	}
+	go driver.run()
+
+	log.Infof("[NET] Network config driver started for partition %v", params.Partition)
+	return driver, nil
+}
+
+// GetOutputFilename returns the config file of the next writer
+func (d *Driver) GetOutputFilename() string {
+	if d.next == nil {
+		return ""
+	}
+	return d.next.GetOutputFilename()
+}
+
+// Stop stops the driver and the next writer
+func (d *Driver) Stop() {
+	d.stopOnce.Do(func() {
+		close(d.stopCh)
+		if d.next != nil {
+			d.next.Stop()
+		}
+		log.Info("[NET] Network config driver stopped")
+	})
+}
+
+// SendSection applies the network config sections and writes the other sections
+// to the next writer
+func (d *Driver) SendSection(
+	name string,
+	obj interface{},
+) (<-chan struct{}, <-chan error, error) {
+	var err error
+	switch name {
+	case FDBSection:
+		fdb := &fdbSection{}
+		if err = decodeSection(obj, fdb); err == nil {
+			d.mutex.Lock()
+			d.fdb = fdb
+			d.mutex.Unlock()
+		}
+	case ARPSection:
+		arps := &arpSection{}
+		if err = decodeSection(obj, arps); err == nil {
+			d.mutex.Lock()
+			d.arps = arps
+			d.mutex.Unlock()
+		}
+	case RouteSection:
+		routes := &routeSection{}
+		if err = decodeSection(obj, routes); err == nil {
+			d.mutex.Lock()
+			d.routes = routes
+			d.mutex.Unlock()
+		}
+	default:
+		if d.next != nil {
+			return d.next.SendSection(name, obj)
+		}
+		log.Debugf("[NET] Ignoring config section %v", name)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid config section %v: %v", name, err)
+	}
+	if name == FDBSection || name == ARPSection || name == RouteSection {
+		select {
+		case d.updateCh <- struct{}{}:
+		default:
+		}
+	}
+
+	done := make(chan struct{})
+	close(done)
+	return done, make(chan error), nil
+}
+
+// Healthy returns an error when the driver is stopped or a sync is wedged. A sync
+// failing on BIG-IP is not unhealthy, as a restart of CIS does not fix BIG-IP,
+// it is reported by the net config sync metrics instead.
+func (d *Driver) Healthy() error {
+	select {
+	case <-d.stopCh:
+		return fmt.Errorf("network config driver is stopped")
+	default:
+	}
+	d.mutex.Lock()
+	syncStart := d.syncStart
+	d.mutex.Unlock()
+	if !syncStart.IsZero() && time.Since(syncStart) > wedgedSyncTimeout {
+		return fmt.Errorf("network config driver is syncing BIG-IP since %v", syncStart.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func decodeSection(obj interface{}, section interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, section)
+}
+
+// run syncs the network config on an update of a section and on every verify interval,
+// so that the changes made on BIG-IP are reverted
+func (d *Driver) run() {
+	ticker := time.NewTicker(d.VerifyInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case <-d.updateCh:
+		case <-ticker.C:
+		}
+		d.mutex.Lock()
+		d.syncStart = time.Now()
+		d.mutex.Unlock()
+		if err := d.sync(); err != nil {
+			log.Errorf("[NET] Failed to configure the network config on BIG-IP: %v", err)
+		}
+		d.mutex.Lock()
+		d.syncStart = time.Time{}
+		d.mutex.Unlock()
+	}
+}
+
+// sync configures the sections received, a section which is not received is not managed
+func (d *Driver) sync() error {
+	d.mutex.Lock()
+	fdb, arps, routes := d.fdb, d.arps, d.routes
+	d.mutex.Unlock()
+
+	var errs []string
+	if fdb != nil && fdb.TunnelName != "" {
+		if err := d.syncFDB(*fdb); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if arps != nil && !d.DisableARP {
+		if err := d.syncARPs(*arps); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if routes != nil {
+		if err := d.syncRoutes(*routes); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		bigIPPrometheus.NetConfigSyncFailures.WithLabelValues().Inc()
+		return fmt.Errorf("%v", strings.Join(errs, "; "))
+	}
+	bigIPPrometheus.NetConfigLastSync.WithLabelValues().SetToCurrentTime()
+	return nil
+}
@@ -0,0 +1,236 @@
+package netconfig
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	bigIPPrometheus "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/prometheus"
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// mockBigIP serves the FDB tunnel, ARP and route iControl REST endpoints
+type mockBigIP struct {
+	sync.Mutex
+	records  []fdbRecord
+	arps     map[string]arpEntry
+	routes   map[string]staticRoute
+	requests []string
+}
+
+func (bigip *mockBigIP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bigip.Lock()
+	defer bigip.Unlock()
+	bigip.requests = append(bigip.requests, r.Method+" "+r.URL.Path)
+	body, _ := ioutil.ReadAll(r.Body)
+	path := r.URL.Path
+	switch {
+	case path == fdbTunnelURI+"~Common~vxlan-tunnel":
+		if r.Method == http.MethodPatch {
+			var tunnel fdbSection
+			_ = json.Unmarshal(body, &tunnel)
+			bigip.records = tunnel.Records
+		}
+		_ = json.NewEncoder(w).Encode(fdbSection{TunnelName: "vxlan-tunnel", Records: bigip.records})
+	case strings.HasPrefix(path, arpURI):
+		name := strings.TrimPrefix(strings.TrimPrefix(path, arpURI), "/~Common~")
+		switch r.Method {
+		case http.MethodGet:
+			var items []arpEntry
+			for _, entry := range bigip.arps {
+				items = append(items, entry)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+		case http.MethodPost:
+			var entry arpEntry
+			_ = json.Unmarshal(body, &entry)
+			bigip.arps[entry.Name] = entry
+		case http.MethodPatch:
+			entry := bigip.arps[name]
+			_ = json.Unmarshal(body, &entry)
+			bigip.arps[name] = entry
+		case http.MethodDelete:
+			delete(bigip.arps, name)
+		}
+	case strings.HasPrefix(path, routeURI):
+		name := strings.TrimPrefix(strings.TrimPrefix(path, routeURI), "/~Common~")
+		switch r.Method {
+		case http.MethodGet:
+			var items []staticRoute
+			for _, route := range bigip.routes {
+				items = append(items, route)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+		case http.MethodPost:
+			var route staticRoute
+			_ = json.Unmarshal(body, &route)
+			bigip.routes[route.Name] = route
+		case http.MethodPatch:
+			route := bigip.routes[name]
+			_ = json.Unmarshal(body, &route)
+			bigip.routes[name] = route
+		case http.MethodDelete:
+			delete(bigip.routes, name)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"code":404,"message":"01020036:3: The requested object was not found."}`))
+	}
+}
+
+var _ = Describe("Network Config Driver", func() {
+	var bigip *mockBigIP
+	var server *httptest.Server
+	var driver *Driver
+	var mw *test.MockWriter
+
+	BeforeEach(func() {
+		bigip = &mockBigIP{
+			arps: map[string]arpEntry{
+				"k8s-10.244.1.9": {Name: "k8s-10.244.1.9", Partition: "Common", IPAddr: "10.244.1.9", MACAddr: "aa:bb:cc:dd:ee:ff"},
+				"user-arp":       {Name: "user-arp", Partition: "Common", IPAddr: "10.1.1.1", MACAddr: "aa:bb:cc:dd:ee:00"},
+			},
+			routes: map[string]staticRoute{
+				"k8s-node3": {Name: "k8s-node3", Partition: "Common", Network: "10.244.3.0/24", Gateway: "10.10.10.3"},
+			},
+		}
+		server = httptest.NewServer(bigip)
+		mw = &test.MockWriter{FailStyle: test.Success, Sections: make(map[string]interface{})}
+		var err error
+		driver, err = NewDriver(Params{
+			BigIPURL:       server.URL,
+			BigIPUsername:  "admin",
+			BigIPPassword:  "admin",
+			VerifyInterval: time.Hour,
+		}, mw)
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		driver.Stop()
+		server.Close()
+	})
+
+	It("Requires the BIG-IP URL", func() {
+		_, err := NewDriver(Params{}, nil)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("Writes the other sections to the next writer", func() {
+		doneCh, _, err := driver.SendSection("global", map[string]string{"log-level": "INFO"})
+		Expect(err).To(BeNil())
+		Eventually(doneCh).Should(Receive())
+		Expect(mw.Sections).To(HaveKey("global"))
+		Expect(mw.Sections).NotTo(HaveKey(FDBSection))
+	})
+
+	It("Configures the FDB records, ARP entries and static routes", func() {
+		_, _, err := driver.SendSection(FDBSection, map[string]interface{}{
+			"name":    "vxlan-tunnel",
+			"records": []map[string]string{{"name": "0a:0a:0a:0a:0a:01", "endpoint": "10.10.10.1"}},
+		})
+		Expect(err).To(BeNil())
+		_, _, err = driver.SendSection(ARPSection, map[string]interface{}{
+			"arps": []map[string]string{
+				{"name": "k8s-10.244.1.2", "ipAddress": "10.244.1.2", "macAddress": "0a:0a:0a:0a:0a:01"},
+			},
+		})
+		Expect(err).To(BeNil())
+		_, _, err = driver.SendSection(RouteSection, routeSection{Routes: []staticRoute{
+			{Name: "k8s-node1", Network: "10.244.1.0/24", Gateway: "10.10.10.1"},
+		}})
+		Expect(err).To(BeNil())
+		Expect(driver.sync()).To(BeNil())
+
+		bigip.Lock()
+		Expect(bigip.records).To(Equal([]fdbRecord{{Name: "0a:0a:0a:0a:0a:01", Endpoint: "10.10.10.1"}}))
+		Expect(bigip.arps).To(HaveKey("k8s-10.244.1.2"))
+		Expect(bigip.arps).NotTo(HaveKey("k8s-10.244.1.9"), "Stale ARP entry should be deleted")
+		Expect(bigip.arps).To(HaveKey("user-arp"), "ARP entry not created by the controller should be kept")
+		Expect(bigip.routes).To(Equal(map[string]staticRoute{
+			"k8s-node1": {Name: "k8s-node1", Partition: "Common", Network: "10.244.1.0/24", Gateway: "10.10.10.1"},
+		}))
+		bigip.requests = nil
+		bigip.Unlock()
+
+		Expect(driver.sync()).To(BeNil())
+		bigip.Lock()
+		for _, req := range bigip.requests {
+			Expect(req).To(HavePrefix(http.MethodGet), "Config in sync should not be updated")
+		}
+		bigip.Unlock()
+		Expect(driver.Healthy()).To(BeNil())
+	})
+
+	It("Reports the errors of BIG-IP", func() {
+		_, _, err := driver.SendSection(FDBSection, fdbSection{TunnelName: "unknown"})
+		Expect(err).To(BeNil())
+		err = driver.sync()
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring("The requested object was not found"))
+
+		// a failed sync is counted but the driver is healthy, a restart does not fix BIG-IP
+		failures := counterValue(bigIPPrometheus.NetConfigSyncFailures.WithLabelValues())
+		Expect(driver.sync()).NotTo(BeNil())
+		Expect(counterValue(bigIPPrometheus.NetConfigSyncFailures.WithLabelValues())).To(Equal(failures + 1))
+		Expect(driver.Healthy()).To(BeNil())
+
+		// a sync which does not complete is wedged
+		driver.mutex.Lock()
+		driver.syncStart = time.Now().Add(-time.Hour)
+		driver.mutex.Unlock()
+		Expect(driver.Healthy()).NotTo(BeNil())
+
+		driver.Stop()
+		Expect(driver.Healthy()).NotTo(BeNil())
+	})
+
+	It("Writes the static routes of the nodes", func() {
+		routeMgr := NewStaticRouteMgr(true, mw)
+		nodes := []v1.Node{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+				Spec:       v1.NodeSpec{PodCIDRs: []string{"fd00:10:244:1::/64", "10.244.1.0/24"}},
+				Status: v1.NodeStatus{Addresses: []v1.NodeAddress{
+					{Type: v1.NodeInternalIP, Address: "10.10.10.1"},
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "node2"},
+				Spec: v1.NodeSpec{
+					PodCIDR: "10.244.2.0/24",
+					Taints:  []v1.Taint{{Key: "node.kubernetes.io/unreachable", Effect: v1.TaintEffectNoExecute}},
+				},
+				Status: v1.NodeStatus{Addresses: []v1.NodeAddress{
+					{Type: v1.NodeInternalIP, Address: "10.10.10.2"},
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "node3"},
+				Status: v1.NodeStatus{Addresses: []v1.NodeAddress{
+					{Type: v1.NodeInternalIP, Address: "10.10.10.3"},
+				}},
+			},
+		}
+		routeMgr.ProcessNodeUpdate(nodes, nil)
+		Expect(mw.Sections[RouteSection]).To(Equal(routeSection{Routes: []staticRoute{
+			{Name: "k8s-node1", Network: "10.244.1.0/24", Gateway: "10.10.10.1"},
+		}}))
+	})
+})
+
+func counterValue(counter prometheus.Counter) float64 {
+	var m dto.Metric
+	_ = counter.Write(&m)
+	return m.GetCounter().GetValue()
+}
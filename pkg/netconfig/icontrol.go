@@ -0,0 +1,233 @@
+/*-
+ * Copyright (c) 2019-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+)
+
+const (
+	fdbTunnelURI = "/mgmt/tm/net/fdb/tunnel/"
+	arpURI       = "/mgmt/tm/net/arp"
+	routeURI     = "/mgmt/tm/net/route"
+)
+
+// syncFDB replaces the records of the tunnel when they differ from the section
+func (d *Driver) syncFDB(fdb fdbSection) error {
+	uri := fdbTunnelURI + d.objectPath(fdb.TunnelName)
+	var tunnel fdbSection
+	if err := d.request(http.MethodGet, uri, nil, &tunnel); err != nil {
+		return fmt.Errorf("failed to get the FDB records of tunnel %v: %v", fdb.TunnelName, err)
+	}
+	current := make(map[string]string)
+	for _, rec := range tunnel.Records {
+		current[rec.Name] = rec.Endpoint
+	}
+	desired := make(map[string]string)
+	for _, rec := range fdb.Records {
+		desired[rec.Name] = rec.Endpoint
+	}
+	if equalMaps(current, desired) {
+		return nil
+	}
+	records := fdb.Records
+	if records == nil {
+		records = []fdbRecord{}
+	}
+	log.Debugf("[NET] Updating the FDB records of tunnel %v: %v", fdb.TunnelName, records)
+	if err := d.request(http.MethodPatch, uri, map[string]interface{}{"records": records}, nil); err != nil {
+		return fmt.Errorf("failed to update the FDB records of tunnel %v: %v", fdb.TunnelName, err)
+	}
+	return nil
+}
+
+// syncARPs creates, updates and deletes the ARP entries of the controller in the partition
+func (d *Driver) syncARPs(arps arpSection) error {
+	var list struct {
+		Items []arpEntry `json:"items"`
+	}
+	if err := d.request(http.MethodGet, d.partitionFilter(arpURI), nil, &list); err != nil {
+		return fmt.Errorf("failed to get the ARP entries: %v", err)
+	}
+	current := make(map[string]arpEntry)
+	for _, entry := range list.Items {
+		if strings.HasPrefix(entry.Name, objectPrefix) {
+			current[entry.Name] = entry
+		}
+	}
+
+	var errs []string
+	desired := make(map[string]bool)
+	for _, entry := range arps.Entries {
+		desired[entry.Name] = true
+		entry.Partition = d.Partition
+		cur, found := current[entry.Name]
+		var err error
+		if !found {
+			log.Debugf("[NET] Creating ARP entry %v", entry.Name)
+			err = d.request(http.MethodPost, arpURI, entry, nil)
+		} else if cur.IPAddr != entry.IPAddr || !strings.EqualFold(cur.MACAddr, entry.MACAddr) {
+			log.Debugf("[NET] Updating ARP entry %v", entry.Name)
+			err = d.request(http.MethodPatch, arpURI+"/"+d.objectPath(entry.Name),
+				map[string]string{"ipAddress": entry.IPAddr, "macAddress": entry.MACAddr}, nil)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("ARP entry %v: %v", entry.Name, err))
+		}
+	}
+	for name := range current {
+		if desired[name] {
+			continue
+		}
+		log.Debugf("[NET] Deleting ARP entry %v", name)
+		if err := d.request(http.MethodDelete, arpURI+"/"+d.objectPath(name), nil, nil); err != nil {
+			errs = append(errs, fmt.Sprintf("ARP entry %v: %v", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to update the ARP entries: %v", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// syncRoutes creates, updates and deletes the static routes of the controller in the partition
+func (d *Driver) syncRoutes(routes routeSection) error {
+	var list struct {
+		Items []staticRoute `json:"items"`
+	}
+	if err := d.request(http.MethodGet, d.partitionFilter(routeURI), nil, &list); err != nil {
+		return fmt.Errorf("failed to get the static routes: %v", err)
+	}
+	current := make(map[string]staticRoute)
+	for _, route := range list.Items {
+		if strings.HasPrefix(route.Name, objectPrefix) {
+			current[route.Name] = route
+		}
+	}
+
+	var errs []string
+	desired := make(map[string]bool)
+	for _, route := range routes.Routes {
+		desired[route.Name] = true
+		route.Partition = d.Partition
+		cur, found := current[route.Name]
+		var err error
+		if !found {
+			log.Debugf("[NET] Creating static route %v", route.Name)
+			err = d.request(http.MethodPost, routeURI, route, nil)
+		} else if cur.Network != route.Network {
+			// the network of a route can not be modified
+			log.Debugf("[NET] Replacing static route %v", route.Name)
+			err = d.request(http.MethodDelete, routeURI+"/"+d.objectPath(route.Name), nil, nil)
+			if err == nil {
+				err = d.request(http.MethodPost, routeURI, route, nil)
+			}
+		} else if cur.Gateway != route.Gateway {
+			log.Debugf("[NET] Updating static route %v", route.Name)
+			err = d.request(http.MethodPatch, routeURI+"/"+d.objectPath(route.Name),
+				map[string]string{"gw": route.Gateway}, nil)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("static route %v: %v", route.Name, err))
+		}
+	}
+	for name := range current {
+		if desired[name] {
+			continue
+		}
+		log.Debugf("[NET] Deleting static route %v", name)
+		if err := d.request(http.MethodDelete, routeURI+"/"+d.objectPath(name), nil, nil); err != nil {
+			errs = append(errs, fmt.Sprintf("static route %v: %v", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to update the static routes: %v", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// objectPath returns the iControl REST path of the object in the partition
+func (d *Driver) objectPath(name string) string {
+	return "~" + d.Partition + "~" + url.PathEscape(name)
+}
+
+func (d *Driver) partitionFilter(uri string) string {
+	return uri + "?$filter=" + url.QueryEscape("partition eq "+d.Partition)
+}
+
+// request sends the iControl REST request and decodes the response into out
+func (d *Driver) request(method, uri string, body interface{}, out interface{}) error {
+	var reqBody *bytes.Buffer
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewBuffer(data)
+	} else {
+		reqBody = &bytes.Buffer{}
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(d.BigIPURL, "/")+uri, reqBody)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(d.BigIPUsername, d.BigIPPassword)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		var respErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &respErr) == nil && respErr.Message != "" {
+			return fmt.Errorf("%v %v", resp.StatusCode, respErr.Message)
+		}
+		return fmt.Errorf("%v %v", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	if out != nil && len(data) > 0 {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+func equalMaps(m1, m2 map[string]string) bool {
+	if len(m1) != len(m2) {
+		return false
+	}
+	for k, v := range m1 {
+		if v2, ok := m2[k]; !ok || v != v2 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,13 @@
+package netconfig
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestNetConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "NetConfig Suite")
+}
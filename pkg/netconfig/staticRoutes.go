@@ -0,0 +1,116 @@
+/*-
+ * Copyright (c) 2019-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netconfig
+
+import (
+	"net"
+	"time"
+
+	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+	"github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/writer"
+	v1 "k8s.io/api/core/v1"
+)
+
+// StaticRouteMgr writes a static route to the pod CIDR of each node through the node address
+type StaticRouteMgr struct {
+	useNodeInt bool
+	config     writer.Writer
+}
+
+// NewStaticRouteMgr returns the static route manager
+func NewStaticRouteMgr(useNodeInternal bool, config writer.Writer) *StaticRouteMgr {
+	return &StaticRouteMgr{
+		useNodeInt: useNodeInternal,
+		config:     config,
+	}
+}
+
+// ProcessNodeUpdate writes the static routes of the nodes
+func (srm *StaticRouteMgr) ProcessNodeUpdate(obj interface{}, err error) {
+	if nil != err {
+		log.Warningf("[NET] Static route manager unable to get list of nodes: %v", err)
+		return
+	}
+	nodes, ok := obj.([]v1.Node)
+	if !ok {
+		log.Warningf("[NET] Static route manager received poll update with unexpected type")
+		return
+	}
+
+	routes := routeSection{Routes: []staticRoute{}}
+	for _, node := range nodes {
+		if route, ok := srm.nodeRoute(node); ok {
+			routes.Routes = append(routes.Routes, route)
+		}
+	}
+
+	doneCh, errCh, err := srm.config.SendSection(RouteSection, routes)
+	if nil != err {
+		log.Warningf("[NET] Static route manager failed to write config section: %v", err)
+		return
+	}
+	select {
+	case <-doneCh:
+		log.Debugf("[NET] Static route manager wrote config section: %v", routes)
+	case e := <-errCh:
+		log.Warningf("[NET] Static route manager failed to write config section: %v", e)
+	case <-time.After(time.Second):
+		log.Warning("[NET] Static route manager did not receive write response in 1s")
+	}
+}
+
+// nodeRoute returns the route to the pod CIDR of the node of the address family of the node address
+func (srm *StaticRouteMgr) nodeRoute(node v1.Node) (staticRoute, bool) {
+	for _, t := range node.Spec.Taints {
+		if v1.TaintEffectNoExecute == t.Effect {
+			return staticRoute{}, false
+		}
+	}
+	addrType := v1.NodeExternalIP
+	if srm.useNodeInt {
+		addrType = v1.NodeInternalIP
+	}
+	var gateway net.IP
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == addrType {
+			gateway = net.ParseIP(addr.Address)
+			break
+		}
+	}
+	if gateway == nil {
+		return staticRoute{}, false
+	}
+	podCIDRs := node.Spec.PodCIDRs
+	if len(podCIDRs) == 0 && node.Spec.PodCIDR != "" {
+		podCIDRs = []string{node.Spec.PodCIDR}
+	}
+	for _, cidr := range podCIDRs {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Warningf("[NET] Invalid pod CIDR %v of node %v", cidr, node.Name)
+			continue
+		}
+		if (ip.To4() == nil) == (gateway.To4() == nil) {
+			return staticRoute{
+				Name:    objectPrefix + node.Name,
+				Network: cidr,
+				Gateway: gateway.String(),
+			}, true
+		}
+	}
+	return staticRoute{}, false
+}
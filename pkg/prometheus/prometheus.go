@@ -31,6 +31,30 @@ var CurrentErrors = prometheus.NewGaugeVec(
 	[]string{},
 )
 
+var ResourceConflicts = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "bigip_resource_conflicts",
+		Help: "Total count of resources discarded due to conflicts with older resources",
+	},
+	[]string{"kind"},
+)
+
+var NetConfigSyncFailures = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "bigip_net_config_sync_failures",
+		Help: "Total count of failed syncs of the network config driver with BigIP",
+	},
+	[]string{},
+)
+
+var NetConfigLastSync = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "bigip_net_config_last_sync_timestamp_seconds",
+		Help: "Unix time of the last successful sync of the network config driver with BigIP",
+	},
+	[]string{},
+)
+
 // further metrics? todo think about
 // RegisterMetrics registers all Prometheus metrics defined above
 func RegisterMetrics() {
@@ -38,4 +62,7 @@ func RegisterMetrics() {
 	prometheus.MustRegister(MonitoredNodes)
 	prometheus.MustRegister(MonitoredServices)
 	prometheus.MustRegister(CurrentErrors)
+	prometheus.MustRegister(ResourceConflicts)
+	prometheus.MustRegister(NetConfigSyncFailures)
+	prometheus.MustRegister(NetConfigLastSync)
 }
@@ -0,0 +1,296 @@
+// Copyright (c) 2019-2021, F5 Networks, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// tracesPath is the path of the OTLP HTTP receiver for traces
+const tracesPath = "/v1/traces"
+
+type (
+	// exporter posts the spans to the OTLP HTTP receiver with the JSON encoding of OTLP,
+	// which avoids the protobuf and gRPC dependencies of the OTLP exporters. The otlp types
+	// follow the trace messages of opentelemetry-proto v1.0.0, the OTLP/JSON encoding of
+	// which is stable: hex trace and span ids, int64 values as strings and enums as integers.
+	// The reference payload of the exporter tests is to be updated with the proto version.
+	exporter struct {
+		url        string
+		httpClient *http.Client
+	}
+
+	otlpTraces struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+		SchemaURL  string           `json:"schemaUrl,omitempty"`
+	}
+
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes,omitempty"`
+	}
+
+	otlpScopeSpans struct {
+		Scope     otlpScope  `json:"scope"`
+		Spans     []otlpSpan `json:"spans"`
+		SchemaURL string     `json:"schemaUrl,omitempty"`
+	}
+
+	otlpScope struct {
+		Name    string `json:"name,omitempty"`
+		Version string `json:"version,omitempty"`
+	}
+
+	otlpSpan struct {
+		TraceID                string         `json:"traceId"`
+		SpanID                 string         `json:"spanId"`
+		ParentSpanID           string         `json:"parentSpanId,omitempty"`
+		Name                   string         `json:"name"`
+		Kind                   int            `json:"kind"`
+		StartTimeUnixNano      string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano        string         `json:"endTimeUnixNano"`
+		Attributes             []otlpKeyValue `json:"attributes,omitempty"`
+		DroppedAttributesCount int            `json:"droppedAttributesCount,omitempty"`
+		Events                 []otlpEvent    `json:"events,omitempty"`
+		DroppedEventsCount     int            `json:"droppedEventsCount,omitempty"`
+		Links                  []otlpLink     `json:"links,omitempty"`
+		DroppedLinksCount      int            `json:"droppedLinksCount,omitempty"`
+		Status                 otlpStatus     `json:"status"`
+	}
+
+	otlpEvent struct {
+		TimeUnixNano string         `json:"timeUnixNano"`
+		Name         string         `json:"name"`
+		Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	}
+
+	otlpLink struct {
+		TraceID    string         `json:"traceId"`
+		SpanID     string         `json:"spanId"`
+		Attributes []otlpKeyValue `json:"attributes,omitempty"`
+	}
+
+	// otlpStatus codes are 0 unset, 1 ok and 2 error
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+
+	otlpAnyValue struct {
+		StringValue *string         `json:"stringValue,omitempty"`
+		BoolValue   *bool           `json:"boolValue,omitempty"`
+		IntValue    *string         `json:"intValue,omitempty"`
+		DoubleValue *float64        `json:"doubleValue,omitempty"`
+		ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+	}
+
+	otlpArrayValue struct {
+		Values []otlpAnyValue `json:"values"`
+	}
+)
+
+func newExporter(endpoint string, insecure bool) *exporter {
+	scheme := "https"
+	if insecure {
+		scheme = "http"
+	}
+	return &exporter{
+		url:        scheme + "://" + endpoint + tracesPath,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ExportSpans posts the spans to the OTLP receiver
+func (e *exporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(newOTLPTraces(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %v", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("failed to export spans: %v", resp.Status)
+	}
+	return nil
+}
+
+// Shutdown releases the idle connections to the OTLP receiver
+func (e *exporter) Shutdown(ctx context.Context) error {
+	e.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// newOTLPTraces groups the spans by resource and instrumentation library
+func newOTLPTraces(spans []sdktrace.ReadOnlySpan) otlpTraces {
+	var traces otlpTraces
+	resourceIndex := make(map[attribute.Distinct]int)
+	scopeIndex := make(map[attribute.Distinct]map[instrumentation.Library]int)
+	for _, span := range spans {
+		res := span.Resource()
+		key := res.Equivalent()
+		rsIdx, ok := resourceIndex[key]
+		if !ok {
+			rsIdx = len(traces.ResourceSpans)
+			resourceIndex[key] = rsIdx
+			scopeIndex[key] = make(map[instrumentation.Library]int)
+			traces.ResourceSpans = append(traces.ResourceSpans, otlpResourceSpans{
+				Resource:  otlpResource{Attributes: newOTLPKeyValues(res.Attributes())},
+				SchemaURL: res.SchemaURL(),
+			})
+		}
+		rs := &traces.ResourceSpans[rsIdx]
+		lib := span.InstrumentationLibrary()
+		scIdx, ok := scopeIndex[key][lib]
+		if !ok {
+			scIdx = len(rs.ScopeSpans)
+			scopeIndex[key][lib] = scIdx
+			rs.ScopeSpans = append(rs.ScopeSpans, otlpScopeSpans{
+				Scope:     otlpScope{Name: lib.Name, Version: lib.Version},
+				SchemaURL: lib.SchemaURL,
+			})
+		}
+		rs.ScopeSpans[scIdx].Spans = append(rs.ScopeSpans[scIdx].Spans, newOTLPSpan(span))
+	}
+	return traces
+}
+
+func newOTLPSpan(span sdktrace.ReadOnlySpan) otlpSpan {
+	sc := span.SpanContext()
+	s := otlpSpan{
+		TraceID:                sc.TraceID().String(),
+		SpanID:                 sc.SpanID().String(),
+		Name:                   span.Name(),
+		Kind:                   int(span.SpanKind()),
+		StartTimeUnixNano:      unixNano(span.StartTime()),
+		EndTimeUnixNano:        unixNano(span.EndTime()),
+		Attributes:             newOTLPKeyValues(span.Attributes()),
+		DroppedAttributesCount: span.DroppedAttributes(),
+		DroppedEventsCount:     span.DroppedEvents(),
+		DroppedLinksCount:      span.DroppedLinks(),
+	}
+	if span.Parent().HasSpanID() {
+		s.ParentSpanID = span.Parent().SpanID().String()
+	}
+	for _, event := range span.Events() {
+		s.Events = append(s.Events, otlpEvent{
+			TimeUnixNano: unixNano(event.Time),
+			Name:         event.Name,
+			Attributes:   newOTLPKeyValues(event.Attributes),
+		})
+	}
+	for _, link := range span.Links() {
+		s.Links = append(s.Links, otlpLink{
+			TraceID:    link.SpanContext.TraceID().String(),
+			SpanID:     link.SpanContext.SpanID().String(),
+			Attributes: newOTLPKeyValues(link.Attributes),
+		})
+	}
+	switch span.Status().Code {
+	case codes.Ok:
+		s.Status.Code = 1
+	case codes.Error:
+		s.Status.Code = 2
+		s.Status.Message = span.Status().Description
+	}
+	return s
+}
+
+func newOTLPKeyValues(attrs []attribute.KeyValue) []otlpKeyValue {
+	var kvs []otlpKeyValue
+	for _, attr := range attrs {
+		kvs = append(kvs, otlpKeyValue{Key: string(attr.Key), Value: newOTLPAnyValue(attr.Value)})
+	}
+	return kvs
+}
+
+func newOTLPAnyValue(v attribute.Value) otlpAnyValue {
+	var av otlpAnyValue
+	switch v.Type() {
+	case attribute.BOOL:
+		b := v.AsBool()
+		av.BoolValue = &b
+	case attribute.INT64:
+		i := strconv.FormatInt(v.AsInt64(), 10)
+		av.IntValue = &i
+	case attribute.FLOAT64:
+		f := v.AsFloat64()
+		av.DoubleValue = &f
+	case attribute.BOOLSLICE:
+		av.ArrayValue = &otlpArrayValue{}
+		for _, b := range v.AsBoolSlice() {
+			av.ArrayValue.Values = append(av.ArrayValue.Values, newOTLPAnyValue(attribute.BoolValue(b)))
+		}
+	case attribute.INT64SLICE:
+		av.ArrayValue = &otlpArrayValue{}
+		for _, i := range v.AsInt64Slice() {
+			av.ArrayValue.Values = append(av.ArrayValue.Values, newOTLPAnyValue(attribute.Int64Value(i)))
+		}
+	case attribute.FLOAT64SLICE:
+		av.ArrayValue = &otlpArrayValue{}
+		for _, f := range v.AsFloat64Slice() {
+			av.ArrayValue.Values = append(av.ArrayValue.Values, newOTLPAnyValue(attribute.Float64Value(f)))
+		}
+	case attribute.STRINGSLICE:
+		av.ArrayValue = &otlpArrayValue{}
+		for _, s := range v.AsStringSlice() {
+			av.ArrayValue.Values = append(av.ArrayValue.Values, newOTLPAnyValue(attribute.StringValue(s)))
+		}
+	default:
+		s := v.Emit()
+		av.StringValue = &s
+	}
+	return av
+}
+
+func unixNano(t time.Time) string {
+	if t.IsZero() {
+		return "0"
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
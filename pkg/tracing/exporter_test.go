@@ -0,0 +1,188 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otlpReferencePayload is the OTLP/JSON encoding of the spans of the reference test as
+// specified by opentelemetry-proto v1.0.0
+const otlpReferencePayload = `{
+  "resourceSpans": [{
+    "resource": {
+      "attributes": [{"key": "service.name", "value": {"stringValue": "k8s-bigip-ctlr"}}]
+    },
+    "scopeSpans": [{
+      "scope": {"name": "github.com/F5Networks/k8s-bigip-ctlr", "version": "1.0.0"},
+      "spans": [{
+        "traceId": "5b8efff798038103d269b633813fc60c",
+        "spanId": "eee19b7ec3c1b173",
+        "name": "parent",
+        "kind": 2,
+        "startTimeUnixNano": "1544712660000000000",
+        "endTimeUnixNano": "1544712661000000000",
+        "status": {"code": 1}
+      }, {
+        "traceId": "5b8efff798038103d269b633813fc60c",
+        "spanId": "eee19b7ec3c1b174",
+        "parentSpanId": "eee19b7ec3c1b173",
+        "name": "child",
+        "kind": 1,
+        "startTimeUnixNano": "1544712660001000000",
+        "endTimeUnixNano": "1544712660003000000",
+        "attributes": [
+          {"key": "partition", "value": {"stringValue": "test"}},
+          {"key": "retry", "value": {"boolValue": true}},
+          {"key": "request.id", "value": {"intValue": "3"}},
+          {"key": "ratio", "value": {"doubleValue": 0.5}},
+          {"key": "tenants", "value": {"arrayValue": {"values": [{"stringValue": "test"}]}}}
+        ],
+        "events": [{
+          "timeUnixNano": "1544712660002000000",
+          "name": "posted",
+          "attributes": [{"key": "code", "value": {"intValue": "422"}}]
+        }],
+        "status": {"code": 2, "message": "declaration failed"}
+      }]
+    }]
+  }]
+}`
+
+// sequentialIDGenerator returns the trace id of the reference payload and sequential span ids
+type sequentialIDGenerator struct {
+	next byte
+}
+
+func (g *sequentialIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	traceID := trace.TraceID{0x5b, 0x8e, 0xff, 0xf7, 0x98, 0x03, 0x81, 0x03,
+		0xd2, 0x69, 0xb6, 0x33, 0x81, 0x3f, 0xc6, 0x0c}
+	return traceID, g.NewSpanID(ctx, traceID)
+}
+
+func (g *sequentialIDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	g.next++
+	return trace.SpanID{0xee, 0xe1, 0x9b, 0x7e, 0xc3, 0xc1, 0xb1, g.next}
+}
+
+var _ = Describe("OTLP Exporter Tests", func() {
+	var server *httptest.Server
+	var bodies []map[string]interface{}
+	var status int
+	var tp *sdktrace.TracerProvider
+
+	BeforeEach(func() {
+		bodies = nil
+		status = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPost))
+			Expect(r.URL.Path).To(Equal(tracesPath))
+			Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+			var body map[string]interface{}
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(BeNil())
+			bodies = append(bodies, body)
+			w.WriteHeader(status)
+		}))
+		tp = sdktrace.NewTracerProvider(
+			sdktrace.WithSyncer(newExporter(strings.TrimPrefix(server.URL, "http://"), true)),
+			sdktrace.WithResource(resource.NewWithAttributes("", attribute.String("service.name", "test"))),
+		)
+	})
+
+	AfterEach(func() {
+		_ = tp.Shutdown(context.Background())
+		server.Close()
+	})
+
+	It("Posts the spans with the OTLP JSON encoding", func() {
+		tracer := tp.Tracer(TracerName)
+		_, linked := tracer.Start(context.Background(), "linked")
+		linked.End()
+		ctx, parent := tracer.Start(context.Background(), "parent",
+			trace.WithLinks(trace.Link{SpanContext: linked.SpanContext()}))
+		_, child := tracer.Start(ctx, "child", trace.WithAttributes(
+			attribute.Int("request.id", 3),
+			attribute.StringSlice("tenants", []string{"test"})))
+		child.AddEvent("posted")
+		child.SetStatus(codes.Error, "declaration failed")
+		child.End()
+		parent.End()
+
+		Expect(bodies).To(HaveLen(3))
+		rs := bodies[1]["resourceSpans"].([]interface{})[0].(map[string]interface{})
+		Expect(rs["resource"].(map[string]interface{})["attributes"]).To(ContainElement(map[string]interface{}{
+			"key": "service.name", "value": map[string]interface{}{"stringValue": "test"}}))
+		scope := rs["scopeSpans"].([]interface{})[0].(map[string]interface{})
+		Expect(scope["scope"]).To(Equal(map[string]interface{}{"name": TracerName}))
+		span := scope["spans"].([]interface{})[0].(map[string]interface{})
+		Expect(span["name"]).To(Equal("child"))
+		Expect(span["traceId"]).To(Equal(parent.SpanContext().TraceID().String()))
+		Expect(span["parentSpanId"]).To(Equal(parent.SpanContext().SpanID().String()))
+		Expect(span["kind"]).To(BeEquivalentTo(trace.SpanKindInternal))
+		Expect(span["attributes"]).To(ConsistOf(
+			map[string]interface{}{"key": "request.id", "value": map[string]interface{}{"intValue": "3"}},
+			map[string]interface{}{"key": "tenants", "value": map[string]interface{}{
+				"arrayValue": map[string]interface{}{"values": []interface{}{
+					map[string]interface{}{"stringValue": "test"}}}}},
+		))
+		Expect(span["events"].([]interface{})[0].(map[string]interface{})["name"]).To(Equal("posted"))
+		Expect(span["status"]).To(Equal(map[string]interface{}{"code": float64(2), "message": "declaration failed"}))
+
+		span = bodies[2]["resourceSpans"].([]interface{})[0].(map[string]interface{})["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})[0].(map[string]interface{})
+		Expect(span["links"]).To(ConsistOf(map[string]interface{}{
+			"traceId": linked.SpanContext().TraceID().String(),
+			"spanId":  linked.SpanContext().SpanID().String(),
+		}))
+	})
+
+	It("Encodes the spans as the OTLP reference payload", func() {
+		refTP := sdktrace.NewTracerProvider(
+			sdktrace.WithIDGenerator(&sequentialIDGenerator{next: 0x72}),
+			sdktrace.WithResource(resource.NewWithAttributes("", attribute.String("service.name", "k8s-bigip-ctlr"))),
+		)
+		defer func() { _ = refTP.Shutdown(context.Background()) }()
+		start := time.Unix(1544712660, 0)
+		tracer := refTP.Tracer(TracerName, trace.WithInstrumentationVersion("1.0.0"))
+		ctx, parent := tracer.Start(context.Background(), "parent",
+			trace.WithSpanKind(trace.SpanKindServer), trace.WithTimestamp(start))
+		_, child := tracer.Start(ctx, "child", trace.WithTimestamp(start.Add(time.Millisecond)),
+			trace.WithAttributes(
+				attribute.String("partition", "test"),
+				attribute.Bool("retry", true),
+				attribute.Int("request.id", 3),
+				attribute.Float64("ratio", 0.5),
+				attribute.StringSlice("tenants", []string{"test"})))
+		child.AddEvent("posted", trace.WithTimestamp(start.Add(2*time.Millisecond)),
+			trace.WithAttributes(attribute.Int("code", 422)))
+		child.SetStatus(codes.Error, "declaration failed")
+		child.End(trace.WithTimestamp(start.Add(3 * time.Millisecond)))
+		parent.SetStatus(codes.Ok, "")
+		parent.End(trace.WithTimestamp(start.Add(time.Second)))
+
+		data, err := json.Marshal(newOTLPTraces([]sdktrace.ReadOnlySpan{
+			parent.(sdktrace.ReadOnlySpan), child.(sdktrace.ReadOnlySpan)}))
+		Expect(err).To(BeNil())
+		Expect(data).To(MatchJSON(otlpReferencePayload))
+	})
+
+	It("Returns the error of the OTLP receiver", func() {
+		status = http.StatusBadRequest
+		exp := newExporter(strings.TrimPrefix(server.URL, "http://"), true)
+		_, span := tp.Tracer(TracerName).Start(context.Background(), "span")
+		span.End()
+		err := exp.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{span.(sdktrace.ReadOnlySpan)})
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("400"))
+	})
+})
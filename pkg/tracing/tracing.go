@@ -0,0 +1,66 @@
+// Copyright (c) 2019-2021, F5 Networks, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing configures the OpenTelemetry tracer provider of CIS.
+// Without Init the tracer is a no-op tracer.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// TracerName is the instrumentation name of the CIS spans
+	TracerName  = "github.com/F5Networks/k8s-bigip-ctlr"
+	serviceName = "k8s-bigip-ctlr"
+)
+
+// Params defines the tracing parameters
+type Params struct {
+	// Endpoint is the host:port of the OTLP HTTP receiver
+	Endpoint string
+	// Insecure disables TLS to the OTLP receiver
+	Insecure bool
+	// SampleRatio is the fraction of traces sampled
+	SampleRatio float64
+	Version     string
+}
+
+// Init sets the global tracer provider exporting the spans to the OTLP
+// endpoint and returns the function to flush and stop the provider.
+func Init(params Params) (func(context.Context) error, error) {
+	res := resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceNameKey.String(serviceName),
+		semconv.ServiceVersionKey.String(params.Version),
+	)
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(newExporter(params.Endpoint, params.Insecure)),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(params.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Tracer returns the CIS tracer of the global tracer provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
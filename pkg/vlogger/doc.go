@@ -104,6 +104,25 @@ Two related defines can also be used (mainly as a convenience for logger registr
 Note that certain concrete packages will have their own fine-grained filtering for
 logging.  However, the package-level controls will supercede these finer controls.
 
+# STRUCTURED LOGGING
+
+Key/value fields can be attached to the messages with an Entry:
+
+	log.WithFields(log.Fields{"tenant": tenant, "id": id}).Debugf("[AS3] posting request")
+
+Loggers implementing the FieldLogger interface, such as the one returned by
+NewJSONLogger, record the fields in a structured format. Other loggers append
+the fields to the message as key=value pairs.
+
+The bracketed tag a message starts with, for instance "[AS3]", names the subsystem of
+the message. The JSON logger records it in the "subsystem" field and the filtering of a
+subsystem can be set with the following package level functions, which override the
+package-level filtering for its messages:
+
+	SetSubsystemLogLevel(subsystem string, level LogLevel)
+	ResetSubsystemLogLevel(subsystem string)
+	GetSubsystemLogLevels() map[string]LogLevel
+
 # COMPATIBILITY ISSUES
 
 Log levels do not always map 1-to-1 with the underlying 3rd-party logging library.
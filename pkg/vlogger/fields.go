@@ -0,0 +1,249 @@
+// Copyright (c) 2019-2021, F5 Networks, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// fields.go:
+//
+//	Provides structured logging with key/value fields and per-subsystem log levels.
+//	A subsystem is the bracketed tag a message starts with, for instance "[AS3]".
+package vlogger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type (
+	// Fields are the key/value pairs attached to a log message
+	Fields map[string]interface{}
+
+	// FieldLogger is implemented by the concrete loggers which record the
+	// fields of a message in a structured format. For other loggers the
+	// fields are appended to the message as key=value pairs.
+	FieldLogger interface {
+		Logger
+		LogFields(level LogLevel, msg string, fields Fields)
+	}
+
+	// Entry is a set of fields to be logged along with the messages
+	Entry struct {
+		fields Fields
+	}
+)
+
+var (
+	subsystemMutex sync.RWMutex
+	// subsystemLevels overrides the package-level filtering for the messages
+	// of a subsystem, key is the upper case subsystem name.
+	subsystemLevels = make(map[string]LogLevel)
+)
+
+// WithFields creates an Entry with the given fields
+func WithFields(fields Fields) *Entry {
+	return (&Entry{}).WithFields(fields)
+}
+
+// WithFields returns a new Entry with the given fields added to the entry
+func (e *Entry) WithFields(fields Fields) *Entry {
+	merged := make(Fields, len(e.fields)+len(fields))
+	for k, v := range e.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &Entry{fields: merged}
+}
+
+func (e *Entry) Debug(msg string) {
+	e.log(LL_DEBUG, msg)
+}
+
+func (e *Entry) Debugf(format string, params ...interface{}) {
+	e.log(LL_DEBUG, fmt.Sprintf(format, params...))
+}
+
+func (e *Entry) Info(msg string) {
+	e.log(LL_INFO, msg)
+}
+
+func (e *Entry) Infof(format string, params ...interface{}) {
+	e.log(LL_INFO, fmt.Sprintf(format, params...))
+}
+
+func (e *Entry) Warning(msg string) {
+	e.log(LL_WARNING, msg)
+}
+
+func (e *Entry) Warningf(format string, params ...interface{}) {
+	e.log(LL_WARNING, fmt.Sprintf(format, params...))
+}
+
+func (e *Entry) Error(msg string) {
+	e.log(LL_ERROR, msg)
+}
+
+func (e *Entry) Errorf(format string, params ...interface{}) {
+	e.log(LL_ERROR, fmt.Sprintf(format, params...))
+}
+
+func (e *Entry) Critical(msg string) {
+	e.log(LL_CRITICAL, msg)
+}
+
+func (e *Entry) Criticalf(format string, params ...interface{}) {
+	e.log(LL_CRITICAL, fmt.Sprintf(format, params...))
+}
+
+func (e *Entry) log(level LogLevel, msg string) {
+	if !isEnabled(level, msg) {
+		return
+	}
+	if fl, ok := vlog[level].(FieldLogger); ok {
+		fl.LogFields(level, msg, e.fields)
+		return
+	}
+	if len(e.fields) > 0 {
+		msg = msg + " " + e.String()
+	}
+	switch level {
+	case LL_DEBUG:
+		vlog[level].Debug(msg)
+	case LL_INFO:
+		vlog[level].Info(msg)
+	case LL_WARNING:
+		vlog[level].Warning(msg)
+	case LL_ERROR:
+		vlog[level].Error(msg)
+	default:
+		vlog[level].Critical(msg)
+	}
+}
+
+// String formats the fields as key=value pairs sorted by key
+func (e *Entry) String() string {
+	keys := make([]string, 0, len(e.fields))
+	for k := range e.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, e.fields[k]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// SplitSubsystem returns the subsystem tag a message starts with and the
+// message without the tag, for instance "AS3" for "[AS3] posting request".
+func SplitSubsystem(msg string) (string, string) {
+	if !strings.HasPrefix(msg, "[") {
+		return "", msg
+	}
+	end := strings.Index(msg, "]")
+	if end < 2 || strings.ContainsAny(msg[1:end], " \t") {
+		return "", msg
+	}
+	return msg[1:end], strings.TrimLeft(msg[end+1:], " ")
+}
+
+// SetSubsystemLogLevel sets the filtering for the messages of a subsystem,
+// overriding the package-level filtering.
+func SetSubsystemLogLevel(subsystem string, level LogLevel) {
+	subsystemMutex.Lock()
+	subsystemLevels[strings.ToUpper(subsystem)] = level
+	subsystemMutex.Unlock()
+	updateLoggerLevels()
+}
+
+// ResetSubsystemLogLevel removes the filtering of a subsystem, so that the
+// package-level filtering applies to its messages.
+func ResetSubsystemLogLevel(subsystem string) {
+	subsystemMutex.Lock()
+	delete(subsystemLevels, strings.ToUpper(subsystem))
+	subsystemMutex.Unlock()
+	updateLoggerLevels()
+}
+
+// GetSubsystemLogLevels returns the filtering of the subsystems
+func GetSubsystemLogLevels() map[string]LogLevel {
+	subsystemMutex.RLock()
+	defer subsystemMutex.RUnlock()
+	levels := make(map[string]LogLevel, len(subsystemLevels))
+	for k, v := range subsystemLevels {
+		levels[k] = v
+	}
+	return levels
+}
+
+// NewSubsystemLogLevels parses a comma separated list of subsystem=level pairs,
+// for instance "AS3=debug,VxLAN=error".
+func NewSubsystemLogLevels(s string) (map[string]LogLevel, error) {
+	levels := make(map[string]LogLevel)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid subsystem log level '%v', expected subsystem=level", pair)
+		}
+		ll := NewLogLevel(strings.TrimSpace(kv[1]))
+		if ll == nil {
+			return nil, fmt.Errorf("unknown log level '%v' for subsystem %v", kv[1], kv[0])
+		}
+		levels[strings.ToUpper(strings.TrimSpace(kv[0]))] = *ll
+	}
+	return levels, nil
+}
+
+// isEnabled reports whether a message of the given level passes the
+// subsystem and package-level filtering.
+func isEnabled(level LogLevel, msg string) bool {
+	subsystemMutex.RLock()
+	defer subsystemMutex.RUnlock()
+	if len(subsystemLevels) == 0 {
+		// the concrete loggers filter with the package-level
+		return true
+	}
+	minLevel := GetLogLevel()
+	if sub, _ := SplitSubsystem(msg); sub != "" {
+		if ll, ok := subsystemLevels[strings.ToUpper(sub)]; ok {
+			minLevel = ll
+		}
+	}
+	return level >= minLevel
+}
+
+// updateLoggerLevels sets the concrete loggers to the lowest level of the
+// package-level and subsystem filtering, the rest is filtered by isEnabled.
+func updateLoggerLevels() {
+	subsystemMutex.RLock()
+	minLevel := GetLogLevel()
+	for _, ll := range subsystemLevels {
+		if ll < minLevel {
+			minLevel = ll
+		}
+	}
+	subsystemMutex.RUnlock()
+
+	slLogLevel := logLevelToSyslogLevel[minLevel]
+	for i := range vlog {
+		if vlog[i] != nil {
+			vlog[i].SetLogLevel(slLogLevel)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package vlogger_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log/syslog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/F5Networks/k8s-bigip-ctlr/v2/pkg/vlogger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// recordLogger records the messages of the levels it is registered for
+type recordLogger struct {
+	mutex      sync.Mutex
+	messages   []string
+	slLogLevel syslog.Priority
+}
+
+func (rl *recordLogger) record(level, msg string) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	rl.messages = append(rl.messages, level+": "+msg)
+}
+
+func (rl *recordLogger) Debug(msg string) { rl.record("debug", msg) }
+func (rl *recordLogger) Debugf(format string, params ...interface{}) {
+	rl.record("debug", fmt.Sprintf(format, params...))
+}
+func (rl *recordLogger) Info(msg string) { rl.record("info", msg) }
+func (rl *recordLogger) Infof(format string, params ...interface{}) {
+	rl.record("info", fmt.Sprintf(format, params...))
+}
+func (rl *recordLogger) Warning(msg string) { rl.record("warning", msg) }
+func (rl *recordLogger) Warningf(format string, params ...interface{}) {
+	rl.record("warning", fmt.Sprintf(format, params...))
+}
+func (rl *recordLogger) Error(msg string) { rl.record("error", msg) }
+func (rl *recordLogger) Errorf(format string, params ...interface{}) {
+	rl.record("error", fmt.Sprintf(format, params...))
+}
+func (rl *recordLogger) Critical(msg string) { rl.record("critical", msg) }
+func (rl *recordLogger) Criticalf(format string, params ...interface{}) {
+	rl.record("critical", fmt.Sprintf(format, params...))
+}
+func (rl *recordLogger) GetLogLevel() syslog.Priority           { return rl.slLogLevel }
+func (rl *recordLogger) SetLogLevel(slLogLevel syslog.Priority) { rl.slLogLevel = slLogLevel }
+func (rl *recordLogger) Close()                                 {}
+
+type ipAddress string
+
+func (ip ipAddress) String() string {
+	return "ip-" + string(ip)
+}
+
+var _ = Describe("Fields and Subsystem Log Levels", func() {
+	var rl *recordLogger
+	var savedLevel log.LogLevel
+
+	BeforeEach(func() {
+		savedLevel = log.GetLogLevel()
+		rl = &recordLogger{}
+		log.RegisterLogger(log.LL_MIN_LEVEL, log.LL_MAX_LEVEL, rl)
+		log.SetLogLevel(log.LL_DEBUG)
+	})
+
+	AfterEach(func() {
+		for sub := range log.GetSubsystemLogLevels() {
+			log.ResetSubsystemLogLevel(sub)
+		}
+		log.RegisterLogger(log.LL_MIN_LEVEL, log.LL_MAX_LEVEL, &recordLogger{})
+		log.SetLogLevel(savedLevel)
+	})
+
+	It("Renders the fields as sorted key=value pairs", func() {
+		entry := log.WithFields(log.Fields{"tenant": "test", "code": 422}).WithFields(log.Fields{"id": 1})
+		Expect(entry.String()).To(Equal("code=422 id=1 tenant=test"))
+
+		entry.Errorf("[AS3] post failed for %v", "test")
+		log.WithFields(nil).Info("no fields")
+		Expect(rl.messages).To(Equal([]string{
+			"error: [AS3] post failed for test code=422 id=1 tenant=test",
+			"info: no fields",
+		}))
+	})
+
+	It("Splits the subsystem of the message", func() {
+		sub, msg := log.SplitSubsystem("[AS3] posting request")
+		Expect(sub).To(Equal("AS3"))
+		Expect(msg).To(Equal("posting request"))
+
+		for _, msg := range []string{"posting request", "[] empty", "[not a tag] message", "[AS3 unterminated"} {
+			sub, rest := log.SplitSubsystem(msg)
+			Expect(sub).To(BeEmpty(), msg)
+			Expect(rest).To(Equal(msg))
+		}
+	})
+
+	It("Parses the subsystem log levels", func() {
+		levels, err := log.NewSubsystemLogLevels("as3=debug, VxLAN=error,")
+		Expect(err).To(BeNil())
+		Expect(levels).To(Equal(map[string]log.LogLevel{"AS3": log.LL_DEBUG, "VXLAN": log.LL_ERROR}))
+
+		_, err = log.NewSubsystemLogLevels("AS3")
+		Expect(err).NotTo(BeNil())
+		_, err = log.NewSubsystemLogLevels("AS3=verbose")
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("Filters the messages with the subsystem log levels", func() {
+		log.SetLogLevel(log.LL_INFO)
+		log.SetSubsystemLogLevel("as3", log.LL_DEBUG)
+		log.SetSubsystemLogLevel("VxLAN", log.LL_ERROR)
+		Expect(rl.GetLogLevel()).To(Equal(syslog.LOG_DEBUG), "Logger should pass the lowest level")
+
+		log.Debugf("[AS3] debug %v", "shown")
+		log.Debugf("[CORE] debug %v", "hidden")
+		log.Warning("[VxLAN] warning hidden")
+		log.Info("info shown")
+		log.WithFields(log.Fields{"tenant": "test"}).Debug("[AS3] entry shown")
+		Expect(rl.messages).To(Equal([]string{
+			"debug: [AS3] debug shown",
+			"info: info shown",
+			"debug: [AS3] entry shown tenant=test",
+		}))
+
+		log.ResetSubsystemLogLevel("AS3")
+		log.ResetSubsystemLogLevel("VXLAN")
+		Expect(log.GetSubsystemLogLevels()).To(BeEmpty())
+		Expect(rl.GetLogLevel()).To(Equal(syslog.LOG_INFO))
+	})
+
+	It("Changes the log level concurrently with logging", func() {
+		log.SetSubsystemLogLevel("AS3", log.LL_DEBUG)
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				log.SetLogLevel(log.LogLevel(i % log.LL_LOGLEVEL_SIZE))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				log.Debugf("[CORE] message %v", i)
+			}
+		}()
+		wg.Wait()
+	})
+})
+
+var _ = Describe("JSON Logger", func() {
+	var jl log.FieldLogger
+	var stdout, stderr *os.File
+
+	BeforeEach(func() {
+		jl = log.NewJSONLogger()
+		stdout, stderr = os.Stdout, os.Stderr
+		out, err := ioutil.TempFile("", "vlogger")
+		Expect(err).To(BeNil())
+		os.Stdout, os.Stderr = out, out
+	})
+
+	AfterEach(func() {
+		out := os.Stdout
+		os.Stdout, os.Stderr = stdout, stderr
+		out.Close()
+		os.Remove(out.Name())
+	})
+
+	readEntries := func() []map[string]interface{} {
+		data, err := ioutil.ReadFile(os.Stdout.Name())
+		Expect(err).To(BeNil())
+		var entries []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			if line == "" {
+				continue
+			}
+			entry := make(map[string]interface{})
+			Expect(json.Unmarshal([]byte(line), &entry)).To(BeNil(), line)
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	It("Writes a JSON object per message", func() {
+		jl.LogFields(log.LL_ERROR, "[AS3] post failed", log.Fields{
+			"tenant":  "test",
+			"code":    422,
+			"err":     errors.New("declaration failed"),
+			"address": ipAddress("10.1.1.1"),
+		})
+		jl.Infof("synced %v resources", 2)
+
+		entries := readEntries()
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0]).To(HaveKeyWithValue("level", "error"))
+		Expect(entries[0]).To(HaveKeyWithValue("subsystem", "AS3"))
+		Expect(entries[0]).To(HaveKeyWithValue("msg", "post failed"))
+		Expect(entries[0]).To(HaveKeyWithValue("tenant", "test"))
+		Expect(entries[0]).To(HaveKeyWithValue("code", BeNumerically("==", 422)))
+		Expect(entries[0]).To(HaveKeyWithValue("err", "declaration failed"))
+		Expect(entries[0]).To(HaveKeyWithValue("address", "ip-10.1.1.1"))
+		_, err := time.Parse(time.RFC3339Nano, entries[0]["time"].(string))
+		Expect(err).To(BeNil())
+
+		Expect(entries[1]).To(HaveKeyWithValue("level", "info"))
+		Expect(entries[1]).To(HaveKeyWithValue("msg", "synced 2 resources"))
+		Expect(entries[1]).NotTo(HaveKey("subsystem"))
+	})
+
+	It("Writes the fields which can not be marshalled in the default format", func() {
+		jl.LogFields(log.LL_WARNING, "unsupported field", log.Fields{"ch": make(chan int)})
+		entries := readEntries()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0]["ch"]).To(HavePrefix("0x"))
+		Expect(entries[0]).To(HaveKeyWithValue("msg", "unsupported field"))
+	})
+
+	It("Filters the messages below the log level", func() {
+		jl.SetLogLevel(syslog.LOG_WARNING)
+		Expect(jl.GetLogLevel()).To(Equal(syslog.LOG_WARNING))
+		jl.Debug("hidden")
+		jl.Info("hidden")
+		jl.Warning("shown")
+		jl.Critical("shown")
+		entries := readEntries()
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0]).To(HaveKeyWithValue("level", "warning"))
+		Expect(entries[1]).To(HaveKeyWithValue("level", "critical"))
+	})
+})
@@ -22,6 +22,7 @@ import (
 	"log/syslog" // For LOG level definitions
 	"os"
 	"strings"
+	"sync/atomic"
 )
 
 // LogLevel is used for global (package-level) filtering of log messages based on their priority
@@ -126,7 +127,8 @@ var (
 
 	// logLevel indicates the current package-level filtering being applied
 	// (may be further restricted by specific concrete loggers).
-	logLevel LogLevel = LL_DEBUG
+	// It is accessed atomically as it can be changed at runtime.
+	logLevel int32 = LL_DEBUG
 
 	// logLevelToSyslogLevel maps vlogger log levels to the internal representation used
 	// by the implementations (which use syslog's definitions).
@@ -148,62 +150,80 @@ func RegisterLogger(minLogLevel, maxLogLevel LogLevel, log Logger) {
 
 // Debug sends a message to the logger object to record debug/trace level statements
 func Debug(msg string) {
-	vlog[LL_DEBUG].Debug(msg)
+	if isEnabled(LL_DEBUG, msg) {
+		vlog[LL_DEBUG].Debug(msg)
+	}
 }
 
 // Debugf formats a message before sending it to the logger object to record
 // debug/trace level statements
 func Debugf(format string, params ...interface{}) {
-	vlog[LL_DEBUG].Debugf(format, params...)
+	if isEnabled(LL_DEBUG, format) {
+		vlog[LL_DEBUG].Debugf(format, params...)
+	}
 }
 
 // Info sends a message to the logger object to record informational level statements
 // (these should be statements that can normally be logged without causing performance
 // issues).
 func Info(msg string) {
-	vlog[LL_INFO].Info(msg)
+	if isEnabled(LL_INFO, msg) {
+		vlog[LL_INFO].Info(msg)
+	}
 }
 
 // Infof formats a message before sending it to the logger object to record
 // informational level statements (there should be statements that can normally
 // be logged without causing performance issues).
 func Infof(format string, params ...interface{}) {
-	vlog[LL_INFO].Infof(format, params...)
+	if isEnabled(LL_INFO, format) {
+		vlog[LL_INFO].Infof(format, params...)
+	}
 }
 
 // Warning sends a message to the logger object to record warning level statements
 // (these indication conditions that are unexpected or may cause issues but are not
 // normally going to affect the program execution).
 func Warning(msg string) {
-	vlog[LL_WARNING].Warning(msg)
+	if isEnabled(LL_WARNING, msg) {
+		vlog[LL_WARNING].Warning(msg)
+	}
 }
 
 // Warningf formats a message before sending it to the logger object to record
 // warning level statements (these indication conditions that are unexpected or
 // may cause issues but are not normally going to affect the program execution).
 func Warningf(format string, params ...interface{}) {
-	vlog[LL_WARNING].Warningf(format, params...)
+	if isEnabled(LL_WARNING, format) {
+		vlog[LL_WARNING].Warningf(format, params...)
+	}
 }
 
 // Error sends a message to the logger object to record error level statements
 // (these indicate conditions that should not occur and may indicate a failure
 // in performing the requested action).
 func Error(msg string) {
-	vlog[LL_ERROR].Error(msg)
+	if isEnabled(LL_ERROR, msg) {
+		vlog[LL_ERROR].Error(msg)
+	}
 }
 
 // Errorf formats a message before sending it to the logger object to record
 // error level statements (these indicate conditions that should not occur
 // and may indicate a failure in performing the requested action).
 func Errorf(format string, params ...interface{}) {
-	vlog[LL_ERROR].Errorf(format, params...)
+	if isEnabled(LL_ERROR, format) {
+		vlog[LL_ERROR].Errorf(format, params...)
+	}
 }
 
 // Critical sends a message to the logger object to record critical level statements
 // (these indicate conditions that should never occur and might cause a failure/crash
 // of the executing program or unexpected outcome from the requested action).
 func Critical(msg string) {
-	vlog[LL_CRITICAL].Critical(msg)
+	if isEnabled(LL_CRITICAL, msg) {
+		vlog[LL_CRITICAL].Critical(msg)
+	}
 }
 
 // Criticalf formats a message before sending it to the logger object to record
@@ -211,7 +231,9 @@ func Critical(msg string) {
 // and might cause a failure/crash of the executing program or unexpected
 // outcome from the requested action).
 func Criticalf(format string, params ...interface{}) {
-	vlog[LL_CRITICAL].Criticalf(format, params...)
+	if isEnabled(LL_CRITICAL, format) {
+		vlog[LL_CRITICAL].Criticalf(format, params...)
+	}
 }
 
 // Fatal sends a CRITICAL message to the logger object and then exits.
@@ -251,20 +273,15 @@ func Panicf(format string, params ...interface{}) {
 
 // SetLogLevel sets the current package-level filtering
 func SetLogLevel(level LogLevel) {
-	logLevel = level
+	atomic.StoreInt32(&logLevel, int32(level))
 
-	// Update all loggers to the new level
-	slLogLevel := logLevelToSyslogLevel[logLevel]
-	for i, _ := range vlog {
-		if vlog[i] != nil {
-			vlog[i].SetLogLevel(slLogLevel)
-		}
-	}
+	// Update all loggers to the new level, lowered for the subsystem levels
+	updateLoggerLevels()
 }
 
 // GetLogLevel returns the current package-level filtering
 func GetLogLevel() LogLevel {
-	return logLevel
+	return LogLevel(atomic.LoadInt32(&logLevel))
 }
 
 // Close informs the configured loggers that they are being closed and
@@ -0,0 +1,159 @@
+// Copyright (c) 2019-2021, F5 Networks, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// log_json.go:
+//
+//	Provides structured logging, one JSON object per message, through the common interface.
+//	To use, create the logger object with the following syntax:
+//	  NewJSONLogger()
+package vlogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	jsonLogger struct {
+		// slLogLevel uses syslog's definitions which have higher priority
+		// levels defined in descending order (0 is highest), accessed atomically
+		slLogLevel  int32
+		mutex       sync.Mutex
+		fileHandler *os.File
+	}
+)
+
+// NewJSONLogger creates a logger object that prints log messages as JSON
+// objects to the console.
+func NewJSONLogger() *jsonLogger {
+	return &jsonLogger{
+		slLogLevel: int32(syslog.LOG_DEBUG),
+	}
+}
+
+// NewJSONFileLogger creates a JSON logger which redirects stdout and stderr to a file
+func NewJSONFileLogger(fn string) *jsonLogger {
+	jl := NewJSONLogger()
+	f, err := os.OpenFile(fn, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		panic(err)
+	}
+	jl.fileHandler = f
+	// set the stdout & stderr to file writer
+	os.Stdout = f
+	os.Stderr = f
+	return jl
+}
+
+// LogFields writes the message with its level, subsystem and fields as a JSON object.
+func (jl *jsonLogger) LogFields(level LogLevel, msg string, fields Fields) {
+	if jl.GetLogLevel() < logLevelToSyslogLevel[level] {
+		return
+	}
+	entry := make(map[string]interface{}, len(fields)+4)
+	for k, v := range fields {
+		switch val := v.(type) {
+		case error:
+			entry[k] = val.Error()
+		case fmt.Stringer:
+			entry[k] = val.String()
+		default:
+			entry[k] = v
+		}
+	}
+	if sub, rest := SplitSubsystem(msg); sub != "" {
+		entry["subsystem"] = sub
+		msg = rest
+	}
+	entry["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["msg"] = msg
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		// fields which can not be marshalled are logged with their default format
+		for k, v := range entry {
+			entry[k] = fmt.Sprintf("%v", v)
+		}
+		data, _ = json.Marshal(entry)
+	}
+
+	jl.mutex.Lock()
+	defer jl.mutex.Unlock()
+	out := os.Stderr
+	if level == LL_INFO {
+		out = os.Stdout
+	}
+	_, _ = out.Write(append(data, '\n'))
+}
+
+func (jl *jsonLogger) Debug(msg string) {
+	jl.LogFields(LL_DEBUG, msg, nil)
+}
+
+func (jl *jsonLogger) Debugf(format string, params ...interface{}) {
+	jl.LogFields(LL_DEBUG, fmt.Sprintf(format, params...), nil)
+}
+
+func (jl *jsonLogger) Info(msg string) {
+	jl.LogFields(LL_INFO, msg, nil)
+}
+
+func (jl *jsonLogger) Infof(format string, params ...interface{}) {
+	jl.LogFields(LL_INFO, fmt.Sprintf(format, params...), nil)
+}
+
+func (jl *jsonLogger) Warning(msg string) {
+	jl.LogFields(LL_WARNING, msg, nil)
+}
+
+func (jl *jsonLogger) Warningf(format string, params ...interface{}) {
+	jl.LogFields(LL_WARNING, fmt.Sprintf(format, params...), nil)
+}
+
+func (jl *jsonLogger) Error(msg string) {
+	jl.LogFields(LL_ERROR, msg, nil)
+}
+
+func (jl *jsonLogger) Errorf(format string, params ...interface{}) {
+	jl.LogFields(LL_ERROR, fmt.Sprintf(format, params...), nil)
+}
+
+func (jl *jsonLogger) Critical(msg string) {
+	jl.LogFields(LL_CRITICAL, msg, nil)
+}
+
+func (jl *jsonLogger) Criticalf(format string, params ...interface{}) {
+	jl.LogFields(LL_CRITICAL, fmt.Sprintf(format, params...), nil)
+}
+
+func (jl *jsonLogger) SetLogLevel(slLogLevel syslog.Priority) {
+	atomic.StoreInt32(&jl.slLogLevel, int32(slLogLevel))
+}
+
+func (jl *jsonLogger) GetLogLevel() syslog.Priority {
+	return syslog.Priority(atomic.LoadInt32(&jl.slLogLevel))
+}
+
+// Close file
+func (jl *jsonLogger) Close() {
+	if jl.fileHandler != nil {
+		jl.fileHandler.Close()
+	}
+}
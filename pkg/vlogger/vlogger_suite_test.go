@@ -0,0 +1,13 @@
+package vlogger_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestVLogger(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "VLogger Suite")
+}